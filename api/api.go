@@ -0,0 +1,525 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go_proxy/checker"
+	"go_proxy/events"
+	"go_proxy/proxy"
+)
+
+// Controller API所需的应用控制接口
+// 由主程序实现，API只通过此接口操作代理池和本地服务
+type Controller interface {
+	FetchProxies()
+	TestAllProxies()
+	TestUntestedProxies()
+	RetestFailedProxies()
+	ListProxies(filter proxy.Filter) []*proxy.Proxy
+	AddProxyLines(lines []string) (added, skipped int, lineErrors []proxy.LineError)
+	RemoveProxies(addresses []string) int
+	GetPoolStats() proxy.PoolStats
+	GetTrafficStats() proxy.TrafficStats
+	StartServer(host, port string) error
+	StopServer() error
+	ServerStatus() (running bool, host, port, mode string)
+	GetCheckConfig() checker.Config
+	SetCheckConfig(cfg checker.Config) error
+	GetClientACL() (allow, deny []string)
+	SetClientACL(allow, deny []string) error
+	SubscribeEvents(buffer int) (<-chan events.Event, func())
+}
+
+// Server 嵌入式HTTP管理API
+// 提供获取、测试、查询、添加、删除代理以及启停本地代理服务的接口
+// 设置了令牌时所有请求都需携带 Authorization: Bearer <令牌>
+// 为防止网页跨站调用，带有其他站点 Origin 的请求被拒绝，修改类请求(POST/PUT/DELETE)的请求体必须为JSON
+// done 在停止服务时关闭，用于结束仍在推送的事件流
+type Server struct {
+	addr       string
+	token      string
+	controller Controller
+	httpServer *http.Server
+	done       chan struct{}
+}
+
+// eventBuffer 每个事件流订阅的缓冲大小，客户端读取过慢时多出的事件会被丢弃
+const eventBuffer = 256
+
+// eventKeepAlive 事件流空闲时发送注释行的间隔，防止中间代理因超时断开连接
+const eventKeepAlive = 30 * time.Second
+
+// NewServer 创建管理API服务
+// 参数 addr: 监听地址(格式: host:port)
+// 参数 token: 访问令牌，为空表示不校验
+// 参数 controller: 应用控制接口
+func NewServer(addr, token string, controller Controller) *Server {
+	s := &Server{addr: addr, token: token, controller: controller, done: make(chan struct{})}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/proxies", s.handleListProxies)
+	mux.HandleFunc("POST /api/proxies", s.handleAddProxies)
+	mux.HandleFunc("DELETE /api/proxies", s.handleDeleteProxies)
+	mux.HandleFunc("GET /api/stats", s.handleStats)
+	mux.HandleFunc("GET /api/traffic", s.handleTraffic)
+	mux.HandleFunc("POST /api/fetch", s.handleFetch)
+	mux.HandleFunc("POST /api/test", s.handleTest)
+	mux.HandleFunc("GET /api/server", s.handleServerStatus)
+	mux.HandleFunc("POST /api/server/start", s.handleServerStart)
+	mux.HandleFunc("POST /api/server/stop", s.handleServerStop)
+	mux.HandleFunc("GET /api/check-config", s.handleGetCheckConfig)
+	mux.HandleFunc("PUT /api/check-config", s.handleSetCheckConfig)
+	mux.HandleFunc("GET /api/acl", s.handleGetACL)
+	mux.HandleFunc("PUT /api/acl", s.handleSetACL)
+	mux.HandleFunc("GET /api/events", s.handleEvents)
+	s.httpServer = &http.Server{
+		Addr:              addr,
+		Handler:           s.authorize(mux),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s
+}
+
+// Start 开始监听并在后台处理请求
+// 监听失败时返回错误
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("管理API监听失败: %v", err)
+	}
+	go func() {
+		if err := s.httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("管理API服务异常退出: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Stop 优雅关闭API服务，最多等待5秒
+// 先结束所有事件流，否则长连接会一直阻塞关闭
+func (s *Server) Stop() error {
+	close(s.done)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return s.httpServer.Shutdown(ctx)
+}
+
+// GenerateToken 生成随机的访问令牌，用于未指定令牌时保护管理API
+func GenerateToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// IsLoopbackAddr 判断监听地址(格式: host:port)是否只绑定回环地址
+// 主机为空或为 0.0.0.0 等全部网卡地址时返回 false
+func IsLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// authorize 校验访问令牌的中间件
+// 浏览器的 EventSource 无法设置请求头，因此也接受查询参数 token
+// 令牌校验前先拒绝跨站请求：带有其他站点 Origin 的请求，以及请求体不是JSON的修改类请求
+// (网页无需预检即可发送的“简单请求”只能使用 text/plain 等类型)
+func (s *Server) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if origin := r.Header.Get("Origin"); origin != "" && !sameOrigin(origin, r.Host) {
+			writeError(w, http.StatusForbidden, errors.New("拒绝跨站请求"))
+			return
+		}
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+		default:
+			mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if mediaType != "application/json" {
+				writeError(w, http.StatusUnsupportedMediaType, errors.New("请求的 Content-Type 必须为 application/json"))
+				return
+			}
+		}
+		if s.token != "" {
+			token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if token == "" {
+				token = r.URL.Query().Get("token")
+			}
+			if subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+				writeError(w, http.StatusUnauthorized, errors.New("未授权"))
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// sameOrigin 判断请求的 Origin 是否就是API自身的地址
+func sameOrigin(origin, host string) bool {
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, host)
+}
+
+// proxyView 代理的JSON表示，延迟以毫秒输出
+type proxyView struct {
+	Address      string          `json:"address"`
+	Protocol     string          `json:"protocol"`
+	LatencyMs    float64         `json:"latency_ms"`
+	Speed        float64         `json:"speed_kbps"`
+	Anonymity    string          `json:"anonymity"`
+	Country      string          `json:"country"`
+	City         string          `json:"city"`
+	Score        float64         `json:"score"`
+	JitterMs     float64         `json:"jitter_ms"`
+	Stability    float64         `json:"stability"`
+	ExitIP       string          `json:"exit_ip,omitempty"`
+	ASN          uint            `json:"asn,omitempty"`
+	ASOrg        string          `json:"as_org,omitempty"`
+	Tags         []string        `json:"tags,omitempty"`
+	RemoteDNS    bool            `json:"remote_dns"`
+	DNSChecked   bool            `json:"dns_checked"`
+	Targets      map[string]bool `json:"targets,omitempty"`
+	HTTPS        bool            `json:"https"`
+	IPv6         bool            `json:"ipv6"`
+	Pinned       bool            `json:"pinned"`
+	FailCount    int             `json:"fail_count"`
+	Intermittent int             `json:"intermittent"`
+	LastChecked  time.Time       `json:"last_checked"`
+	BytesUp      int64           `json:"bytes_up"`
+	BytesDown    int64           `json:"bytes_down"`
+	Connections  int64           `json:"connections"`
+}
+
+func newProxyView(p *proxy.Proxy) proxyView {
+	return proxyView{
+		Address:      p.Address,
+		Protocol:     p.Protocol,
+		LatencyMs:    p.Latency * 1000,
+		Speed:        p.Speed,
+		Anonymity:    p.Anonymity,
+		Country:      p.Country,
+		City:         p.City,
+		Score:        p.Score,
+		JitterMs:     p.Jitter * 1000,
+		Stability:    p.Stability,
+		ExitIP:       p.ExitIP,
+		ASN:          p.ASN,
+		ASOrg:        p.ASOrg,
+		Tags:         p.Tags,
+		RemoteDNS:    p.RemoteDNS,
+		DNSChecked:   p.DNSChecked,
+		Targets:      p.TargetChecks,
+		HTTPS:        p.SupportsHTTPS,
+		IPv6:         p.IsIPv6(),
+		Pinned:       p.Pinned,
+		FailCount:    p.FailCount,
+		Intermittent: p.Intermittent,
+		LastChecked:  p.LastChecked,
+		BytesUp:      p.BytesUp,
+		BytesDown:    p.BytesDown,
+		Connections:  p.Connections,
+	}
+}
+
+// handleListProxies 返回有效代理列表
+// 查询参数 max_latency(ms)、min_speed(KB/s)、remote_dns(true/false)、https(true/false)、ip_version(4/6)、target(检测目标名称)、tag(标签) 用于筛选
+func (s *Server) handleListProxies(w http.ResponseWriter, r *http.Request) {
+	filter := proxy.NoFilter()
+	query := r.URL.Query()
+	if v := query.Get("max_latency"); v != "" {
+		ms, err := strconv.ParseFloat(v, 64)
+		if err != nil || ms <= 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("无效的 max_latency: %s", v))
+			return
+		}
+		filter.MaxLatency = ms / 1000
+	}
+	if v := query.Get("min_speed"); v != "" {
+		speed, err := strconv.ParseFloat(v, 64)
+		if err != nil || speed < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("无效的 min_speed: %s", v))
+			return
+		}
+		filter.MinSpeed = speed
+	}
+	filter.RemoteDNSOnly = query.Get("remote_dns") == "true"
+	filter.HTTPSOnly = query.Get("https") == "true"
+	filter.Target = query.Get("target")
+	filter.Tag = strings.TrimSpace(query.Get("tag"))
+	switch v := query.Get("ip_version"); v {
+	case "":
+	case "4", "6":
+		filter.IPVersion, _ = strconv.Atoi(v)
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Errorf("无效的 ip_version: %s", v))
+		return
+	}
+
+	proxies := s.controller.ListProxies(filter)
+	views := make([]proxyView, len(proxies))
+	for i, p := range proxies {
+		views[i] = newProxyView(p)
+	}
+	writeJSON(w, http.StatusOK, views)
+}
+
+// handleAddProxies 添加代理到原始列表
+// 请求体为JSON {"proxies": ["socks5://1.2.3.4:1080", ...]}
+// 响应中的 errors 列出每个无法解析的行及原因
+func (s *Server) handleAddProxies(w http.ResponseWriter, r *http.Request) {
+	lines, err := readLines(r, "proxies")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	added, skipped, lineErrors := s.controller.AddProxyLines(lines)
+	errs := make([]string, len(lineErrors))
+	for i, e := range lineErrors {
+		errs[i] = e.Error()
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"added": added, "skipped": skipped, "errors": errs})
+}
+
+// handleDeleteProxies 按地址删除代理
+// 请求体为JSON {"addresses": ["1.2.3.4:1080", ...]}
+func (s *Server) handleDeleteProxies(w http.ResponseWriter, r *http.Request) {
+	addresses, err := readLines(r, "addresses")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	removed := s.controller.RemoveProxies(addresses)
+	writeJSON(w, http.StatusOK, map[string]int{"removed": removed})
+}
+
+// handleStats 返回代理池统计
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.controller.GetPoolStats())
+}
+
+// handleTraffic 返回本地服务经由各上游代理转发的流量统计
+func (s *Server) handleTraffic(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.controller.GetTrafficStats())
+}
+
+// handleFetch 在后台从所有代理源获取代理
+func (s *Server) handleFetch(w http.ResponseWriter, r *http.Request) {
+	s.controller.FetchProxies()
+	writeJSON(w, http.StatusAccepted, map[string]string{"status": "fetching"})
+}
+
+// handleTest 在后台测试代理
+// 查询参数 mode: all(默认，清空后全部重测)、untested(只测新增)、failed(重测失败)
+func (s *Server) handleTest(w http.ResponseWriter, r *http.Request) {
+	switch mode := r.URL.Query().Get("mode"); mode {
+	case "", "all":
+		s.controller.TestAllProxies()
+	case "untested":
+		s.controller.TestUntestedProxies()
+	case "failed":
+		s.controller.RetestFailedProxies()
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Errorf("未知的测试模式: %s", mode))
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]string{"status": "testing"})
+}
+
+// serverStatusView 本地代理服务状态
+type serverStatusView struct {
+	Running bool   `json:"running"`
+	Host    string `json:"host"`
+	Port    string `json:"port"`
+	Mode    string `json:"mode"`
+}
+
+func (s *Server) serverStatus() serverStatusView {
+	running, host, port, mode := s.controller.ServerStatus()
+	return serverStatusView{Running: running, Host: host, Port: port, Mode: mode}
+}
+
+// handleServerStatus 返回本地代理服务状态
+func (s *Server) handleServerStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.serverStatus())
+}
+
+// handleServerStart 启动本地代理服务
+// 请求体可选JSON {"host": "127.0.0.1", "port": "10808"}，缺省时沿用上次的设置
+func (s *Server) handleServerStart(w http.ResponseWriter, r *http.Request) {
+	current := s.serverStatus()
+	req := struct {
+		Host string `json:"host"`
+		Port string `json:"port"`
+	}{Host: current.Host, Port: current.Port}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if err := s.controller.StartServer(req.Host, req.Port); err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.serverStatus())
+}
+
+// handleServerStop 停止本地代理服务
+func (s *Server) handleServerStop(w http.ResponseWriter, r *http.Request) {
+	if err := s.controller.StopServer(); err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.serverStatus())
+}
+
+// handleGetCheckConfig 返回检测服务配置
+func (s *Server) handleGetCheckConfig(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.controller.GetCheckConfig())
+}
+
+// handleSetCheckConfig 更新检测服务配置
+// 请求体为JSON，未提供的字段沿用当前配置
+func (s *Server) handleSetCheckConfig(w http.ResponseWriter, r *http.Request) {
+	cfg := s.controller.GetCheckConfig()
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&cfg); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("无效的JSON: %v", err))
+		return
+	}
+	if err := s.controller.SetCheckConfig(cfg); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, cfg)
+}
+
+// aclView 本地服务客户端访问控制的JSON表示
+// 列表项为IP或CIDR，deny 优先于 allow，allow 为空表示不限制
+type aclView struct {
+	Allow []string `json:"allow"`
+	Deny  []string `json:"deny"`
+}
+
+// handleGetACL 返回本地服务的客户端访问控制列表
+func (s *Server) handleGetACL(w http.ResponseWriter, r *http.Request) {
+	allow, deny := s.controller.GetClientACL()
+	writeJSON(w, http.StatusOK, aclView{Allow: allow, Deny: deny})
+}
+
+// handleSetACL 替换本地服务的客户端访问控制列表
+// 请求体为JSON {"allow": ["192.168.1.0/24"], "deny": ["192.168.1.100"]}，未提供的字段沿用当前设置
+func (s *Server) handleSetACL(w http.ResponseWriter, r *http.Request) {
+	var acl aclView
+	acl.Allow, acl.Deny = s.controller.GetClientACL()
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&acl); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("无效的JSON: %v", err))
+		return
+	}
+	if err := s.controller.SetClientACL(acl.Allow, acl.Deny); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	allow, deny := s.controller.GetClientACL()
+	writeJSON(w, http.StatusOK, aclView{Allow: allow, Deny: deny})
+}
+
+// handleEvents 以 Server-Sent Events 推送代理池和本地服务的状态变化
+// 每个事件的 event 字段为事件类型，data 字段为JSON格式的事件
+// 查询参数 types 可指定只接收的事件类型，以逗号分隔，例如 types=proxy_validated,proxy_removed
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("不支持流式响应"))
+		return
+	}
+	var wanted map[events.Type]bool
+	if types := r.URL.Query().Get("types"); types != "" {
+		wanted = make(map[events.Type]bool)
+		for _, t := range strings.Split(types, ",") {
+			wanted[events.Type(strings.TrimSpace(t))] = true
+		}
+	}
+
+	ch, cancel := s.controller.SubscribeEvents(eventBuffer)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(eventKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.done:
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case event, ok := <-ch:
+			if !ok {
+				return
+			}
+			if wanted != nil && !wanted[event.Type] {
+				continue
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+			flusher.Flush()
+		}
+	}
+}
+
+// readLines 读取JSON请求体中 key 字段的字符串数组，忽略空白项
+func readLines(r *http.Request, key string) ([]string, error) {
+	var payload map[string][]string
+	if err := json.NewDecoder(io.LimitReader(r.Body, 10<<20)).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("无效的JSON: %v", err)
+	}
+	var lines []string
+	for _, line := range payload[key] {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+// writeJSON 以JSON格式写入响应
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError 以 {"error": "..."} 格式写入错误响应
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
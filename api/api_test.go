@@ -0,0 +1,200 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go_proxy/checker"
+	"go_proxy/events"
+	"go_proxy/proxy"
+)
+
+// fakeController 记录调用的 Controller 实现
+type fakeController struct {
+	running    bool
+	host, port string
+	lines      []string
+	removed    []string
+}
+
+func (c *fakeController) FetchProxies()                                     {}
+func (c *fakeController) TestAllProxies()                                   {}
+func (c *fakeController) TestUntestedProxies()                              {}
+func (c *fakeController) RetestFailedProxies()                              {}
+func (c *fakeController) ListProxies(proxy.Filter) []*proxy.Proxy           { return nil }
+func (c *fakeController) GetPoolStats() proxy.PoolStats                     { return proxy.PoolStats{} }
+func (c *fakeController) GetTrafficStats() proxy.TrafficStats               { return proxy.TrafficStats{} }
+func (c *fakeController) GetCheckConfig() checker.Config                    { return checker.Config{} }
+func (c *fakeController) SetCheckConfig(checker.Config) error               { return nil }
+func (c *fakeController) GetClientACL() (allow, deny []string)              { return nil, nil }
+func (c *fakeController) SetClientACL(allow, deny []string) error           { return nil }
+func (c *fakeController) SubscribeEvents(int) (<-chan events.Event, func()) { return nil, func() {} }
+
+func (c *fakeController) AddProxyLines(lines []string) (int, int, []proxy.LineError) {
+	c.lines = append(c.lines, lines...)
+	return len(lines), 0, nil
+}
+
+func (c *fakeController) RemoveProxies(addresses []string) int {
+	c.removed = append(c.removed, addresses...)
+	return len(addresses)
+}
+
+func (c *fakeController) StartServer(host, port string) error {
+	if c.running {
+		return errors.New("服务已在运行")
+	}
+	c.running, c.host, c.port = true, host, port
+	return nil
+}
+
+func (c *fakeController) StopServer() error {
+	if !c.running {
+		return errors.New("服务未在运行")
+	}
+	c.running = false
+	return nil
+}
+
+func (c *fakeController) ServerStatus() (bool, string, string, string) {
+	return c.running, c.host, c.port, "socks5"
+}
+
+const testToken = "secret"
+
+// newTestAPI 创建使用假控制器的API，返回测试服务器和控制器
+func newTestAPI(t *testing.T) (*httptest.Server, *fakeController) {
+	t.Helper()
+	controller := &fakeController{host: "127.0.0.1", port: "10808"}
+	ts := httptest.NewServer(NewServer("127.0.0.1:0", testToken, controller).httpServer.Handler)
+	t.Cleanup(ts.Close)
+	return ts, controller
+}
+
+// do 发送请求并返回响应状态码，out 非nil时解码JSON响应体
+func do(t *testing.T, ts *httptest.Server, method, path, body string, header map[string]string, out interface{}) int {
+	t.Helper()
+	req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("创建请求失败: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	if method != http.MethodGet {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range header {
+		if v == "" {
+			req.Header.Del(k)
+		} else {
+			req.Header.Set(k, v)
+		}
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("解码响应失败: %v", err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestAuthorize(t *testing.T) {
+	ts, _ := newTestAPI(t)
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		header map[string]string
+		want   int
+	}{
+		{"无令牌", http.MethodGet, "/api/stats", map[string]string{"Authorization": ""}, http.StatusUnauthorized},
+		{"错误令牌", http.MethodGet, "/api/stats", map[string]string{"Authorization": "Bearer wrong"}, http.StatusUnauthorized},
+		{"正确令牌", http.MethodGet, "/api/stats", nil, http.StatusOK},
+		{"查询参数令牌", http.MethodGet, "/api/stats?token=" + testToken, map[string]string{"Authorization": ""}, http.StatusOK},
+		{"跨站 Origin", http.MethodGet, "/api/stats", map[string]string{"Origin": "http://evil.example"}, http.StatusForbidden},
+		{"同源 Origin", http.MethodGet, "/api/stats", map[string]string{"Origin": ts.URL}, http.StatusOK},
+		{"纯文本请求体", http.MethodPost, "/api/server/stop", map[string]string{"Content-Type": "text/plain"}, http.StatusUnsupportedMediaType},
+		{"缺少 Content-Type", http.MethodPost, "/api/fetch", map[string]string{"Content-Type": ""}, http.StatusUnsupportedMediaType},
+	}
+	for _, c := range cases {
+		if got := do(t, ts, c.method, c.path, "", c.header, nil); got != c.want {
+			t.Errorf("%s: 状态码 = %d，期望 %d", c.name, got, c.want)
+		}
+	}
+}
+
+func TestServerStartStop(t *testing.T) {
+	ts, controller := newTestAPI(t)
+
+	var status serverStatusView
+	if code := do(t, ts, http.MethodPost, "/api/server/start", `{"port":"1080"}`, nil, &status); code != http.StatusOK {
+		t.Fatalf("启动服务状态码 = %d", code)
+	}
+	if !status.Running || status.Host != "127.0.0.1" || status.Port != "1080" {
+		t.Errorf("启动后状态 = %+v，期望沿用主机 127.0.0.1 并使用端口 1080", status)
+	}
+	if code := do(t, ts, http.MethodPost, "/api/server/start", "", nil, nil); code != http.StatusConflict {
+		t.Errorf("重复启动状态码 = %d，期望 %d", code, http.StatusConflict)
+	}
+
+	if code := do(t, ts, http.MethodPost, "/api/server/stop", "", nil, &status); code != http.StatusOK || status.Running {
+		t.Errorf("停止服务状态码 = %d，运行状态 = %v", code, status.Running)
+	}
+	if controller.running {
+		t.Error("停止后控制器仍在运行")
+	}
+	if code := do(t, ts, http.MethodPost, "/api/server/stop", "", nil, nil); code != http.StatusConflict {
+		t.Errorf("重复停止状态码 = %d，期望 %d", code, http.StatusConflict)
+	}
+}
+
+func TestAddAndDeleteProxies(t *testing.T) {
+	ts, controller := newTestAPI(t)
+
+	var added struct {
+		Added   int `json:"added"`
+		Skipped int `json:"skipped"`
+	}
+	body := `{"proxies": ["socks5://1.2.3.4:1080", " ", "5.6.7.8:8080"]}`
+	if code := do(t, ts, http.MethodPost, "/api/proxies", body, nil, &added); code != http.StatusOK {
+		t.Fatalf("添加代理状态码 = %d", code)
+	}
+	if added.Added != 2 || len(controller.lines) != 2 || controller.lines[1] != "5.6.7.8:8080" {
+		t.Errorf("添加结果 = %+v，控制器收到 %q", added, controller.lines)
+	}
+	if code := do(t, ts, http.MethodPost, "/api/proxies", "1.2.3.4:1080", nil, nil); code != http.StatusBadRequest {
+		t.Errorf("非JSON请求体状态码 = %d，期望 %d", code, http.StatusBadRequest)
+	}
+
+	var removed map[string]int
+	if code := do(t, ts, http.MethodDelete, "/api/proxies", `{"addresses": ["1.2.3.4:1080"]}`, nil, &removed); code != http.StatusOK {
+		t.Fatalf("删除代理状态码 = %d", code)
+	}
+	if removed["removed"] != 1 || len(controller.removed) != 1 || controller.removed[0] != "1.2.3.4:1080" {
+		t.Errorf("删除结果 = %v，控制器收到 %q", removed, controller.removed)
+	}
+}
+
+func TestIsLoopbackAddr(t *testing.T) {
+	cases := map[string]bool{
+		"127.0.0.1:8080": true,
+		"[::1]:8080":     true,
+		"localhost:8080": true,
+		"0.0.0.0:8080":   false,
+		":8080":          false,
+		"192.168.1.2:80": false,
+	}
+	for addr, want := range cases {
+		if got := IsLoopbackAddr(addr); got != want {
+			t.Errorf("IsLoopbackAddr(%q) = %v，期望 %v", addr, got, want)
+		}
+	}
+}
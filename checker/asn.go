@@ -0,0 +1,56 @@
+package checker
+
+import (
+	"net"
+
+	"go_proxy/proxy"
+)
+
+// SetASNDatabase 设置离线ASN数据库(MaxMind GeoLite2 ASN 的 .mmdb 文件)
+// 设置后 BatchLookupLocations 会为尚无ASN的代理补充自治系统号和组织名称，供分散选择模式使用
+// 参数 path: 数据库路径，为空表示不查询ASN
+// 返回错误如果文件无法读取或格式无效，此时保留原有设置
+func (c *Checker) SetASNDatabase(path string) error {
+	var db *mmdbReader
+	if path != "" {
+		var err error
+		if db, err = openMMDB(path); err != nil {
+			return err
+		}
+	}
+	c.geoMutex.Lock()
+	defer c.geoMutex.Unlock()
+	c.asnDB = db
+	return nil
+}
+
+// HasASNDatabase 返回是否已配置离线ASN数据库
+func (c *Checker) HasASNDatabase() bool {
+	c.geoMutex.RLock()
+	defer c.geoMutex.RUnlock()
+	return c.asnDB != nil
+}
+
+// lookupASNMMDB 从离线数据库查询代理IP所属的自治系统
+// 返回是否查到了ASN
+func lookupASNMMDB(db *mmdbReader, p *proxy.Proxy) bool {
+	ip := net.ParseIP(p.Host())
+	if ip == nil {
+		return false
+	}
+	record, err := db.lookup(ip)
+	if err != nil {
+		return false
+	}
+	fields, ok := record.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	number, ok := fields["autonomous_system_number"].(uint64)
+	if !ok || number == 0 {
+		return false
+	}
+	p.ASN = uint(number)
+	p.ASOrg, _ = fields["autonomous_system_organization"].(string)
+	return true
+}
@@ -1,6 +1,9 @@
 package checker
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -11,6 +14,7 @@ import (
 	"net/url"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"go_proxy/proxy"
@@ -21,15 +25,85 @@ import (
 // Checker 代理验证器结构体
 // 用于验证代理的连通性、速度、匿名度和地理位置信息
 // 包含公网IP和超时配置
+// timeout 为单个检测请求的总超时，connectTimeout 为与代理建立连接的超时
 type Checker struct {
-	publicIP string
-	timeout  time.Duration
+	publicIP       string
+	timeout        time.Duration
+	connectTimeout time.Duration
+	timeoutMutex   sync.RWMutex
+
+	// 本机直连时使用的DNS解析器IP，用于DNS泄漏比对
+	localResolver string
+	resolverMutex sync.Mutex
+
+	// 离线地理位置数据库，nil 时使用在线接口查询；离线ASN数据库，nil 时不查询ASN
+	geoDB    *mmdbReader
+	asnDB    *mmdbReader
+	geoMutex sync.RWMutex
+
+	// 检测使用的外部服务
+	config      Config
+	configMutex sync.RWMutex
+
+	// 偶发失败后的重试次数和首次重试前的等待时间，之后每次等待时间翻倍；
+	// latencySamples 为每次检测的延迟采样次数
+	retries        int
+	retryBackoff   time.Duration
+	latencySamples int
+	retryMutex     sync.RWMutex
 }
 
+// dnsEchoURL DNS回显服务地址，%s 处填入随机子域名
+// 服务返回实际为该域名发起解析的DNS服务器IP
+const dnsEchoURL = "http://%s.edns.ip-api.com/json"
+
 // NewChecker 创建新的代理验证器实例
-// 默认超时时间为10秒
+// 默认检测超时和连接超时均为10秒，偶发失败时重试1次，每次检测采样3次延迟
 func NewChecker() *Checker {
-	return &Checker{timeout: 10 * time.Second}
+	return &Checker{
+		timeout:        10 * time.Second,
+		connectTimeout: 10 * time.Second,
+		config:         DefaultConfig(),
+		retries:        defaultRetries,
+		retryBackoff:   defaultRetryBackoff,
+		latencySamples: defaultLatencySamples,
+	}
+}
+
+// SetTimeout 设置单个代理的检测超时时间
+// 超时同时作用于建立连接、连通性检测和测速请求，非正数时忽略
+func (c *Checker) SetTimeout(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	c.timeoutMutex.Lock()
+	defer c.timeoutMutex.Unlock()
+	c.timeout = d
+}
+
+// Timeout 返回当前的检测超时时间
+func (c *Checker) Timeout() time.Duration {
+	c.timeoutMutex.RLock()
+	defer c.timeoutMutex.RUnlock()
+	return c.timeout
+}
+
+// SetConnectTimeout 设置与代理建立连接的超时时间，非正数时忽略
+// 连接超时之后的握手和数据传输仍受检测超时限制
+func (c *Checker) SetConnectTimeout(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	c.timeoutMutex.Lock()
+	defer c.timeoutMutex.Unlock()
+	c.connectTimeout = d
+}
+
+// ConnectTimeout 返回当前的连接超时时间
+func (c *Checker) ConnectTimeout() time.Duration {
+	c.timeoutMutex.RLock()
+	defer c.timeoutMutex.RUnlock()
+	return c.connectTimeout
 }
 
 // InitializePublicIP 获取本机公网IP地址
@@ -58,104 +132,447 @@ func (c *Checker) InitializePublicIP() error {
 
 // CheckConnectivityAndSpeed 检查代理的连通性、响应速度和匿名度
 // 参数 p 是要检查的代理对象
+// 观察到的出口IP记录在 p.ExitIP，出口暴露本机公网IP时判定为透明代理
+// 超时等偶发错误按重试策略重试，重试后成功时累加 p.Intermittent，与最终失败分开统计
 // 返回值：
 //
 //	float64: 延迟时间（秒）
 //	string: 匿名级别（"Elite", "Anonymous" 或 "Transparent"）
-//	error: 如果检查失败返回错误信息
+//	error: 如果检查失败返回错误信息(最后一次尝试的错误)
 func (c *Checker) CheckConnectivityAndSpeed(p *proxy.Proxy) (float64, string, error) {
-	// 计算代理评分
-	c.calculateScore(p)
-	return c.checkProxy(p)
+	return c.CheckConnectivityAndSpeedContext(context.Background(), p)
+}
+
+// CheckConnectivityAndSpeedContext 与 CheckConnectivityAndSpeed 相同，ctx 取消后中止进行中的请求和重试等待
+// 被取消的检测返回 ctx.Err()，代理恢复为检测前的测量结果，不计算评分也不更新检测时间
+func (c *Checker) CheckConnectivityAndSpeedContext(ctx context.Context, p *proxy.Proxy) (float64, string, error) {
+	before := saveMeasurement(p)
+	retries, backoff := c.RetryPolicy()
+	for attempt := 0; ; attempt++ {
+		latency, anonymity, err := c.checkProxy(ctx, p)
+		if ctx.Err() != nil {
+			before.restore(p)
+			return 0, "", ctx.Err()
+		}
+		if err == nil {
+			if attempt > 0 {
+				p.Intermittent++
+			}
+			// 检测结束后按新的测量结果计算代理评分
+			c.calculateScore(p)
+			return latency, anonymity, nil
+		}
+		if attempt >= retries || !retryable(err) {
+			c.calculateScore(p)
+			return latency, anonymity, err
+		}
+		select {
+		case <-time.After(backoff << attempt):
+		case <-ctx.Done():
+			before.restore(p)
+			return 0, "", ctx.Err()
+		}
+	}
+}
+
+// measurement 检测过程中会被改写的测量结果，检测被取消时用于恢复
+type measurement struct {
+	latency, speed, jitter, stability float64
+	anonymity, exitIP                 string
+	hijacked                          bool
+}
+
+// saveMeasurement 保存代理当前的测量结果
+func saveMeasurement(p *proxy.Proxy) measurement {
+	return measurement{
+		latency:   p.Latency,
+		speed:     p.Speed,
+		jitter:    p.Jitter,
+		stability: p.Stability,
+		anonymity: p.Anonymity,
+		exitIP:    p.ExitIP,
+		hijacked:  p.Hijacked,
+	}
+}
+
+// restore 将测量结果写回代理
+func (m measurement) restore(p *proxy.Proxy) {
+	p.Latency = m.latency
+	p.Speed = m.speed
+	p.Jitter = m.jitter
+	p.Stability = m.stability
+	p.Anonymity = m.anonymity
+	p.ExitIP = m.exitIP
+	p.Hijacked = m.hijacked
+}
+
+// contextTransport 为经过的每个请求绑定 ctx，ctx 取消时进行中的请求立即失败
+type contextTransport struct {
+	ctx  context.Context
+	base http.RoundTripper
+}
+
+func (t contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.base.RoundTrip(req.WithContext(t.ctx))
 }
 
+// maxCheckBodySize 连通性检测和匿名度判断读取响应的上限
+const maxCheckBodySize = 1 << 20
+
 // checkProxy 实际执行代理检查的内部方法
-func (c *Checker) checkProxy(p *proxy.Proxy) (float64, string, error) {
+// 依次请求连通性检测地址、匿名度判断地址(与前者相同时复用响应)和测速地址
+// 连通性检测的响应被跳转到其他主机或内容不符合预期时判定为劫持，记录在 p.Hijacked
+// 失败时返回 *CheckError，可通过 ReasonOf 获取失败分类
+// 参数 ctx: 绑定到检测发出的全部请求，取消后请求立即失败
+func (c *Checker) checkProxy(ctx context.Context, p *proxy.Proxy) (float64, string, error) {
+	cfg := c.Config()
+	if p.IsIPv6() && !HasIPv6() {
+		return 0, "", &CheckError{Reason: FailureOther, Err: errNoIPv6}
+	}
 	client, err := c.createProxyClient(p)
 	if err != nil {
-		return 0, "", err
+		return 0, "", &CheckError{Reason: FailureOther, Err: err}
 	}
+	client.Transport = contextTransport{ctx: ctx, base: client.Transport}
 
 	startTime := time.Now()
-	resp, err := client.Get("http://httpbin.org/get")
+	resp, err := client.Get(cfg.ConnectivityURL)
 	if err != nil {
-		return 0, "", err
+		return 0, "", newCheckError(err)
 	}
 	defer resp.Body.Close()
 	p.Latency = time.Since(startTime).Seconds()
 
-	var data map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&data); err == nil {
-		headers, _ := data["headers"].(map[string]interface{})
-		forwardedFor, _ := headers["X-Forwarded-For"].(string)
-		if forwardedFor != "" {
-			p.Anonymity = "Anonymous"
-		} else {
-			p.Anonymity = "Elite"
+	if err := checkRedirect(cfg.ConnectivityURL, resp); err != nil {
+		p.Hijacked = true
+		return 0, "", err
+	}
+	expectStatus := cfg.ExpectStatus
+	if expectStatus == 0 {
+		expectStatus = http.StatusOK
+	}
+	if resp.StatusCode != expectStatus {
+		return 0, "", &CheckError{Reason: FailureStatus, Err: fmt.Errorf("HTTP状态码 %d", resp.StatusCode)}
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxCheckBodySize))
+	if err != nil {
+		return 0, "", newCheckError(err)
+	}
+	if err := checkContent(cfg, body); err != nil {
+		p.Hijacked = true
+		return 0, "", err
+	}
+	p.Hijacked = false
+
+	switch cfg.JudgeURL {
+	case "":
+	case cfg.ConnectivityURL:
+		if err := c.judgeAnonymity(p, body); err != nil {
+			return 0, "", err
+		}
+	default:
+		// 独立的判断服务失败不影响连通性结论，只是无法得出匿名度
+		if judgeBody, err := fetchBody(client, cfg.JudgeURL); err == nil {
+			c.judgeAnonymity(p, judgeBody)
 		}
 	}
 
-	speed, _ := c.checkSpeed(client)
-	p.Speed = speed
+	measureStability(p, client, cfg.ConnectivityURL, p.Latency, c.LatencySamples()-1)
+
+	if cfg.SpeedTestURL != "" {
+		speed, _ := c.checkSpeed(client, cfg.SpeedTestURL, cfg.SpeedTestSize)
+		p.Speed = speed
+	}
 
 	return p.Latency, p.Anonymity, nil
 }
 
-// BatchLookupLocations 批量查询代理IP的地理位置信息
-// 使用本地IP查询API获取国家/省份/城市信息
-// 参数 proxies 是需要查询的代理列表
-// 返回错误如果API调用失败
-func (c *Checker) BatchLookupLocations(proxies []*proxy.Proxy) error {
-	if len(proxies) == 0 {
-		return nil
+// fetchBody 通过代理客户端请求地址并读取响应内容，非200状态码视为失败
+func fetchBody(client *http.Client, target string) ([]byte, error) {
+	resp, err := client.Get(target)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP状态码 %d", resp.StatusCode)
 	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxCheckBodySize))
+}
 
-	client := &http.Client{Timeout: 5 * time.Second}
-	for _, p := range proxies {
-		ip := strings.Split(p.Address, ":")[0]
-		url := fmt.Sprintf("https://ip9.com.cn/get?ip=%s", ip)
+// RecheckOne 立即完整检测单个代理
+// 先检测连通性和速度，成功后再检测DNS泄漏(仅SOCKS5)、HTTPS能力和各检测目标，结果记录在代理的对应字段；
+// DNS泄漏、HTTPS和目标检测失败不视为代理失效；失败次数和最近失败原因由调用方通过 Rotator.RecordCheck 记录
+// 返回连通性检测失败的原因
+func (c *Checker) RecheckOne(p *proxy.Proxy) error {
+	return c.recheck(context.Background(), p)
+}
 
-		resp, err := client.Get(url)
-		if err != nil {
-			continue
+// recheck 同 RecheckOne，ctx 取消时中止连通性检测并返回 ctx.Err()
+func (c *Checker) recheck(ctx context.Context, p *proxy.Proxy) error {
+	if _, _, err := c.CheckConnectivityAndSpeedContext(ctx, p); err != nil {
+		return err
+	}
+	if strings.EqualFold(p.Protocol, "socks5") {
+		c.CheckDNSLeak(p)
+	}
+	c.CheckHTTPS(p)
+	c.CheckTargets(p)
+	return nil
+}
+
+// CheckHTTPS 检测代理能否建立HTTPS连接，结果记录在 p.SupportsHTTPS
+// HTTP代理通过CONNECT建立隧道，SOCKS代理直接转发TLS；
+// 只要TLS握手成功并收到响应即视为支持，不关心状态码，证书校验失败(如中间人篡改)视为不支持
+// 未配置HTTPS检测地址时不检测，保留原有结果
+// 返回是否支持和检测失败的原因
+func (c *Checker) CheckHTTPS(p *proxy.Proxy) (bool, error) {
+	target := c.Config().HTTPSCheckURL
+	if target == "" {
+		return p.SupportsHTTPS, nil
+	}
+	client, err := c.createProxyClient(p)
+	if err != nil {
+		return false, err
+	}
+	resp, err := client.Get(target)
+	if err != nil {
+		p.SupportsHTTPS = false
+		return false, err
+	}
+	resp.Body.Close()
+	p.SupportsHTTPS = true
+	return true, nil
+}
+
+// CheckDNSLeak 检测SOCKS5代理是否存在DNS泄漏
+// 通过代理访问DNS回显服务，将代理侧看到的解析器与本机直连时的解析器比较
+// 每次检测使用唯一的随机子域名，解析请求必然到达权威服务器，不会被缓存掩盖
+// 两者不同说明域名由代理在远端解析，结果记录在 p.RemoteDNS，检测完成后 p.DNSChecked 置为true
+// 参数 p 是要检测的代理，仅支持SOCKS5协议
+// 返回是否远程解析DNS和可能的错误
+func (c *Checker) CheckDNSLeak(p *proxy.Proxy) (bool, error) {
+	if strings.ToLower(p.Protocol) != "socks5" {
+		return false, errors.New("DNS泄漏检测仅支持SOCKS5代理")
+	}
+
+	localResolver, err := c.getLocalResolver()
+	if err != nil {
+		return false, fmt.Errorf("获取本机DNS解析器失败: %v", err)
+	}
+
+	client, err := c.createProxyClient(p)
+	if err != nil {
+		return false, err
+	}
+	proxyResolver, err := lookupResolver(client)
+	if err != nil {
+		return false, err
+	}
+
+	p.RemoteDNS = proxyResolver != localResolver
+	p.DNSChecked = true
+	return p.RemoteDNS, nil
+}
+
+// getLocalResolver 获取本机直连时的DNS解析器IP，结果会被缓存
+func (c *Checker) getLocalResolver() (string, error) {
+	c.resolverMutex.Lock()
+	defer c.resolverMutex.Unlock()
+	if c.localResolver != "" {
+		return c.localResolver, nil
+	}
+
+	resolver, err := lookupResolver(&http.Client{Timeout: c.Timeout()})
+	if err != nil {
+		return "", err
+	}
+	c.localResolver = resolver
+	return resolver, nil
+}
+
+// lookupResolver 使用给定客户端请求DNS回显服务
+// 每次使用随机子域名，避免命中DNS缓存
+// 返回为该请求解析域名的DNS服务器IP
+func lookupResolver(client *http.Client) (string, error) {
+	label := make([]byte, 16)
+	if _, err := rand.Read(label); err != nil {
+		return "", err
+	}
+
+	resp, err := client.Get(fmt.Sprintf(dnsEchoURL, hex.EncodeToString(label)))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		DNS struct {
+			IP string `json:"ip"`
+		} `json:"dns"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	if result.DNS.IP == "" {
+		return "", errors.New("DNS回显服务未返回解析器地址")
+	}
+	return result.DNS.IP, nil
+}
+
+// 在线地理位置查询的并发数、单次请求超时和失败重试次数
+const (
+	geoLookupWorkers = 8
+	geoLookupTimeout = 5 * time.Second
+	geoLookupRetries = 2
+)
+
+// BatchLookupLocations 批量查询代理IP的地理位置信息
+// 配置了离线数据库时直接查询本地 .mmdb 文件，否则使用在线IP查询API获取国家/省份/城市信息，
+// 在线查询以有限并发进行，网络错误、限流和服务端错误会按退避间隔重试
+// 已有国家信息的代理会被跳过；配置了离线ASN数据库时同时为尚无ASN的代理补充ASN
+// 参数 ctx: 取消后停止派发新的查询
+// 参数 proxies: 需要查询的代理列表
+// 参数 progress: 每完成一个查询调用一次，total 为需要查询的数量，可为nil
+// 返回 ctx 被取消时的错误
+func (c *Checker) BatchLookupLocations(ctx context.Context, proxies []*proxy.Proxy, progress func(done, total int)) error {
+	c.geoMutex.RLock()
+	db, asnDB := c.geoDB, c.asnDB
+	c.geoMutex.RUnlock()
+	if asnDB != nil {
+		for _, p := range proxies {
+			if p.ASN == 0 {
+				lookupASNMMDB(asnDB, p)
+			}
 		}
-		defer resp.Body.Close()
-
-		var result struct {
-			Ret  int `json:"ret"`
-			Data struct {
-				Country string `json:"country"`
-				Prov    string `json:"prov"`
-				City    string `json:"city"`
-			} `json:"data"`
+	}
+
+	var pending []*proxy.Proxy
+	for _, p := range proxies {
+		if p.Country == "" { // 代理源未提供地理位置
+			pending = append(pending, p)
 		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	if progress == nil {
+		progress = func(int, int) {}
+	}
 
-		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-			continue
+	if db != nil {
+		for i, p := range pending {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			lookupLocationMMDB(db, p)
+			progress(i+1, len(pending))
 		}
+		return nil
+	}
 
-		if result.Ret == 200 {
-			p.Country = result.Data.Country
-			p.Province = result.Data.Prov
-			p.City = result.Data.City
+	var wg sync.WaitGroup
+	var done int32
+	sem := make(chan struct{}, geoLookupWorkers)
+	client := &http.Client{}
+
+dispatch:
+	for _, p := range pending {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			break dispatch
 		}
+		wg.Add(1)
+		go func(p *proxy.Proxy) {
+			defer func() {
+				<-sem
+				progress(int(atomic.AddInt32(&done, 1)), len(pending))
+				wg.Done()
+			}()
+			for attempt := 0; attempt <= geoLookupRetries; attempt++ {
+				if attempt > 0 {
+					select {
+					case <-time.After(time.Duration(attempt) * 500 * time.Millisecond):
+					case <-ctx.Done():
+						return
+					}
+				}
+				if !lookupLocationHTTP(ctx, client, p) {
+					return
+				}
+			}
+		}(p)
 	}
-	return nil
+	wg.Wait()
+	return ctx.Err()
+}
+
+// LookupIPLocation 查询单个IP的国家/省份/城市，配置了离线数据库时查询本地文件，否则使用在线接口
+// 查询失败时返回空字符串
+func (c *Checker) LookupIPLocation(ctx context.Context, ip string) (country, province, city string) {
+	probe := &proxy.Proxy{Address: net.JoinHostPort(ip, "0")}
+	c.BatchLookupLocations(ctx, []*proxy.Proxy{probe}, nil)
+	return probe.Country, probe.Province, probe.City
+}
+
+// lookupLocationHTTP 通过在线接口查询代理的国家/省份/城市，失败时保持原值
+// 返回是否值得重试(网络错误、限流或服务端错误)
+func lookupLocationHTTP(ctx context.Context, client *http.Client, p *proxy.Proxy) (retry bool) {
+	ctx, cancel := context.WithTimeout(ctx, geoLookupTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		fmt.Sprintf("https://ip9.com.cn/get?ip=%s", url.QueryEscape(p.Host())), nil)
+	if err != nil {
+		return false
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil || errors.Is(ctx.Err(), context.DeadlineExceeded)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return true
+	}
+
+	var result struct {
+		Ret  int `json:"ret"`
+		Data struct {
+			Country string `json:"country"`
+			Prov    string `json:"prov"`
+			City    string `json:"city"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return false
+	}
+	if result.Ret == 200 {
+		p.Country = result.Data.Country
+		p.Province = result.Data.Prov
+		p.City = result.Data.City
+	}
+	return false
 }
 
 // checkSpeed 测试代理的下载速度
-// 通过下载100KB测试文件计算速度（KB/s）
+// 从测速地址下载最多 limit 字节计算速度（KB/s）
 // 参数 client 是配置好代理的HTTP客户端
+// 参数 target 是测速文件地址
+// 参数 limit 是最多下载的字节数，0 表示下载完整文件
 // 返回速度（KB/s）和可能的错误
-func (c *Checker) checkSpeed(client *http.Client) (float64, error) {
+func (c *Checker) checkSpeed(client *http.Client, target string, limit int64) (float64, error) {
 	startTime := time.Now()
-	resp, err := client.Get("http://cachefly.cachefly.net/100kb.test")
+	resp, err := client.Get(target)
 	if err != nil {
 		return 0, err
 	}
 	defer resp.Body.Close()
 
-	data, err := io.ReadAll(resp.Body)
+	var body io.Reader = resp.Body
+	if limit > 0 {
+		body = io.LimitReader(resp.Body, limit)
+	}
+	n, err := io.Copy(io.Discard, body)
 	if err != nil {
 		return 0, err
 	}
@@ -166,29 +583,30 @@ func (c *Checker) checkSpeed(client *http.Client) (float64, error) {
 	}
 
 	// 转换为KB/s
-	speedKBps := float64(len(data)) / 1024 / duration
+	speedKBps := float64(n) / 1024 / duration
 	return speedKBps, nil
 }
 
 // calculateScore 计算代理综合评分
-// 延迟权重40%，速度权重40%，匿名度权重20%
+// 延迟权重30%，速度权重30%，稳定性权重25%，匿名度权重15%
 func (c *Checker) calculateScore(p *proxy.Proxy) {
 	p.LastChecked = time.Now()
 
 	// 计算各项评分
-	latencyScore := (1 - math.Min(p.Latency/5, 1)) * 40
-	speedScore := math.Min(p.Speed/1000, 1) * 40
+	latencyScore := (1 - math.Min(p.Latency/5, 1)) * 30
+	speedScore := math.Min(p.Speed/1000, 1) * 30
+	stabilityScore := p.Stability / 100 * 25
 	anonymityScore := 0.0
 	switch p.Anonymity {
 	case "Elite":
-		anonymityScore = 20
+		anonymityScore = 15
 	case "Anonymous":
-		anonymityScore = 10
+		anonymityScore = 8
 	}
 
 	// 考虑失败次数惩罚
 	failPenalty := float64(p.FailCount) * 5
-	p.Score = math.Max(0, latencyScore+speedScore+anonymityScore-failPenalty)
+	p.Score = math.Max(0, latencyScore+speedScore+stabilityScore+anonymityScore-failPenalty)
 }
 
 // ConcurrentCheck 并发验证代理列表
@@ -209,22 +627,68 @@ func (c *Checker) ConcurrentCheck(proxies []*proxy.Proxy, workers int) {
 	wg.Wait()
 }
 
+// FilterReachable 并发对代理端口做TCP连接预检，只返回端口可连接的代理
+// 参数 ctx: 取消后停止派发新的预检，已取消时未完成预检的代理视为不可达
+// 参数 timeout: 单个连接的超时时间
+// 参数 workers: 最大并发数
+func (c *Checker) FilterReachable(ctx context.Context, proxies []*proxy.Proxy, timeout time.Duration, workers int) []*proxy.Proxy {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, workers)
+	reachable := make([]bool, len(proxies))
+	dialer := &net.Dialer{Timeout: timeout}
+	ipv6 := HasIPv6()
+
+dispatch:
+	for i, p := range proxies {
+		if p.IsIPv6() && !ipv6 {
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			break dispatch
+		}
+		wg.Add(1)
+		go func(i int, p *proxy.Proxy) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			conn, err := dialer.DialContext(ctx, "tcp", p.Address)
+			if err != nil {
+				return
+			}
+			conn.Close()
+			reachable[i] = true
+		}(i, p)
+	}
+	wg.Wait()
+
+	var result []*proxy.Proxy
+	for i, p := range proxies {
+		if reachable[i] {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
 // createProxyClient 创建配置了指定代理的HTTP客户端
 // 根据代理协议（HTTP/HTTPS/SOCKS4/SOCKS5）创建对应的传输层
 // 参数 p 是要使用的代理信息
 // 返回配置好的HTTP客户端和可能的错误
 func (c *Checker) createProxyClient(p *proxy.Proxy) (*http.Client, error) {
-	proxyURL, err := url.Parse(fmt.Sprintf("%s://%s", strings.ToLower(p.Protocol), p.Address))
-	if err != nil {
-		return nil, err
-	}
+	proxyURL := p.URL()
+
+	timeout := c.Timeout()
+	forward := &net.Dialer{Timeout: c.ConnectTimeout()}
 
 	var transport *http.Transport
 	switch strings.ToLower(p.Protocol) {
 	case "http", "https":
-		transport = &http.Transport{Proxy: http.ProxyURL(proxyURL)}
-	case "socks5", "socks4":
-		dialer, err := xproxy.FromURL(proxyURL, xproxy.Direct)
+		transport = &http.Transport{Proxy: http.ProxyURL(proxyURL), DialContext: forward.DialContext}
+	case "socks5", "socks4", "socks4a":
+		dialer, err := xproxy.FromURL(proxyURL, forward)
 		if err != nil {
 			return nil, err
 		}
@@ -233,5 +697,22 @@ func (c *Checker) createProxyClient(p *proxy.Proxy) (*http.Client, error) {
 		return nil, errors.New("不支持的代理协议: " + p.Protocol)
 	}
 
-	return &http.Client{Transport: transport, Timeout: c.timeout}, nil
+	return &http.Client{Transport: transport, Timeout: timeout}, nil
+}
+
+// ipv6Probe 用于判断本机IPv6路由的公网地址，只做路由查找，不会发送数据
+const ipv6Probe = "[2001:4860:4860::8888]:53"
+
+// errNoIPv6 本机没有IPv6网络时检测IPv6代理返回的错误
+var errNoIPv6 = errors.New("本机没有可用的IPv6网络，无法连接IPv6代理")
+
+// HasIPv6 判断本机是否有可用的IPv6出口
+// 通过建立UDP"连接"触发路由查找，没有IPv6默认路由时立即失败
+func HasIPv6() bool {
+	conn, err := net.Dial("udp6", ipv6Probe)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
 }
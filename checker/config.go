@@ -0,0 +1,179 @@
+package checker
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Config 检测代理时使用的外部服务配置
+// 默认服务在部分地区被屏蔽或访问缓慢时，可替换为可达的同类服务
+// ConnectivityURL: 连通性检测地址，延迟以该请求计算
+// ExpectStatus: 连通性检测期望的HTTP状态码，0 表示200
+// ExpectBody: 连通性检测响应中必须包含的内容，为空表示不检查
+// ExpectJSONField: 连通性检测响应必须是包含该字段的JSON，多级字段以点分隔(如 headers.Host)，为空表示不检查
+// JudgeURL: 匿名度判断地址，需返回 httpbin /get 格式的JSON(origin 和 headers 字段)，可使用 -judge 启动的自建服务，为空表示不判断匿名度
+// HTTPSCheckURL: HTTPS能力检测地址，必须为 https 地址，为空表示不检测
+// SpeedTestURL: 测速下载地址，为空表示不测速
+// SpeedTestSize: 测速最多下载的字节数，0 表示下载完整文件
+// Targets: 用户关心的目标站点，每个代理逐一检测并记录是否可用，为空表示不检测
+type Config struct {
+	ConnectivityURL string        `json:"connectivity_url"`
+	ExpectStatus    int           `json:"expect_status,omitempty"`
+	ExpectBody      string        `json:"expect_body,omitempty"`
+	ExpectJSONField string        `json:"expect_json_field,omitempty"`
+	JudgeURL        string        `json:"judge_url"`
+	HTTPSCheckURL   string        `json:"https_check_url"`
+	SpeedTestURL    string        `json:"speed_test_url"`
+	SpeedTestSize   int64         `json:"speed_test_size,omitempty"`
+	Targets         []CheckTarget `json:"targets,omitempty"`
+}
+
+// CheckTarget 目标站点可用性检测项
+// Name: 目标名称，作为检测结果和上游筛选的标识
+// URL: 通过代理请求的地址
+// ExpectStatus: 期望的HTTP状态码，0 表示任意非4xx/5xx状态码(跟随重定向后)
+type CheckTarget struct {
+	Name         string `json:"name"`
+	URL          string `json:"url"`
+	ExpectStatus int    `json:"expect_status,omitempty"`
+}
+
+// DefaultConfig 返回默认的检测服务配置
+// 连通性检测和匿名度判断共用 httpbin，一次请求即可完成
+func DefaultConfig() Config {
+	return Config{
+		ConnectivityURL: "http://httpbin.org/get",
+		ExpectStatus:    200,
+		ExpectJSONField: "origin",
+		JudgeURL:        "http://httpbin.org/get",
+		HTTPSCheckURL:   "https://httpbin.org/get",
+		SpeedTestURL:    "http://cachefly.cachefly.net/100kb.test",
+		SpeedTestSize:   100 << 10,
+	}
+}
+
+// Validate 检查配置中的地址是否为有效的HTTP(S)地址
+func (cfg Config) Validate() error {
+	if cfg.ConnectivityURL == "" {
+		return errors.New("连通性检测地址不能为空")
+	}
+	for _, u := range []struct{ name, value string }{
+		{"连通性检测地址", cfg.ConnectivityURL},
+		{"匿名度判断地址", cfg.JudgeURL},
+		{"HTTPS检测地址", cfg.HTTPSCheckURL},
+		{"测速地址", cfg.SpeedTestURL},
+	} {
+		if u.value == "" {
+			continue
+		}
+		parsed, err := url.Parse(u.value)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("%s无效: %s", u.name, u.value)
+		}
+	}
+	if cfg.HTTPSCheckURL != "" && !strings.HasPrefix(cfg.HTTPSCheckURL, "https://") {
+		return fmt.Errorf("HTTPS检测地址必须以 https:// 开头: %s", cfg.HTTPSCheckURL)
+	}
+	if cfg.ExpectStatus != 0 && (cfg.ExpectStatus < 100 || cfg.ExpectStatus > 599) {
+		return fmt.Errorf("期望状态码无效: %d", cfg.ExpectStatus)
+	}
+	if cfg.SpeedTestSize < 0 {
+		return errors.New("测速下载大小不能为负数")
+	}
+	names := make(map[string]bool, len(cfg.Targets))
+	for _, t := range cfg.Targets {
+		if t.Name == "" {
+			return errors.New("检测目标名称不能为空")
+		}
+		if names[t.Name] {
+			return fmt.Errorf("检测目标名称重复: %s", t.Name)
+		}
+		names[t.Name] = true
+		parsed, err := url.Parse(t.URL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("检测目标 %s 的地址无效: %s", t.Name, t.URL)
+		}
+		if t.ExpectStatus != 0 && (t.ExpectStatus < 100 || t.ExpectStatus > 599) {
+			return fmt.Errorf("检测目标 %s 的期望状态码无效: %d", t.Name, t.ExpectStatus)
+		}
+	}
+	return nil
+}
+
+// HasTarget 判断配置中是否存在指定名称的检测目标
+func (cfg Config) HasTarget(name string) bool {
+	for _, t := range cfg.Targets {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTargets 解析每行一个的检测目标，格式为 "名称 地址 [期望状态码]"
+// 只写地址时以地址的主机名作为名称，空行和 # 开头的行被忽略
+func ParseTargets(text string) ([]CheckTarget, error) {
+	var targets []CheckTarget
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		var t CheckTarget
+		switch {
+		case len(fields) == 1:
+			t.URL = fields[0]
+			if parsed, err := url.Parse(t.URL); err == nil {
+				t.Name = parsed.Hostname()
+			}
+		case len(fields) <= 3:
+			t.Name, t.URL = fields[0], fields[1]
+			if len(fields) == 3 {
+				status, err := strconv.Atoi(fields[2])
+				if err != nil {
+					return nil, fmt.Errorf("第 %d 行状态码无效: %s", i+1, fields[2])
+				}
+				t.ExpectStatus = status
+			}
+		default:
+			return nil, fmt.Errorf("第 %d 行格式无效: %s", i+1, line)
+		}
+		targets = append(targets, t)
+	}
+	return targets, nil
+}
+
+// FormatTargets 将检测目标格式化为 ParseTargets 可解析的文本
+func FormatTargets(targets []CheckTarget) string {
+	lines := make([]string, len(targets))
+	for i, t := range targets {
+		lines[i] = t.Name + " " + t.URL
+		if t.ExpectStatus != 0 {
+			lines[i] += " " + strconv.Itoa(t.ExpectStatus)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// SetConfig 替换检测服务配置，对之后开始的检测生效
+// 返回错误如果配置无效，此时保留原有配置
+func (c *Checker) SetConfig(cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	c.configMutex.Lock()
+	defer c.configMutex.Unlock()
+	c.config = cfg
+	return nil
+}
+
+// Config 返回当前的检测服务配置
+func (c *Checker) Config() Config {
+	c.configMutex.RLock()
+	defer c.configMutex.RUnlock()
+	return c.config
+}
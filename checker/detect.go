@@ -0,0 +1,161 @@
+package checker
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go_proxy/proxy"
+)
+
+// socks4ProbeTarget SOCKS4探测时请求连接的目标，只用于触发应答，是否连接成功不影响判断
+var socks4ProbeTarget = [6]byte{0x00, 0x50, 1, 1, 1, 1} // 1.1.1.1:80
+
+// protocolProbes 协议探测顺序，前面的握手更严格，不会被后面的协议误判
+var protocolProbes = []struct {
+	protocol string
+	probe    func(conn net.Conn, address string) bool
+}{
+	{"socks5", probeSOCKS5},
+	{"socks4", probeSOCKS4},
+	{"http", probeHTTP},
+	{"https", probeHTTPS},
+}
+
+// DetectProtocol 依次按 socks5、socks4、http、https 握手探测代理地址使用的协议
+// 每种协议使用新的TCP连接，握手得到符合协议的应答即视为该协议
+// 参数 ctx: 取消后停止探测
+// 参数 address: 代理地址(格式: host:port)
+// 参数 timeout: 每次握手的超时时间
+// 返回识别出的协议，全部失败时返回错误
+func DetectProtocol(ctx context.Context, address string, timeout time.Duration) (string, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	for _, pr := range protocolProbes {
+		conn, err := dialer.DialContext(ctx, "tcp", address)
+		if err != nil {
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
+			return "", fmt.Errorf("无法连接 %s: %v", address, err)
+		}
+		conn.SetDeadline(time.Now().Add(timeout))
+		ok := pr.probe(conn, address)
+		conn.Close()
+		if ok {
+			return pr.protocol, nil
+		}
+	}
+	return "", errors.New("未能识别代理协议")
+}
+
+// DetectProtocols 并发探测代理协议，识别成功时通过 apply 应用识别出的协议；无法识别的代理保持原协议
+// 参数 workers: 最大并发数
+// 参数 apply: 应用识别结果，会被多个协程并发调用，通常传入 Rotator.SetProtocol 以在锁内修改代理
+// 返回成功识别的数量
+func (c *Checker) DetectProtocols(ctx context.Context, proxies []*proxy.Proxy, timeout time.Duration, workers int, apply func(p *proxy.Proxy, protocol string)) int {
+	var wg sync.WaitGroup
+	var detected int32
+	sem := make(chan struct{}, workers)
+
+dispatch:
+	for _, p := range proxies {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			break dispatch
+		}
+		wg.Add(1)
+		go func(p *proxy.Proxy) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			protocol, err := DetectProtocol(ctx, p.Address, timeout)
+			if err != nil {
+				return
+			}
+			apply(p, protocol)
+			atomic.AddInt32(&detected, 1)
+		}(p)
+	}
+	wg.Wait()
+	return int(detected)
+}
+
+// probeSOCKS5 发送无认证和用户名/密码两种方法的问候，应答版本为5且选择了其中之一即为SOCKS5
+func probeSOCKS5(conn net.Conn, _ string) bool {
+	if _, err := conn.Write([]byte{0x05, 0x02, 0x00, 0x02}); err != nil {
+		return false
+	}
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return false
+	}
+	return reply[0] == 0x05 && (reply[1] == 0x00 || reply[1] == 0x02)
+}
+
+// probeSOCKS4 发送CONNECT请求，应答首字节为0且状态码在 0x5A-0x5D 范围内即为SOCKS4
+func probeSOCKS4(conn net.Conn, _ string) bool {
+	req := append([]byte{0x04, 0x01}, socks4ProbeTarget[:]...)
+	req = append(req, 0x00) // 空用户ID
+	if _, err := conn.Write(req); err != nil {
+		return false
+	}
+	reply := make([]byte, 8)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return false
+	}
+	return reply[0] == 0x00 && reply[1] >= 0x5A && reply[1] <= 0x5D
+}
+
+// probeHTTP 发送明文的代理请求，收到HTTP应答即为HTTP代理
+// TLS端口收到明文请求时通常回复400并提示应使用HTTPS，这种应答留给 probeHTTPS 判断
+func probeHTTP(conn net.Conn, _ string) bool {
+	resp, ok := probeHTTPRequest(conn)
+	if !ok {
+		return false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusBadRequest {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		if strings.Contains(strings.ToLower(string(body)), "https") {
+			return false
+		}
+	}
+	return true
+}
+
+// probeHTTPS 先与代理本身完成TLS握手(代理证书通常无法验证，跳过校验)，再发送代理请求
+func probeHTTPS(conn net.Conn, address string) bool {
+	host, _, _ := net.SplitHostPort(address)
+	tlsConn := tls.Client(conn, &tls.Config{ServerName: host, InsecureSkipVerify: true})
+	if err := tlsConn.Handshake(); err != nil {
+		return false
+	}
+	resp, ok := probeHTTPRequest(tlsConn)
+	if ok {
+		resp.Body.Close()
+	}
+	return ok
+}
+
+// probeHTTPRequest 发送绝对URL形式的代理请求并解析HTTP应答
+func probeHTTPRequest(conn net.Conn) (*http.Response, bool) {
+	if _, err := fmt.Fprint(conn, "GET http://example.com/ HTTP/1.1\r\nHost: example.com\r\nConnection: close\r\n\r\n"); err != nil {
+		return nil, false
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		return nil, false
+	}
+	return resp, true
+}
@@ -0,0 +1,87 @@
+package checker
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// FailureReason 代理检测失败的原因分类
+type FailureReason string
+
+const (
+	FailureRefused FailureReason = "拒绝"
+	FailureTimeout FailureReason = "超时"
+	FailureTLS     FailureReason = "TLS错误"
+	FailureStatus  FailureReason = "状态码异常"
+	FailureBody    FailureReason = "响应异常"
+	FailureHijack  FailureReason = "劫持" // 响应被代理篡改，如跳转到强制门户、注入广告或替换页面内容
+	FailureOther   FailureReason = "其他"
+)
+
+// CheckError 带失败分类的检测错误
+type CheckError struct {
+	Reason FailureReason
+	Err    error
+}
+
+func (e *CheckError) Error() string {
+	return string(e.Reason) + ": " + e.Err.Error()
+}
+
+func (e *CheckError) Unwrap() error {
+	return e.Err
+}
+
+// newCheckError 根据底层错误自动分类并包装
+func newCheckError(err error) *CheckError {
+	return &CheckError{Reason: classifyError(err), Err: err}
+}
+
+// ReasonOf 返回检测错误的失败分类，err 为nil时返回空字符串
+func ReasonOf(err error) FailureReason {
+	if err == nil {
+		return ""
+	}
+	var checkErr *CheckError
+	if errors.As(err, &checkErr) {
+		return checkErr.Reason
+	}
+	return classifyError(err)
+}
+
+// classifyError 根据网络错误的类型判断失败原因
+func classifyError(err error) FailureReason {
+	var netErr net.Error
+	var recordErr tls.RecordHeaderError
+	var certErr *tls.CertificateVerificationError
+	var unknownAuthErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.ECONNRESET):
+		return FailureRefused
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded),
+		errors.As(err, &netErr) && netErr.Timeout():
+		return FailureTimeout
+	case errors.As(err, &recordErr), errors.As(err, &certErr),
+		errors.As(err, &unknownAuthErr), errors.As(err, &hostnameErr):
+		return FailureTLS
+	}
+
+	// 部分代理库只返回文本错误，按错误信息兜底判断
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "refused"), strings.Contains(msg, "reset by peer"):
+		return FailureRefused
+	case strings.Contains(msg, "timeout"), strings.Contains(msg, "deadline exceeded"):
+		return FailureTimeout
+	case strings.Contains(msg, "tls"), strings.Contains(msg, "x509"):
+		return FailureTLS
+	}
+	return FailureOther
+}
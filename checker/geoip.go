@@ -0,0 +1,77 @@
+package checker
+
+import (
+	"net"
+
+	"go_proxy/proxy"
+)
+
+// SetGeoIPDatabase 设置离线地理位置数据库(MaxMind GeoLite2 City/Country 的 .mmdb 文件)
+// 设置后 BatchLookupLocations 直接查询本地数据库，不再调用在线接口
+// 参数 path: 数据库路径，为空表示关闭离线查询
+// 返回错误如果文件无法读取或格式无效，此时保留原有设置
+func (c *Checker) SetGeoIPDatabase(path string) error {
+	var db *mmdbReader
+	if path != "" {
+		var err error
+		if db, err = openMMDB(path); err != nil {
+			return err
+		}
+	}
+	c.geoMutex.Lock()
+	defer c.geoMutex.Unlock()
+	c.geoDB = db
+	return nil
+}
+
+// HasGeoIPDatabase 返回是否已配置离线地理位置数据库
+func (c *Checker) HasGeoIPDatabase() bool {
+	c.geoMutex.RLock()
+	defer c.geoMutex.RUnlock()
+	return c.geoDB != nil
+}
+
+// geoNameLanguages 读取地名时依次尝试的语言
+var geoNameLanguages = []string{"zh-CN", "en"}
+
+// lookupLocationMMDB 从离线数据库查询代理的国家/省份/城市
+// 返回是否查到了国家信息
+func lookupLocationMMDB(db *mmdbReader, p *proxy.Proxy) bool {
+	ip := net.ParseIP(p.Host())
+	if ip == nil {
+		return false
+	}
+	record, err := db.lookup(ip)
+	if err != nil {
+		return false
+	}
+	fields, ok := record.(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	p.Country = geoName(fields["country"])
+	if subdivisions, ok := fields["subdivisions"].([]interface{}); ok && len(subdivisions) > 0 {
+		p.Province = geoName(subdivisions[0])
+	}
+	p.City = geoName(fields["city"])
+	return p.Country != ""
+}
+
+// geoName 从 {"names": {"zh-CN": ..., "en": ...}} 结构中读取地名
+func geoName(v interface{}) string {
+	entry, ok := v.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	names, ok := entry["names"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	for _, lang := range geoNameLanguages {
+		if name, ok := names[lang].(string); ok && name != "" {
+			return name
+		}
+	}
+	return ""
+}
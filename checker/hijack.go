@@ -0,0 +1,55 @@
+package checker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// checkRedirect 检查请求是否被重定向到了其他主机
+// 检测地址本身不会跳转到其他站点，跟随重定向后主机改变通常是代理返回了强制门户或广告页
+func checkRedirect(requested string, resp *http.Response) error {
+	want, err := url.Parse(requested)
+	if err != nil || resp.Request == nil || resp.Request.URL == nil {
+		return nil
+	}
+	if got := resp.Request.URL; !strings.EqualFold(got.Host, want.Host) {
+		return &CheckError{Reason: FailureHijack, Err: fmt.Errorf("请求被重定向到 %s", got.Host)}
+	}
+	return nil
+}
+
+// checkContent 按配置校验检测地址的响应内容
+// 缺少期望的文本或JSON字段说明响应被代理替换或篡改
+func checkContent(cfg Config, body []byte) error {
+	if cfg.ExpectBody != "" && !strings.Contains(string(body), cfg.ExpectBody) {
+		return &CheckError{Reason: FailureHijack, Err: errors.New("响应中未包含期望的内容")}
+	}
+	if cfg.ExpectJSONField != "" {
+		var data interface{}
+		if err := json.Unmarshal(body, &data); err != nil {
+			return &CheckError{Reason: FailureHijack, Err: errors.New("响应不是有效的JSON")}
+		}
+		if !hasJSONField(data, cfg.ExpectJSONField) {
+			return &CheckError{Reason: FailureHijack, Err: fmt.Errorf("响应中缺少字段 %s", cfg.ExpectJSONField)}
+		}
+	}
+	return nil
+}
+
+// hasJSONField 判断JSON对象中是否存在以点分隔的字段路径，例如 "headers.Host"
+func hasJSONField(data interface{}, path string) bool {
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := data.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if data, ok = obj[key]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,152 @@
+package checker
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"go_proxy/proxy"
+)
+
+// proxyHeaders 代理转发请求时可能添加的请求头，出现任意一个说明目标站点能识别出代理
+var proxyHeaders = []string{
+	"Via",
+	"X-Forwarded-For",
+	"X-Forwarded-Host",
+	"X-Forwarded-Proto",
+	"Forwarded",
+	"X-Real-Ip",
+	"Client-Ip",
+	"X-Client-Ip",
+	"X-Cluster-Client-Ip",
+	"X-Proxy-Id",
+	"X-Bluecoat-Via",
+	"Proxy-Connection",
+}
+
+// leakHeaders 代理用来传递客户端IP的请求头，其中出现本机公网IP说明代理为透明代理
+var leakHeaders = []string{
+	"X-Forwarded-For",
+	"Forwarded",
+	"X-Real-Ip",
+	"Client-Ip",
+	"X-Client-Ip",
+	"X-Cluster-Client-Ip",
+	"Via",
+}
+
+// judgeAnonymity 根据判断服务的响应判断代理匿名度并记录出口IP
+// 响应需为 httpbin /get 格式的JSON(origin 和 headers 字段)，可以是 httpbin 或自建的判断服务(见 JudgeHandler)
+// 返回错误如果响应不是有效的JSON
+func (c *Checker) judgeAnonymity(p *proxy.Proxy, body []byte) error {
+	var data struct {
+		Origin  string                     `json:"origin"`
+		Headers map[string]json.RawMessage `json:"headers"`
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return &CheckError{Reason: FailureBody, Err: fmt.Errorf("无法解析响应: %v", err)}
+	}
+	headers := make(http.Header, len(data.Headers))
+	for name, raw := range data.Headers {
+		headers.Set(name, headerValue(raw))
+	}
+	p.ExitIP = exitIPFromOrigin(data.Origin)
+	p.Anonymity = classifyAnonymity(c.publicIP, data.Origin, headers)
+	return nil
+}
+
+// headerValue 取出判断服务返回的请求头值，兼容字符串和字符串数组两种格式
+func headerValue(raw json.RawMessage) string {
+	var value string
+	if json.Unmarshal(raw, &value) == nil {
+		return value
+	}
+	var values []string
+	if json.Unmarshal(raw, &values) == nil {
+		return strings.Join(values, ", ")
+	}
+	return ""
+}
+
+// classifyAnonymity 按目标站点看到的信息划分匿名度
+// Transparent: 出口IP或转发请求头中出现本机公网IP，目标站点能看到真实IP
+// Anonymous: 未暴露真实IP，但带有代理相关的请求头，或 origin 含多个IP(httpbin 将 X-Forwarded-For 合并到 origin)
+// Elite: 目标站点看不出经过了代理
+// 参数 publicIP: 本机公网IP，为空时无法识别透明代理
+func classifyAnonymity(publicIP, origin string, headers http.Header) string {
+	if publicIP != "" {
+		if containsIP(origin, publicIP) {
+			return "Transparent"
+		}
+		for _, name := range leakHeaders {
+			if containsIP(headers.Get(name), publicIP) {
+				return "Transparent"
+			}
+		}
+	}
+	if strings.Contains(origin, ",") {
+		return "Anonymous"
+	}
+	for _, name := range proxyHeaders {
+		if headers.Get(name) != "" {
+			return "Anonymous"
+		}
+	}
+	return "Elite"
+}
+
+// containsIP 判断请求头值中是否包含指定IP
+// 值按逗号、分号、空格和等号切分后逐项比较，兼容 "for=1.2.3.4" 和 "[2001:db8::1]:80" 等写法，
+// 避免 1.2.3.4 误匹配 11.2.3.45
+func containsIP(value, ip string) bool {
+	want := net.ParseIP(ip)
+	if want == nil {
+		return false
+	}
+	fields := strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ';' || r == ' ' || r == '=' || r == '"'
+	})
+	for _, field := range fields {
+		if host, _, err := net.SplitHostPort(field); err == nil {
+			field = host
+		}
+		field = strings.Trim(field, "[]")
+		if got := net.ParseIP(field); got != nil && got.Equal(want) {
+			return true
+		}
+	}
+	return false
+}
+
+// exitIPFromOrigin 从httpbin返回的origin中取出实际连接目标站点的IP
+// 经过转发时origin形如 "客户端IP, 出口IP"，最后一项为出口
+func exitIPFromOrigin(origin string) string {
+	parts := strings.Split(origin, ",")
+	return strings.TrimSpace(parts[len(parts)-1])
+}
+
+// JudgeHandler 返回自建匿名度判断服务的处理器
+// 以 httpbin /get 相同的格式返回请求的来源IP(origin)和收到的全部请求头(headers)，
+// 部署在公网可访问的主机上后，将检测设置中的匿名度判断地址指向它即可替代 httpbin
+func JudgeHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			origin = r.RemoteAddr
+		}
+		headers := make(map[string]string, len(r.Header)+1)
+		for name, values := range r.Header {
+			headers[name] = strings.Join(values, ", ")
+		}
+		headers["Host"] = r.Host
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"origin":  origin,
+			"headers": headers,
+			"url":     r.URL.String(),
+		})
+	})
+}
@@ -0,0 +1,303 @@
+package checker
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"os"
+)
+
+// mmdbMetadataMarker MaxMind DB 元数据段的起始标记
+var mmdbMetadataMarker = []byte("\xab\xcd\xefMaxMind.com")
+
+// mmdbReader MaxMind DB(.mmdb)文件的只读解析器
+// 只实现查询所需的部分: 二叉搜索树遍历和数据段解码，整个文件读入内存
+// 格式说明见 https://maxmind.github.io/MaxMind-DB/
+type mmdbReader struct {
+	buf        []byte
+	data       []byte // 数据段
+	nodeCount  uint
+	recordSize uint
+	ipVersion  uint
+	ipv4Start  uint // IPv6数据库中 ::/96 子树的起始节点，用于查询IPv4地址
+}
+
+// openMMDB 读取并解析 MaxMind DB 文件
+func openMMDB(path string) (*mmdbReader, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return newMMDBReader(buf)
+}
+
+// newMMDBReader 从内存中的数据库内容创建解析器
+func newMMDBReader(buf []byte) (*mmdbReader, error) {
+	idx := bytes.LastIndex(buf, mmdbMetadataMarker)
+	if idx < 0 {
+		return nil, errors.New("不是有效的MaxMind DB文件: 未找到元数据")
+	}
+	metaStart := idx + len(mmdbMetadataMarker)
+	meta, _, err := (&mmdbReader{data: buf[metaStart:]}).decode(0)
+	if err != nil {
+		return nil, fmt.Errorf("解析元数据失败: %v", err)
+	}
+	metadata, ok := meta.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("元数据格式无效")
+	}
+
+	r := &mmdbReader{
+		buf:        buf,
+		nodeCount:  uint(asUint(metadata["node_count"])),
+		recordSize: uint(asUint(metadata["record_size"])),
+		ipVersion:  uint(asUint(metadata["ip_version"])),
+	}
+	switch r.recordSize {
+	case 24, 28, 32:
+	default:
+		return nil, fmt.Errorf("不支持的记录长度: %d", r.recordSize)
+	}
+	treeSize := r.nodeCount * r.recordSize / 4
+	dataStart := treeSize + 16 // 搜索树后有16字节的分隔
+	if dataStart > uint(idx) {
+		return nil, errors.New("数据库文件已损坏")
+	}
+	r.data = buf[dataStart:idx]
+
+	if r.ipVersion == 6 {
+		node := uint(0)
+		for i := 0; i < 96 && node < r.nodeCount; i++ {
+			node = r.readRecord(node, 0)
+		}
+		r.ipv4Start = node
+	}
+	return r, nil
+}
+
+// readRecord 读取节点的左(bit=0)或右(bit=1)记录
+func (r *mmdbReader) readRecord(node uint, bit uint) uint {
+	switch r.recordSize {
+	case 24:
+		off := node*6 + bit*3
+		b := r.buf[off : off+3]
+		return uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+	case 28:
+		off := node * 7
+		b := r.buf[off : off+7]
+		if bit == 0 {
+			return uint(b[3]&0xF0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+		}
+		return uint(b[3]&0x0F)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6])
+	default:
+		off := node*8 + bit*4
+		return uint(binary.BigEndian.Uint32(r.buf[off : off+4]))
+	}
+}
+
+// lookup 查询IP对应的数据记录，数据库中没有该IP时返回nil
+func (r *mmdbReader) lookup(ip net.IP) (interface{}, error) {
+	node := uint(0)
+	bits := 128
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+		bits = 32
+		if r.ipVersion == 6 {
+			node = r.ipv4Start
+		}
+	} else if r.ipVersion == 4 {
+		return nil, errors.New("IPv4数据库无法查询IPv6地址")
+	}
+
+	for i := 0; i < bits && node < r.nodeCount; i++ {
+		bit := uint(ip[i/8]>>(7-uint(i%8))) & 1
+		node = r.readRecord(node, bit)
+	}
+	if node == r.nodeCount {
+		return nil, nil
+	}
+	if node < r.nodeCount {
+		return nil, errors.New("搜索树无效")
+	}
+	offset := node - r.nodeCount - 16
+	if offset >= uint(len(r.data)) {
+		return nil, errors.New("数据指针越界")
+	}
+	value, _, err := r.decode(offset)
+	return value, err
+}
+
+// MaxMind DB 数据段的字段类型
+const (
+	mmdbExtended = 0
+	mmdbPointer  = 1
+	mmdbString   = 2
+	mmdbDouble   = 3
+	mmdbBytes    = 4
+	mmdbUint16   = 5
+	mmdbUint32   = 6
+	mmdbMap      = 7
+	mmdbInt32    = 8
+	mmdbUint64   = 9
+	mmdbUint128  = 10
+	mmdbArray    = 11
+	mmdbBool     = 14
+	mmdbFloat    = 15
+)
+
+// decode 解码数据段中 offset 处的值，返回值和下一个字段的偏移
+// 映射解码为 map[string]interface{}，数组为 []interface{}，整数统一为 uint64 或 int64
+func (r *mmdbReader) decode(offset uint) (interface{}, uint, error) {
+	if offset >= uint(len(r.data)) {
+		return nil, 0, errors.New("数据偏移越界")
+	}
+	ctrl := r.data[offset]
+	offset++
+	typ := uint(ctrl >> 5)
+
+	if typ == mmdbPointer {
+		target, next, err := r.decodePointer(ctrl, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		value, _, err := r.decode(target)
+		return value, next, err
+	}
+
+	if typ == mmdbExtended {
+		if offset >= uint(len(r.data)) {
+			return nil, 0, errors.New("数据偏移越界")
+		}
+		typ = 7 + uint(r.data[offset])
+		offset++
+	}
+
+	size := uint(ctrl & 0x1F)
+	if size >= 29 {
+		n := size - 28
+		if offset+n > uint(len(r.data)) {
+			return nil, 0, errors.New("数据偏移越界")
+		}
+		v := uint(0)
+		for _, b := range r.data[offset : offset+n] {
+			v = v<<8 | uint(b)
+		}
+		switch size {
+		case 29:
+			size = 29 + v
+		case 30:
+			size = 285 + v
+		default:
+			size = 65821 + v
+		}
+		offset += n
+	}
+
+	switch typ {
+	case mmdbMap:
+		m := make(map[string]interface{}, size)
+		for i := uint(0); i < size; i++ {
+			key, next, err := r.decode(offset)
+			if err != nil {
+				return nil, 0, err
+			}
+			value, next, err := r.decode(next)
+			if err != nil {
+				return nil, 0, err
+			}
+			if k, ok := key.(string); ok {
+				m[k] = value
+			}
+			offset = next
+		}
+		return m, offset, nil
+	case mmdbArray:
+		list := make([]interface{}, 0, size)
+		for i := uint(0); i < size; i++ {
+			value, next, err := r.decode(offset)
+			if err != nil {
+				return nil, 0, err
+			}
+			list = append(list, value)
+			offset = next
+		}
+		return list, offset, nil
+	case mmdbBool:
+		return size != 0, offset, nil
+	}
+
+	if offset+size > uint(len(r.data)) {
+		return nil, 0, errors.New("数据偏移越界")
+	}
+	raw := r.data[offset : offset+size]
+	next := offset + size
+	switch typ {
+	case mmdbString:
+		return string(raw), next, nil
+	case mmdbBytes:
+		return append([]byte(nil), raw...), next, nil
+	case mmdbDouble:
+		if size != 8 {
+			return nil, 0, errors.New("double 长度无效")
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(raw)), next, nil
+	case mmdbFloat:
+		if size != 4 {
+			return nil, 0, errors.New("float 长度无效")
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(raw))), next, nil
+	case mmdbUint16, mmdbUint32, mmdbUint64, mmdbUint128:
+		// uint128 只保留低64位，查询地理位置时不会用到
+		v := uint64(0)
+		for _, b := range raw {
+			v = v<<8 | uint64(b)
+		}
+		return v, next, nil
+	case mmdbInt32:
+		v := uint32(0)
+		for _, b := range raw {
+			v = v<<8 | uint32(b)
+		}
+		return int64(int32(v)), next, nil
+	default:
+		return nil, 0, fmt.Errorf("不支持的数据类型: %d", typ)
+	}
+}
+
+// decodePointer 解析指针字段，返回指向的数据段偏移和指针之后的偏移
+func (r *mmdbReader) decodePointer(ctrl byte, offset uint) (uint, uint, error) {
+	n := uint(ctrl>>3)&0x3 + 1
+	if offset+n > uint(len(r.data)) {
+		return 0, 0, errors.New("数据偏移越界")
+	}
+	b := r.data[offset : offset+n]
+	vvv := uint(ctrl & 0x7)
+	var target uint
+	switch n {
+	case 1:
+		target = vvv<<8 | uint(b[0])
+	case 2:
+		target = (vvv<<16 | uint(b[0])<<8 | uint(b[1])) + 2048
+	case 3:
+		target = (vvv<<24 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])) + 526336
+	default:
+		target = uint(binary.BigEndian.Uint32(b))
+	}
+	return target, offset + n, nil
+}
+
+// asUint 将解码出的整数值转换为 uint64，类型不符时返回0
+func asUint(v interface{}) uint64 {
+	switch n := v.(type) {
+	case uint64:
+		return n
+	case int64:
+		if n > 0 {
+			return uint64(n)
+		}
+	}
+	return 0
+}
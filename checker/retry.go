@@ -0,0 +1,53 @@
+package checker
+
+import (
+	"errors"
+	"io"
+	"syscall"
+	"time"
+)
+
+// 默认的检测重试次数和首次重试前的等待时间
+const (
+	defaultRetries      = 1
+	defaultRetryBackoff = time.Second
+)
+
+// maxRetries 重试次数上限，避免失效代理长时间占用检测并发
+const maxRetries = 5
+
+// SetRetryPolicy 设置检测失败后的重试策略
+// 只有超时、连接被重置等偶发错误会重试，第 n 次重试前等待 backoff*2^(n-1)
+// 参数 retries: 重试次数，0 表示不重试，超过上限时按上限处理
+// 参数 backoff: 首次重试前的等待时间
+func (c *Checker) SetRetryPolicy(retries int, backoff time.Duration) {
+	if retries < 0 {
+		retries = 0
+	}
+	if retries > maxRetries {
+		retries = maxRetries
+	}
+	if backoff < 0 {
+		backoff = 0
+	}
+	c.retryMutex.Lock()
+	defer c.retryMutex.Unlock()
+	c.retries = retries
+	c.retryBackoff = backoff
+}
+
+// RetryPolicy 返回当前的重试次数和首次重试前的等待时间
+func (c *Checker) RetryPolicy() (int, time.Duration) {
+	c.retryMutex.RLock()
+	defer c.retryMutex.RUnlock()
+	return c.retries, c.retryBackoff
+}
+
+// retryable 判断检测错误是否为可能在重试后恢复的偶发错误
+// 拒绝连接、状态码异常、劫持等稳定复现的错误不重试
+func retryable(err error) bool {
+	if ReasonOf(err) == FailureTimeout {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,159 @@
+package checker
+
+import (
+	"sync"
+	"time"
+
+	"go_proxy/proxy"
+)
+
+// revalidateMaxAge 复检后清理有效代理时允许的最长未检测时间
+const revalidateMaxAge = 24 * time.Hour
+
+// Revalidator 有效代理定期复检器
+// 按间隔并发重新检测有效列表和搁置列表中的代理，更新延迟/速度/评分，
+// 失败时累加 FailCount，复检成功的搁置代理放回有效列表，每轮结束后清理失效代理
+type Revalidator struct {
+	checker *Checker
+	rotator *proxy.Rotator
+
+	interval time.Duration
+	workers  int
+	onRound  func(tested, failed, recovered int)
+
+	mutex   sync.Mutex
+	ticker  *time.Ticker
+	stop    chan struct{}
+	running bool
+}
+
+// NewRevalidator 创建复检器
+// 参数 interval: 复检间隔
+// 参数 workers: 最大并发数
+func NewRevalidator(c *Checker, r *proxy.Rotator, interval time.Duration, workers int) *Revalidator {
+	return &Revalidator{checker: c, rotator: r, interval: interval, workers: workers}
+}
+
+// OnRound 设置每轮复检结束时的回调，参数为本轮检测数、失败数和恢复的搁置代理数
+func (v *Revalidator) OnRound(fn func(tested, failed, recovered int)) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	v.onRound = fn
+}
+
+// Configure 修改复检间隔和并发数，运行中时以新间隔重新计时
+// 非正数的参数保持原值
+func (v *Revalidator) Configure(interval time.Duration, workers int) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	if interval > 0 {
+		v.interval = interval
+	}
+	if workers > 0 {
+		v.workers = workers
+	}
+	if v.running {
+		v.ticker.Reset(v.interval)
+	}
+}
+
+// Settings 返回当前的复检间隔和并发数
+func (v *Revalidator) Settings() (interval time.Duration, workers int) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	return v.interval, v.workers
+}
+
+// Start 启动定期复检，已在运行时忽略
+func (v *Revalidator) Start() {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	if v.running {
+		return
+	}
+	v.running = true
+	v.ticker = time.NewTicker(v.interval)
+	v.stop = make(chan struct{})
+	ticker, stop := v.ticker, v.stop
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				v.RunOnce()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止定期复检，正在进行的一轮会继续完成
+func (v *Revalidator) Stop() {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	if !v.running {
+		return
+	}
+	v.running = false
+	v.ticker.Stop()
+	close(v.stop)
+}
+
+// Running 返回复检器是否在运行
+func (v *Revalidator) Running() bool {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	return v.running
+}
+
+// RunOnce 立即执行一轮复检
+// 返回本轮检测的代理数和失败数
+func (v *Revalidator) RunOnce() (tested, failed int) {
+	v.mutex.Lock()
+	workers, onRound := v.workers, v.onRound
+	v.mutex.Unlock()
+
+	proxies, err := v.rotator.GetValidProxies()
+	if err != nil {
+		return 0, 0
+	}
+	proxies = append(proxies, v.rotator.GetSidelinedProxies()...)
+	if len(proxies) == 0 {
+		return 0, 0
+	}
+
+	var wg sync.WaitGroup
+	var countMutex sync.Mutex
+	var recovered int
+	sem := make(chan struct{}, workers)
+	for _, p := range proxies {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(p *proxy.Proxy) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			_, _, err := v.checker.CheckConnectivityAndSpeed(p)
+			v.rotator.RecordCheck(p, p.Latency, p.Speed, err)
+			if err != nil {
+				countMutex.Lock()
+				failed++
+				countMutex.Unlock()
+				return
+			}
+			if v.rotator.Rehabilitate(p) {
+				countMutex.Lock()
+				recovered++
+				countMutex.Unlock()
+			}
+		}(p)
+	}
+	wg.Wait()
+
+	v.rotator.CleanupProxies(revalidateMaxAge)
+	if onRound != nil {
+		onRound(len(proxies), failed, recovered)
+	}
+	return len(proxies), failed
+}
@@ -0,0 +1,50 @@
+package checker
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// maxConcurrency 批量测试允许的最大并发数，避免耗尽本机文件描述符
+const maxConcurrency = 2000
+
+// TestSettings 批量测试的吞吐设置
+// 网络较差时降低并发、延长超时可减少误判，带宽充足的服务器上提高并发可加快测试
+// Concurrency: 同时检测的代理数
+// ConnectTimeout: 与代理建立连接的超时
+// CheckTimeout: 单个检测请求(连通性、匿名度、测速)的总超时
+// SpeedTestSize: 测速最多下载的字节数，0 表示下载完整文件
+type TestSettings struct {
+	Concurrency    int
+	ConnectTimeout time.Duration
+	CheckTimeout   time.Duration
+	SpeedTestSize  int64
+}
+
+// DefaultTestSettings 返回默认的测试设置：并发200，连接和检测超时10秒，测速下载100KB
+func DefaultTestSettings() TestSettings {
+	return TestSettings{
+		Concurrency:    200,
+		ConnectTimeout: 10 * time.Second,
+		CheckTimeout:   10 * time.Second,
+		SpeedTestSize:  DefaultConfig().SpeedTestSize,
+	}
+}
+
+// Validate 检查测试设置是否在合理范围内
+func (s TestSettings) Validate() error {
+	if s.Concurrency <= 0 || s.Concurrency > maxConcurrency {
+		return fmt.Errorf("并发数必须在 1-%d 之间", maxConcurrency)
+	}
+	if s.ConnectTimeout <= 0 || s.CheckTimeout <= 0 {
+		return errors.New("超时时间必须为正数")
+	}
+	if s.ConnectTimeout > s.CheckTimeout {
+		return errors.New("连接超时不能大于检测超时")
+	}
+	if s.SpeedTestSize < 0 {
+		return errors.New("测速下载大小不能为负数")
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package checker
+
+import (
+	"io"
+	"math"
+	"net/http"
+	"time"
+
+	"go_proxy/proxy"
+)
+
+// defaultLatencySamples 默认每次检测的延迟采样次数(含连通性检测本身)
+const defaultLatencySamples = 3
+
+// maxLatencySamples 延迟采样次数上限
+const maxLatencySamples = 10
+
+// SetLatencySamples 设置每次检测的延迟采样次数
+// 连通性检测成功后再请求 n-1 次检测地址，用于计算平均延迟、抖动和成功率；1 表示只采样一次
+func (c *Checker) SetLatencySamples(n int) {
+	if n < 1 {
+		n = 1
+	}
+	if n > maxLatencySamples {
+		n = maxLatencySamples
+	}
+	c.retryMutex.Lock()
+	defer c.retryMutex.Unlock()
+	c.latencySamples = n
+}
+
+// LatencySamples 返回每次检测的延迟采样次数
+func (c *Checker) LatencySamples() int {
+	c.retryMutex.RLock()
+	defer c.retryMutex.RUnlock()
+	return c.latencySamples
+}
+
+// measureStability 在首次连通性检测成功后继续采样延迟，计算稳定性
+// 结果写入 p.Latency(成功采样的平均延迟)、p.Jitter 和 p.Stability
+// 参数 first: 首次检测的延迟(秒)
+// 参数 extra: 额外采样次数
+func measureStability(p *proxy.Proxy, client *http.Client, target string, first float64, extra int) {
+	latencies := []float64{first}
+	for i := 0; i < extra; i++ {
+		start := time.Now()
+		resp, err := client.Get(target)
+		if err != nil {
+			continue
+		}
+		io.Copy(io.Discard, io.LimitReader(resp.Body, maxCheckBodySize))
+		resp.Body.Close()
+		if resp.StatusCode < http.StatusBadRequest {
+			latencies = append(latencies, time.Since(start).Seconds())
+		}
+	}
+	mean, jitter := latencyStats(latencies)
+	p.Latency = mean
+	p.Jitter = jitter
+	p.Stability = stabilityOf(float64(len(latencies))/float64(extra+1), mean, jitter)
+}
+
+// latencyStats 计算延迟样本的平均值和标准差(抖动)
+func latencyStats(latencies []float64) (mean, jitter float64) {
+	for _, l := range latencies {
+		mean += l
+	}
+	mean /= float64(len(latencies))
+	for _, l := range latencies {
+		jitter += (l - mean) * (l - mean)
+	}
+	return mean, math.Sqrt(jitter / float64(len(latencies)))
+}
+
+// stabilityOf 根据采样成功率和抖动计算0-100的稳定性评分
+// 抖动按相对平均延迟的比例(变异系数)扣分，抖动达到平均延迟时该项为0
+func stabilityOf(successRatio, mean, jitter float64) float64 {
+	consistency := 1.0
+	if mean > 0 {
+		consistency = 1 - math.Min(jitter/mean, 1)
+	}
+	return successRatio * consistency * 100
+}
@@ -0,0 +1,60 @@
+package checker
+
+import (
+	"context"
+	"sync"
+
+	"go_proxy/proxy"
+)
+
+// CheckResult 单个代理的完整检测结果，检测数据已写入代理的对应字段
+// Proxy: 被检测的代理
+// Err: 连通性检测失败的原因，nil 表示检测成功
+// Retried: 检测中出现偶发错误、重试后才成功
+type CheckResult struct {
+	Proxy   *proxy.Proxy
+	Err     error
+	Retried bool
+}
+
+// CheckStream 并发完整检测代理(同 RecheckOne)，每完成一个代理即从返回的通道发出结果
+// 全部检测完成或 ctx 取消后关闭通道；被取消的检测没有结论，不发出结果，代理保持检测前的状态
+// 调用方需读完通道，否则检测协程会阻塞
+// 参数 ctx: 取消后停止派发新的检测并中止进行中的检测
+// 参数 proxies: 待检测的代理，按顺序派发
+// 参数 workers: 最大并发数
+func (c *Checker) CheckStream(ctx context.Context, proxies []*proxy.Proxy, workers int) <-chan CheckResult {
+	if workers <= 0 {
+		workers = 1
+	}
+	results := make(chan CheckResult, workers)
+	go func() {
+		defer close(results)
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, workers)
+
+	dispatch:
+		for _, p := range proxies {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				break dispatch
+			}
+			wg.Add(1)
+			go func(p *proxy.Proxy) {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				intermittent := p.Intermittent
+				err := c.recheck(ctx, p)
+				if ctx.Err() != nil {
+					return
+				}
+				results <- CheckResult{Proxy: p, Err: err, Retried: err == nil && p.Intermittent > intermittent}
+			}(p)
+		}
+		wg.Wait()
+	}()
+	return results
+}
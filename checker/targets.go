@@ -0,0 +1,56 @@
+package checker
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"go_proxy/proxy"
+)
+
+// CheckTargets 通过代理逐一请求配置的检测目标，结果记录在 p.TargetChecks
+// 每次检测生成新的结果表整体替换，不修改已有的表，读取方无需加锁
+// 未配置检测目标时不检测，保留原有结果
+// 返回通过的目标数
+func (c *Checker) CheckTargets(p *proxy.Proxy) int {
+	targets := c.Config().Targets
+	if len(targets) == 0 {
+		return 0
+	}
+	client, err := c.createProxyClient(p)
+	if err != nil {
+		return 0
+	}
+	results := make(map[string]bool, len(targets))
+	passed := 0
+	for _, t := range targets {
+		if err := checkTarget(client, t); err == nil {
+			results[t.Name] = true
+			passed++
+		} else {
+			results[t.Name] = false
+		}
+	}
+	p.TargetChecks = results
+	return passed
+}
+
+// checkTarget 请求单个检测目标并校验状态码
+func checkTarget(client *http.Client, t CheckTarget) error {
+	resp, err := client.Get(t.URL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxCheckBodySize))
+	if t.ExpectStatus != 0 {
+		if resp.StatusCode != t.ExpectStatus {
+			return fmt.Errorf("HTTP状态码 %d", resp.StatusCode)
+		}
+		return nil
+	}
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("HTTP状态码 %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+// Package config 保存和加载应用的用户设置
+// 设置以JSON格式保存在用户配置目录下，启动时加载，缺少的项使用默认值
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go_proxy/checker"
+	"go_proxy/fetcher"
+	"go_proxy/lang"
+	"go_proxy/proxy"
+	"go_proxy/server"
+	"go_proxy/storage"
+)
+
+// appDirName 用户配置目录下本应用的子目录名
+const appDirName = "go_proxy"
+
+// fileName 设置文件名
+const fileName = "settings.json"
+
+// Settings 应用的全部用户设置
+// Theme: 界面主题模式(light/dark/system)
+// Language: 界面语言(zh-CN/en-US)
+// AutoPersist: 代理池变化后是否自动保存
+// Storage: 代理存储后端(json/sqlite)，命令行 -storage 指定时以命令行为准并保存
+// Sources: 代理源列表，为空表示使用内置代理源
+type Settings struct {
+	Theme       string                `json:"theme"`
+	Language    lang.Language         `json:"language"`
+	AutoPersist bool                  `json:"auto_persist"`
+	Storage     string                `json:"storage"`
+	Server      ServerSettings        `json:"server"`
+	Rotation    RotationSettings      `json:"rotation"`
+	AutoRefresh AutoRefreshSettings   `json:"auto_refresh"`
+	Revalidate  RevalidateSettings    `json:"revalidate"`
+	Check       CheckSettings         `json:"check"`
+	Import      ImportSettings        `json:"import"`
+	Filter      proxy.Filter          `json:"filter"`
+	Sort        proxy.SortOrder       `json:"sort"`
+	Sources     []fetcher.ProxySource `json:"sources,omitempty"`
+}
+
+// ServerSettings 本地代理服务的设置
+// Mode: 监听协议(socks5/http)
+// Pool: 绑定的命名代理池，空表示使用全部有效代理
+// RequiredTarget: 上游代理必须通过的检测目标，空表示不限制
+// StickyMinutes/AffinityMinutes: 会话保持和目标主机亲和时长(分钟)，0 表示关闭
+// RateLimitConnKB/RateLimitGlobalKB: 单连接和全局转发限速(KB/s)，0 表示不限制
+// MaxConns/ConnQueue: 并发连接上限和排队数，0 表示不限制/不排队
+// ProxyMaxConns/ProxyCooldownSeconds: 单个上游代理的并发连接上限和再次选用的冷却时间(秒)，0 表示不限制
+// TargetBlock/TargetAllow/ClientAllow/ClientDeny: 访问规则，以逗号或空白分隔
+// AuthUser/AuthPass: 客户端认证凭据，用户名为空表示不认证
+// FollowRotation: 固定当前代理，新连接都使用定时轮换选出的代理，直到下次轮换
+type ServerSettings struct {
+	Host                 string            `json:"host"`
+	Port                 string            `json:"port"`
+	Mode                 server.ListenMode `json:"mode"`
+	ChainMode            bool              `json:"chain_mode"`
+	PremiumOnly          bool              `json:"premium_only"`
+	Pool                 string            `json:"pool,omitempty"`
+	RequiredTarget       string            `json:"required_target,omitempty"`
+	StickyMinutes        int               `json:"sticky_minutes"`
+	AffinityMinutes      int               `json:"affinity_minutes"`
+	RateLimitConnKB      int               `json:"rate_limit_conn_kb"`
+	RateLimitGlobalKB    int               `json:"rate_limit_global_kb"`
+	MaxConns             int               `json:"max_conns"`
+	ConnQueue            int               `json:"conn_queue"`
+	ProxyMaxConns        int               `json:"proxy_max_conns"`
+	ProxyCooldownSeconds int               `json:"proxy_cooldown_seconds"`
+	TargetBlock          string            `json:"target_block,omitempty"`
+	TargetAllow          string            `json:"target_allow,omitempty"`
+	ClientAllow          string            `json:"client_allow,omitempty"`
+	ClientDeny           string            `json:"client_deny,omitempty"`
+	AuthUser             string            `json:"auth_user,omitempty"`
+	AuthPass             string            `json:"auth_pass,omitempty"`
+	FollowRotation       bool              `json:"follow_rotation"`
+}
+
+// RotationSettings 定时轮换的设置
+type RotationSettings struct {
+	IntervalSeconds int `json:"interval_seconds"`
+}
+
+// AutoRefreshSettings 定时获取代理的设置
+// Test: 获取后是否自动测试新增代理
+type AutoRefreshSettings struct {
+	IntervalMinutes int  `json:"interval_minutes"`
+	Test            bool `json:"test"`
+}
+
+// RevalidateSettings 有效代理定期复检的设置
+type RevalidateSettings struct {
+	IntervalMinutes int `json:"interval_minutes"`
+	Workers         int `json:"workers"`
+}
+
+// CheckSettings 代理检测的设置，时间以秒为单位
+// Service: 检测使用的外部服务地址和检测目标
+// Retries/RetryBackoff: 偶发失败后的重试次数和首次重试间隔
+// LatencySamples: 每次检测的延迟采样次数
+// Concurrency/ConnectTimeout/Timeout: 批量测试的并发数、连接超时和检测超时
+type CheckSettings struct {
+	Service        checker.Config `json:"service"`
+	Retries        int            `json:"retries"`
+	RetryBackoff   float64        `json:"retry_backoff"`
+	LatencySamples int            `json:"latency_samples"`
+	Concurrency    int            `json:"concurrency"`
+	ConnectTimeout float64        `json:"connect_timeout"`
+	Timeout        float64        `json:"timeout"`
+}
+
+// ImportSettings 导入代理时的设置
+// Precheck: 是否先做端口预检；Premium: 是否标记为高级；Detect: 是否识别未写明的协议
+type ImportSettings struct {
+	Precheck bool `json:"precheck"`
+	Premium  bool `json:"premium"`
+	Detect   bool `json:"detect"`
+	// SubscriptionURL 最近一次订阅导入使用的订阅地址
+	SubscriptionURL string `json:"subscription_url,omitempty"`
+}
+
+// Default 返回首次启动时使用的默认设置
+func Default() Settings {
+	test := checker.DefaultTestSettings()
+	return Settings{
+		Theme:       "system",
+		Language:    lang.ZhCN,
+		AutoPersist: true,
+		Storage:     storage.BackendJSON,
+		Server: ServerSettings{
+			Host: "127.0.0.1",
+			Port: "10808",
+			Mode: server.ModeSOCKS5,
+		},
+		Rotation:    RotationSettings{IntervalSeconds: 60},
+		AutoRefresh: AutoRefreshSettings{IntervalMinutes: 30, Test: true},
+		Revalidate:  RevalidateSettings{IntervalMinutes: 15, Workers: 50},
+		Check: CheckSettings{
+			Service:        checker.DefaultConfig(),
+			Retries:        1,
+			RetryBackoff:   1,
+			LatencySamples: 3,
+			Concurrency:    test.Concurrency,
+			ConnectTimeout: test.ConnectTimeout.Seconds(),
+			Timeout:        test.CheckTimeout.Seconds(),
+		},
+		Filter: proxy.NoFilter(),
+		Sort:   proxy.DefaultSortOrder,
+	}
+}
+
+// DefaultPath 返回默认的设置文件路径: <用户配置目录>/go_proxy/settings.json
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, appDirName, fileName), nil
+}
+
+// Load 从文件加载设置，文件中缺少的项保留默认值
+// 文件不存在时返回默认设置和nil
+func Load(path string) (Settings, error) {
+	s := Default()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return Default(), fmt.Errorf("解析设置文件失败: %v", err)
+	}
+	s.fixInvalid()
+	return s, nil
+}
+
+// fixInvalid 将无效的数值替换为默认值，避免手工编辑出错导致定时任务或检测无法进行
+func (s *Settings) fixInvalid() {
+	def := Default()
+	if !lang.Supported(s.Language) {
+		s.Language = def.Language
+	}
+	if s.Storage != storage.BackendJSON && s.Storage != storage.BackendSQLite {
+		s.Storage = def.Storage
+	}
+	if s.Server.Mode != server.ModeSOCKS5 && s.Server.Mode != server.ModeHTTP {
+		s.Server.Mode = def.Server.Mode
+	}
+	if s.Rotation.IntervalSeconds <= 0 {
+		s.Rotation = def.Rotation
+	}
+	if s.AutoRefresh.IntervalMinutes <= 0 {
+		s.AutoRefresh.IntervalMinutes = def.AutoRefresh.IntervalMinutes
+	}
+	if s.Revalidate.IntervalMinutes <= 0 || s.Revalidate.Workers <= 0 {
+		s.Revalidate = def.Revalidate
+	}
+	if s.Check.Service.Validate() != nil {
+		s.Check.Service = def.Check.Service
+	}
+	if s.Check.LatencySamples <= 0 {
+		s.Check.LatencySamples = def.Check.LatencySamples
+	}
+	test := checker.TestSettings{
+		Concurrency:    s.Check.Concurrency,
+		ConnectTimeout: time.Duration(s.Check.ConnectTimeout * float64(time.Second)),
+		CheckTimeout:   time.Duration(s.Check.Timeout * float64(time.Second)),
+		SpeedTestSize:  s.Check.Service.SpeedTestSize,
+	}
+	if test.Validate() != nil {
+		s.Check.Concurrency = def.Check.Concurrency
+		s.Check.ConnectTimeout = def.Check.ConnectTimeout
+		s.Check.Timeout = def.Check.Timeout
+	}
+}
+
+// Save 将设置写入文件
+// 先写入临时文件再重命名，避免写入中途退出导致设置文件损坏；
+// 设置中含认证密码，文件只对当前用户可读
+func Save(path string, s Settings) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
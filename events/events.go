@@ -0,0 +1,72 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Type 事件类型
+type Type string
+
+const (
+	ProxyValidated Type = "proxy_validated" // 代理检测成功并进入有效列表
+	ProxyRemoved   Type = "proxy_removed"   // 代理从代理池中删除(手动删除、拉黑或自动清理)
+	ProxyRotated   Type = "proxy_rotated"   // 代理轮换切换到新的代理
+	ServerStarted  Type = "server_started"  // 本地代理服务已启动
+	ServerStopped  Type = "server_stopped"  // 本地代理服务已停止
+)
+
+// Event 代理池或本地服务的状态变化事件
+// Data 为事件附带的数据，内容由事件类型决定，会被编码为JSON
+type Event struct {
+	Type Type        `json:"type"`
+	Time time.Time   `json:"time"`
+	Data interface{} `json:"data,omitempty"`
+}
+
+// Bus 进程内的事件总线
+// 发布不会阻塞：订阅者的缓冲区已满时该订阅者会丢失这条事件，避免慢速订阅者拖慢代理池
+type Bus struct {
+	mutex       sync.Mutex
+	subscribers map[chan Event]struct{}
+}
+
+// NewBus 创建事件总线
+func NewBus() *Bus {
+	return &Bus{subscribers: make(map[chan Event]struct{})}
+}
+
+// Publish 向所有订阅者发布事件
+// 参数 typ: 事件类型
+// 参数 data: 事件数据，可为nil
+func (b *Bus) Publish(typ Type, data interface{}) {
+	event := Event{Type: typ, Time: time.Now(), Data: data}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// Subscribe 订阅事件
+// 参数 buffer: 订阅通道的缓冲大小
+// 返回接收事件的通道和取消订阅的函数，取消后通道会被关闭
+func (b *Bus) Subscribe(buffer int) (<-chan Event, func()) {
+	ch := make(chan Event, buffer)
+	b.mutex.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mutex.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mutex.Lock()
+			delete(b.subscribers, ch)
+			b.mutex.Unlock()
+			close(ch)
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package fetcher
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// rawListHosts 按纯文本列表处理的主机，这些主机上的社区代理列表默认使用条件请求
+var rawListHosts = map[string]bool{
+	"raw.githubusercontent.com":  true,
+	"gist.githubusercontent.com": true,
+}
+
+// cachedList 纯文本列表最近一次完整下载的内容和校验信息
+type cachedList struct {
+	etag         string
+	lastModified string
+	body         []byte
+}
+
+var (
+	// listCacheMutex 保护 listCache
+	listCacheMutex sync.Mutex
+	// listCache 纯文本列表的缓存，以页面地址为键，只在本次运行中有效
+	listCache = make(map[string]cachedList)
+)
+
+// isRawList 判断代理源是否按纯文本列表使用条件请求获取
+// 显式配置了 RawList 或地址位于 GitHub raw 主机时为 true
+func (s ProxySource) isRawList() bool {
+	if s.RawList {
+		return true
+	}
+	u, err := url.Parse(s.URL)
+	return err == nil && rawListHosts[u.Host]
+}
+
+// setConditionalHeaders 为已缓存的列表添加 If-None-Match/If-Modified-Since 请求头
+func setConditionalHeaders(req *http.Request, pageURL string) {
+	listCacheMutex.Lock()
+	cached, ok := listCache[pageURL]
+	listCacheMutex.Unlock()
+	if !ok {
+		return
+	}
+	if cached.etag != "" {
+		req.Header.Set("If-None-Match", cached.etag)
+	}
+	if cached.lastModified != "" {
+		req.Header.Set("If-Modified-Since", cached.lastModified)
+	}
+}
+
+// conditionalBody 返回条件请求的响应内容
+// 304 时返回缓存的内容；200 时读取响应，带有 ETag 或 Last-Modified 时缓存以便下次条件请求
+func conditionalBody(resp *http.Response, pageURL string) (io.Reader, error) {
+	if resp.StatusCode == http.StatusNotModified {
+		listCacheMutex.Lock()
+		cached, found := listCache[pageURL]
+		listCacheMutex.Unlock()
+		if !found {
+			return nil, fmt.Errorf("%s 返回未修改，但没有缓存的列表", pageURL)
+		}
+		return bytes.NewReader(cached.body), nil
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	listCacheMutex.Lock()
+	if etag != "" || lastModified != "" {
+		listCache[pageURL] = cachedList{etag: etag, lastModified: lastModified, body: content}
+	} else {
+		delete(listCache, pageURL)
+	}
+	listCacheMutex.Unlock()
+	return bytes.NewReader(content), nil
+}
@@ -1,12 +1,17 @@
 package fetcher
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
+	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -21,114 +26,350 @@ import (
 // URL: 代理列表的网页或API地址
 // Protocol: 代理协议类型(http/https/socks4/socks5)
 // IsAPI: 是否为API响应(true)或HTML页面(false)
+// Name: 代理源名称，仅用于显示
+// Disabled: 是否停用该代理源
+// Regex: 自定义提取正则，有分组时取第1个分组作为 IP:端口
+// JSONPath: 自定义JSON路径(以.分隔)，指向包含 ip/port 字段的条目数组
+// Premium: 是否将该源获取的代理标记为高级
+// PageURL: 分页地址模板，{page} 替换为页码(从2开始)，URL 为第1页
+// MaxPages: 最多获取的页数(含第1页)，不大于1时不翻页
+// Fields: JSON条目的字段映射，设置后按映射读取延迟、在线率等源站元数据，JSONPath 为空时条目位于 data
+// RawList: 是否为纯文本列表(如GitHub raw文件)，使用 ETag 条件请求避免重复下载未变化的列表；GitHub raw 地址自动启用
+// Provider: 付费代理服务商的接入配置，设置后按服务商的接口认证和解析，获取的代理标记为高级
+// Table: HTML表格解析配置，按列取出代理及国家、匿名度，为空时用正则提取整个页面
 type ProxySource struct {
-	URL      string
-	Protocol string
-	IsAPI    bool
+	URL      string          `json:"url" yaml:"url"`
+	Protocol string          `json:"protocol" yaml:"protocol"`
+	IsAPI    bool            `json:"is_api" yaml:"is_api"`
+	Name     string          `json:"name,omitempty" yaml:"name,omitempty"`
+	Disabled bool            `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+	Regex    string          `json:"regex,omitempty" yaml:"regex,omitempty"`
+	JSONPath string          `json:"json_path,omitempty" yaml:"json_path,omitempty"`
+	Premium  bool            `json:"premium,omitempty" yaml:"premium,omitempty"`
+	PageURL  string          `json:"page_url,omitempty" yaml:"page_url,omitempty"`
+	MaxPages int             `json:"max_pages,omitempty" yaml:"max_pages,omitempty"`
+	RawList  bool            `json:"raw_list,omitempty" yaml:"raw_list,omitempty"`
+	Fields   *FieldMap       `json:"fields,omitempty" yaml:"fields,omitempty"`
+	Table    *TableConfig    `json:"table,omitempty" yaml:"table,omitempty"`
+	Provider *ProviderConfig `json:"provider,omitempty" yaml:"provider,omitempty"`
 }
 
 // proxySources 内置代理源列表
-// 包含16个免费代理源，覆盖HTTP/HTTPS/SOCKS4/SOCKS5协议
+// 包含19个免费代理源，覆盖HTTP/HTTPS/SOCKS4/SOCKS5协议
 // 混合使用API接口和HTML页面类型的数据源
 var proxySources = []ProxySource{
-	{"https://api.proxyscrape.com/v3/free-proxy-list/get?request=displayproxies&protocol=http", "http", true},
-	{"https://openproxylist.xyz/http.txt", "http", true},
-	{"https://www.proxy-list.download/api/v1/get?type=http", "http", true},
-	{"https://proxylist.geonode.com/api/proxy-list?limit=500&page=1&sort_by=lastChecked&sort_type=desc&protocols=http", "http", true},
-	{"https://free-proxy-list.net/", "http", false},
-	{"http://www.kxdaili.com/dailiip/1/1.html", "http", false},
-	{"http://www.66ip.cn/nmtq.php?get_num=300&isp=0&anonym=0&type=2", "http", true},
-	{"http://proxylist.fatezero.org/proxy.list", "http", false},
-	{"https://www.proxy-list.download/api/v1/get?type=https", "https", true},
-	{"https://api.proxyscrape.com/v3/free-proxy-list/get?request=displayproxies&protocol=socks4", "socks4", true},
-	{"https://openproxylist.xyz/socks4.txt", "socks4", true},
-	{"https://www.proxy-list.download/api/v1/get?type=socks4", "socks4", true},
-	{"https://api.proxyscrape.com/v3/free-proxy-list/get?request=displayproxies&protocol=socks5", "socks5", true},
-	{"https://openproxylist.xyz/socks5.txt", "socks5", true},
-	{"https://www.proxy-list.download/api/v1/get?type=socks5", "socks5", true},
-	{"https://www.proxyscan.io/api/proxy?type=socks5&format=txt", "socks5", true},
+	{URL: "https://api.proxyscrape.com/v3/free-proxy-list/get?request=displayproxies&protocol=http", Protocol: "http", IsAPI: true},
+	{URL: "https://openproxylist.xyz/http.txt", Protocol: "http", IsAPI: true},
+	{URL: "https://www.proxy-list.download/api/v1/get?type=http", Protocol: "http", IsAPI: true},
+	{URL: "https://proxylist.geonode.com/api/proxy-list?limit=500&page=1&sort_by=lastChecked&sort_type=desc&protocols=http", Protocol: "http", IsAPI: true, Fields: &FieldMap{Latency: "latency", Uptime: "upTime"}},
+	{URL: "https://free-proxy-list.net/", Protocol: "http", IsAPI: false, Table: &TableConfig{Rows: "table.table-striped tbody tr", IP: 1, Port: 2, Country: 3, Anonymity: 5}},
+	{URL: "http://www.kxdaili.com/dailiip/1/1.html", Protocol: "http", IsAPI: false, PageURL: "http://www.kxdaili.com/dailiip/1/{page}.html", MaxPages: 10},
+	{URL: "http://www.66ip.cn/nmtq.php?get_num=300&isp=0&anonym=0&type=2", Protocol: "http", IsAPI: true},
+	{URL: "http://proxylist.fatezero.org/proxy.list", Protocol: "http", IsAPI: false},
+	{URL: "https://www.proxy-list.download/api/v1/get?type=https", Protocol: "https", IsAPI: true},
+	{URL: "https://api.proxyscrape.com/v3/free-proxy-list/get?request=displayproxies&protocol=socks4", Protocol: "socks4", IsAPI: true},
+	{URL: "https://openproxylist.xyz/socks4.txt", Protocol: "socks4", IsAPI: true},
+	{URL: "https://www.proxy-list.download/api/v1/get?type=socks4", Protocol: "socks4", IsAPI: true},
+	{URL: "https://api.proxyscrape.com/v3/free-proxy-list/get?request=displayproxies&protocol=socks5", Protocol: "socks5", IsAPI: true},
+	{URL: "https://openproxylist.xyz/socks5.txt", Protocol: "socks5", IsAPI: true},
+	{URL: "https://www.proxy-list.download/api/v1/get?type=socks5", Protocol: "socks5", IsAPI: true},
+	{URL: "https://www.proxyscan.io/api/proxy?type=socks5&format=txt", Protocol: "socks5", IsAPI: true},
+	{URL: "https://raw.githubusercontent.com/TheSpeedX/PROXY-List/master/http.txt", Protocol: "http", IsAPI: true, RawList: true},
+	{URL: "https://raw.githubusercontent.com/TheSpeedX/PROXY-List/master/socks4.txt", Protocol: "socks4", IsAPI: true, RawList: true},
+	{URL: "https://raw.githubusercontent.com/TheSpeedX/PROXY-List/master/socks5.txt", Protocol: "socks5", IsAPI: true, RawList: true},
 }
 
 // FetchAllProxies 从所有代理源并发获取代理列表
-// 使用goroutine并发请求所有代理源提高获取速度
-// 自动去重相同地址的代理
+// 使用goroutine并发请求所有代理源提高获取速度，同一主机的请求按 hostInterval 错开，
+// 偶发失败的源会退避重试，全部源的获取总时长不超过 fetchTimeout
+// 自动去重相同地址的代理，并记录每个代理源的获取统计(见 SourcesStats)
+// 参数 ctx: 取消后中止进行中的请求，已获取完成的源的代理仍会返回
 // 返回值：
 //
 //	[]*proxy.Proxy: 去重后的代理列表
 //	error: 如果所有源都获取失败返回错误
-func FetchAllProxies() ([]*proxy.Proxy, error) {
+func FetchAllProxies(ctx context.Context) ([]*proxy.Proxy, error) {
 	var wg sync.WaitGroup
-	proxyChan := make(chan []*proxy.Proxy, len(proxySources))
-	errChan := make(chan error, len(proxySources))
+	sources := Sources()
+	results := make(chan sourceResult, len(sources))
+	fetchCtx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
 
-	for _, source := range proxySources {
+	for _, source := range sources {
+		if source.Disabled {
+			continue
+		}
 		wg.Add(1)
 		go func(s ProxySource) {
 			defer wg.Done()
-			proxies, err := fetchFromSource(s)
-			if err != nil {
-				errChan <- err
-				return
-			}
-			proxyChan <- proxies
+			results <- fetchFromSource(fetchCtx, s)
 		}(source)
 	}
 
 	go func() {
 		wg.Wait()
-		close(proxyChan)
-		close(errChan)
+		close(results)
 	}()
 
 	allProxies := make([]*proxy.Proxy, 0)
-	seen := make(map[string]bool)
+	seen := make(map[string]*proxy.Proxy)
+	attempted, failed := 0, 0
 
-	for p := range proxyChan {
-		for _, proxyItem := range p {
-			if !seen[proxyItem.Address] {
-				seen[proxyItem.Address] = true
-				allProxies = append(allProxies, proxyItem)
+	for r := range results {
+		// 取消导致的失败不是代理源的问题，不逐个输出也不计入统计；
+		// 超过总时长导致的失败仍然计入，说明该源响应过慢
+		if r.err != nil && ctx.Err() != nil {
+			continue
+		}
+		attempted++
+		if r.err != nil {
+			failed++
+			log.Printf("error fetching proxies after %d retries: %v", r.retries, r.err)
+		}
+		stats := SourceStats{
+			Source:    r.source,
+			LastFetch: time.Now(),
+			Duration:  r.duration,
+			Status:    r.status,
+			Pages:     r.pages,
+			Retries:   r.retries,
+			Fetched:   len(r.proxies),
+		}
+		if r.err != nil {
+			stats.Err = r.err.Error()
+		}
+		addresses := make(map[string]struct{})
+		for _, proxyItem := range r.proxies {
+			// 多个源提供同一代理时合并各源给出的信息
+			if existing, ok := seen[proxyItem.Address]; ok {
+				existing.FillMissing(proxyItem)
+				continue
 			}
+			seen[proxyItem.Address] = proxyItem
+			allProxies = append(allProxies, proxyItem)
+			addresses[proxyItem.Address] = struct{}{}
 		}
+		stats.Unique = len(addresses)
+		recordFetch(stats, addresses)
 	}
 
-	for err := range errChan {
-		log.Printf("error fetching proxies: %v", err)
+	if attempted > 0 && failed == attempted {
+		return allProxies, fmt.Errorf("全部 %d 个代理源获取失败", attempted)
 	}
-
 	return allProxies, nil
 }
 
+// sourceResult 单个代理源的获取结果
+// pages: 成功获取的页数
+// retries: 各页面偶发失败后的重试次数之和
+type sourceResult struct {
+	source   ProxySource
+	proxies  []*proxy.Proxy
+	status   int
+	pages    int
+	retries  int
+	duration time.Duration
+	err      error
+}
+
 // fetchFromSource 从单个代理源获取代理
+// 配置了分页时依次获取后续页面，页面之间等待 pageCrawlDelay；
+// 后续页面失败或没有代理时停止翻页，已获取的页面仍然有效
+// 参数 ctx 取消后请求和等待立即中止
 // 参数 source 是要获取的代理源配置
-// 根据IsAPI标志选择合适的解析器
-// 返回该源的代理列表和可能的错误
-func fetchFromSource(source ProxySource) ([]*proxy.Proxy, error) {
-	client := &http.Client{Timeout: 15 * time.Second}
-	req, err := http.NewRequest("GET", source.URL, nil)
+// 返回该源的获取结果，status 和 err 为第1页的结果
+func fetchFromSource(ctx context.Context, source ProxySource) (result sourceResult) {
+	result.source = source
+	start := time.Now()
+	defer func() {
+		result.duration = time.Since(start)
+	}()
+
+	client := &http.Client{Timeout: requestTimeout}
+	for page := 1; page <= source.pageCount(); page++ {
+		if page > 1 && sleepContext(ctx, pageCrawlDelay) != nil {
+			break
+		}
+		proxies, status, attempts, err := fetchPage(ctx, client, source, source.pageURL(page))
+		if attempts > 1 {
+			result.retries += attempts - 1
+		}
+		if page == 1 {
+			result.status, result.err = status, err
+			if err != nil {
+				return result
+			}
+		} else if err != nil {
+			log.Printf("fetching page %d of %s stopped: %v", page, source.URL, err)
+			break
+		}
+		if len(proxies) == 0 {
+			break
+		}
+		result.pages++
+		result.proxies = append(result.proxies, proxies...)
+	}
+
+	if source.Premium {
+		for _, p := range result.proxies {
+			p.IsPremium = true
+		}
+	}
+	return result
+}
+
+// fetchPage 获取代理源的一个页面，超时、429 和 5xx 时退避重试最多 fetchRetries 次
+// 返回解析出的代理、最后一次请求的HTTP状态码、请求次数和可能的错误
+func fetchPage(ctx context.Context, client *http.Client, source ProxySource, pageURL string) ([]*proxy.Proxy, int, int, error) {
+	host := pageURL
+	if u, err := url.Parse(pageURL); err == nil {
+		host = u.Host
+	}
+	var proxies []*proxy.Proxy
+	var status int
+	var err error
+	attempts := 0
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			if sleepContext(ctx, retryDelay(err, attempt)) != nil {
+				return nil, status, attempts, err
+			}
+		}
+		if waitErr := limiter.wait(ctx, host); waitErr != nil {
+			if err == nil {
+				err = waitErr
+			}
+			return nil, status, attempts, err
+		}
+		attempts++
+		proxies, status, err = fetchOnce(ctx, client, source, pageURL)
+		if err == nil {
+			return proxies, status, attempts, nil
+		}
+		if attempt >= fetchRetries || !retryable(err) || ctx.Err() != nil {
+			return nil, status, attempts, err
+		}
+	}
+}
+
+// fetchOnce 请求一次代理源的指定页面并按配置选择解析器
+// 纯文本列表使用条件请求，列表未变化(304)时解析上次下载的内容
+// 返回解析出的代理、HTTP状态码(未收到响应时为0)和可能的错误，非200状态码返回 *statusError
+func fetchOnce(ctx context.Context, client *http.Client, source ProxySource, pageURL string) ([]*proxy.Proxy, int, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36")
+	if source.Provider != nil {
+		source.Provider.authorize(req)
+	}
+	rawList := source.isRawList()
+	if rawList {
+		setConditionalHeaders(req, pageURL)
+	}
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("bad status: %s from %s", resp.Status, source.URL)
+	notModified := rawList && resp.StatusCode == http.StatusNotModified
+	if resp.StatusCode != http.StatusOK && !notModified {
+		return nil, resp.StatusCode, &statusError{
+			url:        pageURL,
+			status:     resp.Status,
+			code:       resp.StatusCode,
+			retryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
 	}
 
-	if source.IsAPI {
-		return parseAPIResponse(resp.Body, source.Protocol)
+	body := io.Reader(resp.Body)
+	if rawList {
+		if body, err = conditionalBody(resp, pageURL); err != nil {
+			return nil, resp.StatusCode, err
+		}
+	}
+
+	var proxies []*proxy.Proxy
+	switch {
+	case source.Provider != nil:
+		proxies, err = parseProviderResponse(body, *source.Provider, source.Protocol)
+	case source.Fields != nil:
+		proxies, err = parseJSONFields(body, source.JSONPath, *source.Fields, source.Protocol)
+	case source.JSONPath != "":
+		proxies, err = parseJSONPath(body, source.JSONPath, source.Protocol)
+	case source.Regex != "":
+		proxies, err = parseWithRegex(body, source.Regex, source.Protocol)
+	case source.IsAPI:
+		proxies, err = parseAPIResponse(body, source.Protocol)
+	case source.Table != nil:
+		proxies, err = parseHTMLTable(body, *source.Table, source.Protocol)
+	default:
+		proxies, err = parseHTMLResponse(body, source.Protocol)
+	}
+	return proxies, resp.StatusCode, err
+}
+
+// apiProxyItem JSON格式API返回的单个代理条目
+// 兼容基础的 {"ip","port"} 格式和 geonode 的扩展字段
+// geonode 的端口为字符串，因此使用 flexPort 同时兼容数字和字符串
+type apiProxyItem struct {
+	IP             string   `json:"ip"`
+	Port           flexPort `json:"port"`
+	Protocols      []string `json:"protocols"`
+	AnonymityLevel string   `json:"anonymityLevel"`
+	Country        string   `json:"country"`
+	City           string   `json:"city"`
+}
+
+// flexPort 可从JSON数字或字符串解析的端口号
+type flexPort int
+
+// UnmarshalJSON 解析 8080 或 "8080" 两种形式的端口
+func (p *flexPort) UnmarshalJSON(data []byte) error {
+	n, err := strconv.Atoi(strings.Trim(string(data), `"`))
+	if err != nil {
+		return fmt.Errorf("无效的端口: %s", data)
+	}
+	*p = flexPort(n)
+	return nil
+}
+
+// anonymityLevels 将源站的匿名级别映射为检查器使用的名称
+var anonymityLevels = map[string]string{
+	"elite":       "Elite",
+	"anonymous":   "Anonymous",
+	"transparent": "Transparent",
+}
+
+// toProxy 将API条目转换为代理，源站提供的协议、国家和匿名度会被保留
+// 条目声明了多种协议时优先使用代理源声明的协议
+func (item apiProxyItem) toProxy(defaultProtocol string) *proxy.Proxy {
+	protocol := defaultProtocol
+	if len(item.Protocols) > 0 {
+		protocol = strings.ToLower(item.Protocols[0])
+		for _, p := range item.Protocols {
+			if strings.EqualFold(p, defaultProtocol) {
+				protocol = defaultProtocol
+				break
+			}
+		}
+	}
+	return &proxy.Proxy{
+		Address:   net.JoinHostPort(item.IP, strconv.Itoa(int(item.Port))),
+		Protocol:  protocol,
+		Anonymity: anonymityLevels[strings.ToLower(item.AnonymityLevel)],
+		Country:   item.Country,
+		Province:  "",
+		City:      item.City,
 	}
-	return parseHTMLResponse(resp.Body, source.Protocol)
 }
 
 // parseAPIResponse 解析API响应获取代理列表
-// 支持JSON格式和纯文本格式的API响应
+// 支持JSON格式(含geonode扩展字段)、逐行JSON和纯文本格式的API响应
+// 纯文本中带 scheme:// 前缀的条目按前缀协议解析
 // 参数 body 是HTTP响应体
 // 参数 protocol 是代理协议类型
 // 返回解析出的代理列表和可能的错误
@@ -139,72 +380,117 @@ func parseAPIResponse(body io.Reader, protocol string) ([]*proxy.Proxy, error) {
 	}
 
 	var jsonResp struct {
-		Data []struct {
-			Ip   string `json:"ip"`
-			Port int    `json:"port"`
-		} `json:"data"`
+		Data []apiProxyItem `json:"data"`
 	}
 	if err := json.Unmarshal(content, &jsonResp); err == nil && len(jsonResp.Data) > 0 {
-		proxies := make([]*proxy.Proxy, len(jsonResp.Data))
-		for i, item := range jsonResp.Data {
-			proxies[i] = &proxy.Proxy{
-				Address:  fmt.Sprintf("%s:%d", item.Ip, item.Port),
-				Protocol: protocol,
-				Country:  "",
-				Province: "",
-				City:     "",
+		proxies := make([]*proxy.Proxy, 0, len(jsonResp.Data))
+		for _, item := range jsonResp.Data {
+			if item.IP == "" || item.Port <= 0 {
+				continue
 			}
+			proxies = append(proxies, item.toProxy(protocol))
 		}
 		return proxies, nil
 	}
 
-	lines := strings.Split(string(content), "\n")
-	proxyRegex := regexp.MustCompile(`\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3}:\d+`)
-
-	var proxies []*proxy.Proxy
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if proxyRegex.MatchString(line) {
-			proxies = append(proxies, &proxy.Proxy{
-				Address:  line,
-				Protocol: protocol,
-				Country:  "",
-				Province: "",
-				City:     "",
-			})
-		}
+	if proxies := parseJSONLines(content, protocol); len(proxies) > 0 {
+		return proxies, nil
 	}
 
-	return proxies, nil
+	return extractProxies(string(content), protocol), nil
 }
 
 // parseHTMLResponse 解析HTML页面提取代理列表
-// 使用正则表达式从HTML文本中提取IP:端口格式的代理
+// 页面内容为逐行JSON(如fatezero)时按JSON解析，否则用正则从页面文本中提取代理
 // 参数 body 是HTTP响应体
 // 参数 protocol 是代理协议类型
 // 返回解析出的代理列表和可能的错误
 func parseHTMLResponse(body io.Reader, protocol string) ([]*proxy.Proxy, error) {
-	doc, err := goquery.NewDocumentFromReader(body)
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	if proxies := parseJSONLines(content, protocol); len(proxies) > 0 {
+		return proxies, nil
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(content))
 	if err != nil {
 		return nil, err
 	}
 
 	var proxies []*proxy.Proxy
-	proxyRegex := regexp.MustCompile(`\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3}:\d+`)
-
 	doc.Find("body").Each(func(i int, s *goquery.Selection) {
-		text := s.Text()
-		matches := proxyRegex.FindAllString(text, -1)
-		for _, match := range matches {
-			proxies = append(proxies, &proxy.Proxy{
-				Address:  match,
-				Protocol: protocol,
-				Country:  "",
-				Province: "",
-				City:     "",
-			})
-		}
+		proxies = append(proxies, extractProxies(s.Text(), protocol)...)
 	})
 
 	return proxies, nil
 }
+
+// proxyRegex 匹配 IP:端口 或 [IPv6]:端口 格式的代理，可带 scheme:// 前缀
+// 第1个分组为协议(可能为空)，第2个分组为地址
+var proxyRegex = regexp.MustCompile(`(?i)(?:\b(https?|socks4a?|socks5h?)://)?(\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3}:\d+|\[[0-9a-f:.]+\]:\d+)`)
+
+// extractProxies 从文本中提取所有代理
+// 带有协议前缀的条目使用前缀声明的协议，否则使用代理源的默认协议
+func extractProxies(text, protocol string) []*proxy.Proxy {
+	var proxies []*proxy.Proxy
+	for _, match := range proxyRegex.FindAllStringSubmatch(text, -1) {
+		host, port, err := net.SplitHostPort(match[2])
+		if err != nil || net.ParseIP(host) == nil {
+			continue
+		}
+		proxies = append(proxies, &proxy.Proxy{
+			Address:  net.JoinHostPort(host, port),
+			Protocol: normalizeProtocol(match[1], protocol),
+			Country:  "",
+			Province: "",
+			City:     "",
+		})
+	}
+	return proxies
+}
+
+// parseJSONLines 解析每行一个JSON对象的代理列表(fatezero格式)
+// 每行包含 host、port、type 字段，type 缺省时使用代理源的默认协议
+// 内容不是该格式时返回nil
+func parseJSONLines(content []byte, protocol string) []*proxy.Proxy {
+	var proxies []*proxy.Proxy
+	for _, line := range bytes.Split(content, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 || line[0] != '{' {
+			continue
+		}
+		var item struct {
+			Host    string   `json:"host"`
+			Port    flexPort `json:"port"`
+			Type    string   `json:"type"`
+			Country string   `json:"country"`
+		}
+		if err := json.Unmarshal(line, &item); err != nil || item.Host == "" || item.Port <= 0 {
+			continue
+		}
+		proxies = append(proxies, &proxy.Proxy{
+			Address:  net.JoinHostPort(item.Host, strconv.Itoa(int(item.Port))),
+			Protocol: normalizeProtocol(item.Type, protocol),
+			Country:  item.Country,
+			Province: "",
+			City:     "",
+		})
+	}
+	return proxies
+}
+
+// normalizeProtocol 规范化条目自带的协议名，为空时返回默认协议
+// socks5h 与 socks5 在本项目中等价(域名均交由代理解析)
+func normalizeProtocol(scheme, defaultProtocol string) string {
+	scheme = strings.ToLower(strings.TrimSpace(scheme))
+	switch scheme {
+	case "":
+		return defaultProtocol
+	case "socks5h":
+		return "socks5"
+	default:
+		return scheme
+	}
+}
@@ -0,0 +1,41 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestFetchAllProxiesMergesCountry 两个源提供同一代理，只有一个源给出国家时合并结果保留国家
+func TestFetchAllProxiesMergesCountry(t *testing.T) {
+	bare := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "1.2.3.4:8080")
+	}))
+	defer bare.Close()
+	withCountry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"host":"1.2.3.4","port":8080,"type":"http","country":"JP"}`)
+	}))
+	defer withCountry.Close()
+
+	previous := Sources()
+	t.Cleanup(func() { SetSources(previous) })
+	if err := SetSources([]ProxySource{
+		{URL: bare.URL, Protocol: "http", IsAPI: true},
+		{URL: withCountry.URL, Protocol: "http", IsAPI: true},
+	}); err != nil {
+		t.Fatalf("SetSources 返回错误: %v", err)
+	}
+
+	proxies, err := FetchAllProxies(context.Background())
+	if err != nil {
+		t.Fatalf("FetchAllProxies 返回错误: %v", err)
+	}
+	if len(proxies) != 1 {
+		t.Fatalf("代理数 = %d，期望 1", len(proxies))
+	}
+	if proxies[0].Country != "JP" {
+		t.Errorf("国家 = %q，期望 \"JP\"", proxies[0].Country)
+	}
+}
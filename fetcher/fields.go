@@ -0,0 +1,172 @@
+package fetcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+
+	"go_proxy/proxy"
+)
+
+// FieldMap JSON代理条目的字段映射，值为条目中对应字段的键名，为空时使用默认键名
+// 用于保留源站提供的元数据，检测前据此优先安排更可能有效的代理
+// IP/Port: 地址和端口，默认为 ip、port
+// Protocols: 协议，字段可以是字符串或字符串数组，默认为 protocols
+// Latency: 源站测得的延迟(毫秒)，默认不读取
+// Uptime: 源站统计的在线率(0-100)，默认不读取
+// Anonymity/Country/City: 匿名度、国家和城市，默认为 anonymityLevel、country、city
+type FieldMap struct {
+	IP        string `json:"ip,omitempty" yaml:"ip,omitempty"`
+	Port      string `json:"port,omitempty" yaml:"port,omitempty"`
+	Protocols string `json:"protocols,omitempty" yaml:"protocols,omitempty"`
+	Latency   string `json:"latency,omitempty" yaml:"latency,omitempty"`
+	Uptime    string `json:"uptime,omitempty" yaml:"uptime,omitempty"`
+	Anonymity string `json:"anonymity,omitempty" yaml:"anonymity,omitempty"`
+	Country   string `json:"country,omitempty" yaml:"country,omitempty"`
+	City      string `json:"city,omitempty" yaml:"city,omitempty"`
+}
+
+// defaultDataPath 使用字段映射但未配置JSON路径时，代理条目数组所在的路径
+const defaultDataPath = "data"
+
+// withDefaults 返回补全默认键名后的字段映射
+func (m FieldMap) withDefaults() FieldMap {
+	defaults := map[*string]string{
+		&m.IP:        "ip",
+		&m.Port:      "port",
+		&m.Protocols: "protocols",
+		&m.Anonymity: "anonymityLevel",
+		&m.Country:   "country",
+		&m.City:      "city",
+	}
+	for field, key := range defaults {
+		if *field == "" {
+			*field = key
+		}
+	}
+	return m
+}
+
+// parseJSONFields 按字段映射解析JSON响应中的代理条目
+// 参数 path: 条目数组的JSON路径，为空时为 data
+func parseJSONFields(body io.Reader, path string, fields FieldMap, protocol string) ([]*proxy.Proxy, error) {
+	if path == "" {
+		path = defaultDataPath
+	}
+	var root interface{}
+	if err := json.NewDecoder(body).Decode(&root); err != nil {
+		return nil, err
+	}
+	node, err := jsonPathNode(root, path)
+	if err != nil {
+		return nil, err
+	}
+	items, ok := node.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("JSON路径 %s 不是代理条目数组", path)
+	}
+
+	fields = fields.withDefaults()
+	proxies := make([]*proxy.Proxy, 0, len(items))
+	for _, raw := range items {
+		item, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if p := fields.toProxy(item, protocol); p != nil {
+			proxies = append(proxies, p)
+		}
+	}
+	return proxies, nil
+}
+
+// toProxy 按字段映射将JSON条目转换为代理，地址或端口无效时返回nil
+// 条目声明了多种协议时优先使用代理源声明的协议
+func (m FieldMap) toProxy(item map[string]interface{}, defaultProtocol string) *proxy.Proxy {
+	ip := jsonString(item[m.IP])
+	port, ok := jsonNumber(item[m.Port])
+	if ip == "" || !ok || port <= 0 || port > 65535 {
+		return nil
+	}
+
+	protocol := defaultProtocol
+	var protocols []string
+	switch v := item[m.Protocols].(type) {
+	case string:
+		protocols = []string{v}
+	case []interface{}:
+		for _, p := range v {
+			protocols = append(protocols, jsonString(p))
+		}
+	}
+	if len(protocols) > 0 {
+		protocol = normalizeProtocol(protocols[0], defaultProtocol)
+		for _, p := range protocols {
+			if strings.EqualFold(p, defaultProtocol) {
+				protocol = defaultProtocol
+				break
+			}
+		}
+	}
+
+	p := &proxy.Proxy{
+		Address:   net.JoinHostPort(ip, strconv.Itoa(int(port))),
+		Protocol:  protocol,
+		Anonymity: normalizeAnonymity(jsonString(item[m.Anonymity])),
+		Country:   jsonString(item[m.Country]),
+		City:      jsonString(item[m.City]),
+	}
+	if m.Latency != "" {
+		if ms, ok := jsonNumber(item[m.Latency]); ok && ms > 0 {
+			p.SourceLatency = ms / 1000
+		}
+	}
+	if m.Uptime != "" {
+		if uptime, ok := jsonNumber(item[m.Uptime]); ok && uptime > 0 {
+			p.Uptime = uptime
+			if p.Uptime > 100 {
+				p.Uptime = 100
+			}
+		}
+	}
+	return p
+}
+
+// jsonPathNode 按以.分隔的路径逐级进入JSON对象
+func jsonPathNode(root interface{}, path string) (interface{}, error) {
+	node := root
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("JSON路径 %s 无效: %s 不是对象", path, key)
+		}
+		node = obj[key]
+	}
+	return node, nil
+}
+
+// jsonString 返回JSON字符串或数字的文本形式，其他类型返回空
+func jsonString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+// jsonNumber 返回JSON数字或数字字符串的值
+func jsonNumber(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return n, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,174 @@
+package fetcher
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"go_proxy/proxy"
+)
+
+// 支持的付费代理服务商
+const (
+	ProviderWebshare    = "webshare"
+	ProviderProxyScrape = "proxyscrape"
+	ProviderBrightData  = "brightdata"
+)
+
+// Providers 全部支持的付费代理服务商
+var Providers = []string{ProviderWebshare, ProviderProxyScrape, ProviderBrightData}
+
+// webshareMaxPages Webshare 未配置最大页数时默认获取的页数，每页100个代理
+const webshareMaxPages = 10
+
+// brightDataGateway Bright Data 超级代理网关的地址
+const brightDataGateway = "brd.superproxy.io:22225"
+
+// ProviderConfig 付费代理服务商的接入配置，服务商的代理均标记为高级代理
+// Name: 服务商(webshare/proxyscrape/brightdata)
+// APIKey: API密钥，Webshare 和 ProxyScrape 为API密钥，Bright Data 为API令牌
+// Zone: Bright Data 的区域名
+// Customer: Bright Data 的客户ID
+// Password: Bright Data 的区域密码，用于代理认证
+type ProviderConfig struct {
+	Name     string `json:"name" yaml:"name"`
+	APIKey   string `json:"api_key" yaml:"api_key"`
+	Zone     string `json:"zone,omitempty" yaml:"zone,omitempty"`
+	Customer string `json:"customer,omitempty" yaml:"customer,omitempty"`
+	Password string `json:"password,omitempty" yaml:"password,omitempty"`
+}
+
+// Validate 检查服务商配置是否完整
+func (c *ProviderConfig) Validate() error {
+	c.Name = strings.ToLower(strings.TrimSpace(c.Name))
+	c.APIKey = strings.TrimSpace(c.APIKey)
+	switch c.Name {
+	case ProviderWebshare, ProviderProxyScrape:
+	case ProviderBrightData:
+		if c.Zone == "" || c.Customer == "" || c.Password == "" {
+			return errors.New("Bright Data 需要配置区域、客户ID和区域密码")
+		}
+	default:
+		return fmt.Errorf("不支持的代理服务商: %s", c.Name)
+	}
+	if c.APIKey == "" {
+		return fmt.Errorf("代理服务商 %s 需要配置API密钥", c.Name)
+	}
+	return nil
+}
+
+// applyProviderDefaults 为服务商代理源补全默认的接口地址和分页配置，已配置的地址保持不变
+func (s *ProxySource) applyProviderDefaults() {
+	s.Premium = true
+	s.IsAPI = true
+	switch s.Provider.Name {
+	case ProviderWebshare:
+		if s.URL == "" {
+			s.URL = "https://proxy.webshare.io/api/v2/proxy/list/?mode=direct&page=1&page_size=100"
+			s.PageURL = "https://proxy.webshare.io/api/v2/proxy/list/?mode=direct&page={page}&page_size=100"
+		}
+		if s.MaxPages == 0 {
+			s.MaxPages = webshareMaxPages
+		}
+	case ProviderProxyScrape:
+		if s.URL == "" {
+			s.URL = "https://api.proxyscrape.com/v2/account/datacenter_shared/proxy-list?type=getproxies&protocol=" +
+				s.Protocol + "&format=normal&status=online"
+		}
+	case ProviderBrightData:
+		if s.URL == "" {
+			s.URL = "https://api.brightdata.com/zone/route_ips?zone=" + url.QueryEscape(s.Provider.Zone)
+		}
+	}
+	if s.Name == "" {
+		s.Name = s.Provider.Name
+	}
+}
+
+// authorize 为服务商接口请求添加认证信息
+// API密钥不写入代理源地址，避免出现在日志、统计和界面中
+func (c ProviderConfig) authorize(req *http.Request) {
+	switch c.Name {
+	case ProviderWebshare:
+		req.Header.Set("Authorization", "Token "+c.APIKey)
+	case ProviderProxyScrape:
+		query := req.URL.Query()
+		query.Set("auth", c.APIKey)
+		req.URL.RawQuery = query.Encode()
+	case ProviderBrightData:
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+}
+
+// parseProviderResponse 按服务商的接口格式解析代理列表
+func parseProviderResponse(body io.Reader, provider ProviderConfig, protocol string) ([]*proxy.Proxy, error) {
+	switch provider.Name {
+	case ProviderWebshare:
+		return parseWebshare(body, protocol)
+	case ProviderBrightData:
+		return parseBrightData(body, provider, protocol)
+	}
+	return parseAPIResponse(body, protocol)
+}
+
+// parseWebshare 解析 Webshare 代理列表接口的一页结果，跳过服务商标记为不可用的代理
+// 返回的代理带有账户的认证信息
+func parseWebshare(body io.Reader, protocol string) ([]*proxy.Proxy, error) {
+	var resp struct {
+		Results []struct {
+			Address     string   `json:"proxy_address"`
+			Port        flexPort `json:"port"`
+			Username    string   `json:"username"`
+			Password    string   `json:"password"`
+			Valid       bool     `json:"valid"`
+			CountryCode string   `json:"country_code"`
+			City        string   `json:"city_name"`
+		} `json:"results"`
+	}
+	if err := json.NewDecoder(body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("解析 Webshare 响应失败: %v", err)
+	}
+	proxies := make([]*proxy.Proxy, 0, len(resp.Results))
+	for _, item := range resp.Results {
+		if !item.Valid || item.Address == "" || item.Port <= 0 {
+			continue
+		}
+		proxies = append(proxies, &proxy.Proxy{
+			Address:  net.JoinHostPort(item.Address, strconv.Itoa(int(item.Port))),
+			Protocol: protocol,
+			Country:  item.CountryCode,
+			City:     item.City,
+			Username: item.Username,
+			Password: item.Password,
+		})
+	}
+	return proxies, nil
+}
+
+// parseBrightData 解析 Bright Data 区域IP列表接口的结果
+// Bright Data 的代理只能经由超级代理网关访问，由网关在区域分配的IP中选择出口，
+// 而代理池以地址区分代理，因此区域分配了IP时生成一个带区域认证信息的网关代理
+func parseBrightData(body io.Reader, provider ProviderConfig, protocol string) ([]*proxy.Proxy, error) {
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Fields(string(content)) {
+		if net.ParseIP(line) == nil {
+			continue
+		}
+		return []*proxy.Proxy{{
+			Address:  brightDataGateway,
+			Protocol: protocol,
+			Username: "brd-customer-" + provider.Customer + "-zone-" + provider.Zone,
+			Password: provider.Password,
+		}}, nil
+	}
+	return nil, nil
+}
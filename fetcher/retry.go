@@ -0,0 +1,119 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// fetchTimeout 一次获取全部代理源的总时长上限，超时后仍在进行的请求和重试被中止
+const fetchTimeout = 2 * time.Minute
+
+// requestTimeout 单次请求代理源的超时
+const requestTimeout = 15 * time.Second
+
+// fetchRetries 请求代理源偶发失败(超时、429、5xx)后的最多重试次数
+const fetchRetries = 2
+
+// fetchRetryBackoff 首次重试前的等待时间，之后每次翻倍
+const fetchRetryBackoff = 2 * time.Second
+
+// maxRetryAfter 服务端通过 Retry-After 要求等待的最长时间，超过时按此值等待
+const maxRetryAfter = 30 * time.Second
+
+// pageCrawlDelay 分页获取时两页之间的等待时间，避免翻页过快被源站封禁
+const pageCrawlDelay = 2 * time.Second
+
+// hostInterval 对同一主机的两次请求之间的最短间隔
+// 内置代理源中有多个源位于同一主机，同时请求容易触发429
+const hostInterval = time.Second
+
+// statusError 代理源返回了非200状态码
+type statusError struct {
+	url        string
+	status     string
+	code       int
+	retryAfter time.Duration
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("bad status: %s from %s", e.status, e.url)
+}
+
+// retryable 判断请求失败是否值得重试：网络错误、超时、429 和 5xx 状态码
+// 其他状态码和响应解析失败说明代理源本身有问题，重试没有意义
+func retryable(err error) bool {
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.code == http.StatusTooManyRequests || se.code >= 500
+	}
+	var ue *url.Error
+	return errors.As(err, &ue)
+}
+
+// retryDelay 返回第 attempt 次重试(从1开始)前的等待时间
+// 服务端给出 Retry-After 时优先使用，最长不超过 maxRetryAfter
+func retryDelay(err error, attempt int) time.Duration {
+	var se *statusError
+	if errors.As(err, &se) && se.retryAfter > 0 {
+		if se.retryAfter > maxRetryAfter {
+			return maxRetryAfter
+		}
+		return se.retryAfter
+	}
+	return fetchRetryBackoff << (attempt - 1)
+}
+
+// parseRetryAfter 解析以秒数表示的 Retry-After 响应头，无法解析时返回0
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// hostLimiter 按主机限制请求频率
+// 每次请求预约该主机的下一个可用时间点，同一主机的请求依次间隔 interval 发出
+type hostLimiter struct {
+	interval time.Duration
+	mutex    sync.Mutex
+	next     map[string]time.Time
+}
+
+// limiter 所有代理源请求共用的主机限速器
+var limiter = &hostLimiter{interval: hostInterval, next: make(map[string]time.Time)}
+
+// wait 等待直到可以请求指定主机，ctx 取消时返回其错误
+func (l *hostLimiter) wait(ctx context.Context, host string) error {
+	l.mutex.Lock()
+	now := time.Now()
+	at := l.next[host]
+	if at.Before(now) {
+		at = now
+	}
+	l.next[host] = at.Add(l.interval)
+	l.mutex.Unlock()
+
+	return sleepContext(ctx, time.Until(at))
+}
+
+// sleepContext 等待指定时间，ctx 取消时提前返回其错误
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
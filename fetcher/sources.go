@@ -0,0 +1,216 @@
+package fetcher
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go_proxy/proxy"
+
+	"gopkg.in/yaml.v3"
+)
+
+// sourcesMutex 保护 proxySources，代理源可在运行时增删
+var sourcesMutex sync.RWMutex
+
+// pagePlaceholder 分页地址模板中代表页码的占位符
+const pagePlaceholder = "{page}"
+
+// maxPages 单个代理源允许配置的最大页数
+const maxPages = 50
+
+// Sources 返回当前代理源列表的副本
+func Sources() []ProxySource {
+	sourcesMutex.RLock()
+	defer sourcesMutex.RUnlock()
+	sources := make([]ProxySource, len(proxySources))
+	copy(sources, proxySources)
+	return sources
+}
+
+// SetSources 替换代理源列表，每个代理源都会先经过校验
+// 参数 sources: 新的代理源列表
+// 返回第一个无效代理源的错误
+func SetSources(sources []ProxySource) error {
+	for i := range sources {
+		if err := sources[i].Validate(); err != nil {
+			return err
+		}
+	}
+	sourcesMutex.Lock()
+	defer sourcesMutex.Unlock()
+	proxySources = append([]ProxySource(nil), sources...)
+	return nil
+}
+
+// Validate 检查代理源配置是否有效，并规范化协议名
+// 付费服务商的代理源未配置地址时使用服务商的默认接口
+func (s *ProxySource) Validate() error {
+	s.Protocol = normalizeProtocol(s.Protocol, "http")
+	if s.Provider != nil {
+		if err := s.Provider.Validate(); err != nil {
+			return err
+		}
+		s.applyProviderDefaults()
+	}
+	if !strings.HasPrefix(s.URL, "http://") && !strings.HasPrefix(s.URL, "https://") {
+		return fmt.Errorf("无效的代理源地址: %q", s.URL)
+	}
+	if !knownProtocol(s.Protocol) {
+		return fmt.Errorf("代理源 %s 的协议无效: %s", s.URL, s.Protocol)
+	}
+	if s.Regex != "" {
+		if _, err := regexp.Compile(s.Regex); err != nil {
+			return fmt.Errorf("代理源 %s 的正则无效: %v", s.URL, err)
+		}
+	}
+	if s.Table != nil {
+		if err := s.Table.Validate(); err != nil {
+			return fmt.Errorf("代理源 %s 的表格配置无效: %v", s.URL, err)
+		}
+	}
+	if s.MaxPages < 0 || s.MaxPages > maxPages {
+		return fmt.Errorf("代理源 %s 的最大页数必须在 0-%d 之间", s.URL, maxPages)
+	}
+	if s.MaxPages > 1 {
+		if !strings.Contains(s.PageURL, pagePlaceholder) {
+			return fmt.Errorf("代理源 %s 的分页地址必须包含 %s", s.URL, pagePlaceholder)
+		}
+		if !strings.HasPrefix(s.PageURL, "http://") && !strings.HasPrefix(s.PageURL, "https://") {
+			return fmt.Errorf("无效的分页地址: %q", s.PageURL)
+		}
+	}
+	return nil
+}
+
+// pageCount 返回需要获取的页数，未配置分页时为1
+func (s ProxySource) pageCount() int {
+	if s.MaxPages > 1 && s.PageURL != "" {
+		return s.MaxPages
+	}
+	return 1
+}
+
+// pageURL 返回第 page 页的地址，第1页为 URL
+func (s ProxySource) pageURL(page int) string {
+	if page <= 1 {
+		return s.URL
+	}
+	return strings.ReplaceAll(s.PageURL, pagePlaceholder, strconv.Itoa(page))
+}
+
+// LoadSources 从JSON或YAML配置文件加载代理源列表，替换当前列表
+// 按扩展名 .yaml/.yml 判断为YAML，其余按JSON解析
+// 参数 path: 配置文件路径
+func LoadSources(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var sources []ProxySource
+	if isYAML(path) {
+		err = yaml.Unmarshal(data, &sources)
+	} else {
+		err = json.Unmarshal(data, &sources)
+	}
+	if err != nil {
+		return fmt.Errorf("解析代理源配置失败: %v", err)
+	}
+	if len(sources) == 0 {
+		return errors.New("代理源配置为空")
+	}
+	return SetSources(sources)
+}
+
+// SaveSources 将当前代理源列表保存到配置文件，格式由扩展名决定
+// 参数 path: 配置文件路径
+func SaveSources(path string) error {
+	sources := Sources()
+	var data []byte
+	var err error
+	if isYAML(path) {
+		data, err = yaml.Marshal(sources)
+	} else {
+		data, err = json.MarshalIndent(sources, "", "  ")
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// isYAML 根据扩展名判断配置文件是否为YAML格式
+func isYAML(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// parseWithRegex 用自定义正则从响应中提取代理
+// 正则有分组时取第1个分组，否则取整个匹配作为 IP:端口
+func parseWithRegex(body io.Reader, pattern, protocol string) ([]*proxy.Proxy, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	var proxies []*proxy.Proxy
+	for _, match := range re.FindAllStringSubmatch(string(content), -1) {
+		address := match[0]
+		if len(match) > 1 {
+			address = match[1]
+		}
+		p, err := proxy.ParseProxyLine(address)
+		if err != nil {
+			continue
+		}
+		if !strings.Contains(address, "://") {
+			p.Protocol = protocol
+		}
+		proxies = append(proxies, p)
+	}
+	return proxies, nil
+}
+
+// parseJSONPath 按自定义JSON路径取出代理条目数组并解析
+// 路径以.分隔逐级进入对象，例如 "result.list"
+func parseJSONPath(body io.Reader, path, protocol string) ([]*proxy.Proxy, error) {
+	var root interface{}
+	if err := json.NewDecoder(body).Decode(&root); err != nil {
+		return nil, err
+	}
+	node, err := jsonPathNode(root, path)
+	if err != nil {
+		return nil, err
+	}
+
+	// 再次编码后按通用条目格式解析，复用 apiProxyItem 的字段兼容逻辑
+	raw, err := json.Marshal(node)
+	if err != nil {
+		return nil, err
+	}
+	var items []apiProxyItem
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, fmt.Errorf("JSON路径 %s 不是代理条目数组: %v", path, err)
+	}
+	proxies := make([]*proxy.Proxy, 0, len(items))
+	for _, item := range items {
+		if item.IP == "" || item.Port <= 0 {
+			continue
+		}
+		proxies = append(proxies, item.toProxy(protocol))
+	}
+	return proxies, nil
+}
@@ -0,0 +1,105 @@
+package fetcher
+
+import (
+	"sync"
+	"time"
+)
+
+// SourceStats 代理源及其最近一次获取的统计，用于找出长期无效的代理源
+// LastFetch: 最近一次获取的时间，零值表示本次运行中尚未获取
+// Status: 最后一次请求的HTTP状态码，未收到响应时为0
+// Pages: 成功获取的页数，配置了分页的源可能大于1
+// Retries: 偶发失败(超时、429、5xx)后的重试次数
+// Err: 获取失败的原因，成功时为空
+// Fetched: 解析出的代理数
+// Unique: 去重后由该源新增的代理数，多个源提供同一代理时只计入最先返回的源
+// Tested/Valid: 最近一次获取的代理中已完成检测和检测通过的数量，同一代理重测时以最后一次结果为准
+type SourceStats struct {
+	Source    ProxySource
+	LastFetch time.Time
+	Duration  time.Duration
+	Status    int
+	Pages     int
+	Retries   int
+	Err       string
+	Fetched   int
+	Unique    int
+	Tested    int
+	Valid     int
+}
+
+// ValidRate 返回已检测代理中检测通过的比例，没有检测结果时为0
+func (s SourceStats) ValidRate() float64 {
+	if s.Tested == 0 {
+		return 0
+	}
+	return float64(s.Valid) / float64(s.Tested)
+}
+
+// sourceRecord 单个代理源的统计记录
+// addresses: 最近一次获取中计入该源的代理地址
+// results: 这些代理的检测结果(地址 -> 是否通过)
+type sourceRecord struct {
+	stats     SourceStats
+	addresses map[string]struct{}
+	results   map[string]bool
+}
+
+var (
+	// statsMutex 保护 sourceRecords
+	statsMutex sync.Mutex
+	// sourceRecords 各代理源的统计，以代理源地址为键
+	sourceRecords = make(map[string]*sourceRecord)
+)
+
+// recordFetch 记录一次获取的结果，替换该源上一次获取的统计和检测结果
+// 参数 addresses: 去重后计入该源的代理地址
+func recordFetch(stats SourceStats, addresses map[string]struct{}) {
+	statsMutex.Lock()
+	defer statsMutex.Unlock()
+	sourceRecords[stats.Source.URL] = &sourceRecord{
+		stats:     stats,
+		addresses: addresses,
+		results:   make(map[string]bool),
+	}
+}
+
+// RecordValidation 记录一个代理的检测结果，计入最近一次获取中提供该代理的代理源的有效率
+// 不是由代理源获取的代理(如手动导入)会被忽略
+// 参数 address: 代理地址
+// 参数 valid: 检测是否通过
+func RecordValidation(address string, valid bool) {
+	statsMutex.Lock()
+	defer statsMutex.Unlock()
+	for _, record := range sourceRecords {
+		if _, ok := record.addresses[address]; ok {
+			record.results[address] = valid
+			return
+		}
+	}
+}
+
+// SourcesStats 按代理源列表的顺序返回每个代理源的统计，尚未获取的源只有 Source 字段
+func SourcesStats() []SourceStats {
+	sources := Sources()
+	statsMutex.Lock()
+	defer statsMutex.Unlock()
+	result := make([]SourceStats, len(sources))
+	for i, source := range sources {
+		record, ok := sourceRecords[source.URL]
+		if !ok {
+			result[i] = SourceStats{Source: source}
+			continue
+		}
+		stats := record.stats
+		stats.Source = source
+		stats.Tested = len(record.results)
+		for _, valid := range record.results {
+			if valid {
+				stats.Valid++
+			}
+		}
+		result[i] = stats
+	}
+	return result
+}
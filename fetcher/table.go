@@ -0,0 +1,164 @@
+package fetcher
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+
+	"go_proxy/proxy"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// TableConfig HTML表格的解析配置，按CSS选择器取出代理所在的行，再按列号取出各字段
+// Rows: 选择代理行的CSS选择器，例如 "table.table-striped tbody tr"
+// 列号从1开始，0 表示页面没有该列
+// IP: IP所在列，单元格为 IP:端口 时 Port 可以为0
+// Protocol: 协议列，为0或内容无法识别时使用代理源声明的协议
+// Country: 国家列，原样保存单元格内容(如 US)
+// Anonymity: 匿名度列，识别 elite/anonymous/transparent 和 高匿/普匿/透明 等写法
+type TableConfig struct {
+	Rows      string `json:"rows" yaml:"rows"`
+	IP        int    `json:"ip" yaml:"ip"`
+	Port      int    `json:"port,omitempty" yaml:"port,omitempty"`
+	Protocol  int    `json:"protocol,omitempty" yaml:"protocol,omitempty"`
+	Country   int    `json:"country,omitempty" yaml:"country,omitempty"`
+	Anonymity int    `json:"anonymity,omitempty" yaml:"anonymity,omitempty"`
+}
+
+// Validate 检查表格解析配置是否完整
+func (t *TableConfig) Validate() error {
+	t.Rows = strings.TrimSpace(t.Rows)
+	if t.Rows == "" {
+		return errors.New("表格解析需要指定行选择器")
+	}
+	if t.IP <= 0 {
+		return errors.New("表格解析需要指定IP所在列")
+	}
+	if t.Port < 0 || t.Protocol < 0 || t.Country < 0 || t.Anonymity < 0 {
+		return errors.New("表格列号不能为负数")
+	}
+	return nil
+}
+
+// ParseTableColumns 由行选择器和逗号分隔的列号创建表格解析配置，行选择器为空时返回nil
+// 参数 columns: 依次为 IP、端口、协议、国家、匿名度 所在列，可以省略末尾的列，例如 "1,2,0,3,5"
+func ParseTableColumns(rows, columns string) (*TableConfig, error) {
+	rows = strings.TrimSpace(rows)
+	if rows == "" {
+		return nil, nil
+	}
+	var numbers [5]int
+	fields := strings.Split(columns, ",")
+	if len(fields) > len(numbers) {
+		return nil, fmt.Errorf("表格列最多 %d 个: %s", len(numbers), columns)
+	}
+	for i, field := range fields {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("无效的表格列号: %s", field)
+		}
+		numbers[i] = n
+	}
+	table := &TableConfig{
+		Rows:      rows,
+		IP:        numbers[0],
+		Port:      numbers[1],
+		Protocol:  numbers[2],
+		Country:   numbers[3],
+		Anonymity: numbers[4],
+	}
+	return table, table.Validate()
+}
+
+// parseHTMLTable 按表格配置解析HTML页面中的代理，保留源站提供的国家和匿名度
+// 按配置没有解析出任何代理时(如源站改版)，退回到对整个页面的正则提取
+func parseHTMLTable(body io.Reader, table TableConfig, protocol string) ([]*proxy.Proxy, error) {
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+
+	var proxies []*proxy.Proxy
+	doc.Find(table.Rows).Each(func(i int, row *goquery.Selection) {
+		cells := row.Find("td")
+		cell := func(column int) string {
+			if column <= 0 || column > cells.Length() {
+				return ""
+			}
+			return strings.TrimSpace(cells.Eq(column - 1).Text())
+		}
+		if p := tableRowProxy(cell, table, protocol); p != nil {
+			proxies = append(proxies, p)
+		}
+	})
+	if len(proxies) > 0 {
+		return proxies, nil
+	}
+	return parseHTMLResponse(bytes.NewReader(content), protocol)
+}
+
+// tableRowProxy 将表格的一行转换为代理，IP或端口无效时返回nil
+// 参数 cell: 按列号(从1开始)取出单元格文本
+func tableRowProxy(cell func(column int) string, table TableConfig, protocol string) *proxy.Proxy {
+	host := cell(table.IP)
+	port := cell(table.Port)
+	if table.Port == 0 || table.Port == table.IP {
+		h, p, err := net.SplitHostPort(host)
+		if err != nil {
+			return nil
+		}
+		host, port = h, p
+	}
+	host = strings.Trim(host, "[]")
+	if net.ParseIP(host) == nil {
+		return nil
+	}
+	if n, err := strconv.Atoi(port); err != nil || n <= 0 || n > 65535 {
+		return nil
+	}
+	if scheme := normalizeProtocol(cell(table.Protocol), protocol); knownProtocol(scheme) {
+		protocol = scheme
+	}
+	return &proxy.Proxy{
+		Address:   net.JoinHostPort(host, port),
+		Protocol:  protocol,
+		Country:   cell(table.Country),
+		Anonymity: normalizeAnonymity(cell(table.Anonymity)),
+	}
+}
+
+// knownProtocol 判断协议名是否为支持的代理协议，用于排除 yes/no 这类非协议的列内容
+func knownProtocol(scheme string) bool {
+	switch scheme {
+	case "http", "https", "socks4", "socks4a", "socks5":
+		return true
+	}
+	return false
+}
+
+// normalizeAnonymity 将源站的匿名度描述映射为检查器使用的名称，无法识别时返回空
+func normalizeAnonymity(text string) string {
+	text = strings.ToLower(text)
+	switch {
+	case strings.Contains(text, "elite"), strings.Contains(text, "高匿"):
+		return "Elite"
+	case strings.Contains(text, "transparent"), strings.Contains(text, "透明"):
+		return "Transparent"
+	case strings.Contains(text, "anonymous"), strings.Contains(text, "匿"):
+		return "Anonymous"
+	}
+	return ""
+}
@@ -0,0 +1,599 @@
+package lang
+
+// enUS 英语译文，按界面模块分组
+var enUS = map[string]string{
+	// 通用
+	"代理池工具": "Proxy Pool Tool",
+	"保存":    "Save",
+	"取消":    "Cancel",
+	"关闭":    "Close",
+	"删除":    "Delete",
+	"添加":    "Add",
+	"设置":    "Set",
+	"确认":    "Confirm",
+	"恢复默认":  "Restore defaults",
+	"不限":    "Any",
+	"全部":    "All",
+	"无":     "None",
+	"是":     "Yes",
+	"高级":    "Premium",
+	"可选":    "Optional",
+	"名称:":   "Name:",
+	"协议:":   "Protocol:",
+	"国家:":   "Country:",
+	"标签:":   "Tags:",
+	"代理池:":  "Pool:",
+	"地址:":   "URL:",
+	"端口:":   "Port:",
+	"启动":    "Start",
+	"停止":    "Stop",
+	"生成":    "Generate",
+	"复制":    "Copy",
+
+	// 工具栏
+	"输入IP地址":             "Enter IP address",
+	"浅色":                 "Light",
+	"深色":                 "Dark",
+	"跟随系统":               "System",
+	"获取代理":               "Fetch proxies",
+	"代理源":                "Sources",
+	"黑名单":                "Blacklist",
+	"代理池":                "Pools",
+	"测试代理":               "Test proxies",
+	"测试未测试":              "Test untested",
+	"重测失败":               "Retest failed",
+	"导入代理":               "Import",
+	"从剪贴板导入":             "Import from clipboard",
+	"订阅导入":               "Import subscription",
+	"订阅地址":               "Subscription URL",
+	"导入":                 "Import",
+	"导出代理":               "Export",
+	"查询IP":               "Look up IP",
+	"正在查询IP: %s":         "Looking up IP: %s",
+	"查询IP失败: %v":         "IP lookup failed: %v",
+	"IP %s 位置: %s %s %s": "IP %s location: %s %s %s",
+	"已更新代理 %s 的位置为 %s %s %s": "Updated location of proxy %s to %s %s %s",
+	"清空列表":          "Clear list",
+	"确定要清空所有代理列表吗?": "Clear all proxy lists?",
+
+	// 主界面
+	"进度":             "Progress",
+	"当前代理信息将在此显示...": "Current proxy details will be shown here...",
+	"未检测":            "Not checked",
+	"否(存在DNS泄漏)":     "No (DNS leak)",
+	"当前代理: %s\n协议: %s\n出口IP: %s\n国家: %s\n省份: %s\n城市: %s\n延迟: %.0fms\n速度: %.2fKB/s\n匿名度: %s\n远程DNS: %s": "Current proxy: %s\nProtocol: %s\nExit IP: %s\nCountry: %s\nProvince: %s\nCity: %s\nLatency: %.0fms\nSpeed: %.2fKB/s\nAnonymity: %s\nRemote DNS: %s",
+	"\nHTTPS: 支持":                   "\nHTTPS: supported",
+	"\nHTTPS: 不支持或未检测":              "\nHTTPS: unsupported or not checked",
+	"\n标签: ":                        "\nTags: ",
+	"\n检测目标: ":                      "\nCheck targets: ",
+	"\n高级代理: 是":                     "\nPremium: yes",
+	"\n稳定性: %.0f (抖动 %.0fms)":       "\nStability: %.0f (jitter %.0fms)",
+	"\n不稳定: %d 次检测重试后才成功":           "\nFlaky: succeeded only after %d check retries",
+	"\n代理源报告: 在线率 %.0f%%，延迟 %.0fms": "\nSource reported: uptime %.0f%%, latency %.0fms",
+	"\n认证用户: ":                      "\nAuth user: ",
+	"\n候选协议: ":                      "\nCandidate protocols: ",
+	"\n最近失败: ":                      "\nLast failure: ",
+	"当前代理详情":                        "Current proxy",
+	"应用日志":                          "App log",
+	"连接日志":                          "Connection log",
+	"实时日志":                          "Live log",
+	"搜索日志":                          "Search log",
+	"保存日志":                          "Save log",
+	"检测历史: %d 次，成功率 %.0f%%":         "Check history: %d checks, %.0f%% success",
+	"\n延迟(ms): 最小 %.0f / 平均 %.0f / 最大 %.0f":   "\nLatency (ms): min %.0f / avg %.0f / max %.0f",
+	"\n速度(KB/s): 最小 %.2f / 平均 %.2f / 最大 %.2f": "\nSpeed (KB/s): min %.2f / avg %.2f / max %.2f",
+	"\n延迟走势: ": "\nLatency trend: ",
+	"代理池统计":    "Pool statistics",
+	"原始代理: %d    有效代理: %d    近期检测: %d": "Raw: %d    Valid: %d    Recently checked: %d",
+	"    搁置待复检: %d":                    "    Sidelined (awaiting recheck): %d",
+	"\n平均延迟: ":                         "\nAvg latency: ",
+	"    平均速度: ":                       "    Avg speed: ",
+	"\n协议: ":                           "\nProtocols: ",
+	"\n国家(前5): ":                       "\nCountries (top 5): ",
+	"流量统计":                             "Traffic",
+	"上传: %s    下载: %s    连接: %d    失败连接: %d": "Up: %s    Down: %s    Connections: %d    Failed: %d",
+	"\n流量最大: ":     "\nTop traffic: ",
+	"%s 失败%d/成功%d": "%s failed %d/ok %d",
+	"\n失败最多: ":     "\nMost failures: ",
+
+	// 设置面板
+	"例如: 500 (ms)":      "e.g. 500 (ms)",
+	"例如: 1024 (KB/s)":   "e.g. 1024 (KB/s)",
+	"仅远程DNS解析 (无DNS泄漏)": "Remote DNS only (no DNS leak)",
+	"仅支持HTTPS的代理":       "HTTPS-capable proxies only",
+	"仅IPv4":             "IPv4 only",
+	"仅IPv6":             "IPv6 only",
+	"应用筛选":              "Apply filter",
+	"最大延迟 (ms):":        "Max latency (ms):",
+	"最低速度 (KB/s):":      "Min speed (KB/s):",
+	"IP版本:":             "IP version:",
+	"筛选器":               "Filter",
+	"检测设置":              "Check settings",
+	"自动刷新":              "Auto refresh",
+	"数据存储":              "Storage",
+	"界面":                "Interface",
+	"语言:":               "Language:",
+	"自动保存代理池并在启动时恢复": "Save the pool automatically and restore it on startup",
+	"自动保存:":        "Auto save:",
+	"启用定时获取代理":     "Fetch proxies periodically",
+	"获取后自动测试新增代理":  "Test new proxies after fetching",
+	"例如: 30 (分钟)":  "e.g. 30 (minutes)",
+	"设置间隔":         "Set interval",
+	"定期复检有效代理":     "Revalidate valid proxies periodically",
+	"应用复检设置":       "Apply revalidation",
+	"自动刷新:":        "Auto refresh:",
+	"间隔(分钟):":      "Interval (min):",
+	"定期复检:":        "Revalidation:",
+	"复检间隔(分钟):":    "Revalidate every (min):",
+	"复检并发:":        "Revalidation workers:",
+	"重试次数，0 不重试":   "Retries, 0 = none",
+	"首次间隔(秒)，之后翻倍": "First delay (s), doubled afterwards",
+	"1 表示只采样一次":    "1 = single sample",
+	"导入时快速预检 (丢弃端口不可达的代理)": "Precheck on import (drop proxies with unreachable ports)",
+	"取消预检":       "Cancel precheck",
+	"导入的代理标记为高级": "Mark imported proxies as premium",
+	"自动识别未写明协议的代理 (socks5/socks4/http/https)": "Detect the protocol when not given (socks5/socks4/http/https)",
+	"并发与超时:":       "Concurrency & timeouts:",
+	"测试设置...":      "Test settings...",
+	"失败重试:":        "Retries on failure:",
+	"延迟采样次数:":      "Latency samples:",
+	"检测服务:":        "Check service:",
+	"设置检测地址...":    "Set check URLs...",
+	"导入预检:":        "Import precheck:",
+	"导入标记:":        "Import marking:",
+	"协议识别:":        "Protocol detection:",
+	"离线GeoIP:":     "Offline GeoIP:",
+	"选择 .mmdb 数据库": "Choose .mmdb database",
+	"使用在线接口":       "Use online API",
+	"离线ASN:":       "Offline ASN:",
+	"不查询ASN":       "No ASN lookup",
+
+	// 服务控制
+	"例如: 127.0.0.1、0.0.0.0 或网卡IP": "e.g. 127.0.0.1, 0.0.0.0 or an interface IP",
+	"⚠ 无效的监听地址":                   "⚠ Invalid listen address",
+	"⚠ 非本机地址，局域网内其他设备可以连接此服务":     "⚠ Not a loopback address, other devices on the LAN can connect",
+	"例如: 10808":     "e.g. 10808",
+	"服务未运行":         "Server stopped",
+	"服务运行于 %s://%s": "Server running at %s://%s",
+	"启动服务":          "Start server",
+	"停止服务":          "Stop server",
+	"测试本地服务":        "Test local server",
+	"验证出口IP":        "Verify exit IP",
+	"正在通过本地服务验证...": "Verifying through the local server...",
+	"验证失败: %v":      "Verification failed: %v",
+	"链路正常":          "Chain OK",
+	"重新验证":          "Verify again",
+	"状态:":           "Status:",
+	"出口IP:":         "Exit IP:",
+	"位置:":           "Location:",
+	"延迟:":           "Latency:",
+	"上游代理:":         "Upstream proxy:",
+	"未知":            "Unknown",
+	"启用系统代理":        "Use as system proxy",
+	"双代理链式转发 (延迟约翻倍)":  "Chain two proxies (roughly doubles latency)",
+	"固定当前代理 (跟随轮换)":    "Pin current proxy (follow rotation)",
+	"检测目标名称，留空不限":      "Check target name, empty = any",
+	"代理池名称，留空使用全部":     "Pool name, empty = all proxies",
+	"0 表示关闭":           "0 = off",
+	"单连接，0 不限":         "Per connection, 0 = unlimited",
+	"全局，0 不限":          "Global, 0 = unlimited",
+	"上限，0 不限":          "Limit, 0 = unlimited",
+	"排队数，0 不排队":        "Queue, 0 = no queue",
+	"并发上限，0 不限":        "Max conns, 0 = unlimited",
+	"冷却秒数，0 不限":        "Cooldown seconds, 0 = none",
+	"单代理限制:":           "Per-proxy limits:",
+	"监听地址:":            "Listen address:",
+	"监听协议:":            "Listen protocol:",
+	"本地端口:":            "Local port:",
+	"当前状态:":            "Status:",
+	"当前连接:":            "Connections:",
+	"转发模式:":            "Forwarding:",
+	"上游范围:":            "Upstreams:",
+	"目标可用:":            "Target OK:",
+	"会话保持(分钟):":        "Sticky session (min):",
+	"主机亲和(分钟):":        "Host affinity (min):",
+	"系统代理:":            "System proxy:",
+	"并发连接:":            "Max connections:",
+	"限速(KB/s):":        "Rate limit (KB/s):",
+	"服务控制":             "Server",
+	"启动本地代理服务以使用轮换IP":  "Start the local proxy server to use rotating IPs",
+	"活动 %d (不限)":       "Active %d (unlimited)",
+	"活动 %d / %d，排队 %d": "Active %d / %d, queued %d",
+	"只使用高级代理":          "Premium proxies only",
+
+	// 访问控制
+	"例如: 10.0.0.0/8, example.com":   "e.g. 10.0.0.0/8, example.com",
+	"留空表示允许所有目标":                    "Empty = allow all targets",
+	"例如: 127.0.0.1, 192.168.1.0/24": "e.g. 127.0.0.1, 192.168.1.0/24",
+	"例如: 192.168.1.100，优先于允许列表":     "e.g. 192.168.1.100, takes precedence over the allow list",
+	"应用规则":      "Apply rules",
+	"留空表示不需要认证": "Empty = no authentication",
+	"应用认证":      "Apply authentication",
+	"目标黑名单:":    "Blocked targets:",
+	"目标白名单:":    "Allowed targets:",
+	"允许的客户端:":   "Allowed clients:",
+	"拒绝的客户端:":   "Denied clients:",
+	"认证用户名:":    "Auth username:",
+	"认证密码:":     "Auth password:",
+	"访问控制":      "Access control",
+
+	// 代理列表
+	"协议":               "Protocol",
+	"代理地址":             "Address",
+	"延迟(ms)":           "Latency(ms)",
+	"速度(KB/s)":         "Speed(KB/s)",
+	"稳定性":              "Stability",
+	"评分":               "Score",
+	"匿名度":              "Anonymity",
+	"流量":               "Traffic",
+	"地区":               "Region",
+	"检测时间":             "Checked",
+	"出口IP":             "Exit IP",
+	"国家":               "Country",
+	"测试选中":             "Test selected",
+	"清除选中":             "Clear selection",
+	"测试选中 (%d)":        "Test selected (%d)",
+	"固定":               "Pin",
+	"取消固定":             "Unpin",
+	"标记为高级":            "Mark as premium",
+	"条件请求(ETag)":       "Conditional (ETag)",
+	"取消高级":             "Unmark premium",
+	"编辑标签...":          "Edit tags...",
+	"复制地址":             "Copy address",
+	"已复制代理地址 %s":       "Copied proxy address %s",
+	"复制为…":             "Copy as…",
+	"环境变量":             "Environment variables",
+	"复制失败: %v":         "Copy failed: %v",
+	"已将代理 %s 复制为 %s":   "Copied proxy %s as %s",
+	"立即重测":             "Retest now",
+	"加入黑名单":            "Add to blacklist",
+	"远程":               "Remote",
+	"泄漏":               "Leak",
+	"显示出口IP列":          "Show exit IP column",
+	"有效代理列表":           "Valid proxies",
+	"上一页":              "Previous",
+	"下一页":              "Next",
+	"第 %d/%d 页，共 %d 个": "Page %d/%d, %d total",
+	"搜索地址、协议、国家或匿名度": "Search address, protocol, country or anonymity",
+
+	// 代理轮换
+	"例如: 60 (秒)":     "e.g. 60 (seconds)",
+	"点击生成获取终端代理设置命令": "Click Generate to get shell proxy commands",
+	"生成快速连接命令失败: %v": "Failed to generate quick connect commands: %v",
+	"快速连接命令已复制到剪贴板":  "Quick connect commands copied to clipboard",
+	"轮换设置:":          "Rotation:",
+	"当前代理:":          "Current proxy:",
+	"  出口IP: %s":     "  Exit IP: %s",
+	"下一个代理":          "Next proxy",
+	"轮换间隔(秒):":       "Interval (s):",
+	"快速连接:":          "Quick connect:",
+	"代理轮换":           "Proxy rotation",
+	"控制代理自动轮换行为":     "Control automatic proxy rotation",
+	"启用代理轮换":         "Enable rotation",
+	"停止代理轮换":         "Stop rotation",
+
+	// 选择策略
+	"加权随机":          "Weighted random",
+	"轮询":            "Round robin",
+	"最快优先":          "Fastest first",
+	"分散(网段/国家/ASN)": "Diverse (subnet/country/ASN)",
+
+	// 黑名单
+	"例如: 1.2.3.4、1.2.3.0/24 或 1.2.3.4:8080": "e.g. 1.2.3.4, 1.2.3.0/24 or 1.2.3.4:8080",
+	"添加条目": "Add entry",
+	"命中的代理会立即从代理池删除": "Matching proxies are removed from the pool immediately",
+	"黑名单管理": "Blacklist",
+
+	// 检测服务设置
+	"可选，响应中必须包含的内容":                    "Optional, text the response must contain",
+	"可选，响应JSON中必须存在的字段，如 headers.Host": "Optional, field required in the JSON response, e.g. headers.Host",
+	"留空表示不判断匿名度":                       "Empty = skip anonymity check",
+	"留空表示不检测HTTPS":                     "Empty = skip HTTPS check",
+	"留空表示不测速":                          "Empty = skip speed test",
+	"0 表示下载完整文件":                       "0 = download the whole file",
+	"每行一个: 名称 地址 [期望状态码]\n例如: google https://www.google.com 200": "One per line: name URL [expected status]\ne.g. google https://www.google.com 200",
+	"连通性检测地址:":   "Connectivity URL:",
+	"期望状态码:":     "Expected status:",
+	"期望响应内容:":    "Expected content:",
+	"期望JSON字段:":  "Expected JSON field:",
+	"匿名度判断地址:":   "Anonymity judge URL:",
+	"HTTPS检测地址:": "HTTPS check URL:",
+	"测速地址:":      "Speed test URL:",
+	"测速大小(KB):":  "Speed test size (KB):",
+	"检测目标:":      "Check targets:",
+	"检测服务设置":     "Check service",
+
+	// 测试设置
+	"并发数:":     "Concurrency:",
+	"连接超时(秒):": "Connect timeout (s):",
+	"检测超时(秒):": "Check timeout (s):",
+	"网络较差时降低并发、延长超时；服务器带宽充足时可提高并发。": "Lower concurrency and raise timeouts on poor networks; raise concurrency when bandwidth allows.",
+	"测试设置":       "Test settings",
+	"并发数必须为整数":   "Concurrency must be an integer",
+	"测速大小必须为整数":  "Speed test size must be an integer",
+	"超时必须为正数(秒)": "Timeouts must be positive (seconds)",
+
+	// 附加监听器
+	"可选，例如: 美国轮询":      "Optional, e.g. US round robin",
+	"例如: 10809":        "e.g. 10809",
+	"留空表示不限，多个国家以逗号分隔": "Empty = any, separate countries with commas",
+	"0 表示每个连接都轮换":      "0 = rotate on every connection",
+	"端口 '%s' 无效":       "Invalid port '%s'",
+	"会话保持时长 '%s' 无效":   "Invalid sticky session duration '%s'",
+	"选择策略:":            "Strategy:",
+	"新建监听器":            "New listener",
+	"认证、访问规则和限速与主服务共用": "Authentication, access rules and rate limits are shared with the main server",
+	"附加监听器":            "Listeners",
+	"目标:":              "Target:",
+	"池:":               "Pool:",
+	"保持%d分钟":           "sticky %d min",
+
+	// 代理池管理
+	"例如: scrape": "e.g. scrape",
+	"带有任一标签的代理，多个以逗号分隔，留空不限": "Proxies with any of these tags, comma separated, empty = any",
+	"毫秒，留空或0表示不限":            "Milliseconds, empty or 0 = unlimited",
+	"只包含支持HTTPS的代理":          "HTTPS-capable proxies only",
+	"最大延迟 '%s' 无效":           "Invalid max latency '%s'",
+	"最大延迟:":                  "Max latency:",
+	"添加代理池":                  "Add pool",
+	"本地服务和附加监听器可以绑定到代理池，只从池中选择上游代理": "The local server and listeners can be bound to a pool to pick upstreams only from it",
+	"代理池管理":                         "Pools",
+	"例如: scrape, stream, cn-direct": "e.g. scrape, stream, cn-direct",
+	"标签":                            "Tags",
+	"编辑标签 - ":                       "Edit tags - ",
+
+	// 代理源
+	" (高级)":     " (premium)",
+	" (最多%d页)":  " (up to %d pages)",
+	"API/纯文本响应": "API/plain text response",
+	"可选，例如: (\\d+\\.\\d+\\.\\d+\\.\\d+:\\d+)": "Optional, e.g. (\\d+\\.\\d+\\.\\d+\\.\\d+:\\d+)",
+	"可选，例如: data.list":                        "Optional, e.g. data.list",
+	"添加代理源":                                   "Add source",
+	"提取正则:":                                   "Extract regex:",
+	"JSON路径:":                                 "JSON path:",
+	"代理源管理":                                   "Proxy sources",
+	"本次运行尚未获取":                                "Not fetched yet",
+	"  重试 %d 次":                               "  %d retries",
+	"  列表未变化":                                 "  list unchanged",
+	"  %d 页":                                  "  %d pages",
+	"分页地址:":                                   "Page URL:",
+	"最大页数:":                                   "Max pages:",
+	"表格行:":                                    "Table rows:",
+	"表格列:":                                    "Table columns:",
+	"付费服务商:":                                  "Paid provider:",
+	"付费服务商的API密钥":                             "API key of the paid provider",
+	"区域":                                      "Zone",
+	"客户ID":                                    "Customer ID",
+	"区域密码":                                    "Zone password",
+	" (表格)":                                   " (table)",
+	"可选，CSS选择器，例如: table.table-striped tbody tr":               "Optional CSS selector, e.g. table.table-striped tbody tr",
+	"IP,端口,协议,国家,匿名度 所在列(从1开始，0 表示没有)，例如: 1,2,0,3,5":           "Columns of IP,port,protocol,country,anonymity (1-based, 0 = none), e.g. 1,2,0,3,5",
+	"可选，{page} 替换为页码，例如: https://example.com/list/{page}.html": "Optional, {page} is replaced by the page number, e.g. https://example.com/list/{page}.html",
+	"含第1页，0 或 1 表示不翻页":                                         "Including page 1, 0 or 1 = no paging",
+	"最大页数必须为整数":                                                "Max pages must be an integer",
+	"  获取 %d  新增 %d":                                           "  fetched %d  new %d",
+	"  有效 %d/%d (%.0f%%)":                                      "  valid %d/%d (%.0f%%)",
+
+	// 连接日志
+	" 经 ": " via ",
+
+	// 托盘
+	"隐藏窗口":            "Hide window",
+	"显示窗口":            "Show window",
+	"当前代理: ":          "Current proxy: ",
+	"当前代理 %s 已复制到剪贴板": "Current proxy %s copied to clipboard",
+	"关闭时最小化到托盘":       "Minimize to tray on close",
+	"退出":              "Quit",
+
+	// 主程序日志与对话框
+	"纯文本 (host:port)":         "Plain text (host:port)",
+	"V2Ray/Xray 出站 (JSON)":    "V2Ray/Xray outbounds (JSON)",
+	"分享链接 (socks://、http://)": "Share links (socks://, http://)",
+	"CSV (含检测结果)":             "CSV (with check results)",
+	"JSON (含检测结果)":            "JSON (with check results)",
+	"定期复检完成: 检测 %d 个，失败 %d 个，恢复 %d 个，当前有效 %d 个，搁置 %d 个。": "Periodic recheck done: %d tested, %d failed, %d recovered, %d valid, %d sidelined.",
+	"保存设置失败: %v":                     "Failed to save settings: %v",
+	"界面语言已切换为 %s":                    "Interface language switched to %s",
+	"保存日志失败: %v":                     "Failed to save log: %v",
+	"已保存 %d 条日志到 %s":                 "Saved %d log entries to %s",
+	"开始从所有源获取在线代理...":                "Fetching proxies from all sources...",
+	"获取代理时发生错误: %v":                  "Error while fetching proxies: %v",
+	"获取已取消，保留已获取到的 %d 个代理(新增 %d 个)。": "Fetch cancelled, kept %d proxies fetched so far (%d new).",
+	"未能获取到任何代理。":                     "No proxies were fetched.",
+	"获取完成，发现 %d 个代理地址。请点击“全部测试”来验证它们。": "Fetch complete, found %d proxy addresses. Click \"Test all\" to verify them.",
+	"获取原始代理失败: %v":                      "Failed to get raw proxies: %v",
+	"没有可测试的代理，请先获取代理。":                  "No proxies to test, fetch proxies first.",
+	"没有未测试的代理。":                         "No untested proxies.",
+	"没有从未检测过的代理。":                       "No never-checked proxies.",
+	"没有选中的代理，请先在列表中点击要测试的行。":            "No proxy selected, click the rows to test in the list first.",
+	"没有测试失败的代理。":                        "No failed proxies.",
+	"%d 个代理的来源给出了不同协议，正在识别...":          "Sources disagree on the protocol of %d proxies, detecting...",
+	"协议识别完成: 识别 %d 个，%d 个未能识别(按原协议检测)。": "Protocol detection done: %d detected, %d undetected (tested with the original protocol).",
+	"开始并发测试 %d 个代理...":                  "Testing %d proxies concurrently...",
+	"清空有效代理失败: %v":                      "Failed to clear valid proxies: %v",
+	"添加有效代理失败: %v":                      "Failed to add valid proxies: %v",
+	"测试已取消，已完成 %d/%d 个，结果已保留。":          "Test cancelled after %d/%d proxies, results kept.",
+	"测试结果: ": "Test results: ",
+	"其中 %d 个代理首次检测偶发失败，重试后成功。": "%d proxies failed intermittently on the first check and passed on retry.",
+	"全部测试流程完成。":                "All tests complete.",
+	"获取有效代理失败: %v":             "Failed to get valid proxies: %v",
+	"基础测试完成。开始批量查询地理位置...":     "Basic tests complete. Looking up locations...",
+	"地理位置查询已取消。":               "Location lookup cancelled.",
+	"批量查询地理位置失败: %v":           "Location lookup failed: %v",
+	"地理位置查询完成，列表已更新。":          "Location lookup complete, list updated.",
+	"成功 %d":                              "succeeded %d",
+	"应用筛选条件并刷新列表...":                     "Applying filters and refreshing the list...",
+	"获取筛选代理失败: %v":                       "Failed to get filtered proxies: %v",
+	"自动保存代理池失败: %v":                      "Failed to auto-save the proxy pool: %v",
+	"加载已保存的原始代理失败: %v":                   "Failed to load saved raw proxies: %v",
+	"加载已保存的有效代理失败: %v":                   "Failed to load saved valid proxies: %v",
+	"加载检测历史失败: %v":                       "Failed to load check history: %v",
+	"已恢复上次保存的代理池: 原始 %d 个，有效 %d 个。":      "Restored the saved proxy pool: %d raw, %d valid.",
+	"已开启代理池自动保存。":                        "Proxy pool auto-save enabled.",
+	"已关闭代理池自动保存。":                        "Proxy pool auto-save disabled.",
+	"读取文件 %s 失败: %v":                     "Failed to read file %s: %v",
+	"忽略不支持的文件: %s，仅支持 %s":                "Ignored unsupported file %s, only %s are supported",
+	"打开文件 %s 失败: %v":                     "Failed to open file %s: %v",
+	"剪贴板为空，没有可导入的代理。":                    "Clipboard is empty, nothing to import.",
+	"无效的订阅地址: %q":                        "Invalid subscription URL: %q",
+	"正在下载订阅内容...":                        "Downloading subscription...",
+	"下载订阅失败: %v":                         "Failed to download subscription: %v",
+	"跳过 %s 节点 %s %s":                     "Skipped %s node %s %s",
+	"%s %d 个":                            "%s %d",
+	"订阅中有 %d 个节点需要专用客户端，未导入(%s)。":        "%d subscription nodes need a dedicated client and were not imported (%s).",
+	"订阅中没有可导入的 socks/http 代理。":           "The subscription has no socks/http proxies to import.",
+	"服务器返回 %s":                           "Server returned %s",
+	"正在对 %d 个代理进行端口预检...":                "Pre-checking ports of %d proxies...",
+	"导入预检已取消，未导入任何代理。":                   "Import pre-check cancelled, nothing imported.",
+	"协议识别已取消，未导入任何代理。":                   "Protocol detection cancelled, nothing imported.",
+	"正在识别 %d 个代理的协议...":                  "Detecting the protocol of %d proxies...",
+	"协议识别完成: 识别 %d 个，%d 个未能识别(按http处理)。": "Protocol detection done: %d detected, %d undetected (treated as http).",
+	"另有 %d 行无法解析，未逐条列出。":                 "%d more lines could not be parsed and are not listed.",
+	"无法解析":                               "Cannot parse ",
+	"成功导入 %d 个代理，跳过 %d 行无效内容，%d 个重复":     "Imported %d proxies, skipped %d invalid lines, %d duplicates",
+	"，%d 个端口不可达已丢弃":                      ", %d dropped with unreachable ports",
+	"。请点击“全部测试”来验证它们。":                   ". Click \"Test all\" to verify them.",
+	"获取代理失败: %v":                         "Failed to get proxies: %v",
+	"无代理可导出":                             "Nothing to export",
+	"当前列表没有可导出的有效代理。":                    "The current list has no valid proxies to export.",
+	"下一步":                      "Next",
+	"导出代理失败: %v":               "Failed to export proxies: %v",
+	"写入导出文件失败: %v":             "Failed to write the export file: %v",
+	"成功导出 %d 个有效代理到 %s":        "Exported %d valid proxies to %s",
+	"，%d 个代理的协议不受该格式支持已跳过":     ", skipped %d proxies whose protocol the format does not support",
+	"已固定代理 %s":                 "Pinned proxy %s",
+	"已取消固定代理 %s":               "Unpinned proxy %s",
+	"保存固定代理失败: %v":             "Failed to save pinned proxies: %v",
+	"已将代理 %s 标记为高级":            "Marked proxy %s as premium",
+	"已取消代理 %s 的高级标记":           "Removed the premium mark from proxy %s",
+	"已删除代理 %s":                 "Deleted proxy %s",
+	"已将 %s 加入黑名单，删除 %d 个代理":    "Blacklisted %s and deleted %d proxies",
+	"已将 %s 移出黑名单":              "Removed %s from the blacklist",
+	"保存黑名单失败: %v":              "Failed to save the blacklist: %v",
+	"已清除代理 %s 的标签":             "Cleared the tags of proxy %s",
+	"代理 %s 的标签已设为: %s":         "Tags of proxy %s set to: %s",
+	"已保存代理池 %s，当前包含 %d 个可用代理。": "Saved pool %s, currently containing %d usable proxies.",
+	"已删除代理池 %s":                "Deleted pool %s",
+	"警告: 本地服务绑定的代理池 %s 已被删除，请重新选择代理池。": "Warning: pool %s used by the local service was deleted, please choose another pool.",
+	"保存代理池失败: %v":                      "Failed to save pools: %v",
+	"加载代理池失败: %v":                      "Failed to load pools: %v",
+	"忽略无效的代理池 %s: %v":                  "Ignored invalid pool %s: %v",
+	"正在重新检测代理 %s ...":                  "Rechecking proxy %s ...",
+	"代理 %s 检测失败: %v":                   "Proxy %s check failed: %v",
+	"代理 %s 检测成功，延迟 %.0fms，速度 %.2fKB/s": "Proxy %s check succeeded, latency %.0fms, speed %.2fKB/s",
+	"加载黑名单失败: %v":                      "Failed to load the blacklist: %v",
+	"忽略无效的黑名单条目: %v":                   "Ignored invalid blacklist entry: %v",
+	"加载固定代理失败: %v":                     "Failed to load pinned proxies: %v",
+	"恢复固定代理失败: %v":                     "Failed to restore pinned proxies: %v",
+	"已恢复 %d 个固定代理。":                    "Restored %d pinned proxies.",
+	"加载代理源配置失败，使用内置代理源: %v":            "Failed to load source config, using built-in sources: %v",
+	"已从 %s 加载 %d 个代理源。":                "Loaded sources from %s: %d in total.",
+	"加载地理位置数据库失败: %v":                  "Failed to load the location database: %v",
+	"已关闭离线地理位置数据库，改用在线接口查询。":           "Offline location database disabled, using the online API.",
+	"已加载离线地理位置数据库: %s":                 "Loaded offline location database: %s",
+	"加载ASN数据库失败: %v":                   "Failed to load the ASN database: %v",
+	"已关闭离线ASN数据库，分散选择模式只按网段和国家避让。":     "Offline ASN database disabled, diverse selection only avoids repeating subnets and countries.",
+	"已加载离线ASN数据库: %s，下次查询地理位置时补充ASN。":  "Loaded offline ASN database %s, ASNs will be filled in on the next location lookup.",
+	"代理源已更新，共 %d 个。":                   "Sources updated, %d in total.",
+	"保存代理源配置失败: %v":                    "Failed to save source config: %v",
+	"代理源已更新，共 %d 个，已保存到 %s。":           "Sources updated, %d in total, saved to %s.",
+	"代理源 %s 不存在":                       "Source %s does not exist",
+	"所有代理列表已清空。":                       "All proxy lists cleared.",
+	"停止服务失败: %v":                       "Failed to stop the service: %v",
+	"启动服务失败: %v":                       "Failed to start the service: %v",
+	"服务已在运行":                           "The service is already running",
+	"没有可用的有效代理来启动服务":                   "No valid proxies available to start the service",
+	"应用访问规则失败: %v":                     "Failed to apply access rules: %v",
+	"服务未在运行":                           "The service is not running",
+	"关闭系统代理失败: %v":                     "Failed to disable the system proxy: %v",
+	"系统代理已关闭。":                         "System proxy disabled.",
+	"本地服务未运行，请先启动服务再启用系统代理。":           "The local service is not running, start it before enabling the system proxy.",
+	"设置系统代理失败: %v":                     "Failed to set the system proxy: %v",
+	"系统代理已指向 %s://%s。":                 "System proxy set to %s://%s.",
+	"提示: 本地服务已启用认证，不支持代理认证的程序将无法通过系统代理访问网络。": "Note: the local service requires authentication, programs without proxy authentication support cannot go through the system proxy.",
+	"启动服务失败: 端口 %d 已被占用。":                    "Failed to start the service: port %d is in use.",
+	"端口被占用": "Port in use",
+	"端口 %d 已被其他程序占用，请更换端口后重试。":                     "Port %d is used by another program, change the port and try again.",
+	"端口 %d 已被其他程序占用。\n是否改用可用端口 %d 启动服务?":           "Port %d is used by another program.\nStart the service on free port %d instead?",
+	"警告: 服务监听于 %s 且未设置认证或允许的客户端，网络中的任何设备都可以使用此代理。": "Warning: the service listens on %s without authentication or allowed clients, any device on the network can use this proxy.",
+	"提示: 服务监听于 %s，局域网内其他设备可以连接此服务。":                "Note: the service listens on %s, other LAN devices can connect to it.",
+	"没有可用的有效代理":                          "No valid proxies available",
+	"监听协议将在重新启动服务后生效。":                   "The listen protocol takes effect after the service restarts.",
+	"本地服务将只使用标记为高级的代理。":                  "The local service will only use premium proxies.",
+	"本地服务将使用全部有效代理。":                     "The local service will use all valid proxies.",
+	"代理池 %s 不存在，请先在代理池管理中添加。":            "Pool %s does not exist, add it in pool management first.",
+	"本地服务和代理轮换将使用全部有效代理。":                "The local service and rotation will use all valid proxies.",
+	"本地服务和代理轮换将只使用代理池 %s 中的代理(当前 %d 个)。": "The local service and rotation will only use proxies in pool %s (currently %d).",
+	"检测目标 %s 不存在，请先在检测服务设置中添加。":          "Check target %s does not exist, add it in check service settings first.",
+	"本地服务的上游代理不再限制检测目标。":                 "Upstream proxies of the local service are no longer restricted by check target.",
+	"本地服务将只使用检测目标 %s 可用的代理。":             "The local service will only use proxies that pass check target %s.",
+	"会话保持已关闭，每个连接都将轮换代理。":                "Sticky sessions disabled, every connection rotates the proxy.",
+	"会话保持已开启，同一客户端 %d 分钟内复用同一代理。":        "Sticky sessions enabled, a client reuses the same proxy for %d minutes.",
+	"目标主机亲和已关闭。":                         "Target host affinity disabled.",
+	"目标主机亲和已开启，同一目标主机 %d 分钟内复用同一代理。":     "Target host affinity enabled, a target host reuses the same proxy for %d minutes.",
+	"转发限速: 单连接 %s，全局 %s。":                "Rate limit: %s per connection, %s global.",
+	"并发连接数已不限制。":                         "Concurrent connections unlimited.",
+	"并发连接上限: %d，最多排队 %d 个。":              "Concurrent connection limit: %d, up to %d queued.",
+	"单代理使用已不限制。":                         "Per-proxy usage unlimited.",
+	"单代理并发上限: %d，冷却时间: %d 秒 (0 表示不限制)。":  "Per-proxy connection limit: %d, cooldown: %d seconds (0 means unlimited).",
+	"不限制": "Unlimited",
+	"已开启双代理链式转发，连接延迟将约为单跳的两倍。":      "Two-hop proxy chaining enabled, connection latency will roughly double.",
+	"已关闭双代理链式转发。":                   "Two-hop proxy chaining disabled.",
+	"已开启固定当前代理，本地服务的新连接将使用轮换选出的代理。": "Pin current proxy enabled, new local service connections use the rotated proxy.",
+	"已关闭固定当前代理，本地服务将为每个连接选择代理。":     "Pin current proxy disabled, the local service picks a proxy for each connection.",
+	"已轮换到新代理: %s":                   "Rotated to new proxy: %s",
+	"没有可切换的有效代理":                    "No valid proxy to switch to",
+	"代理 %s 检测失败，尝试下一个: %v":          "Proxy %s check failed, trying the next one: %v",
+	"已切换到代理 %s，出口IP: %s":            "Switched to proxy %s, exit IP: %s",
+	"未开启固定当前代理，本地服务仍为每个连接选择代理。":     "Pin current proxy is off, the local service still picks a proxy for each connection.",
+	"连续 %d 个代理检测失败，未切换代理":           "%d proxies in a row failed the check, proxy not switched",
+	"访问规则无效: %v":                    "Invalid access rules: %v",
+	"访问规则已更新。":                      "Access rules updated.",
+	"更新附加监听器设置失败: %v":               "Failed to update extra listener settings: %v",
+	"附加监听器已在 %s 启动，选择策略: %s。":       "Extra listener started on %s, selection strategy: %s.",
+	"警告: 附加监听器 %s 未设置认证或允许的客户端，网络中的任何设备都可以使用此代理。": "Warning: extra listener %s has no authentication or allowed clients, any device on the network can use this proxy.",
+	"附加监听器 %s 已停止。":                "Extra listener %s stopped.",
+	"客户端访问控制已更新: 允许 %d 条，拒绝 %d 条。": "Client access control updated: %d allow rules, %d deny rules.",
+	"设置认证失败: 用户名不能为空。":             "Failed to set authentication: username cannot be empty.",
+	"设置认证失败: 用户名和密码不能超过255字节。":     "Failed to set authentication: username and password cannot exceed 255 bytes.",
+	"本地服务认证已关闭。":                   "Local service authentication disabled.",
+	"本地服务认证已启用，用户名: %s":            "Local service authentication enabled, username: %s",
+	"本地服务未运行，请先启动服务。":              "The local service is not running, start it first.",
+	"正在通过本地服务测试连通性...":             "Testing connectivity through the local service...",
+	"本地服务测试失败: %v":                 "Local service test failed: %v",
+	"本地服务测试成功，出口IP: %s，上游代理: %s":   "Local service test succeeded, exit IP: %s, upstream proxy: %s",
+	"本地服务未运行，请先启动服务":               "The local service is not running, start it first",
+	"验证出口IP失败: %v":                 "Exit IP verification failed: %v",
+	"出口IP验证成功: %s，延迟 %dms":         "Exit IP verified: %s, latency %dms",
+	"正在初始化，获取本机公网IP...":            "Initializing, getting the public IP...",
+	"获取公网IP失败: %v":                 "Failed to get the public IP: %v",
+	"公网IP初始化成功。":                   "Public IP initialized.",
+	"管理API已在 %s 启动，访问令牌: %s":       "Management API started on %s, access token: %s",
+	"匿名度判断服务已在 %s 启动，可将检测设置中的匿名度判断地址设为 http://<公网IP>:<端口>/": "Anonymity judge started on %s, set the judge URL in check settings to http://<public IP>:<port>/",
+	"通过API导入 %d 个代理，跳过 %d 行无效内容。":                           "Imported %d proxies via the API, skipped %d invalid lines.",
+	"已删除 %d 个代理。":   "Deleted %d proxies.",
+	"轮换间隔已设置为 %d 秒": "Rotation interval set to %d seconds",
+	"测试设置已更新: 并发 %d，连接超时 %v，检测超时 %v，测速 %d KB。": "Test settings updated: concurrency %d, connect timeout %v, check timeout %v, speed test %d KB.",
+	"检测失败后不再重试。": "Failed checks will not be retried.",
+	"检测偶发失败时最多重试 %d 次，首次间隔 %v，之后每次翻倍。":          "Intermittent check failures are retried up to %d times, first after %v, doubling each time.",
+	"每次检测将采样 %d 次延迟。":                           "Each check samples latency %d times.",
+	"检测服务已更新: 连通性 %s，匿名度 %s，测速 %s，检测目标 %d 个":    "Check services updated: connectivity %s, anonymity %s, speed %s, %d check targets",
+	"警告: 本地服务要求的检测目标 %s 已被删除，在重新检测前将没有可用的上游代理。": "Warning: check target %s required by the local service was deleted, no upstream proxies will be available until rechecked.",
+	"不检测":                       "Not checked",
+	"定期复检已启动。":                  "Periodic recheck started.",
+	"定期复检已停止。":                  "Periodic recheck stopped.",
+	"复检间隔和并发数必须为正数。":            "Recheck interval and concurrency must be positive.",
+	"定期复检设置为每 %d 分钟一次，并发 %d。":   "Periodic recheck set to every %d minutes, concurrency %d.",
+	"当前没有正在进行的获取或测试任务。":         "No fetch or test task is running.",
+	"正在取消获取/测试任务...":            "Cancelling the fetch/test task...",
+	"自动刷新间隔已设置为 %d 分钟":          "Auto refresh interval set to %d minutes",
+	"自动刷新已启动，间隔 %d 分钟":          "Auto refresh started, every %d minutes",
+	"自动刷新已停止":                   "Auto refresh stopped",
+	"自动刷新: 已有获取或测试任务在运行，跳过本轮。":  "Auto refresh: a fetch or test task is running, skipping this round.",
+	"自动刷新: 开始获取代理...":           "Auto refresh: fetching proxies...",
+	"自动刷新: 获取代理时发生错误: %v":       "Auto refresh: error while fetching proxies: %v",
+	"自动刷新已取消，保留已获取的代理，新增 %d 个。": "Auto refresh cancelled, kept the fetched proxies, %d new.",
+	"自动刷新完成: 新增 %d 个代理，测试 %d 个，清理失效有效代理 %d 个、原始代理 %d 个，当前有效 %d 个。": "Auto refresh done: %d new proxies, %d tested, removed %d dead valid and %d raw proxies, %d valid now.",
+	"代理轮换已启动，间隔 %d 秒": "Proxy rotation started, every %d seconds",
+	"代理轮换已停止":         "Proxy rotation stopped",
+}
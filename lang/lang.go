@@ -0,0 +1,78 @@
+// Package lang 提供界面文字的多语言翻译
+// 界面代码中以简体中文原文作为键调用 T，当前语言不是简体中文时返回对应的译文，
+// 没有译文的文字原样返回，因此新增文字即使暂未翻译也不会显示为空
+package lang
+
+import "sync"
+
+// Language 界面语言代码
+type Language string
+
+const (
+	// ZhCN 简体中文(默认)，即界面原文
+	ZhCN Language = "zh-CN"
+	// EnUS 英语
+	EnUS Language = "en-US"
+)
+
+// Languages 全部支持的界面语言，按界面显示顺序排列
+var Languages = []Language{ZhCN, EnUS}
+
+// translations 各语言的译文表，键为简体中文原文
+var translations = map[Language]map[string]string{
+	EnUS: enUS,
+}
+
+var (
+	mutex   sync.RWMutex
+	current = ZhCN
+)
+
+// Label 返回语言的显示名称，始终使用该语言本身书写，便于不懂当前界面语言的用户找到自己的语言
+func (l Language) Label() string {
+	switch l {
+	case EnUS:
+		return "English"
+	}
+	return "简体中文"
+}
+
+// Supported 判断是否为支持的界面语言
+func Supported(l Language) bool {
+	for _, s := range Languages {
+		if s == l {
+			return true
+		}
+	}
+	return false
+}
+
+// Set 切换当前界面语言，不支持的语言按简体中文处理
+// 切换后需要重新创建界面，已经显示的文字不会自动更新
+func Set(l Language) {
+	if !Supported(l) {
+		l = ZhCN
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	current = l
+}
+
+// Current 返回当前界面语言
+func Current() Language {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return current
+}
+
+// T 返回文字在当前语言下的译文
+// 参数 text: 简体中文原文，格式化字符串也以原文(含占位符)为键
+func T(text string) string {
+	mutex.RLock()
+	table := translations[current]
+	mutex.RUnlock()
+	if translated, ok := table[text]; ok {
+		return translated
+	}
+	return text
+}
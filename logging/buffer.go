@@ -0,0 +1,117 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level 界面日志的级别
+type Level int
+
+const (
+	// LevelDebug 调试信息，如每次轮换、单个代理的检测过程
+	LevelDebug Level = iota
+	// LevelInfo 一般操作结果(默认)
+	LevelInfo
+	// LevelError 操作失败
+	LevelError
+)
+
+// Levels 全部日志级别，按从低到高排列
+var Levels = []Level{LevelDebug, LevelInfo, LevelError}
+
+// String 返回级别名称(debug/info/error)
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelError:
+		return "error"
+	}
+	return "info"
+}
+
+// Entry 一条界面日志
+type Entry struct {
+	Time    time.Time
+	Level   Level
+	Message string
+}
+
+// String 将日志格式化为 "[15:04:05] INFO 消息"
+func (e Entry) String() string {
+	return fmt.Sprintf("[%s] %-5s %s", e.Time.Format("15:04:05"), strings.ToUpper(e.Level.String()), e.Message)
+}
+
+// Matches 判断日志是否不低于指定级别且包含搜索内容(不区分大小写)
+func (e Entry) Matches(min Level, search string) bool {
+	if e.Level < min {
+		return false
+	}
+	return search == "" || strings.Contains(strings.ToLower(e.Message), strings.ToLower(search))
+}
+
+// Buffer 固定容量的环形日志缓冲区，写满后新日志覆盖最旧的日志
+// 可以在多个协程中同时写入和读取
+type Buffer struct {
+	mutex   sync.Mutex
+	entries []Entry
+	next    int
+	full    bool
+	added   int
+}
+
+// NewBuffer 创建日志缓冲区
+// 参数 capacity: 最多保留的日志条数，不大于0时为1
+func NewBuffer(capacity int) *Buffer {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &Buffer{entries: make([]Entry, capacity)}
+}
+
+// Add 追加一条日志，返回累计写入的条数，可作为日志变化的版本号
+func (b *Buffer) Add(level Level, message string) int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.entries[b.next] = Entry{Time: time.Now(), Level: level, Message: message}
+	b.next++
+	if b.next == len(b.entries) {
+		b.next = 0
+		b.full = true
+	}
+	b.added++
+	return b.added
+}
+
+// Entries 按时间顺序返回不低于指定级别且包含搜索内容的日志
+func (b *Buffer) Entries(min Level, search string) []Entry {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	var ordered []Entry
+	if b.full {
+		ordered = append(ordered, b.entries[b.next:]...)
+	}
+	ordered = append(ordered, b.entries[:b.next]...)
+
+	result := ordered[:0]
+	for _, e := range ordered {
+		if e.Matches(min, search) {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// WriteEntries 将日志逐行写入 w，用于复制和导出
+func WriteEntries(w io.Writer, entries []Entry) error {
+	for _, e := range entries {
+		if _, err := fmt.Fprintln(w, e.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Config 日志配置
+// Level: 日志级别(debug/info/warn/error)，为空时为 info
+// JSON: 是否以JSON格式输出，便于日志系统采集
+// File: 日志文件路径，为空时只输出到标准错误
+// MaxSizeMB: 单个日志文件的大小上限(MB)，超过后轮转
+// Backups: 保留的历史日志文件数量
+type Config struct {
+	Level     string
+	JSON      bool
+	File      string
+	MaxSizeMB int
+	Backups   int
+}
+
+// NewLogger 按配置创建日志实例
+// 设置了日志文件时同时输出到标准错误和文件，返回的 io.Closer 用于退出时关闭文件(未设置文件时为nil)
+func NewLogger(cfg Config) (*logrus.Logger, io.Closer, error) {
+	logger := logrus.New()
+	level := logrus.InfoLevel
+	if cfg.Level != "" {
+		var err error
+		if level, err = logrus.ParseLevel(cfg.Level); err != nil {
+			return nil, nil, fmt.Errorf("无效的日志级别 %q", cfg.Level)
+		}
+	}
+	logger.SetLevel(level)
+	if cfg.JSON {
+		logger.SetFormatter(&logrus.JSONFormatter{})
+	}
+
+	if cfg.File == "" {
+		return logger, nil, nil
+	}
+	file, err := NewRotatingFile(cfg.File, int64(cfg.MaxSizeMB)<<20, cfg.Backups)
+	if err != nil {
+		return nil, nil, err
+	}
+	logger.SetOutput(io.MultiWriter(os.Stderr, file))
+	return logger, file, nil
+}
@@ -0,0 +1,107 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// defaultMaxSize 未指定大小上限时单个日志文件的大小上限
+const defaultMaxSize = 10 << 20
+
+// RotatingFile 按大小轮转的日志文件
+// 写入后超过大小上限时，当前文件依次改名为 .1、.2 …，超出保留数量的最旧文件被删除
+type RotatingFile struct {
+	path    string
+	maxSize int64
+	backups int
+
+	mutex sync.Mutex
+	file  *os.File
+	size  int64
+}
+
+// NewRotatingFile 打开(必要时创建)日志文件，追加写入
+// 参数 maxSize: 单个文件的大小上限(字节)，不大于0时为10MB
+// 参数 backups: 保留的历史文件数量，为0时轮转即丢弃旧内容
+func NewRotatingFile(path string, maxSize int64, backups int) (*RotatingFile, error) {
+	if maxSize <= 0 {
+		maxSize = defaultMaxSize
+	}
+	if backups < 0 {
+		backups = 0
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("创建日志目录失败: %v", err)
+	}
+	f := &RotatingFile{path: path, maxSize: maxSize, backups: backups}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// open 以追加方式打开日志文件并读取当前大小
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("打开日志文件失败: %v", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+// Write 写入一条日志，写入后超过大小上限时轮转
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	if err == nil && f.size >= f.maxSize {
+		err = f.rotate()
+	}
+	return n, err
+}
+
+// rotate 关闭当前文件，依次后移历史文件并重新打开，调用方需持有锁
+func (f *RotatingFile) rotate() error {
+	f.file.Close()
+	f.file = nil
+	if f.backups == 0 {
+		os.Remove(f.path)
+	} else {
+		os.Remove(backupName(f.path, f.backups))
+		for i := f.backups - 1; i >= 1; i-- {
+			os.Rename(backupName(f.path, i), backupName(f.path, i+1))
+		}
+		os.Rename(f.path, backupName(f.path, 1))
+	}
+	return f.open()
+}
+
+// Close 关闭日志文件
+func (f *RotatingFile) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// backupName 返回第 n 个历史文件的路径
+func backupName(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
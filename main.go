@@ -2,25 +2,46 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"go_proxy/api"
 	"go_proxy/checker"
+	"go_proxy/config"
+	"go_proxy/events"
 	"go_proxy/fetcher"
+	"go_proxy/lang"
+	"go_proxy/logging"
 	"go_proxy/proxy"
 	"go_proxy/server"
+	"go_proxy/storage"
+	"go_proxy/sysproxy"
 	"go_proxy/theme"
 	"go_proxy/ui"
+	"io"
 	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/data/binding"
 	"fyne.io/fyne/v2/dialog"
-	"fyne.io/fyne/v2/storage"
+	fynestorage "fyne.io/fyne/v2/storage"
 	"fyne.io/fyne/v2/widget"
+	"github.com/sirupsen/logrus"
 )
 
 // App 用于统一管理应用的状态和组件
@@ -28,13 +49,20 @@ type App struct {
 	fyneApp fyne.App
 	win     fyne.Window
 
-	rotator *proxy.Rotator
-	checker *checker.Checker
-	server  *server.Server
+	rotator     *proxy.Rotator
+	checker     *checker.Checker
+	revalidator *checker.Revalidator
+	server      *server.Server
+	store       storage.Storage
+	// storageBackend 当前使用的存储后端名称，保存到设置
+	storageBackend string
+	events         *events.Bus
 
-	// UI 组件的数据绑定
+	// UI 组件的数据绑定，proxyList 只包含代理列表当前页的代理
 	proxyList       binding.UntypedList
-	logBinding      binding.String
+	listRevision    binding.Int
+	logBuffer       *logging.Buffer
+	logRevision     binding.Int
 	progressBar     *widget.ProgressBar
 	serverRunning   binding.Bool
 	rotationStatus  binding.Bool
@@ -43,23 +71,261 @@ type App struct {
 	rotationStop    chan struct{}
 	rotationSeconds int
 
+	// 固定当前代理模式：本地服务的新连接都使用定时轮换最近选出的代理
+	followRotation bool
+	rotatedProxy   *proxy.Proxy
+	rotatedMutex   sync.Mutex
+
+	// 系统代理是否已指向本地服务，退出或停止服务时自动关闭
+	systemProxyStatus binding.Bool
+
+	// 自动获取并测试代理的定时任务
+	autoRefreshStatus  binding.Bool
+	autoRefreshTicker  *time.Ticker
+	autoRefreshStop    chan struct{}
+	autoRefreshMinutes int
+	autoRefreshTest    bool // 获取后是否自动测试新增代理
+
+	// 正在进行的获取/测试任务数，自动任务在有任务运行时跳过本轮
+	activeTasks int32
+
+	// 批量测试同时检测的代理数
+	testConcurrency int
+
+	// 界面主题模式(light/dark/system)
+	themeMode string
+
+	// 界面语言
+	language lang.Language
+
+	// 同时运行的获取/测试任务共用的上下文，CancelTasks 取消后全部任务停止
+	taskCtx    context.Context
+	taskCancel context.CancelFunc
+	taskMutex  sync.Mutex
+
+	// 导入时是否先对代理端口做TCP预检，以及取消正在进行的预检
+	importPrecheck bool
+	precheckCancel context.CancelFunc
+	precheckMutex  sync.Mutex
+
+	// 导入的代理是否标记为高级
+	importPremium bool
+
+	// 导入时是否自动识别未写明协议的代理
+	importDetect bool
+
+	// 最近一次订阅导入使用的订阅地址
+	subscriptionURL string
+
 	// 筛选条件
-	maxLatency float64
-	minSpeed   float64
+	filter proxy.Filter
+
+	// 代理列表的排序字段和方向，列表刷新后保持不变
+	sortOrder proxy.SortOrder
+
+	// 自定义代理源配置文件路径，为空时代理源保存在设置文件中
+	sourcesPath string
+
+	// 代理源是否被修改过，未修改时设置文件中不保存代理源，始终使用内置代理源
+	customSources bool
+
+	// 设置文件路径，为空时不保存设置；设置变化后延迟合并写入
+	settingsPath  string
+	settingsTimer *time.Timer
+	settingsMutex sync.Mutex
+
+	// 离线地理位置数据库路径，文件不存在时使用在线接口
+	geoIPPath string
+
+	// 离线ASN数据库路径，文件不存在时不查询ASN
+	asnDBPath string
+
+	// 最近一次启动服务使用的监听地址和端口
+	serverHost string
+	serverPort string
+	serverMode server.ListenMode
+
+	// 是否启用双代理链式转发
+	chainMode bool
+
+	// 本地服务是否只使用高级代理
+	premiumOnly bool
+
+	// 本地服务的上游代理必须通过的检测目标，空表示不限制
+	requiredTarget string
+
+	// 本地服务和定时轮换绑定的命名代理池，空表示使用全部有效代理
+	serverPool string
+
+	// 会话保持时长(分钟)，0 表示每个连接都轮换代理
+	stickyMinutes int
+
+	// 目标主机亲和时长(分钟)，0 表示关闭
+	affinityMinutes int
+
+	// 转发限速(KB/s)，分别为单连接和全局上限，0 表示不限制
+	rateLimitConnKB   int
+	rateLimitGlobalKB int
+
+	// 并发连接上限和排队数，0 表示不限制/不排队
+	maxConns  int
+	connQueue int
+
+	// 单个上游代理的并发连接上限和再次选用的冷却时间(秒)，0 表示不限制
+	proxyMaxConns        int
+	proxyCooldownSeconds int
+
+	// 附加监听器，与主服务共用代理池、认证、访问规则和限速，各自使用不同的选择策略
+	listeners *server.ListenerManager
+
+	// 本地服务访问规则
+	targetBlockRules string
+	targetAllowRules string
+	clientAllowRules string
+	clientDenyRules  string
+
+	// 本地服务的客户端认证凭据
+	authUser string
+	authPass string
+
+	// 列表刷新节流
+	refreshTimer *time.Timer
+	refreshMutex sync.Mutex
+
+	// 代理池自动保存，变化后延迟合并写入
+	autoPersist  bool
+	persistTimer *time.Timer
+	persistMutex sync.Mutex
+
+	// 本地服务的日志实例和最近的连接记录(最多 maxConnLog 条)
+	serverLogger *logrus.Logger
+	connLog      []server.ConnRecord
+	connLogMutex sync.Mutex
+
+	// 经过筛选和排序的全部代理、当前页码(从0开始)和刷新次数
+	listItems []*proxy.Proxy
+	listPage  int
+	listRev   int
+	listMutex sync.Mutex
 }
 
+// proxyListPageSize 代理列表每页的代理数量
+// 列表绑定中只放当前页，代理池很大时每次刷新也只需更新一页数据
+const proxyListPageSize = 200
+
+// refreshInterval 测试过程中代理列表的最短刷新间隔
+const refreshInterval = 500 * time.Millisecond
+
+// healthCheckInterval 服务运行期间对有效代理的健康检查间隔
+const healthCheckInterval = 5 * time.Minute
+
+// freshWindow 统计中视为"新鲜"的最近检测时间范围
+const freshWindow = 10 * time.Minute
+
+// autoCleanupMaxAge 自动任务清理有效代理时允许的最长未检测时间
+const autoCleanupMaxAge = 24 * time.Hour
+
+// importPrecheckTimeout 导入预检时单个TCP连接的超时
+const importPrecheckTimeout = 2 * time.Second
+
+// importPrecheckWorkers 导入预检的最大并发数
+const importPrecheckWorkers = 200
+
+// importDetectTimeout 导入时识别协议的单次握手超时
+const importDetectTimeout = 3 * time.Second
+
+// subscriptionTimeout 下载订阅内容的超时
+const subscriptionTimeout = 30 * time.Second
+
+// maxSubscriptionSize 订阅内容的大小上限，超出部分被忽略
+const maxSubscriptionSize = 10 << 20
+
+// maxLoggedLineErrors 导入时在日志中逐条列出的解析错误上限
+const maxLoggedLineErrors = 5
+
+// maxConnLog 界面连接日志保留的最近连接数
+const maxConnLog = 200
+
+// maxAppLog 界面应用日志保留的最近日志条数
+const maxAppLog = 2000
+
+// 本地服务日志文件的轮转大小(MB)和保留的历史文件数
+const (
+	serverLogMaxSizeMB = 10
+	serverLogBackups   = 3
+)
+
+// persistDelay 代理池变化后延迟保存的时间，期间的多次变化合并为一次写入
+const persistDelay = 3 * time.Second
+
+// settingsSaveDelay 设置变化后延迟保存的时间，连续输入搜索关键字等修改合并为一次写入
+const settingsSaveDelay = time.Second
+
+// legacySourcesPath 旧版本保存自定义代理源的文件，设置中没有代理源时导入一次
+var legacySourcesPath = filepath.Join(dataDir, "sources.json")
+
+// dataDir 代理池数据的存放目录
+const dataDir = "data"
+
 // NewApp 创建并初始化一个新的 App
-func NewApp() *App {
-	a := &App{}
-	a.fyneApp = app.New()
-	a.fyneApp.Settings().SetTheme(&theme.MyTheme{})
+// 参数 storageBackend: 代理存储后端(json/sqlite)，为空时使用设置中保存的后端，打开失败时回退到json
+// 参数 settingsPath: 设置文件路径，为空时使用默认设置且不保存
+func NewApp(storageBackend, settingsPath string) *App {
+	settings := config.Default()
+	if settingsPath != "" {
+		loaded, err := config.Load(settingsPath)
+		if err != nil {
+			log.Printf("加载设置失败，使用默认设置: %v", err)
+		}
+		settings = loaded
+	}
+
+	a := &App{settingsPath: settingsPath}
+	a.fyneApp = app.NewWithID("io.github.s1mple09.goproxy")
+	a.fyneApp.Settings().SetTheme(&theme.MyTheme{Mode: settings.Theme})
 	a.win = a.fyneApp.NewWindow("代理池工具 v0.1")
+	a.win.SetOnDropped(func(_ fyne.Position, uris []fyne.URI) {
+		a.ImportDroppedFiles(uris)
+	})
 
 	a.rotator = proxy.NewRotator()
 	a.checker = checker.NewChecker()
+	a.revalidator = checker.NewRevalidator(a.checker, a.rotator,
+		time.Duration(settings.Revalidate.IntervalMinutes)*time.Minute, settings.Revalidate.Workers)
+	a.revalidator.OnRound(func(tested, failed, recovered int) {
+		a.ApplyFiltersAndRefresh()
+		a.Log(fmt.Sprintf(lang.T("定期复检完成: 检测 %d 个，失败 %d 个，恢复 %d 个，当前有效 %d 个，搁置 %d 个。"),
+			tested, failed, recovered, a.rotator.GetValidProxyCount(), a.rotator.GetSidelinedCount()))
+	})
+
+	if storageBackend == "" {
+		storageBackend = settings.Storage
+	}
+	a.storageBackend = storageBackend
+	store, err := storage.New(storageBackend, dataDir)
+	if err != nil {
+		log.Printf("打开%s存储失败，改用JSON存储: %v", storageBackend, err)
+		store = storage.NewDiskStorage(dataDir)
+		a.storageBackend = storage.BackendJSON
+	}
+	a.store = store
+	a.rotator.SetSampleHook(func(address string, sample proxy.Sample) {
+		if err := a.store.RecordCheck(address, sample); err != nil {
+			log.Printf("保存检测结果失败 %s: %v", address, err)
+		}
+	})
+	a.events = events.NewBus()
+	a.listeners = server.NewListenerManager(a.rotator, a.configureListener)
+	a.serverLogger = logrus.New()
+	a.listeners.SetLogger(a.serverLogger)
+	a.rotator.SetRemoveHook(func(addresses []string) {
+		a.events.Publish(events.ProxyRemoved, map[string][]string{"addresses": addresses})
+	})
 
 	a.proxyList = binding.NewUntypedList()
-	a.logBinding = binding.NewString()
+	a.listRevision = binding.NewInt()
+	a.logBuffer = logging.NewBuffer(maxAppLog)
+	a.logRevision = binding.NewInt()
 	a.progressBar = widget.NewProgressBar()
 	a.serverRunning = binding.NewBool()
 	a.serverRunning.Set(false)
@@ -67,315 +333,2674 @@ func NewApp() *App {
 	a.rotationStatus.Set(false)
 	a.currentProxy = binding.NewString()
 	a.currentProxy.Set("无")
-	a.rotationSeconds = 60
 	a.rotationStop = make(chan struct{})
-
-	// 默认不筛选
-	a.maxLatency = -1
-	a.minSpeed = -1
+	a.systemProxyStatus = binding.NewBool()
+	a.autoRefreshStatus = binding.NewBool()
+	a.autoRefreshStop = make(chan struct{})
+	a.applySettings(settings)
+	if a.storageBackend != settings.Storage {
+		a.scheduleSaveSettings() // 命令行指定了不同的存储后端，之后从界面启动时沿用
+	}
 
 	return a
 }
 
-// Log 向UI日志面板添加一条带时间戳的日志
-func (a *App) Log(message string) {
-	timestamp := time.Now().Format("15:04:05")
-	logStr := fmt.Sprintf("[%s] %s\n", timestamp, message)
-	currentLog, _ := a.logBinding.Get()
-	lines := strings.Split(currentLog, "\n")
-	if len(lines) > 100 {
-		lines = lines[len(lines)-100:]
+// applySettings 将加载的设置应用到应用状态和检测器，只在启动时调用
+// 定期复检的设置在创建复检器时已经应用
+func (a *App) applySettings(s config.Settings) {
+	a.themeMode = s.Theme
+	a.language = s.Language
+	lang.Set(s.Language)
+	a.autoPersist = s.AutoPersist
+
+	a.serverHost = s.Server.Host
+	a.serverPort = s.Server.Port
+	a.serverMode = s.Server.Mode
+	a.chainMode = s.Server.ChainMode
+	a.followRotation = s.Server.FollowRotation
+	a.premiumOnly = s.Server.PremiumOnly
+	a.serverPool = s.Server.Pool
+	a.requiredTarget = s.Server.RequiredTarget
+	a.stickyMinutes = s.Server.StickyMinutes
+	a.affinityMinutes = s.Server.AffinityMinutes
+	a.rateLimitConnKB = s.Server.RateLimitConnKB
+	a.rateLimitGlobalKB = s.Server.RateLimitGlobalKB
+	a.maxConns = s.Server.MaxConns
+	a.connQueue = s.Server.ConnQueue
+	a.proxyMaxConns = s.Server.ProxyMaxConns
+	a.proxyCooldownSeconds = s.Server.ProxyCooldownSeconds
+	a.rotator.SetUsageLimits(a.usageLimits())
+	a.targetBlockRules = s.Server.TargetBlock
+	a.targetAllowRules = s.Server.TargetAllow
+	a.clientAllowRules = s.Server.ClientAllow
+	a.clientDenyRules = s.Server.ClientDeny
+	a.authUser = s.Server.AuthUser
+	a.authPass = s.Server.AuthPass
+
+	a.rotationSeconds = s.Rotation.IntervalSeconds
+	a.autoRefreshMinutes = s.AutoRefresh.IntervalMinutes
+	a.autoRefreshTest = s.AutoRefresh.Test
+	a.importPrecheck = s.Import.Precheck
+	a.importPremium = s.Import.Premium
+	a.importDetect = s.Import.Detect
+	a.subscriptionURL = s.Import.SubscriptionURL
+	a.filter = s.Filter
+	a.sortOrder = s.Sort
+
+	if err := a.checker.SetConfig(s.Check.Service); err != nil {
+		log.Printf("设置中的检测服务无效，使用默认检测服务: %v", err)
+	}
+	a.checker.SetRetryPolicy(s.Check.Retries, secondsToDuration(s.Check.RetryBackoff))
+	a.checker.SetLatencySamples(s.Check.LatencySamples)
+	a.testConcurrency = s.Check.Concurrency
+	a.checker.SetTimeout(secondsToDuration(s.Check.Timeout))
+	a.checker.SetConnectTimeout(secondsToDuration(s.Check.ConnectTimeout))
+
+	if len(s.Sources) > 0 {
+		if err := fetcher.SetSources(s.Sources); err != nil {
+			log.Printf("设置中的代理源无效，使用内置代理源: %v", err)
+		} else {
+			a.customSources = true
+		}
+	}
+}
+
+// GetSettings 返回当前的全部设置，即保存到设置文件的内容
+func (a *App) GetSettings() config.Settings {
+	interval, workers := a.revalidator.Settings()
+	retries, backoff := a.checker.RetryPolicy()
+	s := config.Settings{
+		Theme:       a.themeMode,
+		Language:    a.language,
+		AutoPersist: a.autoPersist,
+		Storage:     a.storageBackend,
+		Server: config.ServerSettings{
+			Host:                 a.serverHost,
+			Port:                 a.serverPort,
+			Mode:                 a.serverMode,
+			ChainMode:            a.chainMode,
+			FollowRotation:       a.followRotation,
+			PremiumOnly:          a.premiumOnly,
+			Pool:                 a.serverPool,
+			RequiredTarget:       a.requiredTarget,
+			StickyMinutes:        a.stickyMinutes,
+			AffinityMinutes:      a.affinityMinutes,
+			RateLimitConnKB:      a.rateLimitConnKB,
+			RateLimitGlobalKB:    a.rateLimitGlobalKB,
+			MaxConns:             a.maxConns,
+			ConnQueue:            a.connQueue,
+			ProxyMaxConns:        a.proxyMaxConns,
+			ProxyCooldownSeconds: a.proxyCooldownSeconds,
+			TargetBlock:          a.targetBlockRules,
+			TargetAllow:          a.targetAllowRules,
+			ClientAllow:          a.clientAllowRules,
+			ClientDeny:           a.clientDenyRules,
+			AuthUser:             a.authUser,
+			AuthPass:             a.authPass,
+		},
+		Rotation:    config.RotationSettings{IntervalSeconds: a.rotationSeconds},
+		AutoRefresh: config.AutoRefreshSettings{IntervalMinutes: a.autoRefreshMinutes, Test: a.autoRefreshTest},
+		Revalidate:  config.RevalidateSettings{IntervalMinutes: int(interval / time.Minute), Workers: workers},
+		Check: config.CheckSettings{
+			Service:        a.checker.Config(),
+			Retries:        retries,
+			RetryBackoff:   backoff.Seconds(),
+			LatencySamples: a.checker.LatencySamples(),
+			Concurrency:    a.testConcurrency,
+			ConnectTimeout: a.checker.ConnectTimeout().Seconds(),
+			Timeout:        a.checker.Timeout().Seconds(),
+		},
+		Import: config.ImportSettings{Precheck: a.importPrecheck, Premium: a.importPremium, Detect: a.importDetect, SubscriptionURL: a.subscriptionURL},
+		Filter: a.filter,
+		Sort:   a.sortOrder,
+	}
+	if a.customSources {
+		s.Sources = fetcher.Sources()
+	}
+	return s
+}
+
+// scheduleSaveSettings 在设置变化后延迟保存设置文件，期间的多次修改合并为一次写入
+func (a *App) scheduleSaveSettings() {
+	if a.settingsPath == "" {
+		return
+	}
+	a.settingsMutex.Lock()
+	defer a.settingsMutex.Unlock()
+	if a.settingsTimer != nil {
+		return
+	}
+	a.settingsTimer = time.AfterFunc(settingsSaveDelay, func() {
+		a.settingsMutex.Lock()
+		a.settingsTimer = nil
+		a.settingsMutex.Unlock()
+		a.saveSettings()
+	})
+}
+
+// saveSettings 立即将当前设置写入设置文件
+func (a *App) saveSettings() {
+	if a.settingsPath == "" {
+		return
 	}
-	a.logBinding.Set(strings.Join(lines, "\n") + logStr)
-	log.Println(message)
+	if err := config.Save(a.settingsPath, a.GetSettings()); err != nil {
+		a.LogError(fmt.Sprintf(lang.T("保存设置失败: %v"), err))
+	}
+}
+
+// secondsToDuration 将可带小数的秒数转换为时长
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// SetTheme 切换界面主题模式(light/dark/system)并保存到设置
+func (a *App) SetTheme(mode string) {
+	a.themeMode = mode
+	a.fyneApp.Settings().SetTheme(&theme.MyTheme{Mode: mode})
+	a.scheduleSaveSettings()
+}
+
+// SetLanguage 切换界面语言，界面需要重新创建才能显示新语言
+func (a *App) SetLanguage(l lang.Language) {
+	lang.Set(l)
+	a.language = lang.Current()
+	a.scheduleSaveSettings()
+	a.Log(fmt.Sprintf(lang.T("界面语言已切换为 %s"), a.language.Label()))
+}
+
+// Log 向UI日志面板添加一条 info 级别的日志
+func (a *App) Log(message string) {
+	a.addLog(logging.LevelInfo, message)
+}
+
+// LogError 向UI日志面板添加一条 error 级别的日志，用于操作失败
+func (a *App) LogError(message string) {
+	a.addLog(logging.LevelError, message)
+}
+
+// LogDebug 向UI日志面板添加一条 debug 级别的日志，用于频繁出现的过程信息
+func (a *App) LogDebug(message string) {
+	a.addLog(logging.LevelDebug, message)
+}
+
+// addLog 将日志写入界面日志缓冲区并同时输出到标准错误
+func (a *App) addLog(level logging.Level, message string) {
+	a.logRevision.Set(a.logBuffer.Add(level, message))
+	log.Printf("[%s] %s", level, message)
+}
+
+// GetLogEntries 返回不低于指定级别且包含搜索内容的界面日志，按时间顺序排列
+func (a *App) GetLogEntries(min logging.Level, search string) []logging.Entry {
+	return a.logBuffer.Entries(min, search)
+}
+
+// ExportLog 将日志保存到用户选择的文本文件
+func (a *App) ExportLog(entries []logging.Entry) {
+	fileDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil || writer == nil {
+			return
+		}
+		defer writer.Close()
+		if err := logging.WriteEntries(writer, entries); err != nil {
+			a.LogError(fmt.Sprintf(lang.T("保存日志失败: %v"), err))
+			return
+		}
+		a.Log(fmt.Sprintf(lang.T("已保存 %d 条日志到 %s"), len(entries), writer.URI().Name()))
+	}, a.win)
+	fileDialog.SetFileName("go_proxy_" + time.Now().Format("20060102_150405") + ".log")
+	fileDialog.Show()
 }
 
 // FetchProxies 获取代理但不显示，仅存入原始列表
 func (a *App) FetchProxies() {
 	go func() {
-		a.Log("开始从所有源获取在线代理...")
+		ctx := a.beginTask()
+		defer a.endTask()
+		a.Log(lang.T("开始从所有源获取在线代理..."))
 		a.progressBar.Show()
 		a.progressBar.SetValue(0)
 
-		proxies, err := fetcher.FetchAllProxies()
+		proxies, err := fetcher.FetchAllProxies(ctx)
 		if err != nil {
-			a.Log(fmt.Sprintf("获取代理时发生错误: %v", err))
+			a.LogError(fmt.Sprintf(lang.T("获取代理时发生错误: %v"), err))
+		}
+		if ctx.Err() != nil {
+			// 取消时只追加已获取到的代理，不替换原有的原始列表
+			added := a.rotator.AddRawProxies(proxies)
+			a.schedulePersist()
+			a.progressBar.Hide()
+			a.Log(fmt.Sprintf(lang.T("获取已取消，保留已获取到的 %d 个代理(新增 %d 个)。"), len(proxies), added))
+			return
 		}
 		if len(proxies) == 0 {
-			a.Log("未能获取到任何代理。")
+			a.Log(lang.T("未能获取到任何代理。"))
 			a.progressBar.Hide()
 			return
 		}
 
 		a.rotator.SetRawProxies(proxies)
+		a.schedulePersist()
 		a.progressBar.SetValue(1)
 		time.Sleep(1 * time.Second)
 		a.progressBar.Hide()
-		a.Log(fmt.Sprintf("获取完成，发现 %d 个代理地址。请点击“全部测试”来验证它们。", len(proxies)))
+		a.Log(fmt.Sprintf(lang.T("获取完成，发现 %d 个代理地址。请点击“全部测试”来验证它们。"), len(proxies)))
 	}()
 }
 
 // TestAllProxies 高并发测试所有原始代理，并将有效代理存入列表
+// 测试前会清空现有的有效代理列表
 func (a *App) TestAllProxies() {
 	go func() {
 		rawProxies, err := a.rotator.GetRawProxies()
 		if err != nil {
-			a.Log(fmt.Sprintf("获取原始代理失败: %v", err))
+			a.LogError(fmt.Sprintf(lang.T("获取原始代理失败: %v"), err))
 			return
 		}
 		if len(rawProxies) == 0 {
-			a.Log("没有可测试的代理，请先获取代理。")
+			a.Log(lang.T("没有可测试的代理，请先获取代理。"))
 			return
 		}
-		a.Log(fmt.Sprintf("开始并发测试 %d 个代理...", len(rawProxies)))
-		a.progressBar.Show()
-		a.progressBar.SetValue(0)
-		if err := a.rotator.SetValidProxies([]*proxy.Proxy{}); err != nil { // 开始测试前清空有效列表
-			a.Log(fmt.Sprintf("清空有效代理失败: %v", err))
+		a.runTests(rawProxies, true)
+	}()
+}
+
+// TestUntestedProxies 只测试尚未进入有效列表的原始代理，保留已有的测试结果
+func (a *App) TestUntestedProxies() {
+	go func() {
+		proxies := a.rotator.GetUntestedProxies()
+		if len(proxies) == 0 {
+			a.Log(lang.T("没有未测试的代理。"))
 			return
 		}
-		a.ApplyFiltersAndRefresh()
-
-		var wg sync.WaitGroup
-		var testedCount int
-		var testedMutex sync.Mutex
-
-		concurrencyLimit := 200
-		sem := make(chan struct{}, concurrencyLimit)
-
-		for _, p := range rawProxies {
-			wg.Add(1)
-			sem <- struct{}{}
-			go func(pr *proxy.Proxy) {
-				defer func() {
-					<-sem
-					wg.Done()
-				}()
-				if _, _, err := a.checker.CheckConnectivityAndSpeed(pr); err == nil {
-					// 测试成功，立即添加到有效列表并刷新UI
-					if err := a.rotator.AddValidProxies([]*proxy.Proxy{pr}); err != nil {
-						a.Log(fmt.Sprintf("添加有效代理失败: %v", err))
-					}
-					a.ApplyFiltersAndRefresh()
-				}
-				testedMutex.Lock()
-				testedCount++
-				a.progressBar.SetValue(float64(testedCount) / float64(len(rawProxies)))
-				testedMutex.Unlock()
-			}(p)
-		}
-		wg.Wait()
-
-		a.Log("基础测试完成。开始后台批量查询地理位置...")
-		// 后台批量查询地理位置，不阻塞主流程
-		go func() {
-			validProxies, err := a.rotator.GetValidProxies()
-			if err != nil {
-				a.Log(fmt.Sprintf("获取有效代理失败: %v", err))
-				return
-			}
-			if len(validProxies) > 0 {
-				if err := a.checker.BatchLookupLocations(validProxies); err != nil {
-					a.Log(fmt.Sprintf("批量查询地理位置失败: %v", err))
-				} else {
-					a.Log("地理位置查询完成，列表已更新。")
-					a.ApplyFiltersAndRefresh() // 再次刷新以显示地理位置
-				}
-			}
-		}()
-
-		a.progressBar.SetValue(1)
-		time.Sleep(1 * time.Second)
-		a.progressBar.Hide()
-		a.Log("全部测试流程完成。")
+		a.runTests(proxies, false)
 	}()
 }
 
-// ApplyFilters 应用筛选条件并刷新UI
-func (a *App) ApplyFilters(maxLatencyStr, minSpeedStr string) {
-	if maxLatencyStr == "" {
-		a.maxLatency = -1
-	} else {
-		maxLatency, err := strconv.ParseFloat(maxLatencyStr, 64)
-		if err != nil || maxLatency <= 0 {
-			a.maxLatency = -1
-		} else {
-			a.maxLatency = maxLatency / 1000 // ms转换为秒
+// TestNeverCheckedProxies 只测试从未检测过的原始代理，跳过检测过但失败的代理，保留已有的测试结果
+func (a *App) TestNeverCheckedProxies() {
+	go func() {
+		proxies := a.rotator.GetNeverCheckedProxies()
+		if len(proxies) == 0 {
+			a.Log(lang.T("没有从未检测过的代理。"))
+			return
 		}
-	}
+		a.runTests(proxies, false)
+	}()
+}
 
-	if minSpeedStr == "" {
-		a.minSpeed = -1
-	} else {
-		minSpeed, err := strconv.ParseFloat(minSpeedStr, 64)
-		if err != nil || minSpeed < 0 {
-			a.minSpeed = -1
-		} else {
-			a.minSpeed = minSpeed
-		}
+// TestSelectedProxies 只测试代理列表中选中的代理，保留其他代理的测试结果
+func (a *App) TestSelectedProxies(proxies []*proxy.Proxy) {
+	if len(proxies) == 0 {
+		a.Log(lang.T("没有选中的代理，请先在列表中点击要测试的行。"))
+		return
 	}
+	go a.runTests(proxies, false)
+}
 
-	a.Log("应用筛选条件并刷新列表...")
-	a.ApplyFiltersAndRefresh()
+// RetestFailedProxies 只重新测试失败次数大于0的代理，保留已有的测试结果
+func (a *App) RetestFailedProxies() {
+	go func() {
+		proxies := a.rotator.GetFailedProxies()
+		if len(proxies) == 0 {
+			a.Log(lang.T("没有测试失败的代理。"))
+			return
+		}
+		a.runTests(proxies, false)
+	}()
 }
 
-// ApplyFiltersAndRefresh 从rotator获取、筛选、排序并更新UI
-func (a *App) ApplyFiltersAndRefresh() {
-	proxies, err := a.rotator.GetFilteredAndSortedProxies(a.maxLatency, a.minSpeed)
-	if err != nil {
-		a.Log(fmt.Sprintf("获取筛选代理失败: %v", err))
+// resolveProtocols 识别来源给出了不同协议的代理，避免按错误的协议检测而误判失效
+// 同一地址只检测一次，由协议识别决定最终协议；未能识别的代理按原协议检测
+func (a *App) resolveProtocols(ctx context.Context, proxies []*proxy.Proxy) {
+	var ambiguous []*proxy.Proxy
+	for _, p := range proxies {
+		if p.AmbiguousProtocol() {
+			ambiguous = append(ambiguous, p)
+		}
+	}
+	if len(ambiguous) == 0 {
 		return
 	}
-	var proxyItems []interface{}
-	for _, p := range proxies {
-		proxyItems = append(proxyItems, p)
+	a.Log(fmt.Sprintf(lang.T("%d 个代理的来源给出了不同协议，正在识别..."), len(ambiguous)))
+	detected := a.checker.DetectProtocols(ctx, ambiguous, importDetectTimeout, importPrecheckWorkers, a.rotator.SetProtocol)
+	if ctx.Err() != nil {
+		return
 	}
-	a.proxyList.Set(proxyItems)
+	a.Log(fmt.Sprintf(lang.T("协议识别完成: 识别 %d 个，%d 个未能识别(按原协议检测)。"), detected, len(ambiguous)-detected))
 }
 
-// ImportProxies 从文件导入代理
-func (a *App) ImportProxies() {
-	fileDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
-		if err != nil || reader == nil {
+// runTests 高并发测试给定代理，测试成功的代理加入有效列表
+// 代理源报告了在线率或延迟的代理优先检测，来源给出不同协议的代理先识别协议
+// 失败时累加代理的 FailCount，成功时清零
+// 参数 proxies: 待测试的代理
+// 参数 clearValid: 是否在测试前清空有效代理列表
+func (a *App) runTests(proxies []*proxy.Proxy, clearValid bool) {
+	ctx := a.beginTask()
+	defer a.endTask()
+	proxies = proxy.PrioritizeCandidates(proxies)
+	a.Log(fmt.Sprintf(lang.T("开始并发测试 %d 个代理..."), len(proxies)))
+	a.progressBar.Show()
+	a.progressBar.SetValue(0)
+	if clearValid {
+		if err := a.rotator.SetValidProxies([]*proxy.Proxy{}); err != nil { // 开始测试前清空有效列表
+			a.LogError(fmt.Sprintf(lang.T("清空有效代理失败: %v"), err))
 			return
 		}
-		defer reader.Close()
+		a.ApplyFiltersAndRefresh()
+	}
+	a.resolveProtocols(ctx, proxies)
 
-		var importedProxies []*proxy.Proxy
-		scanner := bufio.NewScanner(reader)
-		for scanner.Scan() {
-			line := strings.TrimSpace(scanner.Text())
-			if line != "" {
-				importedProxies = append(importedProxies, &proxy.Proxy{Address: line, Protocol: "http"})
+	var testedCount, successCount, retriedCount int
+	failures := make(map[checker.FailureReason]int)
+	for result := range a.checker.CheckStream(ctx, proxies, a.testConcurrency) {
+		pr, err := result.Proxy, result.Err
+		a.rotator.RecordCheck(pr, pr.Latency, pr.Speed, err)
+		fetcher.RecordValidation(pr.Address, err == nil)
+		testedCount++
+		if err != nil {
+			failures[checker.ReasonOf(err)]++
+		} else {
+			successCount++
+			if result.Retried {
+				retriedCount++
 			}
+			// 测试成功，立即添加到有效列表并刷新UI
+			if err := a.rotator.AddValidProxies([]*proxy.Proxy{pr}); err != nil {
+				a.LogError(fmt.Sprintf(lang.T("添加有效代理失败: %v"), err))
+			}
+			a.publishValidated(pr)
+			a.scheduleRefresh()
 		}
-		if len(importedProxies) > 0 {
-			a.rotator.AddRawProxies(importedProxies)
-			a.Log(fmt.Sprintf("成功导入 %d 个代理。请点击“全部测试”来验证它们。", len(importedProxies)))
-		}
-	}, a.win)
-	fileDialog.SetFilter(storage.NewExtensionFileFilter([]string{".txt"}))
-	fileDialog.Show()
+		a.progressBar.SetValue(float64(testedCount) / float64(len(proxies)))
+	}
+	a.flushRefresh()
+	if ctx.Err() != nil {
+		a.Log(fmt.Sprintf(lang.T("测试已取消，已完成 %d/%d 个，结果已保留。"), testedCount, len(proxies)))
+	}
+	a.Log(lang.T("测试结果: ") + formatTally(successCount, failures))
+	if retriedCount > 0 {
+		a.Log(fmt.Sprintf(lang.T("其中 %d 个代理首次检测偶发失败，重试后成功。"), retriedCount))
+	}
+
+	if ctx.Err() == nil {
+		a.lookupLocations(ctx)
+	}
+
+	a.progressBar.SetValue(1)
+	time.Sleep(1 * time.Second)
+	a.progressBar.Hide()
+	a.Log(lang.T("全部测试流程完成。"))
 }
 
-// ExportProxies 导出当前显示的有效代理到文件
-func (a *App) ExportProxies() {
-	proxies, err := a.rotator.GetFilteredAndSortedProxies(a.maxLatency, a.minSpeed)
+// lookupLocations 批量查询有效代理的地理位置，查询进度显示在进度条上
+// 参数 ctx: 取消后停止查询，已查询到的位置保留
+func (a *App) lookupLocations(ctx context.Context) {
+	validProxies, err := a.rotator.GetValidProxies()
 	if err != nil {
-		a.Log(fmt.Sprintf("获取代理失败: %v", err))
+		a.LogError(fmt.Sprintf(lang.T("获取有效代理失败: %v"), err))
 		return
 	}
-	if len(proxies) == 0 {
-		dialog.ShowInformation("无代理可导出", "当前列表没有可导出的有效代理。", a.win)
+	if len(validProxies) == 0 {
 		return
 	}
 
-	fileDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
-		if err != nil || writer == nil {
-			return
-		}
-		defer writer.Close()
+	a.Log(lang.T("基础测试完成。开始批量查询地理位置..."))
+	a.progressBar.SetValue(0)
+	err = a.checker.BatchLookupLocations(ctx, validProxies, func(done, total int) {
+		a.progressBar.SetValue(float64(done) / float64(total))
+	})
+	if ctx.Err() != nil {
+		a.Log(lang.T("地理位置查询已取消。"))
+		a.ApplyFiltersAndRefresh()
+		return
+	}
+	if err != nil {
+		a.LogError(fmt.Sprintf(lang.T("批量查询地理位置失败: %v"), err))
+		return
+	}
+	a.Log(lang.T("地理位置查询完成，列表已更新。"))
+	a.ApplyFiltersAndRefresh() // 再次刷新以显示地理位置
+}
 
-		for _, p := range proxies {
-			line := fmt.Sprintf("%s\n", p.Address)
-			_, _ = writer.Write([]byte(line))
+// formatTally 按失败原因汇总测试结果，失败原因按数量降序排列
+func formatTally(successCount int, failures map[checker.FailureReason]int) string {
+	reasons := make([]checker.FailureReason, 0, len(failures))
+	for reason := range failures {
+		reasons = append(reasons, reason)
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		if failures[reasons[i]] != failures[reasons[j]] {
+			return failures[reasons[i]] > failures[reasons[j]]
 		}
-		a.Log(fmt.Sprintf("成功导出 %d 个有效代理到 %s", len(proxies), writer.URI().Name()))
-	}, a.win)
-	fileDialog.SetFileName("valid_proxies.txt")
-	fileDialog.Show()
-}
+		return reasons[i] < reasons[j]
+	})
 
-// ClearProxies 清空所有代理
-func (a *App) ClearProxies() {
-	a.rotator.SetRawProxies([]*proxy.Proxy{})
-	a.rotator.SetValidProxies([]*proxy.Proxy{})
-	a.ApplyFiltersAndRefresh()
-	a.Log("所有代理列表已清空。")
+	parts := []string{fmt.Sprintf(lang.T("成功 %d"), successCount)}
+	for _, reason := range reasons {
+		parts = append(parts, fmt.Sprintf("%s %d", reason, failures[reason]))
+	}
+	return strings.Join(parts, ", ")
 }
 
-// ToggleServer 启动或停止本地代理服务
-func (a *App) ToggleServer(portStr string) {
-	running, _ := a.serverRunning.Get()
-	if running {
-		if a.server != nil {
-			if err := a.server.Stop(); err != nil {
-				a.Log(fmt.Sprintf("停止服务失败: %v", err))
-				return
-			}
-			a.serverRunning.Set(false)
+// ApplyFilters 应用筛选条件并刷新UI
+func (a *App) ApplyFilters(maxLatencyStr, minSpeedStr string, remoteDNSOnly, httpsOnly bool, ipVersion int) {
+	filter := proxy.NoFilter()
+	if maxLatencyStr != "" {
+		maxLatency, err := strconv.ParseFloat(maxLatencyStr, 64)
+		if err == nil && maxLatency > 0 {
+			filter.MaxLatency = maxLatency / 1000 // ms转换为秒
 		}
-		return
 	}
 
-	if a.rotator.GetValidProxyCount() == 0 {
-		a.Log("错误：没有可用的有效代理来启动服务。")
-		return
+	if minSpeedStr != "" {
+		minSpeed, err := strconv.ParseFloat(minSpeedStr, 64)
+		if err == nil && minSpeed >= 0 {
+			filter.MinSpeed = minSpeed
+		}
 	}
+	filter.RemoteDNSOnly = remoteDNSOnly
+	filter.HTTPSOnly = httpsOnly
+	filter.IPVersion = ipVersion
+	// 搜索框和列筛选由列表上方的控件单独设置，这里保持不变
+	filter.Search, filter.Protocol = a.filter.Search, a.filter.Protocol
+	filter.Country, filter.Anonymity = a.filter.Country, a.filter.Anonymity
+	a.filter = filter
+	a.setListPage(0)
+	a.scheduleSaveSettings()
 
-	port, err := strconv.Atoi(portStr)
-	if err != nil || port <= 0 || port > 65535 {
-		a.Log(fmt.Sprintf("错误：端口 '%s' 无效。", portStr))
-		return
-	}
+	a.Log(lang.T("应用筛选条件并刷新列表..."))
+	a.ApplyFiltersAndRefresh()
+}
 
-	a.server = server.NewServer("127.0.0.1", port, a.rotator)
-	if err := a.server.Start(); err != nil {
-		a.Log(fmt.Sprintf("启动服务失败: %v", err))
-		return
-	}
-	a.serverRunning.Set(true)
+// SetTableFilter 设置代理列表的搜索关键字和列筛选，立即刷新列表
+// 参数 search: 按地址、协议、国家或匿名度子串搜索，为空表示不限制
+// 参数 protocol、country、anonymity: 列筛选的取值，为空表示不限制
+func (a *App) SetTableFilter(search, protocol, country, anonymity string) {
+	a.filter.Search = strings.TrimSpace(search)
+	a.filter.Protocol = protocol
+	a.filter.Country = country
+	a.filter.Anonymity = anonymity
+	a.setListPage(0)
+	a.refreshProxyList()
+	a.scheduleSaveSettings()
 }
 
-func main() {
-	myApp := NewApp()
-	myApp.progressBar.Hide()
+// GetFilterOptions 返回有效代理中出现过的协议、国家和匿名度，用于列筛选下拉框，均已排序
+func (a *App) GetFilterOptions() (protocols, countries, anonymities []string) {
+	valid, _ := a.rotator.GetValidProxies()
+	protocolSet, countrySet, anonymitySet := map[string]bool{}, map[string]bool{}, map[string]bool{}
+	for _, p := range valid {
+		protocolSet[p.Protocol] = true
+		countrySet[p.Country] = true
+		anonymitySet[p.Anonymity] = true
+	}
+	return sortedKeys(protocolSet), sortedKeys(countrySet), sortedKeys(anonymitySet)
+}
 
-	go func() {
-		myApp.Log("正在初始化，获取本机公网IP...")
-		if err := myApp.checker.InitializePublicIP(); err != nil {
-			myApp.Log(fmt.Sprintf("获取公网IP失败: %v", err))
-		} else {
-			myApp.Log("公网IP初始化成功。")
+// sortedKeys 返回集合中非空的键，按字母顺序排列
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		if key != "" {
+			keys = append(keys, key)
 		}
-	}()
+	}
+	sort.Strings(keys)
+	return keys
+}
 
-	ui.SetupUI(myApp)
-	myApp.win.ShowAndRun()
-	log.Println("应用已退出")
+// GetSortOrder 返回代理列表当前的排序字段和方向
+func (a *App) GetSortOrder() proxy.SortOrder {
+	return a.sortOrder
 }
 
-// --- 实现 ui.Apper 接口 ---
-func (a *App) GetWindow() fyne.Window              { return a.win }
-func (a *App) GetProxyList() binding.UntypedList   { return a.proxyList }
-func (a *App) GetLogBinding() binding.String       { return a.logBinding }
-func (a *App) GetProgressBar() *widget.ProgressBar { return a.progressBar }
-func (a *App) GetServerStatus() binding.Bool       { return a.serverRunning }
-func (a *App) GetRotationStatus() binding.Bool     { return a.rotationStatus }
-func (a *App) GetCurrentProxy() binding.String     { return a.currentProxy }
+// SetSortOrder 设置代理列表的排序字段和方向，立即重新排序
+func (a *App) SetSortOrder(order proxy.SortOrder) {
+	a.sortOrder = order
+	a.setListPage(0)
+	a.refreshProxyList()
+	a.scheduleSaveSettings()
+}
 
-// ToggleRotation 切换代理轮换状态
-func (a *App) ToggleRotation(enable bool) {
-	if enable {
-		a.startRotation()
-	} else {
-		a.stopRotation()
-	}
+// ApplyFiltersAndRefresh 从rotator获取、筛选、排序并更新UI
+func (a *App) ApplyFiltersAndRefresh() {
+	a.refreshProxyList()
+	a.schedulePersist()
 }
 
-// SetRotationInterval 设置轮换间隔时间(秒)
+// refreshProxyList 按当前筛选条件和排序重新生成列表数据
+func (a *App) refreshProxyList() {
+	proxies, err := a.rotator.GetSortedProxies(a.filter, a.sortOrder)
+	if err != nil {
+		a.LogError(fmt.Sprintf(lang.T("获取筛选代理失败: %v"), err))
+		return
+	}
+	a.listMutex.Lock()
+	a.listItems = proxies
+	a.listMutex.Unlock()
+	a.showListPage()
+}
+
+// showListPage 将当前页的代理放入列表绑定并通知界面刷新
+// 页码超出范围时调整到最后一页
+func (a *App) showListPage() {
+	a.listMutex.Lock()
+	pages := listPages(len(a.listItems))
+	if a.listPage >= pages {
+		a.listPage = pages - 1
+	}
+	start := a.listPage * proxyListPageSize
+	end := start + proxyListPageSize
+	if end > len(a.listItems) {
+		end = len(a.listItems)
+	}
+	pageItems := make([]interface{}, 0, end-start)
+	for _, p := range a.listItems[start:end] {
+		pageItems = append(pageItems, p)
+	}
+	a.listRev++
+	rev := a.listRev
+	a.listMutex.Unlock()
+
+	a.proxyList.Set(pageItems)
+	a.listRevision.Set(rev)
+}
+
+// listPages 返回 total 个代理需要的页数，至少为1
+func listPages(total int) int {
+	if total == 0 {
+		return 1
+	}
+	return (total + proxyListPageSize - 1) / proxyListPageSize
+}
+
+// setListPage 修改当前页码，不刷新列表
+func (a *App) setListPage(page int) {
+	a.listMutex.Lock()
+	defer a.listMutex.Unlock()
+	if page < 0 {
+		page = 0
+	}
+	a.listPage = page
+}
+
+// SetListPage 切换代理列表显示的页，页码从0开始，超出范围时调整到第一页或最后一页
+func (a *App) SetListPage(page int) {
+	a.setListPage(page)
+	a.showListPage()
+}
+
+// ListPageInfo 返回代理列表当前页码(从0开始)、总页数和筛选后的代理总数
+func (a *App) ListPageInfo() (page, pages, total int) {
+	a.listMutex.Lock()
+	defer a.listMutex.Unlock()
+	return a.listPage, listPages(len(a.listItems)), len(a.listItems)
+}
+
+// FindListedProxy 在筛选后的代理列表(包括不在当前页的)中按地址查找代理，找不到时返回nil
+func (a *App) FindListedProxy(address string) *proxy.Proxy {
+	a.listMutex.Lock()
+	defer a.listMutex.Unlock()
+	for _, p := range a.listItems {
+		if p.Address == address {
+			return p
+		}
+	}
+	return nil
+}
+
+// schedulePersist 请求一次延迟保存代理池
+// 未开启自动保存时忽略，persistDelay 内的多次请求合并为一次写入
+func (a *App) schedulePersist() {
+	if !a.autoPersist {
+		return
+	}
+	a.persistMutex.Lock()
+	defer a.persistMutex.Unlock()
+	if a.persistTimer != nil {
+		return
+	}
+	a.persistTimer = time.AfterFunc(persistDelay, func() {
+		a.persistMutex.Lock()
+		a.persistTimer = nil
+		a.persistMutex.Unlock()
+		if err := a.savePool(); err != nil {
+			a.LogError(fmt.Sprintf(lang.T("自动保存代理池失败: %v"), err))
+		}
+	})
+}
+
+// savePool 将原始和有效代理列表写入存储
+func (a *App) savePool() error {
+	raw, err := a.rotator.GetRawProxies()
+	if err != nil {
+		return err
+	}
+	valid, err := a.rotator.GetValidProxies()
+	if err != nil {
+		return err
+	}
+	if err := a.store.SaveRawProxies(raw); err != nil {
+		return err
+	}
+	return a.store.SaveValidProxies(valid)
+}
+
+// restorePool 启动时从存储恢复上次保存的原始和有效代理列表，以及这些代理的检测历史
+func (a *App) restorePool() {
+	if !a.autoPersist {
+		return
+	}
+	raw, err := a.store.LoadRawProxies()
+	if err != nil {
+		a.LogError(fmt.Sprintf(lang.T("加载已保存的原始代理失败: %v"), err))
+		return
+	}
+	valid, err := a.store.LoadValidProxies()
+	if err != nil {
+		a.LogError(fmt.Sprintf(lang.T("加载已保存的有效代理失败: %v"), err))
+		return
+	}
+	if len(raw) == 0 && len(valid) == 0 {
+		return
+	}
+	// 有效代理同时加入原始列表，与测试流程中两者共享同一对象保持一致
+	a.rotator.AddValidProxies(valid)
+	a.rotator.AddRawProxies(valid)
+	a.rotator.AddRawProxies(raw)
+	if history, err := a.store.LoadCheckHistory(time.Time{}); err != nil {
+		a.LogError(fmt.Sprintf(lang.T("加载检测历史失败: %v"), err))
+	} else {
+		a.rotator.RestoreHistory(history)
+	}
+	a.ApplyFiltersAndRefresh()
+	a.Log(fmt.Sprintf(lang.T("已恢复上次保存的代理池: 原始 %d 个，有效 %d 个。"), len(raw), len(valid)))
+}
+
+// SetAutoPersist 开启或关闭代理池自动保存，设置保存到设置文件
+// 开启时立即保存一次当前代理池
+func (a *App) SetAutoPersist(enabled bool) {
+	if a.autoPersist == enabled {
+		return
+	}
+	a.autoPersist = enabled
+	a.scheduleSaveSettings()
+	if enabled {
+		a.schedulePersist()
+		a.Log(lang.T("已开启代理池自动保存。"))
+	} else {
+		a.Log(lang.T("已关闭代理池自动保存。"))
+	}
+}
+
+// scheduleRefresh 请求一次延迟刷新
+// 在 refreshInterval 内的多次请求会合并为一次 ApplyFiltersAndRefresh
+func (a *App) scheduleRefresh() {
+	a.refreshMutex.Lock()
+	defer a.refreshMutex.Unlock()
+	if a.refreshTimer != nil {
+		return
+	}
+	a.refreshTimer = time.AfterFunc(refreshInterval, func() {
+		a.refreshMutex.Lock()
+		a.refreshTimer = nil
+		a.refreshMutex.Unlock()
+		a.ApplyFiltersAndRefresh()
+	})
+}
+
+// flushRefresh 取消尚未触发的延迟刷新并立即刷新列表
+func (a *App) flushRefresh() {
+	a.refreshMutex.Lock()
+	if a.refreshTimer != nil {
+		a.refreshTimer.Stop()
+		a.refreshTimer = nil
+	}
+	a.refreshMutex.Unlock()
+	a.ApplyFiltersAndRefresh()
+}
+
+// ImportProxies 从文件导入代理，也可以将文件直接拖放到窗口(见 ImportDroppedFiles)
+func (a *App) ImportProxies() {
+	fileDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil || reader == nil {
+			return
+		}
+		defer reader.Close()
+
+		lines, err := readLines(reader)
+		if err != nil {
+			a.LogError(fmt.Sprintf(lang.T("读取文件 %s 失败: %v"), reader.URI().Name(), err))
+			return
+		}
+		a.importProxyLines(lines)
+	}, a.win)
+	fileDialog.SetFilter(fynestorage.NewExtensionFileFilter(importFileExtensions))
+	fileDialog.Show()
+}
+
+// importFileExtensions 文件导入和拖放导入支持的文件扩展名
+var importFileExtensions = []string{".txt", ".csv", ".json"}
+
+// ImportDroppedFiles 导入拖放到窗口的代理文件，多个文件合并为一次导入
+// 不支持的文件类型和无法读取的文件被跳过并记录日志
+func (a *App) ImportDroppedFiles(uris []fyne.URI) {
+	var lines []string
+	for _, uri := range uris {
+		supported := false
+		for _, ext := range importFileExtensions {
+			if strings.EqualFold(uri.Extension(), ext) {
+				supported = true
+			}
+		}
+		if !supported {
+			a.Log(fmt.Sprintf(lang.T("忽略不支持的文件: %s，仅支持 %s"), uri.Name(), strings.Join(importFileExtensions, "/")))
+			continue
+		}
+		reader, err := fynestorage.Reader(uri)
+		if err != nil {
+			a.LogError(fmt.Sprintf(lang.T("打开文件 %s 失败: %v"), uri.Name(), err))
+			continue
+		}
+		fileLines, err := readLines(reader)
+		reader.Close()
+		if err != nil {
+			a.LogError(fmt.Sprintf(lang.T("读取文件 %s 失败: %v"), uri.Name(), err))
+			continue
+		}
+		lines = append(lines, fileLines...)
+	}
+	if len(lines) == 0 {
+		return
+	}
+	a.importProxyLines(lines)
+}
+
+// readLines 逐行读取导入文件的内容，单行最长 maxSubscriptionSize 字节(整个JSON写在一行的文件)
+func readLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxSubscriptionSize)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
+
+// ImportFromClipboard 从剪贴板导入代理，每行一个
+func (a *App) ImportFromClipboard() {
+	content := a.win.Clipboard().Content()
+	if strings.TrimSpace(content) == "" {
+		a.Log(lang.T("剪贴板为空，没有可导入的代理。"))
+		return
+	}
+	a.importProxyLines(strings.Split(content, "\n"))
+}
+
+// ImportSubscription 下载订阅内容并导入其中可用的 socks/http 代理
+// 订阅内容可以是 base64 编码的链接列表，ss:// 和 vmess:// 等需要专用客户端的节点只统计不导入
+// 参数 subURL: 订阅地址，导入成功后保存到设置中
+func (a *App) ImportSubscription(subURL string) {
+	subURL = strings.TrimSpace(subURL)
+	if u, err := url.Parse(subURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		a.LogError(fmt.Sprintf(lang.T("无效的订阅地址: %q"), subURL))
+		return
+	}
+	go func() {
+		a.Log(lang.T("正在下载订阅内容..."))
+		data, err := downloadSubscription(subURL)
+		if err != nil {
+			a.LogError(fmt.Sprintf(lang.T("下载订阅失败: %v"), err))
+			return
+		}
+		a.subscriptionURL = subURL
+		a.scheduleSaveSettings()
+
+		usable, nodes := proxy.SplitSubscription(proxy.DecodeSubscription(data))
+		if len(nodes) > 0 {
+			counts := make(map[string]int)
+			for _, node := range nodes {
+				counts[node.Scheme]++
+				a.LogDebug(fmt.Sprintf(lang.T("跳过 %s 节点 %s %s"), node.Scheme, node.Server, node.Name))
+			}
+			schemes := make([]string, 0, len(counts))
+			for scheme, n := range counts {
+				schemes = append(schemes, fmt.Sprintf(lang.T("%s %d 个"), scheme, n))
+			}
+			sort.Strings(schemes)
+			a.Log(fmt.Sprintf(lang.T("订阅中有 %d 个节点需要专用客户端，未导入(%s)。"), len(nodes), strings.Join(schemes, "，")))
+		}
+		if len(usable) == 0 {
+			a.Log(lang.T("订阅中没有可导入的 socks/http 代理。"))
+			return
+		}
+		a.importProxyLines(usable)
+	}()
+}
+
+// downloadSubscription 下载订阅内容，最多读取 maxSubscriptionSize 字节
+func downloadSubscription(subURL string) ([]byte, error) {
+	client := &http.Client{Timeout: subscriptionTimeout}
+	resp, err := client.Get(subURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf(lang.T("服务器返回 %s"), resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxSubscriptionSize))
+}
+
+// GetSubscriptionURL 返回最近一次订阅导入使用的订阅地址
+func (a *App) GetSubscriptionURL() string {
+	return a.subscriptionURL
+}
+
+// importProxyLines 解析文本行并将有效代理加入原始列表
+// 文件导入和剪贴板导入共用此流程，空行不计入跳过数量
+// 开启预检或协议识别时在后台处理完成后再加入
+func (a *App) importProxyLines(lines []string) {
+	parsed, untyped, skipped, lineErrors := parseProxyLines(lines)
+	a.logLineErrors(lineErrors)
+	if a.importPremium {
+		for _, p := range parsed {
+			p.IsPremium = true
+		}
+	}
+
+	detect := a.importDetect && len(untyped) > 0
+	if (!a.importPrecheck && !detect) || len(parsed) == 0 {
+		a.addImportedProxies(parsed, skipped, 0)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a.precheckMutex.Lock()
+	if a.precheckCancel != nil {
+		a.precheckCancel()
+	}
+	a.precheckCancel = cancel
+	a.precheckMutex.Unlock()
+
+	go func() {
+		defer cancel()
+		unreachable := 0
+		if a.importPrecheck {
+			a.Log(fmt.Sprintf(lang.T("正在对 %d 个代理进行端口预检..."), len(parsed)))
+			reachable := a.checker.FilterReachable(ctx, parsed, importPrecheckTimeout, importPrecheckWorkers)
+			if ctx.Err() != nil {
+				a.Log(lang.T("导入预检已取消，未导入任何代理。"))
+				return
+			}
+			unreachable = len(parsed) - len(reachable)
+			parsed = reachable
+		}
+		if detect {
+			a.detectImportedProtocols(ctx, parsed, untyped)
+			if ctx.Err() != nil {
+				a.Log(lang.T("协议识别已取消，未导入任何代理。"))
+				return
+			}
+		}
+		a.addImportedProxies(parsed, skipped, unreachable)
+	}()
+}
+
+// detectImportedProtocols 识别导入时未写明协议的代理，只处理 parsed 中仍保留的代理
+// 无法识别的代理保持默认的http协议
+func (a *App) detectImportedProtocols(ctx context.Context, parsed, untyped []*proxy.Proxy) {
+	kept := make(map[*proxy.Proxy]bool, len(parsed))
+	for _, p := range parsed {
+		kept[p] = true
+	}
+	var candidates []*proxy.Proxy
+	for _, p := range untyped {
+		if kept[p] {
+			candidates = append(candidates, p)
+		}
+	}
+	if len(candidates) == 0 {
+		return
+	}
+
+	a.Log(fmt.Sprintf(lang.T("正在识别 %d 个代理的协议..."), len(candidates)))
+	detected := a.checker.DetectProtocols(ctx, candidates, importDetectTimeout, importPrecheckWorkers, a.rotator.SetProtocol)
+	if ctx.Err() != nil {
+		return
+	}
+	a.Log(fmt.Sprintf(lang.T("协议识别完成: 识别 %d 个，%d 个未能识别(按http处理)。"), detected, len(candidates)-detected))
+}
+
+// parseProxyLines 逐行解析代理文本，返回解析出的代理、无法解析的行数(空行和注释行不计)和每行的错误
+// untyped 为 parsed 中未写明协议(按默认http处理)的代理
+// 内容为JSON时按 V2Ray/Xray 出站配置解析，跳过数为无法使用的出站数量，没有逐行错误
+func parseProxyLines(lines []string) (parsed, untyped []*proxy.Proxy, skipped int, lineErrors []proxy.LineError) {
+	content := strings.TrimSpace(strings.Join(lines, "\n"))
+	if strings.HasPrefix(content, "{") || strings.HasPrefix(content, "[") {
+		parsed, skipped, err := proxy.ParseV2RayJSON([]byte(content))
+		if err == nil {
+			return parsed, nil, skipped, nil
+		}
+	}
+
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		p, err := proxy.ParseProxyLine(line)
+		if err == proxy.ErrCommentLine {
+			continue
+		}
+		if err != nil {
+			lineErrors = append(lineErrors, proxy.LineError{Line: i + 1, Text: line, Err: err})
+			continue
+		}
+		parsed = append(parsed, p)
+		if !hasExplicitProtocol(line) {
+			untyped = append(untyped, p)
+		}
+	}
+	return parsed, untyped, len(lineErrors), lineErrors
+}
+
+// hasExplicitProtocol 判断代理行是否写明了协议(scheme:// 前缀或CSV中的协议列)
+func hasExplicitProtocol(line string) bool {
+	if strings.Contains(line, "://") {
+		return true
+	}
+	if !strings.Contains(line, ",") {
+		return false
+	}
+	for _, field := range strings.Split(line, ",") {
+		switch strings.ToLower(strings.Trim(strings.TrimSpace(field), `"`)) {
+		case "http", "https", "socks4", "socks4a", "socks5":
+			return true
+		}
+	}
+	return false
+}
+
+// logLineErrors 在日志中列出前 maxLoggedLineErrors 条解析错误，其余只给出数量
+func (a *App) logLineErrors(lineErrors []proxy.LineError) {
+	for i, e := range lineErrors {
+		if i == maxLoggedLineErrors {
+			a.Log(fmt.Sprintf(lang.T("另有 %d 行无法解析，未逐条列出。"), len(lineErrors)-i))
+			break
+		}
+		a.Log(lang.T("无法解析") + e.Error())
+	}
+}
+
+// addImportedProxies 将导入的代理加入原始列表并记录结果
+// 参数 skipped: 无法解析的行数
+// 参数 unreachable: 预检时端口无法连接而丢弃的数量
+func (a *App) addImportedProxies(proxies []*proxy.Proxy, skipped, unreachable int) {
+	added := a.rotator.AddRawProxies(proxies)
+	a.schedulePersist()
+	duplicates := len(proxies) - added
+	message := fmt.Sprintf(lang.T("成功导入 %d 个代理，跳过 %d 行无效内容，%d 个重复"), added, skipped, duplicates)
+	if a.importPrecheck {
+		message += fmt.Sprintf(lang.T("，%d 个端口不可达已丢弃"), unreachable)
+	}
+	a.Log(message + lang.T("。请点击“全部测试”来验证它们。"))
+}
+
+// SetImportPrecheck 开启或关闭导入时的端口预检
+func (a *App) SetImportPrecheck(enabled bool) {
+	a.importPrecheck = enabled
+	a.scheduleSaveSettings()
+}
+
+// SetImportDetect 开启或关闭导入时的协议自动识别
+func (a *App) SetImportDetect(enabled bool) {
+	a.importDetect = enabled
+	a.scheduleSaveSettings()
+}
+
+// SetImportPremium 设置之后导入的代理是否标记为高级
+func (a *App) SetImportPremium(enabled bool) {
+	a.importPremium = enabled
+	a.scheduleSaveSettings()
+}
+
+// CancelImportPrecheck 取消正在进行的导入预检
+func (a *App) CancelImportPrecheck() {
+	a.precheckMutex.Lock()
+	defer a.precheckMutex.Unlock()
+	if a.precheckCancel != nil {
+		a.precheckCancel()
+		a.precheckCancel = nil
+	}
+}
+
+// exportFormats 导出对话框中可选的格式，按显示顺序排列
+var exportFormats = []struct {
+	label  string
+	format proxy.ExportFormat
+}{
+	{"纯文本 (host:port)", proxy.FormatText},
+	{"Clash (YAML)", proxy.FormatClash},
+	{"V2Ray/Xray 出站 (JSON)", proxy.FormatV2Ray},
+	{"分享链接 (socks://、http://)", proxy.FormatShareLinks},
+	{"CSV (含检测结果)", proxy.FormatCSV},
+	{"JSON (含检测结果)", proxy.FormatJSON},
+	{"proxychains.conf", proxy.FormatProxychains},
+}
+
+// ExportProxies 导出当前显示的有效代理到文件
+// 先选择导出格式，再选择保存位置
+func (a *App) ExportProxies() {
+	proxies, err := a.rotator.GetFilteredAndSortedProxies(a.filter)
+	if err != nil {
+		a.LogError(fmt.Sprintf(lang.T("获取代理失败: %v"), err))
+		return
+	}
+	if len(proxies) == 0 {
+		dialog.ShowInformation(lang.T("无代理可导出"), lang.T("当前列表没有可导出的有效代理。"), a.win)
+		return
+	}
+
+	labels := make([]string, len(exportFormats))
+	for i, f := range exportFormats {
+		labels[i] = lang.T(f.label)
+	}
+	formatSelect := widget.NewSelect(labels, nil)
+	formatSelect.SetSelectedIndex(0)
+	dialog.ShowCustomConfirm(lang.T("导出代理"), lang.T("下一步"), lang.T("取消"), formatSelect, func(ok bool) {
+		if ok {
+			a.exportProxiesAs(exportFormats[formatSelect.SelectedIndex()].format, proxies)
+		}
+	}, a.win)
+}
+
+// exportProxiesAs 按指定格式将代理写入用户选择的文件
+func (a *App) exportProxiesAs(format proxy.ExportFormat, proxies []*proxy.Proxy) {
+	data, exported, err := proxy.Export(format, proxies)
+	if err != nil {
+		a.LogError(fmt.Sprintf(lang.T("导出代理失败: %v"), err))
+		return
+	}
+
+	fileDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil || writer == nil {
+			return
+		}
+		defer writer.Close()
+
+		if _, err := writer.Write(data); err != nil {
+			a.LogError(fmt.Sprintf(lang.T("写入导出文件失败: %v"), err))
+			return
+		}
+		message := fmt.Sprintf(lang.T("成功导出 %d 个有效代理到 %s"), exported, writer.URI().Name())
+		if skipped := len(proxies) - exported; skipped > 0 {
+			message += fmt.Sprintf(lang.T("，%d 个代理的协议不受该格式支持已跳过"), skipped)
+		}
+		a.Log(message)
+	}, a.win)
+	fileDialog.SetFileName("valid_proxies" + format.Extension())
+	fileDialog.Show()
+}
+
+// TogglePin 切换代理的固定状态
+// 固定的代理不会被自动清理，并单独保存以便重启后恢复
+func (a *App) TogglePin(p *proxy.Proxy) {
+	pinned := !p.Pinned
+	a.rotator.SetPinned(p.Address, pinned)
+
+	var err error
+	if pinned {
+		err = a.store.UpsertProxies(storage.PinnedList, []*proxy.Proxy{p})
+		a.Log(fmt.Sprintf(lang.T("已固定代理 %s"), p.Address))
+	} else {
+		err = a.store.DeleteProxies(storage.PinnedList, []*proxy.Proxy{p})
+		a.Log(fmt.Sprintf(lang.T("已取消固定代理 %s"), p.Address))
+	}
+	if err != nil {
+		a.LogError(fmt.Sprintf(lang.T("保存固定代理失败: %v"), err))
+	}
+	a.ApplyFiltersAndRefresh()
+}
+
+// TogglePremium 切换代理的高级标记
+// 高级代理可供本地服务在“只使用高级代理”模式下选用
+func (a *App) TogglePremium(p *proxy.Proxy) {
+	premium := !p.IsPremium
+	a.rotator.SetPremium(p.Address, premium)
+	if p.Pinned {
+		if err := a.store.UpsertProxies(storage.PinnedList, []*proxy.Proxy{p}); err != nil {
+			a.LogError(fmt.Sprintf(lang.T("保存固定代理失败: %v"), err))
+		}
+	}
+	if premium {
+		a.Log(fmt.Sprintf(lang.T("已将代理 %s 标记为高级"), p.Address))
+	} else {
+		a.Log(fmt.Sprintf(lang.T("已取消代理 %s 的高级标记"), p.Address))
+	}
+	a.ApplyFiltersAndRefresh()
+}
+
+// DeleteProxy 从代理池中删除单个代理，固定的代理同时取消固定
+func (a *App) DeleteProxy(p *proxy.Proxy) {
+	if !a.rotator.RemoveProxy(p.Address) {
+		return
+	}
+	if p.Pinned {
+		if err := a.store.DeleteProxies(storage.PinnedList, []*proxy.Proxy{p}); err != nil {
+			a.LogError(fmt.Sprintf(lang.T("保存固定代理失败: %v"), err))
+		}
+	}
+	a.schedulePersist()
+	a.ApplyFiltersAndRefresh()
+	a.Log(fmt.Sprintf(lang.T("已删除代理 %s"), p.Address))
+}
+
+// BlacklistProxy 将代理地址加入黑名单并从代理池中删除
+// 固定的代理同时取消固定
+func (a *App) BlacklistProxy(p *proxy.Proxy) {
+	if p.Pinned {
+		if err := a.store.DeleteProxies(storage.PinnedList, []*proxy.Proxy{p}); err != nil {
+			a.LogError(fmt.Sprintf(lang.T("保存固定代理失败: %v"), err))
+		}
+	}
+	a.AddBlacklistEntry(p.Address)
+}
+
+// GetBlacklist 返回黑名单的全部条目
+func (a *App) GetBlacklist() []string {
+	return a.rotator.GetBlacklist()
+}
+
+// AddBlacklistEntry 添加黑名单条目(代理地址、IP或CIDR)，命中的代理立即从代理池删除
+// 黑名单单独保存，之后抓取或导入的命中代理都会被忽略
+// 返回错误如果条目格式无效
+func (a *App) AddBlacklistEntry(entry string) error {
+	removed, err := a.rotator.Blacklist(entry)
+	if err != nil {
+		return err
+	}
+	a.saveBlacklist()
+	if removed > 0 {
+		a.schedulePersist()
+		a.ApplyFiltersAndRefresh()
+	}
+	a.Log(fmt.Sprintf(lang.T("已将 %s 加入黑名单，删除 %d 个代理"), strings.TrimSpace(entry), removed))
+	return nil
+}
+
+// RemoveBlacklistEntry 删除黑名单条目，之前被删除的代理需重新获取或导入
+func (a *App) RemoveBlacklistEntry(entry string) {
+	if !a.rotator.Unblacklist(entry) {
+		return
+	}
+	a.saveBlacklist()
+	a.Log(fmt.Sprintf(lang.T("已将 %s 移出黑名单"), entry))
+}
+
+// saveBlacklist 将当前黑名单写入存储
+func (a *App) saveBlacklist() {
+	if err := a.store.SaveBlacklist(a.rotator.GetBlacklist()); err != nil {
+		a.LogError(fmt.Sprintf(lang.T("保存黑名单失败: %v"), err))
+	}
+}
+
+// SetProxyTags 替换代理的标签，标签随代理池一起保存
+// 参数 tags: 新的标签，为空表示清除全部标签
+func (a *App) SetProxyTags(p *proxy.Proxy, tags []string) {
+	a.rotator.SetTags(p.Address, tags)
+	if p.Pinned {
+		if err := a.store.UpsertProxies(storage.PinnedList, []*proxy.Proxy{p}); err != nil {
+			a.LogError(fmt.Sprintf(lang.T("保存固定代理失败: %v"), err))
+		}
+	}
+	a.schedulePersist()
+	a.ApplyFiltersAndRefresh()
+	if len(p.Tags) == 0 {
+		a.Log(fmt.Sprintf(lang.T("已清除代理 %s 的标签"), p.Address))
+	} else {
+		a.Log(fmt.Sprintf(lang.T("代理 %s 的标签已设为: %s"), p.Address, strings.Join(p.Tags, ", ")))
+	}
+}
+
+// GetPools 返回全部命名代理池定义
+func (a *App) GetPools() []proxy.NamedPool {
+	return a.rotator.Pools()
+}
+
+// SavePool 添加或替换命名代理池并保存
+// 返回错误如果定义不合法
+func (a *App) SavePool(pool proxy.NamedPool) error {
+	if err := a.rotator.SetPool(pool); err != nil {
+		return err
+	}
+	a.savePools()
+	name := strings.TrimSpace(pool.Name)
+	a.Log(fmt.Sprintf(lang.T("已保存代理池 %s，当前包含 %d 个可用代理。"), name, a.rotator.PoolSize(name)))
+	return nil
+}
+
+// RemovePool 删除命名代理池并保存，绑定到该池的服务之后将选不到上游代理
+func (a *App) RemovePool(name string) {
+	if !a.rotator.RemovePool(name) {
+		return
+	}
+	a.savePools()
+	a.Log(fmt.Sprintf(lang.T("已删除代理池 %s"), name))
+	if name == a.serverPool {
+		a.Log(fmt.Sprintf(lang.T("警告: 本地服务绑定的代理池 %s 已被删除，请重新选择代理池。"), name))
+	}
+}
+
+// savePools 将当前的代理池定义写入存储
+func (a *App) savePools() {
+	if err := a.store.SavePools(a.rotator.Pools()); err != nil {
+		a.LogError(fmt.Sprintf(lang.T("保存代理池失败: %v"), err))
+	}
+}
+
+// restorePools 从存储中恢复命名代理池定义
+func (a *App) restorePools() {
+	pools, err := a.store.LoadPools()
+	if err != nil {
+		a.LogError(fmt.Sprintf(lang.T("加载代理池失败: %v"), err))
+		return
+	}
+	for _, pool := range pools {
+		if err := a.rotator.SetPool(pool); err != nil {
+			a.Log(fmt.Sprintf(lang.T("忽略无效的代理池 %s: %v"), pool.Name, err))
+		}
+	}
+}
+
+// RecheckProxy 在后台立即重新检测单个代理，检测成功的代理加入有效列表
+func (a *App) RecheckProxy(p *proxy.Proxy) {
+	a.LogDebug(fmt.Sprintf(lang.T("正在重新检测代理 %s ..."), p.Address))
+	go func() {
+		err := a.checker.RecheckOne(p)
+		a.rotator.RecordCheck(p, p.Latency, p.Speed, err)
+		fetcher.RecordValidation(p.Address, err == nil)
+		if err != nil {
+			a.LogError(fmt.Sprintf(lang.T("代理 %s 检测失败: %v"), p.Address, err))
+		} else {
+			if err := a.rotator.AddValidProxies([]*proxy.Proxy{p}); err != nil {
+				a.LogError(fmt.Sprintf(lang.T("添加有效代理失败: %v"), err))
+			}
+			a.publishValidated(p)
+			a.Log(fmt.Sprintf(lang.T("代理 %s 检测成功，延迟 %.0fms，速度 %.2fKB/s"), p.Address, p.Latency*1000, p.Speed))
+		}
+		a.schedulePersist()
+		a.ApplyFiltersAndRefresh()
+	}()
+}
+
+// restoreBlacklist 从存储中恢复黑名单，需在恢复代理池之前调用
+func (a *App) restoreBlacklist() {
+	entries, err := a.store.LoadBlacklist()
+	if err != nil {
+		a.LogError(fmt.Sprintf(lang.T("加载黑名单失败: %v"), err))
+		return
+	}
+	for _, entry := range entries {
+		if _, err := a.rotator.Blacklist(entry); err != nil {
+			a.Log(fmt.Sprintf(lang.T("忽略无效的黑名单条目: %v"), err))
+		}
+	}
+}
+
+// restorePinnedProxies 从存储中恢复固定的代理到原始和有效列表
+func (a *App) restorePinnedProxies() {
+	pinned, err := a.store.LoadProxies(storage.PinnedList)
+	if err != nil {
+		a.LogError(fmt.Sprintf(lang.T("加载固定代理失败: %v"), err))
+		return
+	}
+	if len(pinned) == 0 {
+		return
+	}
+	for _, p := range pinned {
+		p.Pinned = true
+	}
+	a.rotator.AddRawProxies(pinned)
+	if err := a.rotator.AddValidProxies(pinned); err != nil {
+		a.LogError(fmt.Sprintf(lang.T("恢复固定代理失败: %v"), err))
+		return
+	}
+	a.ApplyFiltersAndRefresh()
+	a.Log(fmt.Sprintf(lang.T("已恢复 %d 个固定代理。"), len(pinned)))
+}
+
+// loadProxySources 从启动参数指定的配置文件加载自定义代理源
+// 未指定文件时使用设置中保存的代理源；设置中没有代理源时导入一次旧版本的 data/sources.json，
+// 都没有时使用内置代理源
+func (a *App) loadProxySources() {
+	path := a.sourcesPath
+	if path == "" {
+		if a.customSources {
+			return
+		}
+		path = legacySourcesPath
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return
+	}
+	if err := fetcher.LoadSources(path); err != nil {
+		a.LogError(fmt.Sprintf(lang.T("加载代理源配置失败，使用内置代理源: %v"), err))
+		return
+	}
+	a.customSources = true
+	if a.sourcesPath == "" {
+		a.scheduleSaveSettings()
+	}
+	a.Log(fmt.Sprintf(lang.T("已从 %s 加载 %d 个代理源。"), path, len(fetcher.Sources())))
+}
+
+// loadGeoIPDatabase 加载启动参数指定的离线地理位置数据库，文件不存在时使用在线接口
+func (a *App) loadGeoIPDatabase() {
+	if _, err := os.Stat(a.geoIPPath); os.IsNotExist(err) {
+		return
+	}
+	a.SetGeoIPDatabase(a.geoIPPath)
+}
+
+// SetGeoIPDatabase 设置离线地理位置数据库(.mmdb)，路径为空时改用在线接口查询
+func (a *App) SetGeoIPDatabase(path string) {
+	if err := a.checker.SetGeoIPDatabase(path); err != nil {
+		a.LogError(fmt.Sprintf(lang.T("加载地理位置数据库失败: %v"), err))
+		return
+	}
+	if path == "" {
+		a.Log(lang.T("已关闭离线地理位置数据库，改用在线接口查询。"))
+	} else {
+		a.Log(fmt.Sprintf(lang.T("已加载离线地理位置数据库: %s"), path))
+	}
+}
+
+// ChooseGeoIPDatabase 通过文件对话框选择离线地理位置数据库
+func (a *App) ChooseGeoIPDatabase() {
+	fileDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil || reader == nil {
+			return
+		}
+		reader.Close()
+		a.SetGeoIPDatabase(reader.URI().Path())
+	}, a.win)
+	fileDialog.SetFilter(fynestorage.NewExtensionFileFilter([]string{".mmdb"}))
+	fileDialog.Show()
+}
+
+// loadASNDatabase 加载启动参数指定的离线ASN数据库，文件不存在时不查询ASN
+func (a *App) loadASNDatabase() {
+	if _, err := os.Stat(a.asnDBPath); os.IsNotExist(err) {
+		return
+	}
+	a.SetASNDatabase(a.asnDBPath)
+}
+
+// SetASNDatabase 设置离线ASN数据库(.mmdb)，路径为空时不再查询ASN
+// ASN 用于分散选择模式避开同一自治系统的代理
+func (a *App) SetASNDatabase(path string) {
+	if err := a.checker.SetASNDatabase(path); err != nil {
+		a.LogError(fmt.Sprintf(lang.T("加载ASN数据库失败: %v"), err))
+		return
+	}
+	if path == "" {
+		a.Log(lang.T("已关闭离线ASN数据库，分散选择模式只按网段和国家避让。"))
+	} else {
+		a.Log(fmt.Sprintf(lang.T("已加载离线ASN数据库: %s，下次查询地理位置时补充ASN。"), path))
+	}
+}
+
+// ChooseASNDatabase 通过文件对话框选择离线ASN数据库
+func (a *App) ChooseASNDatabase() {
+	fileDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil || reader == nil {
+			return
+		}
+		reader.Close()
+		a.SetASNDatabase(reader.URI().Path())
+	}, a.win)
+	fileDialog.SetFilter(fynestorage.NewExtensionFileFilter([]string{".mmdb"}))
+	fileDialog.Show()
+}
+
+// GetProxySources 返回当前代理源列表
+func (a *App) GetProxySources() []fetcher.ProxySource {
+	return fetcher.Sources()
+}
+
+// SetProxySources 替换代理源列表并保存
+// 启动时指定了代理源配置文件时保存到该文件，否则保存到设置文件
+func (a *App) SetProxySources(sources []fetcher.ProxySource) error {
+	if err := fetcher.SetSources(sources); err != nil {
+		return err
+	}
+	a.customSources = true
+	if a.sourcesPath == "" {
+		a.scheduleSaveSettings()
+		a.Log(fmt.Sprintf(lang.T("代理源已更新，共 %d 个。"), len(sources)))
+		return nil
+	}
+	if err := fetcher.SaveSources(a.sourcesPath); err != nil {
+		return fmt.Errorf(lang.T("保存代理源配置失败: %v"), err)
+	}
+	a.Log(fmt.Sprintf(lang.T("代理源已更新，共 %d 个，已保存到 %s。"), len(sources), a.sourcesPath))
+	return nil
+}
+
+// GetSourceStats 返回每个代理源最近一次获取的统计
+func (a *App) GetSourceStats() []fetcher.SourceStats {
+	return fetcher.SourcesStats()
+}
+
+// SetSourceEnabled 启用或停用指定地址的代理源，并像代理源管理对话框一样保存
+func (a *App) SetSourceEnabled(sourceURL string, enabled bool) error {
+	sources := fetcher.Sources()
+	found := false
+	for i := range sources {
+		if sources[i].URL == sourceURL {
+			sources[i].Disabled = !enabled
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf(lang.T("代理源 %s 不存在"), sourceURL)
+	}
+	return a.SetProxySources(sources)
+}
+
+// ClearProxies 清空所有代理，搁置待复检和被隔离的代理一并清除
+func (a *App) ClearProxies() {
+	a.rotator.SetRawProxies([]*proxy.Proxy{})
+	a.rotator.SetValidProxies([]*proxy.Proxy{})
+	a.rotator.ClearSidelined()
+	a.ApplyFiltersAndRefresh()
+	a.Log(lang.T("所有代理列表已清空。"))
+}
+
+// ToggleServer 启动或停止本地代理服务
+func (a *App) ToggleServer(host, portStr string) {
+	if running, _ := a.serverRunning.Get(); running {
+		if err := a.StopServer(); err != nil {
+			a.LogError(fmt.Sprintf(lang.T("停止服务失败: %v"), err))
+		}
+		return
+	}
+
+	err := a.StartServer(host, portStr)
+	var inUse *server.PortInUseError
+	switch {
+	case errors.As(err, &inUse):
+		port, _ := strconv.Atoi(portStr)
+		a.promptPortInUse(port)
+	case err != nil:
+		a.LogError(fmt.Sprintf(lang.T("启动服务失败: %v"), err))
+	}
+}
+
+// StartServer 按给定地址和端口启动本地代理服务
+// 端口被占用时返回 *server.PortInUseError，由调用方决定如何处理
+func (a *App) StartServer(host, portStr string) error {
+	if running, _ := a.serverRunning.Get(); running {
+		return errors.New(lang.T("服务已在运行"))
+	}
+	if a.rotator.GetValidProxyCount() == 0 {
+		return errors.New(lang.T("没有可用的有效代理来启动服务"))
+	}
+
+	host = strings.TrimSpace(host)
+	if err := server.ValidateBindHost(host); err != nil {
+		return err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port <= 0 || port > 65535 {
+		return fmt.Errorf(lang.T("端口 '%s' 无效"), portStr)
+	}
+	a.serverHost = host
+	a.serverPort = portStr
+	a.scheduleSaveSettings()
+
+	a.server = server.NewServer(host, port, a.rotator)
+	a.server.SetLogger(a.serverLogger)
+	a.server.SetConnectionHook(a.recordConnection)
+	if !a.server.IsPortAvailable(port) {
+		return &server.PortInUseError{Addr: net.JoinHostPort(host, portStr)}
+	}
+	if err := a.applyAccessRules(a.server); err != nil {
+		return fmt.Errorf(lang.T("应用访问规则失败: %v"), err)
+	}
+	a.server.SetChainMode(a.chainMode)
+	a.server.SetFixedUpstream(a.fixedUpstream())
+	a.server.SetPremiumOnly(a.premiumOnly)
+	a.server.SetTarget(a.requiredTarget)
+	a.server.SetPool(a.serverPool)
+	a.server.SetStickySessions(time.Duration(a.stickyMinutes) * time.Minute)
+	a.server.SetHostAffinity(time.Duration(a.affinityMinutes) * time.Minute)
+	a.server.SetRateLimits(int64(a.rateLimitConnKB)*1024, int64(a.rateLimitGlobalKB)*1024)
+	a.server.SetConnectionLimit(a.maxConns, a.connQueue)
+	a.server.SetListenMode(a.serverMode)
+	if err := a.server.Start(); err != nil {
+		return err
+	}
+	a.server.StartHealthChecks(healthCheckInterval)
+	a.serverRunning.Set(true)
+	a.warnPublicBind()
+	a.events.Publish(events.ServerStarted, map[string]string{"host": host, "port": portStr, "mode": string(a.serverMode)})
+	return nil
+}
+
+// StopServer 停止本地代理服务
+func (a *App) StopServer() error {
+	if running, _ := a.serverRunning.Get(); !running || a.server == nil {
+		return errors.New(lang.T("服务未在运行"))
+	}
+	if err := a.server.Stop(); err != nil {
+		return err
+	}
+	a.serverRunning.Set(false)
+	a.ToggleSystemProxy(false) // 系统代理不能继续指向已停止的服务
+	a.events.Publish(events.ServerStopped, nil)
+	return nil
+}
+
+// ToggleSystemProxy 开启或关闭操作系统级别的代理
+// 开启时将系统代理指向正在运行的本地服务，服务未运行或设置失败时保持关闭
+func (a *App) ToggleSystemProxy(enable bool) {
+	if enabled, _ := a.systemProxyStatus.Get(); enabled == enable {
+		return
+	}
+	if !enable {
+		if err := sysproxy.Clear(); err != nil {
+			a.LogError(fmt.Sprintf(lang.T("关闭系统代理失败: %v"), err))
+		} else {
+			a.Log(lang.T("系统代理已关闭。"))
+		}
+		a.systemProxyStatus.Set(false)
+		return
+	}
+
+	if running, _ := a.serverRunning.Get(); !running {
+		a.Log(lang.T("本地服务未运行，请先启动服务再启用系统代理。"))
+		a.systemProxyStatus.Set(false)
+		return
+	}
+	port, _ := strconv.Atoi(a.serverPort)
+	host := a.connectHost()
+	if err := sysproxy.Set(host, port, a.serverMode == server.ModeSOCKS5); err != nil {
+		a.LogError(fmt.Sprintf(lang.T("设置系统代理失败: %v"), err))
+		a.systemProxyStatus.Set(false)
+		return
+	}
+	a.systemProxyStatus.Set(true)
+	a.Log(fmt.Sprintf(lang.T("系统代理已指向 %s://%s。"), a.serverMode, net.JoinHostPort(host, a.serverPort)))
+	if a.authUser != "" {
+		a.Log(lang.T("提示: 本地服务已启用认证，不支持代理认证的程序将无法通过系统代理访问网络。"))
+	}
+}
+
+// connectHost 返回客户端连接本地服务时使用的地址，监听所有网卡时使用回环地址
+func (a *App) connectHost() string {
+	if ip := net.ParseIP(a.serverHost); ip != nil && ip.IsUnspecified() {
+		return "127.0.0.1"
+	}
+	return a.serverHost
+}
+
+// SubscribeEvents 订阅代理池和本地服务的状态变化事件
+// 返回事件通道和取消订阅的函数
+func (a *App) SubscribeEvents(buffer int) (<-chan events.Event, func()) {
+	return a.events.Subscribe(buffer)
+}
+
+// proxyEvent 代理相关事件附带的数据，延迟以毫秒表示
+type proxyEvent struct {
+	Address   string  `json:"address"`
+	Protocol  string  `json:"protocol"`
+	LatencyMs float64 `json:"latency_ms"`
+	Country   string  `json:"country,omitempty"`
+}
+
+// newProxyEvent 生成代理事件数据
+func newProxyEvent(p *proxy.Proxy) proxyEvent {
+	return proxyEvent{Address: p.Address, Protocol: p.Protocol, LatencyMs: p.Latency * 1000, Country: p.Country}
+}
+
+// publishValidated 发布代理检测成功事件
+func (a *App) publishValidated(p *proxy.Proxy) {
+	a.events.Publish(events.ProxyValidated, newProxyEvent(p))
+}
+
+// promptPortInUse 提示端口被占用，并询问是否改用下一个可用端口
+func (a *App) promptPortInUse(port int) {
+	a.LogError(fmt.Sprintf(lang.T("启动服务失败: 端口 %d 已被占用。"), port))
+	next := a.server.NextAvailablePort(port + 1)
+	if next == 0 {
+		dialog.ShowInformation(lang.T("端口被占用"), fmt.Sprintf(lang.T("端口 %d 已被其他程序占用，请更换端口后重试。"), port), a.win)
+		return
+	}
+	message := fmt.Sprintf(lang.T("端口 %d 已被其他程序占用。\n是否改用可用端口 %d 启动服务?"), port, next)
+	dialog.ShowConfirm(lang.T("端口被占用"), message, func(ok bool) {
+		if ok {
+			a.ToggleServer(a.serverHost, strconv.Itoa(next))
+		}
+	}, a.win)
+}
+
+// warnPublicBind 监听地址不是回环地址时提示服务暴露在网络中
+// 既没有认证也没有客户端允许列表时，任何能访问本机的设备都可以把它当作开放代理使用
+func (a *App) warnPublicBind() {
+	if server.IsLoopbackHost(a.serverHost) {
+		return
+	}
+	if a.authUser == "" && strings.TrimSpace(a.clientAllowRules) == "" {
+		a.Log(fmt.Sprintf(lang.T("警告: 服务监听于 %s 且未设置认证或允许的客户端，网络中的任何设备都可以使用此代理。"), a.serverHost))
+		return
+	}
+	a.Log(fmt.Sprintf(lang.T("提示: 服务监听于 %s，局域网内其他设备可以连接此服务。"), a.serverHost))
+}
+
+// QuickConnectString 生成可直接粘贴到终端的代理环境变量设置命令
+// 服务运行时指向本地服务，否则使用当前筛选条件下延迟最低的代理
+// 根据操作系统生成PowerShell或bash格式，没有可用代理时返回错误
+func (a *App) QuickConnectString() (string, error) {
+	var proxyURL string
+	if running, _ := a.serverRunning.Get(); running {
+		serverURL := &url.URL{Scheme: string(a.serverMode), Host: net.JoinHostPort(a.connectHost(), a.serverPort)}
+		if a.authUser != "" {
+			serverURL.User = url.UserPassword(a.authUser, a.authPass)
+		}
+		proxyURL = serverURL.String()
+	} else {
+		proxies, err := a.rotator.GetFilteredAndSortedProxies(a.filter)
+		if err != nil {
+			return "", err
+		}
+		if len(proxies) == 0 {
+			return "", errors.New(lang.T("没有可用的有效代理"))
+		}
+		best := proxies[0]
+		proxyURL = best.URL().String()
+	}
+	return proxy.EnvSnippet(proxyURL), nil
+}
+
+// SetServerMode 设置本地服务的监听协议，下次启动服务时生效
+// 参数 mode: "socks5" 或 "http"
+func (a *App) SetServerMode(mode string) {
+	a.serverMode = server.ListenMode(mode)
+	a.scheduleSaveSettings()
+	if running, _ := a.serverRunning.Get(); running {
+		a.Log(lang.T("监听协议将在重新启动服务后生效。"))
+	}
+}
+
+// SetPremiumOnly 设置本地服务是否只使用高级代理，服务运行中时立即生效
+func (a *App) SetPremiumOnly(enabled bool) {
+	a.premiumOnly = enabled
+	a.scheduleSaveSettings()
+	if a.server != nil {
+		a.server.SetPremiumOnly(enabled)
+	}
+	if enabled {
+		a.Log(lang.T("本地服务将只使用标记为高级的代理。"))
+	} else {
+		a.Log(lang.T("本地服务将使用全部有效代理。"))
+	}
+}
+
+// SetServerPool 将本地服务和定时轮换绑定到命名代理池，服务运行中时立即生效
+// 参数 name: 代理池名称，为空表示使用全部有效代理
+func (a *App) SetServerPool(name string) {
+	name = strings.TrimSpace(name)
+	if name != "" && !a.rotator.HasPool(name) {
+		a.LogError(fmt.Sprintf(lang.T("代理池 %s 不存在，请先在代理池管理中添加。"), name))
+		return
+	}
+	a.serverPool = name
+	a.scheduleSaveSettings()
+	if a.server != nil {
+		a.server.SetPool(name)
+	}
+	if name == "" {
+		a.Log(lang.T("本地服务和代理轮换将使用全部有效代理。"))
+	} else {
+		a.Log(fmt.Sprintf(lang.T("本地服务和代理轮换将只使用代理池 %s 中的代理(当前 %d 个)。"), name, a.rotator.PoolSize(name)))
+	}
+}
+
+// SetRequiredTarget 设置本地服务的上游代理必须通过的检测目标，服务运行中时立即生效
+// 参数 name: 检测设置中的目标名称，为空表示不限制
+func (a *App) SetRequiredTarget(name string) {
+	name = strings.TrimSpace(name)
+	if name != "" && !a.checker.Config().HasTarget(name) {
+		a.LogError(fmt.Sprintf(lang.T("检测目标 %s 不存在，请先在检测服务设置中添加。"), name))
+		return
+	}
+	a.requiredTarget = name
+	a.scheduleSaveSettings()
+	if a.server != nil {
+		a.server.SetTarget(name)
+	}
+	if name == "" {
+		a.Log(lang.T("本地服务的上游代理不再限制检测目标。"))
+	} else {
+		a.Log(fmt.Sprintf(lang.T("本地服务将只使用检测目标 %s 可用的代理。"), name))
+	}
+}
+
+// SetStickySessions 设置会话保持时长，服务运行中时立即生效
+// 开启后同一客户端在时长内的连接复用同一个上游代理，避免登录等会话中途更换IP
+// 参数 minutes: 会话保持时长(分钟)，0 表示关闭
+func (a *App) SetStickySessions(minutes int) {
+	if minutes < 0 {
+		minutes = 0
+	}
+	a.stickyMinutes = minutes
+	a.scheduleSaveSettings()
+	if a.server != nil {
+		a.server.SetStickySessions(time.Duration(minutes) * time.Minute)
+	}
+	if minutes == 0 {
+		a.Log(lang.T("会话保持已关闭，每个连接都将轮换代理。"))
+	} else {
+		a.Log(fmt.Sprintf(lang.T("会话保持已开启，同一客户端 %d 分钟内复用同一代理。"), minutes))
+	}
+}
+
+// SetHostAffinity 设置目标主机亲和时长，服务运行中时立即生效
+// 开启后同一目标主机在时长内的连接复用同一个上游代理，适合会标记频繁换IP的网站
+// 参数 minutes: 亲和时长(分钟)，0 表示关闭
+func (a *App) SetHostAffinity(minutes int) {
+	if minutes < 0 {
+		minutes = 0
+	}
+	a.affinityMinutes = minutes
+	a.scheduleSaveSettings()
+	if a.server != nil {
+		a.server.SetHostAffinity(time.Duration(minutes) * time.Minute)
+	}
+	if minutes == 0 {
+		a.Log(lang.T("目标主机亲和已关闭。"))
+	} else {
+		a.Log(fmt.Sprintf(lang.T("目标主机亲和已开启，同一目标主机 %d 分钟内复用同一代理。"), minutes))
+	}
+}
+
+// SetRateLimits 设置本地服务转发限速，上传和下载方向分别计算，对之后建立的连接生效
+// 参数 connKB: 每个连接的速率上限(KB/s)，0 表示不限制
+// 参数 globalKB: 所有连接合计的速率上限(KB/s)，0 表示不限制
+func (a *App) SetRateLimits(connKB, globalKB int) {
+	if connKB < 0 {
+		connKB = 0
+	}
+	if globalKB < 0 {
+		globalKB = 0
+	}
+	a.rateLimitConnKB = connKB
+	a.rateLimitGlobalKB = globalKB
+	a.scheduleSaveSettings()
+	if a.server != nil {
+		a.server.SetRateLimits(int64(connKB)*1024, int64(globalKB)*1024)
+	}
+	a.reconfigureListeners()
+	a.Log(fmt.Sprintf(lang.T("转发限速: 单连接 %s，全局 %s。"), formatRateLimit(connKB), formatRateLimit(globalKB)))
+}
+
+// SetConnectionLimit 设置本地服务同时处理的最大连接数，服务运行中时立即生效
+// 每个监听器分别计算上限
+// 参数 max: 并发上限，0 表示不限制
+// 参数 queue: 达到上限后最多排队的连接数，0 表示直接拒绝
+func (a *App) SetConnectionLimit(max, queue int) {
+	if max < 0 {
+		max = 0
+	}
+	if queue < 0 {
+		queue = 0
+	}
+	a.maxConns = max
+	a.connQueue = queue
+	a.scheduleSaveSettings()
+	if a.server != nil {
+		a.server.SetConnectionLimit(max, queue)
+	}
+	a.reconfigureListeners()
+	if max == 0 {
+		a.Log(lang.T("并发连接数已不限制。"))
+	} else {
+		a.Log(fmt.Sprintf(lang.T("并发连接上限: %d，最多排队 %d 个。"), max, queue))
+	}
+}
+
+// SetProxyUsageLimits 设置单个上游代理的并发连接上限和再次选用的冷却时间，对之后的新连接立即生效
+// 所有代理都超出限制时仍会选用承载连接最少的代理，不会因此拒绝客户端连接
+// 参数 maxConns: 单个代理同时承载的最大连接数，0 表示不限制
+// 参数 cooldownSeconds: 代理被选用后再次被选用前的最短间隔(秒)，0 表示不限制
+func (a *App) SetProxyUsageLimits(maxConns, cooldownSeconds int) {
+	if maxConns < 0 {
+		maxConns = 0
+	}
+	if cooldownSeconds < 0 {
+		cooldownSeconds = 0
+	}
+	a.proxyMaxConns = maxConns
+	a.proxyCooldownSeconds = cooldownSeconds
+	a.scheduleSaveSettings()
+	a.rotator.SetUsageLimits(a.usageLimits())
+	if maxConns == 0 && cooldownSeconds == 0 {
+		a.Log(lang.T("单代理使用已不限制。"))
+	} else {
+		a.Log(fmt.Sprintf(lang.T("单代理并发上限: %d，冷却时间: %d 秒 (0 表示不限制)。"), maxConns, cooldownSeconds))
+	}
+}
+
+// usageLimits 返回当前设置对应的单代理使用限制
+func (a *App) usageLimits() proxy.UsageLimits {
+	return proxy.UsageLimits{
+		MaxConns: a.proxyMaxConns,
+		Cooldown: time.Duration(a.proxyCooldownSeconds) * time.Second,
+	}
+}
+
+// GetConnectionGauge 返回本地服务(含附加监听器)正在处理和排队的连接数，以及每个监听器的并发上限
+func (a *App) GetConnectionGauge() (active, queued, max int) {
+	if running, _ := a.serverRunning.Get(); running && a.server != nil {
+		active, queued = a.server.ConnectionStats()
+	}
+	extraActive, extraQueued := a.listeners.ConnectionStats()
+	return active + extraActive, queued + extraQueued, a.maxConns
+}
+
+// formatRateLimit 格式化限速值，0 显示为不限制
+func formatRateLimit(kb int) string {
+	if kb == 0 {
+		return lang.T("不限制")
+	}
+	return fmt.Sprintf("%d KB/s", kb)
+}
+
+// SetChainMode 开启或关闭双代理链式转发，服务运行中时立即生效
+func (a *App) SetChainMode(enabled bool) {
+	a.chainMode = enabled
+	a.scheduleSaveSettings()
+	if a.server != nil {
+		a.server.SetChainMode(enabled)
+	}
+	if enabled {
+		a.Log(lang.T("已开启双代理链式转发，连接延迟将约为单跳的两倍。"))
+	} else {
+		a.Log(lang.T("已关闭双代理链式转发。"))
+	}
+}
+
+// SetFollowRotation 开启或关闭固定当前代理模式，服务运行中时立即生效
+// 开启后本地服务的新连接都使用定时轮换选出的代理，轮换才真正改变出口IP；
+// 轮换未启用时立即选出一个代理，直到开启轮换或关闭该模式都固定使用它
+func (a *App) SetFollowRotation(enabled bool) {
+	a.followRotation = enabled
+	a.scheduleSaveSettings()
+	if enabled {
+		a.rotatedMutex.Lock()
+		current := a.rotatedProxy
+		a.rotatedMutex.Unlock()
+		if current == nil || !a.rotator.IsValid(current) {
+			a.rotateProxy()
+		}
+	}
+	if a.server != nil {
+		a.server.SetFixedUpstream(a.fixedUpstream())
+	}
+	if enabled {
+		a.Log(lang.T("已开启固定当前代理，本地服务的新连接将使用轮换选出的代理。"))
+	} else {
+		a.Log(lang.T("已关闭固定当前代理，本地服务将为每个连接选择代理。"))
+	}
+}
+
+// fixedUpstream 返回本地服务应固定使用的上游代理，未开启固定当前代理时为nil
+func (a *App) fixedUpstream() *proxy.Proxy {
+	if !a.followRotation {
+		return nil
+	}
+	a.rotatedMutex.Lock()
+	defer a.rotatedMutex.Unlock()
+	return a.rotatedProxy
+}
+
+// rotateProxy 选出下一个代理作为当前代理，开启固定当前代理时同时切换本地服务的上游
+// 固定当前代理时按本地服务的高级代理限制选择
+func (a *App) rotateProxy() {
+	p := a.rotator.GetNextProxy("", a.followRotation && a.premiumOnly, a.serverPool)
+	if p == nil {
+		return
+	}
+	a.useRotatedProxy(p)
+	a.LogDebug(fmt.Sprintf(lang.T("已轮换到新代理: %s"), p.Address))
+}
+
+// useRotatedProxy 将代理设为当前代理，开启固定当前代理时同时设为本地服务的上游
+func (a *App) useRotatedProxy(p *proxy.Proxy) {
+	a.rotatedMutex.Lock()
+	a.rotatedProxy = p
+	a.rotatedMutex.Unlock()
+	if a.followRotation && a.server != nil {
+		a.server.SetFixedUpstream(p)
+	}
+	a.currentProxy.Set(p.Address)
+	a.events.Publish(events.ProxyRotated, newProxyEvent(p))
+}
+
+// nextProxyAttempts 手动切换代理时最多检测的候选代理数
+const nextProxyAttempts = 5
+
+// NextProxy 立即切换到下一个代理，用于当前出口IP被封禁时手动更换IP
+// 候选代理先检测连通性，成功后才切换并显示新的出口IP；
+// 检测失败的代理记为失败并继续尝试下一个，最多尝试 nextProxyAttempts 个
+func (a *App) NextProxy() {
+	go func() {
+		for attempt := 0; attempt < nextProxyAttempts; attempt++ {
+			p := a.rotator.GetNextProxy("", a.followRotation && a.premiumOnly, a.serverPool)
+			if p == nil {
+				a.LogError(lang.T("没有可切换的有效代理"))
+				return
+			}
+			_, _, err := a.checker.CheckConnectivityAndSpeed(p)
+			a.rotator.RecordCheck(p, p.Latency, p.Speed, err)
+			fetcher.RecordValidation(p.Address, err == nil)
+			if err != nil {
+				a.Log(fmt.Sprintf(lang.T("代理 %s 检测失败，尝试下一个: %v"), p.Address, err))
+				continue
+			}
+			a.useRotatedProxy(p)
+			a.schedulePersist()
+			a.Log(fmt.Sprintf(lang.T("已切换到代理 %s，出口IP: %s"), p.Address, p.ExitDescription()))
+			if a.server != nil && !a.followRotation {
+				a.Log(lang.T("未开启固定当前代理，本地服务仍为每个连接选择代理。"))
+			}
+			return
+		}
+		a.LogError(fmt.Sprintf(lang.T("连续 %d 个代理检测失败，未切换代理"), nextProxyAttempts))
+	}()
+}
+
+// SetAccessRules 设置本地服务的访问规则
+// 规则以逗号或空白分隔，支持IP、CIDR和域名；服务运行中时立即生效
+func (a *App) SetAccessRules(targetBlock, targetAllow, clientAllow, clientDeny string) {
+	for _, rules := range []string{targetBlock, targetAllow, clientAllow, clientDeny} {
+		if _, err := server.ParseAccessList(rules); err != nil {
+			a.LogError(fmt.Sprintf(lang.T("访问规则无效: %v"), err))
+			return
+		}
+	}
+	a.targetBlockRules = targetBlock
+	a.targetAllowRules = targetAllow
+	a.clientAllowRules = clientAllow
+	a.clientDenyRules = clientDeny
+	a.scheduleSaveSettings()
+
+	if running, _ := a.serverRunning.Get(); running && a.server != nil {
+		if err := a.applyAccessRules(a.server); err != nil {
+			a.LogError(fmt.Sprintf(lang.T("应用访问规则失败: %v"), err))
+			return
+		}
+	}
+	a.reconfigureListeners()
+	a.Log(lang.T("访问规则已更新。"))
+}
+
+// applyAccessRules 将已保存的访问规则应用到服务实例
+func (a *App) applyAccessRules(srv *server.Server) error {
+	if err := srv.SetTargetBlocklist(a.targetBlockRules); err != nil {
+		return err
+	}
+	if err := srv.SetTargetAllowlist(a.targetAllowRules); err != nil {
+		return err
+	}
+	if err := srv.SetClientAllowlist(a.clientAllowRules); err != nil {
+		return err
+	}
+	if err := srv.SetClientDenylist(a.clientDenyRules); err != nil {
+		return err
+	}
+	srv.SetAuth(a.authUser, a.authPass)
+	return nil
+}
+
+// configureListener 将认证、访问规则和限速应用到附加监听器
+func (a *App) configureListener(srv *server.Server) error {
+	if err := a.applyAccessRules(srv); err != nil {
+		return err
+	}
+	srv.SetRateLimits(int64(a.rateLimitConnKB)*1024, int64(a.rateLimitGlobalKB)*1024)
+	srv.SetConnectionHook(a.recordConnection)
+	srv.SetConnectionLimit(a.maxConns, a.connQueue)
+	return nil
+}
+
+// recordConnection 保存一条连接记录供界面展示，超过 maxConnLog 条时丢弃最旧的记录
+func (a *App) recordConnection(rec server.ConnRecord) {
+	a.connLogMutex.Lock()
+	defer a.connLogMutex.Unlock()
+	a.connLog = append(a.connLog, rec)
+	if len(a.connLog) > maxConnLog {
+		a.connLog = append([]server.ConnRecord(nil), a.connLog[len(a.connLog)-maxConnLog:]...)
+	}
+}
+
+// GetConnectionLog 返回最近的连接记录，最新的在前
+func (a *App) GetConnectionLog() []server.ConnRecord {
+	a.connLogMutex.Lock()
+	defer a.connLogMutex.Unlock()
+	records := make([]server.ConnRecord, len(a.connLog))
+	for i, rec := range a.connLog {
+		records[len(records)-1-i] = rec
+	}
+	return records
+}
+
+// reconfigureListeners 让运行中的附加监听器使用最新的共用设置
+func (a *App) reconfigureListeners() {
+	if err := a.listeners.Reconfigure(); err != nil {
+		a.LogError(fmt.Sprintf(lang.T("更新附加监听器设置失败: %v"), err))
+	}
+}
+
+// GetListeners 返回运行中的附加监听器
+func (a *App) GetListeners() []server.ListenerConfig {
+	return a.listeners.List()
+}
+
+// StartListener 启动一个附加监听器，与主服务同时运行
+func (a *App) StartListener(config server.ListenerConfig) error {
+	if a.rotator.GetValidProxyCount() == 0 {
+		return errors.New(lang.T("没有可用的有效代理来启动服务"))
+	}
+	if err := a.listeners.Start(config); err != nil {
+		return err
+	}
+	a.Log(fmt.Sprintf(lang.T("附加监听器已在 %s 启动，选择策略: %s。"), config.Addr(), config.Strategy.Label()))
+	if !server.IsLoopbackHost(config.Host) && a.authUser == "" && strings.TrimSpace(a.clientAllowRules) == "" {
+		a.Log(fmt.Sprintf(lang.T("警告: 附加监听器 %s 未设置认证或允许的客户端，网络中的任何设备都可以使用此代理。"), config.Addr()))
+	}
+	return nil
+}
+
+// StopListener 停止指定地址的附加监听器
+func (a *App) StopListener(addr string) error {
+	if err := a.listeners.Stop(addr); err != nil {
+		return err
+	}
+	a.Log(fmt.Sprintf(lang.T("附加监听器 %s 已停止。"), addr))
+	return nil
+}
+
+// GetClientACL 返回本地服务的客户端允许列表和拒绝列表
+func (a *App) GetClientACL() (allow, deny []string) {
+	return server.SplitRules(a.clientAllowRules), server.SplitRules(a.clientDenyRules)
+}
+
+// SetClientACL 替换本地服务的客户端允许列表和拒绝列表，服务运行中时立即生效
+// 参数 allow: 允许连接的客户端IP/CIDR，为空表示不限制
+// 参数 deny: 拒绝连接的客户端IP/CIDR，优先于允许列表
+func (a *App) SetClientACL(allow, deny []string) error {
+	allowRules, denyRules := strings.Join(allow, ", "), strings.Join(deny, ", ")
+	for _, rules := range []string{allowRules, denyRules} {
+		if _, err := server.ParseAccessList(rules); err != nil {
+			return err
+		}
+	}
+	a.clientAllowRules = allowRules
+	a.clientDenyRules = denyRules
+	a.scheduleSaveSettings()
+	if running, _ := a.serverRunning.Get(); running && a.server != nil {
+		if err := a.applyAccessRules(a.server); err != nil {
+			return err
+		}
+	}
+	if err := a.listeners.Reconfigure(); err != nil {
+		return err
+	}
+	a.Log(fmt.Sprintf(lang.T("客户端访问控制已更新: 允许 %d 条，拒绝 %d 条。"), len(allow), len(deny)))
+	return nil
+}
+
+// SetServerAuth 设置本地服务的认证用户名和密码，用户名为空表示关闭认证
+// 服务运行中时立即生效
+func (a *App) SetServerAuth(user, pass string) {
+	if user == "" && pass != "" {
+		a.LogError(lang.T("设置认证失败: 用户名不能为空。"))
+		return
+	}
+	if len(user) > 255 || len(pass) > 255 {
+		a.LogError(lang.T("设置认证失败: 用户名和密码不能超过255字节。"))
+		return
+	}
+	a.authUser = user
+	a.authPass = pass
+	a.scheduleSaveSettings()
+	if running, _ := a.serverRunning.Get(); running && a.server != nil {
+		a.server.SetAuth(user, pass)
+	}
+	a.reconfigureListeners()
+	if user == "" {
+		a.Log(lang.T("本地服务认证已关闭。"))
+	} else {
+		a.Log(fmt.Sprintf(lang.T("本地服务认证已启用，用户名: %s"), user))
+	}
+}
+
+// TestLocalServer 通过本地SOCKS5服务发起一次请求，验证整条代理链路
+func (a *App) TestLocalServer() {
+	running, _ := a.serverRunning.Get()
+	if !running || a.server == nil {
+		a.Log(lang.T("本地服务未运行，请先启动服务。"))
+		return
+	}
+	go func() {
+		a.Log(lang.T("正在通过本地服务测试连通性..."))
+		report, err := a.server.SelfTest()
+		if err != nil {
+			a.LogError(fmt.Sprintf(lang.T("本地服务测试失败: %v"), err))
+			return
+		}
+		upstreamAddr := lang.T("未知")
+		if report.Upstream != nil {
+			upstreamAddr = fmt.Sprintf("%s://%s", report.Upstream.Protocol, report.Upstream.Address)
+		}
+		a.Log(fmt.Sprintf(lang.T("本地服务测试成功，出口IP: %s，上游代理: %s"), report.ExitIP, upstreamAddr))
+	}()
+}
+
+// VerifyExit 通过本地服务发起请求，返回观察到的出口IP及其地理位置和请求延迟
+// 请求经过监听器、轮换和上游转发，验证整条链路按当前配置工作；会阻塞直到请求和位置查询完成
+func (a *App) VerifyExit() (*server.ExitReport, error) {
+	running, _ := a.serverRunning.Get()
+	if !running || a.server == nil {
+		return nil, errors.New(lang.T("本地服务未运行，请先启动服务"))
+	}
+	report, err := a.server.SelfTest()
+	if err != nil {
+		a.LogError(fmt.Sprintf(lang.T("验证出口IP失败: %v"), err))
+		return nil, err
+	}
+	report.Country, report.Province, report.City = a.checker.LookupIPLocation(context.Background(), report.ExitIP)
+	a.Log(fmt.Sprintf(lang.T("出口IP验证成功: %s，延迟 %dms"), report.ExitIP, report.Latency.Milliseconds()))
+	return report, nil
+}
+
+func main() {
+	storageBackend := flag.String("storage", "", "代理存储后端: json 或 sqlite，为空时使用设置中保存的后端；指定后保存到设置")
+	settingsPath := flag.String("config", "", "设置文件路径，为空时使用用户配置目录下的 go_proxy/settings.json")
+	sourcesPath := flag.String("sources", "", "自定义代理源配置文件(JSON或YAML)，为空时代理源保存在设置文件中")
+	apiAddr := flag.String("api", "", "管理API监听地址(例如 127.0.0.1:8080)，为空时不启用")
+	apiToken := flag.String("api-token", "", "管理API访问令牌，为空时自动生成随机令牌并输出到日志；监听非回环地址时必须指定")
+	headless := flag.Bool("headless", false, "无界面运行，只通过管理API控制(需同时指定 -api 或 -judge)")
+	judgeAddr := flag.String("judge", "", "自建匿名度判断服务监听地址(例如 0.0.0.0:8088)，为空时不启用")
+	listenHost := flag.String("listen", "", "本地代理服务的监听地址(127.0.0.1、0.0.0.0 或网卡IP)，为空时使用设置中保存的地址")
+	logLevel := flag.String("log-level", "info", "本地服务日志级别: debug、info、warn 或 error")
+	logJSON := flag.Bool("log-json", false, "本地服务日志以JSON格式输出")
+	logFile := flag.String("log-file", filepath.Join(dataDir, "server.log"), "本地服务日志文件，按大小轮转，为空时只输出到终端")
+	geoIPPath := flag.String("geoip", filepath.Join(dataDir, "GeoLite2-City.mmdb"), "离线地理位置数据库(MaxMind .mmdb)，不存在时使用在线接口")
+	asnDBPath := flag.String("asn-db", filepath.Join(dataDir, "GeoLite2-ASN.mmdb"), "离线ASN数据库(MaxMind .mmdb)，不存在时不查询ASN")
+	flag.Parse()
+	if *headless && *apiAddr == "" && *judgeAddr == "" {
+		log.Fatal("无界面模式需要通过 -api 指定管理API监听地址，或通过 -judge 只运行匿名度判断服务")
+	}
+	if *listenHost != "" {
+		if err := server.ValidateBindHost(*listenHost); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *apiAddr != "" && *apiToken == "" {
+		if !api.IsLoopbackAddr(*apiAddr) {
+			log.Fatalf("管理API监听非回环地址 %s 时必须通过 -api-token 指定访问令牌", *apiAddr)
+		}
+		token, err := api.GenerateToken()
+		if err != nil {
+			log.Fatalf("生成管理API访问令牌失败: %v", err)
+		}
+		*apiToken = token
+		log.Printf("未指定 -api-token，本次运行的管理API访问令牌: %s", token)
+	}
+	if *settingsPath == "" {
+		path, err := config.DefaultPath()
+		if err != nil {
+			log.Printf("无法确定用户配置目录，设置将不会保存: %v", err)
+		}
+		*settingsPath = path
+	}
+	serverLogger, logCloser, err := logging.NewLogger(logging.Config{
+		Level:     *logLevel,
+		JSON:      *logJSON,
+		File:      *logFile,
+		MaxSizeMB: serverLogMaxSizeMB,
+		Backups:   serverLogBackups,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	myApp := NewApp(*storageBackend, *settingsPath)
+	myApp.sourcesPath = *sourcesPath
+	if *listenHost != "" {
+		myApp.serverHost = *listenHost
+	}
+	myApp.serverLogger = serverLogger
+	myApp.listeners.SetLogger(serverLogger)
+	myApp.geoIPPath = *geoIPPath
+	myApp.loadGeoIPDatabase()
+	myApp.asnDBPath = *asnDBPath
+	myApp.loadASNDatabase()
+	myApp.progressBar.Hide()
+
+	go func() {
+		myApp.Log(lang.T("正在初始化，获取本机公网IP..."))
+		if err := myApp.checker.InitializePublicIP(); err != nil {
+			myApp.LogError(fmt.Sprintf(lang.T("获取公网IP失败: %v"), err))
+		} else {
+			myApp.Log(lang.T("公网IP初始化成功。"))
+		}
+	}()
+
+	var apiServer *api.Server
+	if *apiAddr != "" {
+		apiServer = api.NewServer(*apiAddr, *apiToken, myApp)
+		if err := apiServer.Start(); err != nil {
+			log.Fatal(err)
+		}
+		myApp.Log(fmt.Sprintf(lang.T("管理API已在 %s 启动，访问令牌: %s"), *apiAddr, *apiToken))
+	}
+
+	var judgeServer *http.Server
+	if *judgeAddr != "" {
+		judgeServer, err = startJudgeServer(*judgeAddr)
+		if err != nil {
+			log.Fatal(err)
+		}
+		myApp.Log(fmt.Sprintf(lang.T("匿名度判断服务已在 %s 启动，可将检测设置中的匿名度判断地址设为 http://<公网IP>:<端口>/"), *judgeAddr))
+	}
+
+	if *headless {
+		myApp.restoreBlacklist()
+		myApp.restorePools()
+		myApp.restorePool()
+		myApp.restorePinnedProxies()
+		myApp.loadProxySources()
+		log.Printf("无界面模式运行中，管理API: %s，判断服务: %s，按 Ctrl+C 退出", *apiAddr, *judgeAddr)
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		<-signals
+	} else {
+		ui.SetupUI(myApp)
+		ui.SetupTray(myApp)
+		myApp.restoreBlacklist()
+		myApp.restorePools()
+		myApp.restorePool()
+		myApp.restorePinnedProxies()
+		myApp.loadProxySources()
+		myApp.win.ShowAndRun()
+	}
+	if apiServer != nil {
+		apiServer.Stop()
+	}
+	if judgeServer != nil {
+		judgeServer.Close()
+	}
+	myApp.listeners.StopAll()
+	myApp.ToggleSystemProxy(false)
+	myApp.persistMutex.Lock()
+	if myApp.persistTimer != nil {
+		myApp.persistTimer.Stop()
+	}
+	myApp.persistMutex.Unlock()
+	myApp.settingsMutex.Lock()
+	if myApp.settingsTimer != nil {
+		myApp.settingsTimer.Stop()
+	}
+	myApp.settingsMutex.Unlock()
+	myApp.saveSettings()
+	if myApp.autoPersist {
+		if err := myApp.savePool(); err != nil {
+			log.Printf("保存代理池失败: %v", err)
+		}
+	}
+	if err := myApp.store.Close(); err != nil {
+		log.Printf("关闭存储失败: %v", err)
+	}
+	if logCloser != nil {
+		logCloser.Close()
+	}
+	log.Println("应用已退出")
+}
+
+// startJudgeServer 在指定地址启动自建的匿名度判断服务
+// 返回错误如果地址无法监听
+func startJudgeServer(addr string) (*http.Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("匿名度判断服务监听失败: %v", err)
+	}
+	srv := &http.Server{Handler: checker.JudgeHandler(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("匿名度判断服务异常退出: %v", err)
+		}
+	}()
+	return srv, nil
+}
+
+// --- 实现 api.Controller 接口 ---
+
+// ListProxies 返回符合筛选条件的有效代理，按延迟升序
+func (a *App) ListProxies(filter proxy.Filter) []*proxy.Proxy {
+	proxies, _ := a.rotator.GetFilteredAndSortedProxies(filter)
+	return proxies
+}
+
+// AddProxyLines 解析代理文本并加入原始列表，返回新增数量、无法解析的行数和每行的错误
+func (a *App) AddProxyLines(lines []string) (added, skipped int, lineErrors []proxy.LineError) {
+	parsed, _, skipped, lineErrors := parseProxyLines(lines)
+	added = a.rotator.AddRawProxies(parsed)
+	a.schedulePersist()
+	a.Log(fmt.Sprintf(lang.T("通过API导入 %d 个代理，跳过 %d 行无效内容。"), added, skipped))
+	return added, skipped, lineErrors
+}
+
+// RemoveProxies 按地址删除代理并刷新列表，返回删除数量
+func (a *App) RemoveProxies(addresses []string) int {
+	removed := a.rotator.RemoveProxies(addresses)
+	if removed > 0 {
+		a.ApplyFiltersAndRefresh()
+		a.Log(fmt.Sprintf(lang.T("已删除 %d 个代理。"), removed))
+	}
+	return removed
+}
+
+// ServerStatus 返回本地代理服务的运行状态和监听设置
+func (a *App) ServerStatus() (running bool, host, port, mode string) {
+	running, _ = a.serverRunning.Get()
+	return running, a.serverHost, a.serverPort, string(a.serverMode)
+}
+
+// --- 实现 ui.Apper 接口 ---
+func (a *App) GetWindow() fyne.Window                        { return a.win }
+func (a *App) GetProxyList() binding.UntypedList             { return a.proxyList }
+func (a *App) GetListRevision() binding.Int                  { return a.listRevision }
+func (a *App) GetLogRevision() binding.Int                   { return a.logRevision }
+func (a *App) GetProgressBar() *widget.ProgressBar           { return a.progressBar }
+func (a *App) GetServerStatus() binding.Bool                 { return a.serverRunning }
+func (a *App) GetRotationStatus() binding.Bool               { return a.rotationStatus }
+func (a *App) GetSystemProxyStatus() binding.Bool            { return a.systemProxyStatus }
+func (a *App) GetCurrentProxy() binding.String               { return a.currentProxy }
+func (a *App) GetProxyHistory(address string) []proxy.Sample { return a.rotator.GetHistory(address) }
+func (a *App) GetAutoRefreshStatus() binding.Bool            { return a.autoRefreshStatus }
+func (a *App) GetServerHost() string                         { return a.serverHost }
+func (a *App) GetServerPort() string                         { return a.serverPort }
+func (a *App) GetAutoPersist() bool                          { return a.autoPersist }
+func (a *App) GetServerMode() string                         { return string(a.serverMode) }
+func (a *App) GetPoolStats() proxy.PoolStats                 { return a.rotator.Stats(freshWindow) }
+func (a *App) GetTrafficStats() proxy.TrafficStats           { return a.rotator.TrafficStats() }
+
+// ToggleRotation 切换代理轮换状态
+func (a *App) ToggleRotation(enable bool) {
+	if enable {
+		a.startRotation()
+	} else {
+		a.stopRotation()
+	}
+}
+
+// SetRotationInterval 设置轮换间隔时间(秒)
 func (a *App) SetRotationInterval(seconds int) {
 	if seconds <= 0 {
 		return
 	}
 	a.rotationSeconds = seconds
-	a.Log(fmt.Sprintf("轮换间隔已设置为 %d 秒", seconds))
+	a.scheduleSaveSettings()
+	a.Log(fmt.Sprintf(lang.T("轮换间隔已设置为 %d 秒"), seconds))
 	if running, _ := a.rotationStatus.Get(); running {
 		a.stopRotation()
 		a.startRotation()
 	}
 }
 
+// GetTestSettings 返回当前的批量测试设置
+func (a *App) GetTestSettings() checker.TestSettings {
+	return checker.TestSettings{
+		Concurrency:    a.testConcurrency,
+		ConnectTimeout: a.checker.ConnectTimeout(),
+		CheckTimeout:   a.checker.Timeout(),
+		SpeedTestSize:  a.checker.Config().SpeedTestSize,
+	}
+}
+
+// SetTestSettings 修改批量测试的并发数、超时和测速大小并保存到设置文件
+// 对之后开始的测试生效
+func (a *App) SetTestSettings(s checker.TestSettings) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	cfg := a.checker.Config()
+	cfg.SpeedTestSize = s.SpeedTestSize
+	if err := a.checker.SetConfig(cfg); err != nil {
+		return err
+	}
+	a.testConcurrency = s.Concurrency
+	a.checker.SetTimeout(s.CheckTimeout)
+	a.checker.SetConnectTimeout(s.ConnectTimeout)
+	a.scheduleSaveSettings()
+	a.Log(fmt.Sprintf(lang.T("测试设置已更新: 并发 %d，连接超时 %v，检测超时 %v，测速 %d KB。"),
+		s.Concurrency, s.ConnectTimeout, s.CheckTimeout, s.SpeedTestSize>>10))
+	return nil
+}
+
+// SetCheckRetries 设置检测偶发失败(超时、连接重置)后的重试次数和首次重试间隔
+func (a *App) SetCheckRetries(retries int, backoffSeconds float64) {
+	a.checker.SetRetryPolicy(retries, secondsToDuration(backoffSeconds))
+	a.scheduleSaveSettings()
+	retries, backoff := a.checker.RetryPolicy()
+	if retries == 0 {
+		a.Log(lang.T("检测失败后不再重试。"))
+		return
+	}
+	a.Log(fmt.Sprintf(lang.T("检测偶发失败时最多重试 %d 次，首次间隔 %v，之后每次翻倍。"), retries, backoff))
+}
+
+// SetLatencySamples 设置每次检测的延迟采样次数，用于计算抖动和稳定性
+func (a *App) SetLatencySamples(n int) {
+	a.checker.SetLatencySamples(n)
+	a.scheduleSaveSettings()
+	a.Log(fmt.Sprintf(lang.T("每次检测将采样 %d 次延迟。"), a.checker.LatencySamples()))
+}
+
+// GetCheckConfig 返回当前的检测服务配置
+func (a *App) GetCheckConfig() checker.Config {
+	return a.checker.Config()
+}
+
+// SetCheckConfig 替换检测服务配置，对之后开始的检测生效
+func (a *App) SetCheckConfig(cfg checker.Config) error {
+	if err := a.checker.SetConfig(cfg); err != nil {
+		return err
+	}
+	a.scheduleSaveSettings()
+	a.Log(fmt.Sprintf(lang.T("检测服务已更新: 连通性 %s，匿名度 %s，测速 %s，检测目标 %d 个"),
+		cfg.ConnectivityURL, orNone(cfg.JudgeURL), orNone(cfg.SpeedTestURL), len(cfg.Targets)))
+	if a.requiredTarget != "" && !cfg.HasTarget(a.requiredTarget) {
+		a.Log(fmt.Sprintf(lang.T("警告: 本地服务要求的检测目标 %s 已被删除，在重新检测前将没有可用的上游代理。"), a.requiredTarget))
+	}
+	return nil
+}
+
+// orNone 空字符串显示为“不检测”
+func orNone(s string) string {
+	if s == "" {
+		return lang.T("不检测")
+	}
+	return s
+}
+
+// ToggleRevalidation 开启或关闭有效代理的定期复检
+func (a *App) ToggleRevalidation(enable bool) {
+	if enable == a.revalidator.Running() {
+		return
+	}
+	if enable {
+		a.revalidator.Start()
+		a.Log(lang.T("定期复检已启动。"))
+	} else {
+		a.revalidator.Stop()
+		a.Log(lang.T("定期复检已停止。"))
+	}
+}
+
+// SetRevalidation 设置定期复检的间隔(分钟)和并发数
+func (a *App) SetRevalidation(minutes, workers int) {
+	if minutes <= 0 || workers <= 0 {
+		a.LogError(lang.T("复检间隔和并发数必须为正数。"))
+		return
+	}
+	a.revalidator.Configure(time.Duration(minutes)*time.Minute, workers)
+	a.scheduleSaveSettings()
+	a.Log(fmt.Sprintf(lang.T("定期复检设置为每 %d 分钟一次，并发 %d。"), minutes, workers))
+}
+
+// beginTask 标记一个获取/测试任务开始
+// 返回任务应遵守的上下文，没有其他任务在运行时创建新的上下文
+func (a *App) beginTask() context.Context {
+	a.taskMutex.Lock()
+	defer a.taskMutex.Unlock()
+	if atomic.AddInt32(&a.activeTasks, 1) == 1 || a.taskCtx == nil {
+		a.taskCtx, a.taskCancel = context.WithCancel(context.Background())
+	}
+	return a.taskCtx
+}
+
+// endTask 标记一个获取/测试任务结束，最后一个任务结束时释放上下文
+func (a *App) endTask() {
+	a.taskMutex.Lock()
+	defer a.taskMutex.Unlock()
+	if atomic.AddInt32(&a.activeTasks, -1) == 0 && a.taskCancel != nil {
+		a.taskCancel()
+		a.taskCtx, a.taskCancel = nil, nil
+	}
+}
+
+// CancelTasks 取消正在进行的获取和测试任务
+// 进行中的请求立即中止，已完成的获取和检测结果保留
+func (a *App) CancelTasks() {
+	a.taskMutex.Lock()
+	defer a.taskMutex.Unlock()
+	if a.taskCancel == nil {
+		a.Log(lang.T("当前没有正在进行的获取或测试任务。"))
+		return
+	}
+	a.taskCancel()
+	a.Log(lang.T("正在取消获取/测试任务..."))
+}
+
+// ToggleAutoRefresh 开启或关闭自动获取并测试代理
+func (a *App) ToggleAutoRefresh(enable bool) {
+	running, _ := a.autoRefreshStatus.Get()
+	if enable == running {
+		return
+	}
+	if enable {
+		a.startAutoRefresh()
+	} else {
+		a.stopAutoRefresh()
+	}
+}
+
+// SetAutoRefreshInterval 设置自动获取并测试的间隔(分钟)
+func (a *App) SetAutoRefreshInterval(minutes int) {
+	if minutes <= 0 {
+		return
+	}
+	a.autoRefreshMinutes = minutes
+	a.scheduleSaveSettings()
+	a.Log(fmt.Sprintf(lang.T("自动刷新间隔已设置为 %d 分钟"), minutes))
+	if running, _ := a.autoRefreshStatus.Get(); running {
+		a.stopAutoRefresh()
+		a.startAutoRefresh()
+	}
+}
+
+// SetAutoRefreshTest 设置自动获取后是否自动测试新增代理
+func (a *App) SetAutoRefreshTest(enabled bool) {
+	a.autoRefreshTest = enabled
+	a.scheduleSaveSettings()
+}
+
+// startAutoRefresh 启动自动获取并测试的定时任务
+func (a *App) startAutoRefresh() {
+	a.autoRefreshStatus.Set(true)
+	a.autoRefreshTicker = time.NewTicker(time.Duration(a.autoRefreshMinutes) * time.Minute)
+	ticker, stop := a.autoRefreshTicker, a.autoRefreshStop
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				a.autoRefreshCycle()
+			case <-stop:
+				return
+			}
+		}
+	}()
+	a.Log(fmt.Sprintf(lang.T("自动刷新已启动，间隔 %d 分钟"), a.autoRefreshMinutes))
+}
+
+// stopAutoRefresh 停止自动获取并测试的定时任务
+// 正在执行的一轮任务会继续完成
+func (a *App) stopAutoRefresh() {
+	a.autoRefreshStatus.Set(false)
+	if a.autoRefreshTicker != nil {
+		a.autoRefreshTicker.Stop()
+	}
+	close(a.autoRefreshStop)
+	a.autoRefreshStop = make(chan struct{})
+	a.Log(lang.T("自动刷新已停止"))
+}
+
+// autoRefreshCycle 执行一轮自动刷新：获取新代理、(可选)只测试新增代理、清理失效代理
+// 已有获取或测试任务在运行时跳过本轮
+func (a *App) autoRefreshCycle() {
+	if atomic.LoadInt32(&a.activeTasks) > 0 {
+		a.Log(lang.T("自动刷新: 已有获取或测试任务在运行，跳过本轮。"))
+		return
+	}
+	ctx := a.beginTask()
+	defer a.endTask()
+
+	a.Log(lang.T("自动刷新: 开始获取代理..."))
+	proxies, err := fetcher.FetchAllProxies(ctx)
+	if err != nil {
+		a.LogError(fmt.Sprintf(lang.T("自动刷新: 获取代理时发生错误: %v"), err))
+	}
+	added := a.rotator.AddRawProxies(proxies)
+	if ctx.Err() != nil {
+		a.ApplyFiltersAndRefresh()
+		a.Log(fmt.Sprintf(lang.T("自动刷新已取消，保留已获取的代理，新增 %d 个。"), added))
+		return
+	}
+
+	var untested []*proxy.Proxy
+	if a.autoRefreshTest {
+		untested = a.rotator.GetUntestedProxies()
+		if len(untested) > 0 {
+			a.runTests(untested, false)
+		}
+	}
+
+	before := a.rotator.GetValidProxyCount()
+	a.rotator.CleanupProxies(autoCleanupMaxAge)
+	removedValid := before - a.rotator.GetValidProxyCount()
+	removedRaw := a.rotator.PruneDeadRawProxies()
+	a.ApplyFiltersAndRefresh()
+
+	a.Log(fmt.Sprintf(lang.T("自动刷新完成: 新增 %d 个代理，测试 %d 个，清理失效有效代理 %d 个、原始代理 %d 个，当前有效 %d 个。"),
+		added, len(untested), removedValid, removedRaw, a.rotator.GetValidProxyCount()))
+}
+
 // startRotation 开始代理轮换
 func (a *App) startRotation() {
 	a.rotationStatus.Set(true)
@@ -384,17 +3009,13 @@ func (a *App) startRotation() {
 		for {
 			select {
 			case <-a.rotationTicker.C:
-				proxy := a.rotator.GetNextProxy("", false)
-				if proxy != nil {
-					a.currentProxy.Set(proxy.Address)
-					a.Log(fmt.Sprintf("已轮换到新代理: %s", proxy.Address))
-				}
+				a.rotateProxy()
 			case <-a.rotationStop:
 				return
 			}
 		}
 	}()
-	a.Log(fmt.Sprintf("代理轮换已启动，间隔 %d 秒", a.rotationSeconds))
+	a.Log(fmt.Sprintf(lang.T("代理轮换已启动，间隔 %d 秒"), a.rotationSeconds))
 }
 
 // stopRotation 停止代理轮换
@@ -405,5 +3026,5 @@ func (a *App) stopRotation() {
 	}
 	close(a.rotationStop)
 	a.rotationStop = make(chan struct{})
-	a.Log("代理轮换已停止")
+	a.Log(lang.T("代理轮换已停止"))
 }
@@ -0,0 +1,122 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// blacklist 代理黑名单，条目可以是代理地址(host:port)、IP 或 CIDR 网段
+// addresses: 精确匹配的代理地址
+// networks: IP 和 CIDR 条目，键为规范化后的条目文本，IP 条目以单地址网段保存
+type blacklist struct {
+	addresses map[string]bool
+	networks  map[string]*net.IPNet
+}
+
+// newBlacklist 创建空黑名单
+func newBlacklist() *blacklist {
+	return &blacklist{
+		addresses: make(map[string]bool),
+		networks:  make(map[string]*net.IPNet),
+	}
+}
+
+// add 添加条目，返回规范化后的条目文本
+func (b *blacklist) add(entry string) (string, error) {
+	key, network, err := parseBlacklistEntry(entry)
+	if err != nil {
+		return "", err
+	}
+	if network != nil {
+		b.networks[key] = network
+	} else {
+		b.addresses[key] = true
+	}
+	return key, nil
+}
+
+// remove 删除条目，返回条目此前是否存在
+func (b *blacklist) remove(entry string) bool {
+	key, network, err := parseBlacklistEntry(entry)
+	if err != nil {
+		return false
+	}
+	if network != nil {
+		_, ok := b.networks[key]
+		delete(b.networks, key)
+		return ok
+	}
+	ok := b.addresses[key]
+	delete(b.addresses, key)
+	return ok
+}
+
+// matches 判断代理地址是否命中黑名单
+// 地址与条目完全相同，或主机为IP且落在某个IP/CIDR条目内即视为命中
+func (b *blacklist) matches(address string) bool {
+	if b.addresses[strings.ToLower(address)] {
+		return true
+	}
+	if len(b.networks) == 0 {
+		return false
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, network := range b.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// entries 返回全部条目，按文本排序
+func (b *blacklist) entries() []string {
+	list := make([]string, 0, len(b.addresses)+len(b.networks))
+	for addr := range b.addresses {
+		list = append(list, addr)
+	}
+	for key := range b.networks {
+		list = append(list, key)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// parseBlacklistEntry 解析并规范化黑名单条目
+// CIDR 和 IP 条目返回对应的网段，代理地址条目返回的网段为nil
+func parseBlacklistEntry(entry string) (string, *net.IPNet, error) {
+	entry = strings.TrimSpace(entry)
+	if entry == "" {
+		return "", nil, errors.New("黑名单条目不能为空")
+	}
+	if strings.Contains(entry, "/") {
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return "", nil, fmt.Errorf("无效的CIDR网段 %q", entry)
+		}
+		return network.String(), network, nil
+	}
+	if ip := net.ParseIP(entry); ip != nil {
+		bits := 128
+		if ip4 := ip.To4(); ip4 != nil {
+			ip = ip4
+			bits = 32
+		}
+		return ip.String(), &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	host, port, err := net.SplitHostPort(entry)
+	if err != nil || host == "" || port == "" {
+		return "", nil, fmt.Errorf("无效的黑名单条目 %q，应为 IP、CIDR 或 host:port", entry)
+	}
+	return net.JoinHostPort(strings.ToLower(host), port), nil, nil
+}
@@ -0,0 +1,73 @@
+package proxy
+
+import (
+	"net"
+	"strings"
+)
+
+// sameFunc 判断两个代理在某一维度上是否相同
+type sameFunc func(a, b *Proxy) bool
+
+// diversityLevels 分散模式依次尝试的避让条件
+// 先要求网段、ASN、国家都不同；筛不出候选时先放宽国家，再放宽ASN，只保留网段不同
+var diversityLevels = [][]sameFunc{
+	{sameSubnet, sameASN, sameCountry},
+	{sameSubnet, sameASN},
+	{sameSubnet},
+}
+
+// filterDiverse 返回与 previous 不在同一网段、ASN和国家的候选
+// 没有满足全部条件的候选时按 diversityLevels 逐级放宽，仍然没有时返回全部候选
+// 参数 previous: 上一次选中的代理，为nil时不做限制
+func filterDiverse(candidates []*Proxy, previous *Proxy) []*Proxy {
+	if previous == nil {
+		return candidates
+	}
+	for _, level := range diversityLevels {
+		var matched []*Proxy
+		for _, p := range candidates {
+			if p.Address != previous.Address && !sameAny(p, previous, level) {
+				matched = append(matched, p)
+			}
+		}
+		if len(matched) > 0 {
+			return matched
+		}
+	}
+	return candidates
+}
+
+// sameAny 判断两个代理是否在任一给定维度上相同
+func sameAny(a, b *Proxy, checks []sameFunc) bool {
+	for _, same := range checks {
+		if same(a, b) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameSubnet 判断两个代理是否位于同一网段：IPv4 比较 /16，IPv6 比较 /32
+// 地址不是IP字面量时按主机名比较
+func sameSubnet(a, b *Proxy) bool {
+	ipA, ipB := net.ParseIP(a.Host()), net.ParseIP(b.Host())
+	if ipA == nil || ipB == nil {
+		return strings.EqualFold(a.Host(), b.Host())
+	}
+	v4A, v4B := ipA.To4(), ipB.To4()
+	if v4A != nil || v4B != nil {
+		return v4A != nil && v4B != nil && v4A[0] == v4B[0] && v4A[1] == v4B[1]
+	}
+	mask := net.CIDRMask(32, 128)
+	return ipA.Mask(mask).Equal(ipB.Mask(mask))
+}
+
+// sameCountry 判断两个代理是否位于同一国家，国家未知时视为不同
+func sameCountry(a, b *Proxy) bool {
+	return a.Country != "" && strings.EqualFold(a.Country, b.Country)
+}
+
+// sameASN 判断两个代理是否属于同一自治系统，ASN未知时视为不同
+func sameASN(a, b *Proxy) bool {
+	return a.ASN != 0 && a.ASN == b.ASN
+}
@@ -0,0 +1,222 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ExportFormat 代理导出格式
+type ExportFormat string
+
+const (
+	FormatText        ExportFormat = "text"        // 每行一个 host:port，带认证的代理为 user:pass@host:port
+	FormatClash       ExportFormat = "clash"       // Clash/Clash Meta 的 proxies 配置
+	FormatV2Ray       ExportFormat = "v2ray"       // V2Ray/Xray 的 outbounds 配置
+	FormatShareLinks  ExportFormat = "links"       // 每行一个 socks://、http:// 分享链接
+	FormatCSV         ExportFormat = "csv"         // 带表头的CSV，包含延迟、速度、匿名度等检测结果
+	FormatJSON        ExportFormat = "json"        // 包含检测结果的JSON数组
+	FormatProxychains ExportFormat = "proxychains" // 完整的 proxychains.conf
+)
+
+// Extension 返回导出格式对应的文件扩展名
+func (f ExportFormat) Extension() string {
+	switch f {
+	case FormatClash:
+		return ".yaml"
+	case FormatV2Ray, FormatJSON:
+		return ".json"
+	case FormatCSV:
+		return ".csv"
+	case FormatProxychains:
+		return ".conf"
+	default:
+		return ".txt"
+	}
+}
+
+// Export 按指定格式编码代理列表
+// 返回编码后的内容、实际导出的数量(该格式不支持的代理会被跳过)和可能的错误
+func Export(format ExportFormat, proxies []*Proxy) ([]byte, int, error) {
+	switch format {
+	case FormatText:
+		var b strings.Builder
+		for _, p := range proxies {
+			if p.Username != "" {
+				b.WriteString(url.UserPassword(p.Username, p.Password).String() + "@")
+			}
+			b.WriteString(p.Address)
+			b.WriteByte('\n')
+		}
+		return []byte(b.String()), len(proxies), nil
+	case FormatClash:
+		return exportClash(proxies)
+	case FormatV2Ray:
+		return exportV2Ray(proxies)
+	case FormatShareLinks:
+		return exportShareLinks(proxies)
+	case FormatCSV:
+		return exportCSV(proxies)
+	case FormatProxychains:
+		return exportProxychains(proxies)
+	case FormatJSON:
+		data, err := json.MarshalIndent(exportRecords(proxies), "", "  ")
+		return data, len(proxies), err
+	default:
+		return nil, 0, fmt.Errorf("不支持的导出格式: %s", format)
+	}
+}
+
+// exportRecord CSV和JSON导出的单个代理，不包含认证凭据
+// LatencyMs/SpeedKBps: 延迟(毫秒)和速度(KB/s)，0 表示未测量
+// LastChecked: 最近一次检测的时间(RFC 3339)，未检测时为空
+type exportRecord struct {
+	Address       string   `json:"address"`
+	Protocol      string   `json:"protocol"`
+	LatencyMs     float64  `json:"latency_ms"`
+	SpeedKBps     float64  `json:"speed_kbps"`
+	Anonymity     string   `json:"anonymity"`
+	Country       string   `json:"country"`
+	City          string   `json:"city"`
+	Score         float64  `json:"score"`
+	Stability     float64  `json:"stability"`
+	SupportsHTTPS bool     `json:"supports_https"`
+	ExitIP        string   `json:"exit_ip"`
+	Premium       bool     `json:"premium"`
+	FailCount     int      `json:"fail_count"`
+	LastChecked   string   `json:"last_checked"`
+	Tags          []string `json:"tags"`
+}
+
+// exportRecords 将代理转换为导出记录，数值保留到导出精度
+func exportRecords(proxies []*Proxy) []exportRecord {
+	records := make([]exportRecord, 0, len(proxies))
+	for _, p := range proxies {
+		r := exportRecord{
+			Address:       p.Address,
+			Protocol:      p.Protocol,
+			LatencyMs:     math.Round(p.Latency * 1000),
+			SpeedKBps:     math.Round(p.Speed*100) / 100,
+			Anonymity:     p.Anonymity,
+			Country:       p.Country,
+			City:          p.City,
+			Score:         math.Round(p.Score*10) / 10,
+			Stability:     math.Round(p.Stability),
+			SupportsHTTPS: p.SupportsHTTPS,
+			ExitIP:        p.ExitIP,
+			Premium:       p.IsPremium,
+			FailCount:     p.FailCount,
+			Tags:          p.Tags,
+		}
+		if !p.LastChecked.IsZero() {
+			r.LastChecked = p.LastChecked.Format(time.RFC3339)
+		}
+		if r.Tags == nil {
+			r.Tags = []string{}
+		}
+		records = append(records, r)
+	}
+	return records
+}
+
+// csvHeader CSV导出的表头，与 exportRecord 的字段一一对应
+var csvHeader = []string{
+	"address", "protocol", "latency_ms", "speed_kbps", "anonymity", "country", "city",
+	"score", "stability", "supports_https", "exit_ip", "premium", "fail_count", "last_checked", "tags",
+}
+
+// exportCSV 生成带表头的CSV，多个标签以 ; 分隔
+func exportCSV(proxies []*Proxy) ([]byte, int, error) {
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	if err := w.Write(csvHeader); err != nil {
+		return nil, 0, err
+	}
+	formatFloat := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	for _, r := range exportRecords(proxies) {
+		row := []string{
+			r.Address, r.Protocol, formatFloat(r.LatencyMs), formatFloat(r.SpeedKBps), r.Anonymity, r.Country, r.City,
+			formatFloat(r.Score), formatFloat(r.Stability), strconv.FormatBool(r.SupportsHTTPS), r.ExitIP,
+			strconv.FormatBool(r.Premium), strconv.Itoa(r.FailCount), r.LastChecked, strings.Join(r.Tags, ";"),
+		}
+		if err := w.Write(row); err != nil {
+			return nil, 0, err
+		}
+	}
+	w.Flush()
+	return b.Bytes(), len(proxies), w.Error()
+}
+
+// clashProxy Clash 配置中的单个代理条目
+type clashProxy struct {
+	Name     string `yaml:"name"`
+	Type     string `yaml:"type"`
+	Server   string `yaml:"server"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username,omitempty"`
+	Password string `yaml:"password,omitempty"`
+	TLS      bool   `yaml:"tls,omitempty"`
+	UDP      bool   `yaml:"udp,omitempty"`
+}
+
+// exportClash 生成 Clash 的 proxies 配置
+// Clash 不支持 SOCKS4，此类代理会被跳过；名称由国家和延迟生成，重复时追加序号
+func exportClash(proxies []*Proxy) ([]byte, int, error) {
+	entries := make([]clashProxy, 0, len(proxies))
+	names := make(map[string]int)
+	for _, p := range proxies {
+		entry := clashProxy{Server: p.Host(), Username: p.Username, Password: p.Password}
+		switch strings.ToLower(p.Protocol) {
+		case "http":
+			entry.Type = "http"
+		case "https":
+			entry.Type = "http"
+			entry.TLS = true
+		case "socks5":
+			entry.Type = "socks5"
+			entry.UDP = true
+		default:
+			continue
+		}
+		port, ok := proxyPort(p)
+		if !ok {
+			continue
+		}
+		entry.Port = port
+
+		entry.Name = clashName(p)
+		names[entry.Name]++
+		if n := names[entry.Name]; n > 1 {
+			entry.Name = fmt.Sprintf("%s #%d", entry.Name, n)
+		}
+		entries = append(entries, entry)
+	}
+
+	data, err := yaml.Marshal(struct {
+		Proxies []clashProxy `yaml:"proxies"`
+	}{entries})
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, len(entries), nil
+}
+
+// clashName 根据国家和延迟生成代理名称，例如 "美国 120ms"
+func clashName(p *Proxy) string {
+	country := p.Country
+	if country == "" {
+		country = "未知"
+	}
+	if p.Latency <= 0 {
+		return country
+	}
+	return fmt.Sprintf("%s %.0fms", country, p.Latency*1000)
+}
@@ -0,0 +1,88 @@
+package proxy
+
+import "strings"
+
+// Filter 有效代理的筛选条件
+// MaxLatency: 最大允许延迟(秒，-1表示不限制)
+// MinSpeed: 最小允许速度(KB/s，-1表示不限制)
+// RemoteDNSOnly: 是否只保留在远端解析DNS的代理
+// HTTPSOnly: 是否只保留已验证支持HTTPS的代理
+// IPVersion: 只保留IPv4(4)或IPv6(6)地址的代理，0表示不限制
+// Target: 只保留该检测目标可用的代理，空表示不限制
+// Tag: 只保留带有该标签的代理，空表示不限制
+// Search: 搜索关键字，地址、协议、国家或匿名度中包含该子串(不区分大小写)的代理才保留
+// Protocol/Country/Anonymity: 列筛选，只保留该列取值相同的代理，空表示不限制
+type Filter struct {
+	MaxLatency    float64 `json:"max_latency"`
+	MinSpeed      float64 `json:"min_speed"`
+	RemoteDNSOnly bool    `json:"remote_dns_only"`
+	HTTPSOnly     bool    `json:"https_only"`
+	IPVersion     int     `json:"ip_version"`
+	Target        string  `json:"target,omitempty"`
+	Tag           string  `json:"tag,omitempty"`
+	Search        string  `json:"search,omitempty"`
+	Protocol      string  `json:"protocol,omitempty"`
+	Country       string  `json:"country,omitempty"`
+	Anonymity     string  `json:"anonymity,omitempty"`
+}
+
+// NoFilter 返回不做任何限制的筛选条件
+func NoFilter() Filter {
+	return Filter{MaxLatency: -1, MinSpeed: -1}
+}
+
+// Match 判断代理是否满足筛选条件
+func (f Filter) Match(p *Proxy) bool {
+	if f.MaxLatency >= 0 && p.Latency > f.MaxLatency {
+		return false
+	}
+	if f.MinSpeed >= 0 && p.Speed < f.MinSpeed {
+		return false
+	}
+	if f.RemoteDNSOnly && !p.RemoteDNS {
+		return false
+	}
+	if f.HTTPSOnly && !p.SupportsHTTPS {
+		return false
+	}
+	if f.Target != "" && !p.TargetChecks[f.Target] {
+		return false
+	}
+	if f.Tag != "" && !p.HasTag(f.Tag) {
+		return false
+	}
+	if f.Protocol != "" && !strings.EqualFold(p.Protocol, f.Protocol) {
+		return false
+	}
+	if f.Country != "" && p.Country != f.Country {
+		return false
+	}
+	if f.Anonymity != "" && !strings.EqualFold(p.Anonymity, f.Anonymity) {
+		return false
+	}
+	if f.Search != "" && !matchSearch(p, f.Search) {
+		return false
+	}
+	switch f.IPVersion {
+	case 4:
+		if p.IsIPv6() {
+			return false
+		}
+	case 6:
+		if !p.IsIPv6() {
+			return false
+		}
+	}
+	return true
+}
+
+// matchSearch 判断代理的地址、协议、国家或匿名度是否包含搜索关键字(不区分大小写)
+func matchSearch(p *Proxy, search string) bool {
+	search = strings.ToLower(strings.TrimSpace(search))
+	for _, field := range []string{p.Address, p.Protocol, p.Country, p.Anonymity} {
+		if strings.Contains(strings.ToLower(field), search) {
+			return true
+		}
+	}
+	return false
+}
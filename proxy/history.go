@@ -0,0 +1,124 @@
+package proxy
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// maxHistorySamples 每个代理保留的最近检测样本数
+const maxHistorySamples = 50
+
+// Sample 单次检测结果样本
+// Latency: 延迟(秒)
+// Speed: 速度(KB/s)，0表示本次未测速
+// Success: 本次检测是否成功
+type Sample struct {
+	Time    time.Time
+	Latency float64
+	Speed   float64
+	Success bool
+}
+
+// sampleRing 固定容量的样本环形缓冲区，写满后覆盖最旧的样本
+type sampleRing struct {
+	samples []Sample
+	next    int
+}
+
+// add 追加一个样本
+func (r *sampleRing) add(s Sample) {
+	if len(r.samples) < maxHistorySamples {
+		r.samples = append(r.samples, s)
+		return
+	}
+	r.samples[r.next] = s
+	r.next = (r.next + 1) % maxHistorySamples
+}
+
+// list 按时间先后返回所有样本的副本
+func (r *sampleRing) list() []Sample {
+	out := make([]Sample, 0, len(r.samples))
+	out = append(out, r.samples[r.next:]...)
+	out = append(out, r.samples[:r.next]...)
+	return out
+}
+
+// HistoryStats 检测历史的汇总统计
+// 延迟和速度只统计成功的样本，速度忽略未测速的样本
+type HistoryStats struct {
+	Count      int
+	Successes  int
+	MinLatency float64
+	AvgLatency float64
+	MaxLatency float64
+	MinSpeed   float64
+	AvgSpeed   float64
+	MaxSpeed   float64
+}
+
+// SuccessRate 返回检测成功率(0-1)
+func (h HistoryStats) SuccessRate() float64 {
+	if h.Count == 0 {
+		return 0
+	}
+	return float64(h.Successes) / float64(h.Count)
+}
+
+// SummarizeHistory 计算样本的最小/平均/最大延迟和速度
+func SummarizeHistory(samples []Sample) HistoryStats {
+	stats := HistoryStats{Count: len(samples)}
+	var latencySum, speedSum float64
+	var speedCount int
+	for _, s := range samples {
+		if !s.Success {
+			continue
+		}
+		stats.Successes++
+		latencySum += s.Latency
+		if stats.Successes == 1 || s.Latency < stats.MinLatency {
+			stats.MinLatency = s.Latency
+		}
+		stats.MaxLatency = math.Max(stats.MaxLatency, s.Latency)
+
+		if s.Speed > 0 {
+			speedCount++
+			speedSum += s.Speed
+			if speedCount == 1 || s.Speed < stats.MinSpeed {
+				stats.MinSpeed = s.Speed
+			}
+			stats.MaxSpeed = math.Max(stats.MaxSpeed, s.Speed)
+		}
+	}
+	if stats.Successes > 0 {
+		stats.AvgLatency = latencySum / float64(stats.Successes)
+	}
+	if speedCount > 0 {
+		stats.AvgSpeed = speedSum / float64(speedCount)
+	}
+	return stats
+}
+
+// sparkBlocks 迷你折线图使用的字符，从低到高
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// LatencySparkline 将样本延迟绘制为文本迷你折线图
+// 失败的样本显示为 ×
+func LatencySparkline(samples []Sample) string {
+	stats := SummarizeHistory(samples)
+	span := stats.MaxLatency - stats.MinLatency
+
+	var b strings.Builder
+	for _, s := range samples {
+		if !s.Success {
+			b.WriteRune('×')
+			continue
+		}
+		level := 0
+		if span > 0 {
+			level = int((s.Latency - stats.MinLatency) / span * float64(len(sparkBlocks)-1))
+		}
+		b.WriteRune(sparkBlocks[level])
+	}
+	return b.String()
+}
@@ -0,0 +1,113 @@
+package proxy
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	xproxy "golang.org/x/net/proxy"
+)
+
+// init 向 golang.org/x/net/proxy 注册 http 和 https 协议
+// 注册后 xproxy.FromURL 可以为HTTP代理创建通过 CONNECT 建立隧道的拨号器
+func init() {
+	xproxy.RegisterDialerType("http", newHTTPConnectDialer(false))
+	xproxy.RegisterDialerType("https", newHTTPConnectDialer(true))
+}
+
+// httpConnectTimeout 与HTTP代理完成 CONNECT 握手的超时时间
+const httpConnectTimeout = 10 * time.Second
+
+// httpConnectDialer HTTP CONNECT 隧道拨号器
+// addr: 上游代理地址(host:port)
+// useTLS: 是否以TLS连接代理本身(https 协议，与检测时 net/http 的行为一致)
+// user: 代理认证信息，为nil时不发送 Proxy-Authorization
+// forward: 连接上游代理使用的拨号器
+type httpConnectDialer struct {
+	addr    string
+	useTLS  bool
+	user    *url.Userinfo
+	forward xproxy.Dialer
+}
+
+// newHTTPConnectDialer 返回供 xproxy.RegisterDialerType 使用的构造函数
+func newHTTPConnectDialer(useTLS bool) func(*url.URL, xproxy.Dialer) (xproxy.Dialer, error) {
+	return func(u *url.URL, forward xproxy.Dialer) (xproxy.Dialer, error) {
+		return &httpConnectDialer{addr: u.Host, useTLS: useTLS, user: u.User, forward: forward}, nil
+	}
+}
+
+// Dial 通过HTTP代理的 CONNECT 方法连接到目标地址
+// 代理返回2xx以外的状态码时返回错误
+func (d *httpConnectDialer) Dial(network, addr string) (net.Conn, error) {
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+	default:
+		return nil, errors.New("HTTP代理不支持的网络类型: " + network)
+	}
+
+	conn, err := d.forward.Dial("tcp", d.addr)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(httpConnectTimeout))
+	if d.useTLS {
+		host, _, _ := net.SplitHostPort(d.addr)
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: host})
+		if err := tlsConn.Handshake(); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("与HTTPS代理握手失败: %v", err)
+		}
+		conn = tlsConn
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if d.user != nil {
+		password, _ := d.user.Password()
+		req.SetBasicAuth(d.user.Username(), password)
+		req.Header.Set("Proxy-Authorization", req.Header.Get("Authorization"))
+		req.Header.Del("Authorization")
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		conn.Close()
+		return nil, fmt.Errorf("HTTP代理拒绝连接: %s", resp.Status)
+	}
+	conn.SetDeadline(time.Time{})
+
+	if reader.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, reader: reader}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn 先返回握手时已读入缓冲区的数据，再从底层连接读取
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
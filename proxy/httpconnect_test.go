@@ -0,0 +1,103 @@
+package proxy
+
+import (
+	"bufio"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+
+	xproxy "golang.org/x/net/proxy"
+)
+
+// fakeConnectProxy 启动只处理一个 CONNECT 请求的本地HTTP代理，收到的请求发送到返回的通道
+// 参数 status: 对 CONNECT 请求返回的状态码
+func fakeConnectProxy(t *testing.T, status int) (string, <-chan *http.Request) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("监听失败: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	requests := make(chan *http.Request, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		req, err := http.ReadRequest(bufio.NewReader(conn))
+		if err != nil {
+			return
+		}
+		requests <- req
+		resp := &http.Response{StatusCode: status, ProtoMajor: 1, ProtoMinor: 1}
+		resp.Write(conn)
+		if status == http.StatusOK {
+			conn.Write([]byte("pong"))
+		}
+	}()
+	return ln.Addr().String(), requests
+}
+
+func dialHTTPConnect(t *testing.T, proxyURL, target string) (net.Conn, error) {
+	t.Helper()
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		t.Fatalf("解析代理URL失败: %v", err)
+	}
+	dialer, err := xproxy.FromURL(u, xproxy.Direct)
+	if err != nil {
+		t.Fatalf("创建拨号器失败: %v", err)
+	}
+	return dialer.Dial("tcp", target)
+}
+
+func TestHTTPConnectDial(t *testing.T) {
+	addr, requests := fakeConnectProxy(t, http.StatusOK)
+	conn, err := dialHTTPConnect(t, "http://"+addr, "example.com:443")
+	if err != nil {
+		t.Fatalf("CONNECT 失败: %v", err)
+	}
+	defer conn.Close()
+
+	req := <-requests
+	if req.Method != http.MethodConnect || req.RequestURI != "example.com:443" {
+		t.Errorf("请求行 = %s %s，期望 CONNECT example.com:443", req.Method, req.RequestURI)
+	}
+	if auth := req.Header.Get("Proxy-Authorization"); auth != "" {
+		t.Errorf("未设置认证信息时不应发送 Proxy-Authorization，实际 %q", auth)
+	}
+	buf := make([]byte, 4)
+	if _, err := conn.Read(buf); err != nil || string(buf) != "pong" {
+		t.Errorf("隧道数据 = %q (%v)，期望 \"pong\"", buf, err)
+	}
+}
+
+func TestHTTPConnectDialAuth(t *testing.T) {
+	addr, requests := fakeConnectProxy(t, http.StatusOK)
+	conn, err := dialHTTPConnect(t, "http://user:pass@"+addr, "example.com:443")
+	if err != nil {
+		t.Fatalf("CONNECT 失败: %v", err)
+	}
+	conn.Close()
+
+	req := <-requests
+	want := "Basic " + base64.StdEncoding.EncodeToString([]byte("user:pass"))
+	if auth := req.Header.Get("Proxy-Authorization"); auth != want {
+		t.Errorf("Proxy-Authorization = %q，期望 %q", auth, want)
+	}
+	if auth := req.Header.Get("Authorization"); auth != "" {
+		t.Errorf("不应发送 Authorization，实际 %q", auth)
+	}
+}
+
+func TestHTTPConnectDialRejected(t *testing.T) {
+	addr, _ := fakeConnectProxy(t, http.StatusProxyAuthRequired)
+	conn, err := dialHTTPConnect(t, "http://"+addr, "example.com:443")
+	if err == nil {
+		conn.Close()
+		t.Fatal("代理返回407时应返回错误")
+	}
+}
@@ -0,0 +1,139 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// ErrCommentLine 注释行(以 # 或 // 开头)，导入时直接忽略，不计为错误
+var ErrCommentLine = errors.New("注释行")
+
+// LineError 导入时某一行的解析错误
+// Line: 行号，从1开始
+// Text: 该行的原始内容(已去除首尾空白)
+// Err: 解析失败的原因
+type LineError struct {
+	Line int
+	Text string
+	Err  error
+}
+
+// Error 返回带行号的错误描述
+func (e LineError) Error() string {
+	return fmt.Sprintf("第 %d 行 %q: %v", e.Line, e.Text, e.Err)
+}
+
+// ParseProxyLine 解析一行代理文本
+// 支持以下格式，未指定协议时默认为http:
+//   - host:port 或 user:pass@host:port
+//   - scheme://[user:pass@]host:port，兼容 V2Ray 生态的分享链接(socks:// 视为SOCKS5，忽略备注和参数)
+//   - CSV: protocol,host,port[,user,pass] 或 host,port[,protocol[,user,pass]]
+//
+// 以 # 或 // 开头的行返回 ErrCommentLine
+// 返回解析出的代理或格式错误
+func ParseProxyLine(line string) (*Proxy, error) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return nil, errors.New("空行")
+	}
+	if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+		return nil, ErrCommentLine
+	}
+	if !strings.Contains(line, "://") && strings.Contains(line, ",") {
+		return parseCSVLine(line)
+	}
+
+	protocol := "http"
+	var username, password string
+	if i := strings.Index(line, "://"); i >= 0 {
+		var err error
+		protocol, line, username, password, err = parseShareLink(strings.ToLower(line[:i]), line[i+3:])
+		if err != nil {
+			return nil, err
+		}
+	} else if i := strings.LastIndex(line, "@"); i >= 0 {
+		username, password = splitUserinfo(line[:i])
+		line = line[i+1:]
+	}
+	return newParsedProxy(protocol, strings.TrimSuffix(line, "/"), username, password)
+}
+
+// parseCSVLine 解析逗号分隔的代理行
+// 首列为协议名时按 protocol,host,port[,user,pass] 解析，否则按 host,port[,protocol[,user,pass]] 解析；
+// 首列为 host:port 时端口列省略
+func parseCSVLine(line string) (*Proxy, error) {
+	fields := strings.Split(line, ",")
+	for i := range fields {
+		fields[i] = strings.Trim(strings.TrimSpace(fields[i]), `"`)
+	}
+
+	protocol := "http"
+	if isProxyProtocol(fields[0]) {
+		protocol = strings.ToLower(fields[0])
+		fields = fields[1:]
+	}
+	if len(fields) == 0 || fields[0] == "" {
+		return nil, errors.New("CSV行缺少主机")
+	}
+
+	address := fields[0]
+	if _, _, err := net.SplitHostPort(address); err == nil {
+		fields = fields[1:]
+	} else {
+		if len(fields) < 2 {
+			return nil, errors.New("CSV行缺少端口")
+		}
+		address = net.JoinHostPort(fields[0], fields[1])
+		fields = fields[2:]
+	}
+
+	// 协议不在首列时可以出现在地址之后
+	if protocol == "http" && len(fields) > 0 && isProxyProtocol(fields[0]) {
+		protocol = strings.ToLower(fields[0])
+		fields = fields[1:]
+	}
+	var username, password string
+	if len(fields) > 0 {
+		username = fields[0]
+	}
+	if len(fields) > 1 {
+		password = fields[1]
+	}
+	return newParsedProxy(protocol, address, username, password)
+}
+
+// isProxyProtocol 判断字符串是否为支持的代理协议名(不区分大小写)
+func isProxyProtocol(s string) bool {
+	switch strings.ToLower(s) {
+	case "http", "https", "socks4", "socks4a", "socks5":
+		return true
+	}
+	return false
+}
+
+// newParsedProxy 校验协议和 host:port 并创建代理
+func newParsedProxy(protocol, hostport, username, password string) (*Proxy, error) {
+	if !isProxyProtocol(protocol) {
+		return nil, fmt.Errorf("不支持的代理协议: %s", protocol)
+	}
+	host, port, err := net.SplitHostPort(hostport)
+	if err != nil {
+		return nil, fmt.Errorf("无效的代理地址 %q: %v", hostport, err)
+	}
+	if host == "" {
+		return nil, fmt.Errorf("无效的代理地址 %q: 缺少主机", hostport)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n <= 0 || n > 65535 {
+		return nil, fmt.Errorf("无效的代理端口 %q", port)
+	}
+
+	return &Proxy{
+		Address:  net.JoinHostPort(host, port),
+		Protocol: protocol,
+		Username: username,
+		Password: password,
+	}, nil
+}
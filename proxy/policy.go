@@ -0,0 +1,151 @@
+package proxy
+
+import (
+	"sort"
+	"strings"
+)
+
+// Strategy 在候选代理中挑选上游代理的方式
+type Strategy string
+
+const (
+	// StrategyWeighted 按综合评分(Proxy.Score)加权随机选择(默认)
+	StrategyWeighted Strategy = "weighted"
+	// StrategyRoundRobin 按地址顺序依次轮询
+	StrategyRoundRobin Strategy = "round_robin"
+	// StrategyFastest 总是选择延迟最低的代理
+	StrategyFastest Strategy = "fastest"
+	// StrategyDiverse 加权随机，但避免连续两次选中同一网段、国家或ASN的代理
+	StrategyDiverse Strategy = "diverse"
+)
+
+// Strategies 全部挑选方式，按界面显示顺序排列
+var Strategies = []Strategy{StrategyWeighted, StrategyRoundRobin, StrategyFastest, StrategyDiverse}
+
+// Label 返回挑选方式的显示名称
+func (s Strategy) Label() string {
+	switch s {
+	case StrategyRoundRobin:
+		return "轮询"
+	case StrategyFastest:
+		return "最快优先"
+	case StrategyDiverse:
+		return "分散(网段/国家/ASN)"
+	}
+	return "加权随机"
+}
+
+// Policy 上游代理的选择策略
+// Region: 国家筛选，多个国家以逗号分隔，空或 "All" 表示不限制
+// PremiumOnly: 是否只选择标记为高级的代理
+// Strategy: 挑选方式，空值按 StrategyWeighted 处理
+// Target: 检测目标名称，非空时只选择该目标检测通过的代理
+// Pool: 命名代理池名称，非空时只在该池中选择
+// Previous: 上一次选中的上游代理，StrategyDiverse 据此避开同一网段、国家和ASN，可以为nil
+type Policy struct {
+	Region      string
+	PremiumOnly bool
+	Strategy    Strategy
+	Target      string
+	Pool        string
+	Previous    *Proxy
+}
+
+// Allows 判断代理是否满足策略的国家、高级代理和检测目标限制
+func (pol Policy) Allows(p *Proxy) bool {
+	if pol.PremiumOnly && !p.IsPremium {
+		return false
+	}
+	if pol.Target != "" && !p.TargetChecks[pol.Target] {
+		return false
+	}
+	return matchRegion(p, pol.Region)
+}
+
+// SelectProxy 按策略选择一个上游代理
+// 目标为443端口时优先选择已验证支持HTTPS的代理，跳过 exclude 中的地址和超出单代理使用限制的代理
+// 参数 target: 目标地址(格式: host:port)
+// 参数 turn: 轮询序号，仅 StrategyRoundRobin 使用，由调用方每次递增
+// 返回选中的代理，没有满足条件的代理时返回nil
+func (r *Rotator) SelectProxy(target string, policy Policy, exclude map[string]bool, turn uint64) *Proxy {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	all := r.selectable()
+	var candidates []*Proxy
+	for _, p := range r.filterPool(all, policy.Pool) {
+		if !exclude[p.Address] && policy.Allows(p) {
+			candidates = append(candidates, p)
+		}
+	}
+	candidates = r.applyUsageLimits(filterForTarget(target, candidates))
+
+	switch policy.Strategy {
+	case StrategyRoundRobin:
+		return pickRoundRobin(candidates, turn)
+	case StrategyFastest:
+		if p := pickFastest(candidates); p != nil {
+			return p
+		}
+	case StrategyDiverse:
+		return r.pickWeighted(filterDiverse(candidates, policy.Previous))
+	}
+	if len(candidates) == len(all) {
+		// 没有任何代理被筛掉，直接使用缓存的累积权重表
+		return r.pickFromPool()
+	}
+	return r.pickWeighted(candidates)
+}
+
+// filterRegion 只保留位于 region 所列国家的代理，region 为空或 "All" 时返回全部候选
+func filterRegion(candidates []*Proxy, region string) []*Proxy {
+	if regionUnrestricted(region) {
+		return candidates
+	}
+	var matched []*Proxy
+	for _, p := range candidates {
+		if matchRegion(p, region) {
+			matched = append(matched, p)
+		}
+	}
+	return matched
+}
+
+// regionUnrestricted 判断国家筛选是否为不限制
+func regionUnrestricted(region string) bool {
+	region = strings.TrimSpace(region)
+	return region == "" || strings.EqualFold(region, "All")
+}
+
+// matchRegion 判断代理的国家是否在 region 所列的国家中(不区分大小写)
+func matchRegion(p *Proxy, region string) bool {
+	if regionUnrestricted(region) {
+		return true
+	}
+	for _, country := range strings.Split(region, ",") {
+		if country = strings.TrimSpace(country); country != "" && strings.EqualFold(country, p.Country) {
+			return true
+		}
+	}
+	return false
+}
+
+// pickRoundRobin 按地址排序后取第 turn 个候选，候选为空时返回nil
+func pickRoundRobin(candidates []*Proxy, turn uint64) *Proxy {
+	if len(candidates) == 0 {
+		return nil
+	}
+	sorted := append([]*Proxy(nil), candidates...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Address < sorted[j].Address })
+	return sorted[turn%uint64(len(sorted))]
+}
+
+// pickFastest 返回已测出延迟的候选中延迟最低的代理，都未测量时返回nil
+func pickFastest(candidates []*Proxy) *Proxy {
+	var fastest *Proxy
+	for _, p := range candidates {
+		if p.Latency > 0 && (fastest == nil || p.Latency < fastest.Latency) {
+			fastest = p
+		}
+	}
+	return fastest
+}
@@ -0,0 +1,187 @@
+package proxy
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// NamedPool 由标签和筛选条件定义的命名子代理池
+// 池本身不保存代理，选择上游时按条件从有效代理中实时筛选
+// Name: 池名称，唯一
+// Tags: 代理需带有其中任意一个标签，空表示不按标签限制
+// Region: 国家筛选，多个国家以逗号分隔，空表示不限制
+// MaxLatency: 最大延迟(秒)，0 表示不限制
+// HTTPSOnly: 是否只包含已验证支持HTTPS的代理
+type NamedPool struct {
+	Name       string   `json:"name"`
+	Tags       []string `json:"tags,omitempty"`
+	Region     string   `json:"region,omitempty"`
+	MaxLatency float64  `json:"max_latency,omitempty"`
+	HTTPSOnly  bool     `json:"https_only,omitempty"`
+}
+
+// Validate 检查代理池定义是否合法
+func (np NamedPool) Validate() error {
+	if strings.TrimSpace(np.Name) == "" {
+		return errors.New("代理池名称不能为空")
+	}
+	if np.MaxLatency < 0 {
+		return errors.New("最大延迟不能为负数")
+	}
+	return nil
+}
+
+// Match 判断代理是否属于该池
+func (np NamedPool) Match(p *Proxy) bool {
+	if len(np.Tags) > 0 && !p.HasAnyTag(np.Tags) {
+		return false
+	}
+	if np.MaxLatency > 0 && (p.Latency <= 0 || p.Latency > np.MaxLatency) {
+		return false
+	}
+	if np.HTTPSOnly && !p.SupportsHTTPS {
+		return false
+	}
+	return matchRegion(p, np.Region)
+}
+
+// ParseTags 解析以逗号或空白分隔的标签文本，结果经过 NormalizeTags 处理
+func ParseTags(text string) []string {
+	return NormalizeTags(strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == '，' || r == ' ' || r == '\t' || r == '\n'
+	}))
+}
+
+// NormalizeTags 去除空白、转为小写并去重，保持原有顺序
+func NormalizeTags(tags []string) []string {
+	var normalized []string
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
+}
+
+// HasTag 判断代理是否带有指定标签(不区分大小写)
+func (p *Proxy) HasTag(tag string) bool {
+	for _, t := range p.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAnyTag 判断代理是否带有 tags 中的任意一个标签
+func (p *Proxy) HasAnyTag(tags []string) bool {
+	for _, tag := range tags {
+		if p.HasTag(tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetTags 替换指定地址代理的标签(同时作用于原始列表和有效列表)
+// 参数 address: 代理地址
+// 参数 tags: 新的标签，经过 NormalizeTags 处理，为空表示清除全部标签
+func (r *Rotator) SetTags(address string, tags []string) {
+	tags = NormalizeTags(tags)
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, list := range [][]*Proxy{r.rawProxies, r.validProxies} {
+		for _, p := range list {
+			if p.Address == address {
+				p.Tags = tags
+			}
+		}
+	}
+}
+
+// SetPool 添加或替换同名的代理池
+// 返回错误如果定义不合法
+func (r *Rotator) SetPool(pool NamedPool) error {
+	pool.Name = strings.TrimSpace(pool.Name)
+	pool.Region = strings.TrimSpace(pool.Region)
+	pool.Tags = NormalizeTags(pool.Tags)
+	if err := pool.Validate(); err != nil {
+		return err
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.pools[pool.Name] = pool
+	return nil
+}
+
+// RemovePool 删除代理池，返回此前是否存在
+// 已绑定到该池的服务之后将选不到上游代理
+func (r *Rotator) RemovePool(name string) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	_, ok := r.pools[name]
+	delete(r.pools, name)
+	return ok
+}
+
+// Pools 返回全部代理池定义，按名称排序
+func (r *Rotator) Pools() []NamedPool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	pools := make([]NamedPool, 0, len(r.pools))
+	for _, pool := range r.pools {
+		pools = append(pools, pool)
+	}
+	sort.Slice(pools, func(i, j int) bool { return pools[i].Name < pools[j].Name })
+	return pools
+}
+
+// HasPool 判断代理池是否存在
+func (r *Rotator) HasPool(name string) bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	_, ok := r.pools[name]
+	return ok
+}
+
+// PoolSize 返回代理池当前包含的可选代理数量，池不存在时返回0
+func (r *Rotator) PoolSize(name string) int {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return len(r.filterPool(r.selectable(), name))
+}
+
+// InPool 判断代理是否属于指定代理池，name 为空时总是返回 true
+// 用于复用此前选中的代理(如会话保持)前确认其仍满足池的条件
+func (r *Rotator) InPool(name string, p *Proxy) bool {
+	if name == "" {
+		return true
+	}
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	pool, ok := r.pools[name]
+	return ok && pool.Match(p)
+}
+
+// filterPool 只保留属于指定代理池的候选，name 为空时返回全部候选，池不存在时返回空列表
+// 调用方需持有锁
+func (r *Rotator) filterPool(candidates []*Proxy, name string) []*Proxy {
+	if name == "" {
+		return candidates
+	}
+	pool, ok := r.pools[name]
+	if !ok {
+		return nil
+	}
+	var matched []*Proxy
+	for _, p := range candidates {
+		if pool.Match(p) {
+			matched = append(matched, p)
+		}
+	}
+	return matched
+}
@@ -0,0 +1,106 @@
+package proxy
+
+import "time"
+
+const (
+	// quarantineFailCount 失败次数达到该值的代理在转发失败后被暂时隔离
+	quarantineFailCount = 2
+	// quarantineDuration 隔离时长，期间不会被选用
+	quarantineDuration = 2 * time.Minute
+)
+
+// ReportFailure 记录本地服务经由代理转发失败(连接失败或被上游重置)
+// 失败次数加1，连续失败的代理被暂时隔离；固定的代理同样会被隔离，但不会被判定为失效
+// 返回失败次数是否已达到上限，此时调用方应调用 CleanupProxies 清理
+func (r *Rotator) ReportFailure(p *Proxy) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	p.FailCount++
+	if p.FailCount >= quarantineFailCount {
+		r.quarantine[p.Address] = time.Now().Add(quarantineDuration)
+		r.invalidatePool()
+	}
+	return !p.Pinned && p.FailCount >= maxFailCount
+}
+
+// IsQuarantined 判断代理是否处于隔离期
+func (r *Rotator) IsQuarantined(address string) bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.quarantined(address)
+}
+
+// quarantined 判断代理是否处于隔离期，调用方需持有锁
+func (r *Rotator) quarantined(address string) bool {
+	until, ok := r.quarantine[address]
+	return ok && time.Now().Before(until)
+}
+
+// releaseQuarantine 解除已到期的隔离，有代理恢复时丢弃缓存的累积权重表，调用方需持有写锁
+func (r *Rotator) releaseQuarantine() {
+	now := time.Now()
+	for address, until := range r.quarantine {
+		if !now.Before(until) {
+			delete(r.quarantine, address)
+			r.invalidatePool()
+		}
+	}
+}
+
+// maxSidelinedFailCount 搁置的代理失败次数达到该值后不再复检，直接删除
+const maxSidelinedFailCount = 3 * maxFailCount
+
+// GetSidelinedProxies 获取因连续失败被搁置、等待复检的代理
+// 搁置的代理不会被选用，复检成功后由 Rehabilitate 放回有效列表
+func (r *Rotator) GetSidelinedProxies() []*Proxy {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	proxiesCopy := make([]*Proxy, len(r.sidelined))
+	copy(proxiesCopy, r.sidelined)
+	return proxiesCopy
+}
+
+// GetSidelinedCount 获取被搁置的代理数量
+func (r *Rotator) GetSidelinedCount() int {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return len(r.sidelined)
+}
+
+// Rehabilitate 将复检成功的搁置代理放回有效列表，同时解除其隔离期
+// 返回代理此前是否处于搁置状态
+func (r *Rotator) Rehabilitate(p *Proxy) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if !r.unsideline(p.Address) {
+		return false
+	}
+	delete(r.quarantine, p.Address)
+	if !r.blacklist.matches(p.Address) {
+		r.validProxies = append(r.validProxies, p)
+		r.invalidatePool()
+	}
+	return true
+}
+
+// ClearSidelined 清空搁置列表和全部隔离记录
+// 清空代理列表时调用，避免定期复检将刚被清空的代理放回有效列表
+func (r *Rotator) ClearSidelined() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.sidelined = nil
+	r.quarantine = make(map[string]time.Time)
+	r.invalidatePool()
+}
+
+// unsideline 将代理移出搁置列表，调用方需持有写锁
+// 返回代理是否在搁置列表中
+func (r *Rotator) unsideline(address string) bool {
+	for i, p := range r.sidelined {
+		if p.Address == address {
+			r.sidelined = append(r.sidelined[:i], r.sidelined[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,333 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// unmeasuredPool 返回包含一个未测量代理和若干已测量代理的代理池
+// 未测量代理的评分最高，若参与加权选择会被最频繁地选中
+func unmeasuredPool(t *testing.T) (*Rotator, *Proxy) {
+	t.Helper()
+	unmeasured := &Proxy{Address: "10.0.0.1:1080", Protocol: "socks5", Country: "US", Score: 1000}
+	proxies := []*Proxy{unmeasured}
+	for _, address := range []string{"10.0.0.2:1080", "10.0.0.3:1080", "10.0.0.4:1080"} {
+		proxies = append(proxies, &Proxy{
+			Address:     address,
+			Protocol:    "socks5",
+			Country:     "US",
+			Latency:     0.2,
+			Score:       10,
+			LastChecked: time.Now(),
+		})
+	}
+	r := NewRotator()
+	if err := r.SetValidProxies(proxies); err != nil {
+		t.Fatalf("SetValidProxies 返回错误: %v", err)
+	}
+	return r, unmeasured
+}
+
+func TestSelectProxySkipsUnmeasured(t *testing.T) {
+	r, unmeasured := unmeasuredPool(t)
+	policies := []Policy{
+		{},
+		{Region: "US"},
+		{Strategy: StrategyDiverse},
+		{Strategy: StrategyFastest},
+	}
+	for _, policy := range policies {
+		for i := 0; i < 500; i++ {
+			p := r.SelectProxy("example.com:80", policy, nil, uint64(i))
+			if p == nil {
+				t.Fatalf("策略 %+v 未选出代理", policy)
+			}
+			if p == unmeasured {
+				t.Fatalf("策略 %+v 选中了未测量的代理", policy)
+			}
+		}
+	}
+}
+
+func TestWeightedPickSkipsUnmeasured(t *testing.T) {
+	r, unmeasured := unmeasuredPool(t)
+	candidates, _ := r.GetValidProxies()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for i := 0; i < 500; i++ {
+		if p := r.pickWeighted(candidates); p == unmeasured {
+			t.Fatal("pickWeighted 选中了未测量的代理")
+		}
+		if p := r.pickFromPool(); p == unmeasured {
+			t.Fatal("pickFromPool 选中了未测量的代理")
+		}
+	}
+}
+
+func TestWeightedPickAllUnmeasured(t *testing.T) {
+	r := NewRotator()
+	candidates := []*Proxy{{Address: "10.0.0.1:1080"}, {Address: "10.0.0.2:1080"}}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if p := r.pickWeighted(candidates); p == nil {
+		t.Fatal("全部候选都未测量时应仍能选出代理")
+	}
+}
+
+func TestAddRawProxiesKeepsCountry(t *testing.T) {
+	r := NewRotator()
+	raw := &Proxy{Address: "1.2.3.4:8080", Protocol: "http", Country: "JP"}
+	valid := &Proxy{Address: "5.6.7.8:1080", Protocol: "socks5", Country: "DE", Latency: 0.3, LastChecked: time.Now()}
+	r.SetRawProxies([]*Proxy{raw})
+	r.AddValidProxies([]*Proxy{valid})
+
+	// 再次导入不带国家的裸 host:port
+	if added := r.AddRawProxies([]*Proxy{
+		{Address: "1.2.3.4:8080", Protocol: "http"},
+		{Address: "5.6.7.8:1080", Protocol: "socks5"},
+	}); added != 1 {
+		t.Errorf("新增代理数 = %d，期望 1(仅有效列表中的代理加入原始列表)", added)
+	}
+	proxies, _ := r.GetRawProxies()
+	for _, p := range proxies {
+		want := map[string]string{"1.2.3.4:8080": "JP", "5.6.7.8:1080": "DE"}[p.Address]
+		if p.Country != want {
+			t.Errorf("%s 国家 = %q，期望 %q", p.Address, p.Country, want)
+		}
+	}
+
+	// 重新获取后替换原始列表同样保留已知的国家
+	r.SetRawProxies([]*Proxy{{Address: "1.2.3.4:8080", Protocol: "http"}})
+	proxies, _ = r.GetRawProxies()
+	if len(proxies) != 1 || proxies[0].Country != "JP" {
+		t.Errorf("SetRawProxies 后国家 = %q，期望 \"JP\"", proxies[0].Country)
+	}
+}
+
+func TestFillMissingKeepsCountry(t *testing.T) {
+	full := &Proxy{Address: "1.2.3.4:8080", Protocol: "http", Country: "JP"}
+	bare := &Proxy{Address: "1.2.3.4:8080", Protocol: "http"}
+	full.FillMissing(bare)
+	if full.Country != "JP" {
+		t.Errorf("合并裸地址后国家 = %q，期望 \"JP\"", full.Country)
+	}
+	bare.FillMissing(&Proxy{Address: "1.2.3.4:8080", Protocol: "http", Country: "JP"})
+	if bare.Country != "JP" {
+		t.Errorf("裸地址合并带国家的条目后国家 = %q，期望 \"JP\"", bare.Country)
+	}
+}
+
+func TestUntestedAndNeverCheckedProxies(t *testing.T) {
+	fresh := &Proxy{Address: "10.0.0.1:80", Protocol: "http"}
+	failed := &Proxy{Address: "10.0.0.2:80", Protocol: "http", FailCount: 1, LastChecked: time.Now()}
+	valid := &Proxy{Address: "10.0.0.3:80", Protocol: "http", Latency: 0.1, LastChecked: time.Now()}
+	r := NewRotator()
+	r.SetRawProxies([]*Proxy{fresh, failed, valid})
+	r.AddValidProxies([]*Proxy{valid})
+
+	untested := r.GetUntestedProxies()
+	if len(untested) != 2 || untested[0] != fresh || untested[1] != failed {
+		t.Errorf("GetUntestedProxies 应返回不在有效列表中的代理(含检测失败的)，实际 %d 个", len(untested))
+	}
+	unchecked := r.GetNeverCheckedProxies()
+	if len(unchecked) != 1 || unchecked[0] != fresh {
+		t.Errorf("GetNeverCheckedProxies 应只返回从未检测过的代理，实际 %d 个", len(unchecked))
+	}
+}
+
+func TestGetProxyChainAnyTunnelProtocol(t *testing.T) {
+	var proxies []*Proxy
+	for i, protocol := range []string{"http", "https", "socks4", "socks5"} {
+		proxies = append(proxies, &Proxy{
+			Address:     fmt.Sprintf("10.0.1.%d:8080", i+1),
+			Protocol:    protocol,
+			Latency:     0.2,
+			Score:       10,
+			LastChecked: time.Now(),
+		})
+	}
+	r := NewRotator()
+	r.SetValidProxies(proxies[:2])
+	entry, exit := r.GetProxyChain("example.com:80", "", false, "", nil)
+	if entry == nil || exit == nil || entry == exit {
+		t.Fatalf("两个HTTP代理应能组成代理链，实际 entry=%v exit=%v", entry, exit)
+	}
+
+	r.SetValidProxies(proxies)
+	exclude := map[string]bool{proxies[0].Address: true, proxies[1].Address: true}
+	for i := 0; i < 100; i++ {
+		entry, exit := r.GetProxyChain("example.com:80", "", false, "", exclude)
+		if entry == nil || exit == nil {
+			t.Fatal("排除两个代理后仍应能组成代理链")
+		}
+		if exclude[entry.Address] || exclude[exit.Address] {
+			t.Fatalf("代理链选中了已排除的代理: %s -> %s", entry.Address, exit.Address)
+		}
+	}
+}
+
+func TestRecordCheck(t *testing.T) {
+	p := &Proxy{Address: "10.0.0.1:1080", Protocol: "socks5", FailCount: 2}
+	r := NewRotator()
+	r.SetValidProxies([]*Proxy{p})
+
+	p.LastFailure = "timeout"
+	r.RecordCheck(p, 0.25, 100, nil)
+	if p.FailCount != 0 || p.LastFailure != "" || p.Latency != 0.25 || p.LastChecked.IsZero() {
+		t.Errorf("检测成功后 FailCount=%d LastFailure=%q Latency=%v LastChecked=%v", p.FailCount, p.LastFailure, p.Latency, p.LastChecked)
+	}
+	r.RecordCheck(p, 0, 0, errors.New("connection refused"))
+	if p.FailCount != 1 || p.LastFailure != "connection refused" || p.Latency != 0.25 {
+		t.Errorf("检测失败后 FailCount=%d LastFailure=%q Latency=%v，期望 1、connection refused 和 0.25", p.FailCount, p.LastFailure, p.Latency)
+	}
+	if history := r.GetHistory(p.Address); len(history) != 2 || !history[0].Success || history[1].Success {
+		t.Errorf("检测样本 = %+v，期望依次为成功、失败", history)
+	}
+}
+
+func TestClearSidelined(t *testing.T) {
+	p := &Proxy{Address: "10.0.0.1:1080", Protocol: "socks5", FailCount: quarantineFailCount - 1}
+	r := NewRotator()
+	r.SetValidProxies([]*Proxy{p})
+	r.ReportFailure(p)
+	r.sidelined = append(r.sidelined, &Proxy{Address: "10.0.0.2:1080", Protocol: "socks5"})
+
+	r.ClearSidelined()
+	if n := r.GetSidelinedCount(); n != 0 {
+		t.Errorf("清空后仍有 %d 个搁置的代理", n)
+	}
+	if r.IsQuarantined(p.Address) {
+		t.Error("清空后代理仍处于隔离期")
+	}
+}
+
+func TestForgetRemovedProxies(t *testing.T) {
+	cases := []struct {
+		name   string
+		setup  func(r *Rotator, gone, kept *Proxy)
+		remove func(r *Rotator, kept *Proxy)
+		forget bool
+	}{
+		{
+			name: "CleanupProxies 移除被劫持的代理",
+			setup: func(r *Rotator, gone, kept *Proxy) {
+				gone.Hijacked = true
+				r.SetValidProxies([]*Proxy{gone, kept})
+			},
+			remove: func(r *Rotator, kept *Proxy) { r.CleanupProxies(time.Hour) },
+			forget: true,
+		},
+		{
+			name: "PruneDeadRawProxies 移除连续失败的代理",
+			setup: func(r *Rotator, gone, kept *Proxy) {
+				gone.FailCount = maxFailCount
+				r.SetRawProxies([]*Proxy{gone, kept})
+			},
+			remove: func(r *Rotator, kept *Proxy) { r.PruneDeadRawProxies() },
+			forget: true,
+		},
+		{
+			name:   "SetRawProxies 替换原始列表",
+			setup:  func(r *Rotator, gone, kept *Proxy) { r.SetRawProxies([]*Proxy{gone, kept}) },
+			remove: func(r *Rotator, kept *Proxy) { r.SetRawProxies([]*Proxy{kept}) },
+			forget: true,
+		},
+		{
+			name:   "SetValidProxies 替换有效列表",
+			setup:  func(r *Rotator, gone, kept *Proxy) { r.SetValidProxies([]*Proxy{gone, kept}) },
+			remove: func(r *Rotator, kept *Proxy) { r.SetValidProxies([]*Proxy{kept}) },
+			forget: true,
+		},
+		{
+			name: "移出有效列表但仍在原始列表中",
+			setup: func(r *Rotator, gone, kept *Proxy) {
+				r.SetRawProxies([]*Proxy{gone})
+				r.SetValidProxies([]*Proxy{gone, kept})
+			},
+			remove: func(r *Rotator, kept *Proxy) { r.SetValidProxies([]*Proxy{kept}) },
+			forget: false,
+		},
+	}
+	for _, c := range cases {
+		gone := &Proxy{Address: "10.0.0.1:1080", Protocol: "socks5", LastChecked: time.Now()}
+		kept := &Proxy{Address: "10.0.0.2:1080", Protocol: "socks5", LastChecked: time.Now()}
+		r := NewRotator()
+		c.setup(r, gone, kept)
+		for _, p := range []*Proxy{gone, kept} {
+			r.AddSample(p.Address, Sample{Time: time.Now(), Success: true})
+			r.quarantine[p.Address] = time.Now().Add(time.Hour)
+			r.Acquire(p)
+		}
+
+		c.remove(r, kept)
+		forgotten := len(r.GetHistory(gone.Address)) == 0 && !r.IsQuarantined(gone.Address) && r.ActiveConnections(gone.Address) == 0
+		if forgotten != c.forget {
+			t.Errorf("%s: 检测历史、隔离记录和使用情况已删除 = %v，期望 %v", c.name, forgotten, c.forget)
+		}
+		if len(r.GetHistory(kept.Address)) == 0 || !r.IsQuarantined(kept.Address) || r.ActiveConnections(kept.Address) == 0 {
+			t.Errorf("%s: 仍在列表中的代理的记录被删除", c.name)
+		}
+	}
+}
+
+func TestRestoreHistory(t *testing.T) {
+	listed := &Proxy{Address: "10.0.0.1:1080", Protocol: "socks5"}
+	r := NewRotator()
+	r.SetRawProxies([]*Proxy{listed})
+	recorded := 0
+	r.SetSampleHook(func(string, Sample) { recorded++ })
+
+	samples := make([]Sample, maxHistorySamples+5)
+	for i := range samples {
+		samples[i] = Sample{Time: time.Unix(int64(i), 0), Success: true}
+	}
+	r.RestoreHistory(map[string][]Sample{listed.Address: samples, "10.0.0.2:1080": samples})
+
+	history := r.GetHistory(listed.Address)
+	if len(history) != maxHistorySamples || !history[len(history)-1].Time.Equal(samples[len(samples)-1].Time) {
+		t.Errorf("恢复后保留 %d 个样本，期望最近的 %d 个", len(history), maxHistorySamples)
+	}
+	if r.GetHistory("10.0.0.2:1080") != nil {
+		t.Error("不在任何列表中的代理也恢复了检测历史")
+	}
+	if recorded != 0 {
+		t.Errorf("恢复检测历史触发了 %d 次样本回调，期望不触发", recorded)
+	}
+}
+
+func TestScoreChangeInvalidatesPool(t *testing.T) {
+	p := &Proxy{Address: "10.0.0.1:1080", Protocol: "socks5", Latency: 0.1, LastChecked: time.Now(), Score: 50}
+	cases := []struct {
+		name   string
+		update func(r *Rotator)
+	}{
+		{"检测成功", func(r *Rotator) { r.RecordCheck(p, 0.2, 100, nil) }},
+		{"检测失败", func(r *Rotator) { r.RecordCheck(p, 0, 0, errors.New("timeout")) }},
+		{"重新加入已有效的代理", func(r *Rotator) { r.AddValidProxies([]*Proxy{p}) }},
+	}
+	for _, c := range cases {
+		r := NewRotator()
+		r.SetValidProxies([]*Proxy{p})
+		r.GetNextProxy("", false, "")
+		if r.pool == nil {
+			t.Fatalf("%s: 选择代理后未缓存加权代理池", c.name)
+		}
+		c.update(r)
+		if r.pool != nil {
+			t.Errorf("%s: 评分可能变化后仍保留旧的加权代理池", c.name)
+		}
+	}
+}
+
+func TestSetProtocol(t *testing.T) {
+	p := &Proxy{Address: "10.0.0.1:1080", Protocol: "http", ProtocolCandidates: []string{"http", "socks5"}}
+	r := NewRotator()
+	r.SetRawProxies([]*Proxy{p})
+
+	r.SetProtocol(p, "socks5")
+	if p.Protocol != "socks5" || p.AmbiguousProtocol() {
+		t.Errorf("设置协议后 Protocol=%s ProtocolCandidates=%v，期望 socks5 且无候选协议", p.Protocol, p.ProtocolCandidates)
+	}
+}
@@ -0,0 +1,113 @@
+package proxy
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// SnippetFormat 复制单个代理时生成的片段格式
+type SnippetFormat string
+
+const (
+	SnippetCurl        SnippetFormat = "curl"        // curl -x 命令
+	SnippetEnv         SnippetFormat = "env"         // 设置代理环境变量的命令
+	SnippetProxychains SnippetFormat = "proxychains" // proxychains.conf 的 [ProxyList] 片段
+)
+
+// snippetTestURL curl 命令访问的地址，返回出口IP便于确认代理生效
+const snippetTestURL = "https://api.ipify.org"
+
+// Snippet 生成可直接粘贴使用的代理片段
+// proxychains 不支持的协议(如https)返回错误
+func Snippet(format SnippetFormat, p *Proxy) (string, error) {
+	switch format {
+	case SnippetCurl:
+		return fmt.Sprintf("curl -x %s %s", shellQuote(p.URL().String()), snippetTestURL), nil
+	case SnippetEnv:
+		return EnvSnippet(p.URL().String()), nil
+	case SnippetProxychains:
+		line, ok := proxychainsLine(p)
+		if !ok {
+			return "", fmt.Errorf("proxychains 不支持 %s 代理", p.Protocol)
+		}
+		return "[ProxyList]\n" + line, nil
+	default:
+		return "", fmt.Errorf("不支持的片段格式: %s", format)
+	}
+}
+
+// EnvSnippet 生成设置代理环境变量的命令，Windows 下为 PowerShell 语法
+// 始终设置 ALL_PROXY，HTTP代理另外设置 HTTP_PROXY 和 HTTPS_PROXY
+func EnvSnippet(proxyURL string) string {
+	vars := []string{"ALL_PROXY"}
+	if strings.HasPrefix(proxyURL, "http") {
+		vars = append(vars, "HTTP_PROXY", "HTTPS_PROXY")
+	}
+	lines := make([]string, len(vars))
+	for i, name := range vars {
+		if runtime.GOOS == "windows" {
+			lines[i] = fmt.Sprintf("$env:%s=\"%s\"", name, proxyURL)
+		} else {
+			lines[i] = fmt.Sprintf("export %s=%s", name, shellQuote(proxyURL))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// shellQuote 内容含有 shell 特殊字符(如认证信息中的 & 或 ;)时用单引号包裹
+func shellQuote(s string) string {
+	safe := func(r rune) bool {
+		return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("._-:/@%[]", r)
+	}
+	if strings.IndexFunc(s, func(r rune) bool { return !safe(r) }) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// proxychainsLine 生成 proxychains.conf 中的一行代理配置，例如 "socks5 1.2.3.4 1080 user pass"
+// proxychains 只支持 http、socks4 和 socks5，其他协议返回 false
+func proxychainsLine(p *Proxy) (string, bool) {
+	var kind string
+	switch strings.ToLower(p.Protocol) {
+	case "http":
+		kind = "http"
+	case "socks4", "socks4a":
+		kind = "socks4"
+	case "socks5":
+		kind = "socks5"
+	default:
+		return "", false
+	}
+	port, ok := proxyPort(p)
+	if !ok {
+		return "", false
+	}
+	line := fmt.Sprintf("%s %s %d", kind, p.Host(), port)
+	if p.Username != "" {
+		line += " " + p.Username + " " + p.Password
+	}
+	return line, true
+}
+
+// exportProxychains 生成完整的 proxychains.conf，每次连接随机使用列表中的一个代理
+// proxychains 不支持的代理会被跳过
+func exportProxychains(proxies []*Proxy) ([]byte, int, error) {
+	var b strings.Builder
+	b.WriteString("# 由 go_proxy 导出的 proxychains 配置\n")
+	b.WriteString("random_chain\nchain_len = 1\nproxy_dns\n")
+	b.WriteString("tcp_read_time_out 15000\ntcp_connect_time_out 8000\n\n")
+	b.WriteString("[ProxyList]\n")
+	exported := 0
+	for _, p := range proxies {
+		line, ok := proxychainsLine(p)
+		if !ok {
+			continue
+		}
+		b.WriteString(line)
+		b.WriteByte('\n')
+		exported++
+	}
+	return []byte(b.String()), exported, nil
+}
@@ -0,0 +1,118 @@
+package proxy
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+
+	xproxy "golang.org/x/net/proxy"
+)
+
+// init 向 golang.org/x/net/proxy 注册 socks4 和 socks4a 协议
+// 注册后 xproxy.FromURL 即可像 socks5 一样创建 socks4 拨号器
+func init() {
+	xproxy.RegisterDialerType("socks4", newSOCKS4Dialer(false))
+	xproxy.RegisterDialerType("socks4a", newSOCKS4Dialer(true))
+}
+
+// socks4HandshakeTimeout 与SOCKS4代理完成握手的超时时间
+const socks4HandshakeTimeout = 10 * time.Second
+
+// socks4Dialer SOCKS4/SOCKS4a 客户端拨号器
+// addr: 上游代理地址(host:port)
+// userID: SOCKS4 用户标识，取自URL中的用户名
+// remoteDNS: 是否由代理解析域名(SOCKS4a)
+// forward: 连接上游代理使用的拨号器
+type socks4Dialer struct {
+	addr      string
+	userID    string
+	remoteDNS bool
+	forward   xproxy.Dialer
+}
+
+// newSOCKS4Dialer 返回供 xproxy.RegisterDialerType 使用的构造函数
+func newSOCKS4Dialer(remoteDNS bool) func(*url.URL, xproxy.Dialer) (xproxy.Dialer, error) {
+	return func(u *url.URL, forward xproxy.Dialer) (xproxy.Dialer, error) {
+		d := &socks4Dialer{
+			addr:      u.Host,
+			remoteDNS: remoteDNS,
+			forward:   forward,
+		}
+		if u.User != nil {
+			d.userID = u.User.Username()
+		}
+		return d, nil
+	}
+}
+
+// Dial 通过SOCKS4代理连接到目标地址
+// SOCKS4 仅支持 TCP 和 IPv4 目标，SOCKS4a 可将域名交给代理解析
+func (d *socks4Dialer) Dial(network, addr string) (net.Conn, error) {
+	switch network {
+	case "tcp", "tcp4":
+	default:
+		return nil, errors.New("SOCKS4不支持的网络类型: " + network)
+	}
+
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port <= 0 || port > 65535 {
+		return nil, errors.New("无效的目标端口: " + portStr)
+	}
+
+	req := []byte{0x04, 0x01, 0, 0}
+	binary.BigEndian.PutUint16(req[2:4], uint16(port))
+
+	var domain string
+	ip := net.ParseIP(host).To4()
+	if ip == nil {
+		if d.remoteDNS {
+			// SOCKS4a: 0.0.0.x 表示目标地址为随后附加的域名
+			ip = net.IPv4(0, 0, 0, 1).To4()
+			domain = host
+		} else {
+			ipAddr, err := net.ResolveIPAddr("ip4", host)
+			if err != nil {
+				return nil, err
+			}
+			ip = ipAddr.IP.To4()
+		}
+	}
+	req = append(req, ip...)
+	req = append(req, d.userID...)
+	req = append(req, 0)
+	if domain != "" {
+		req = append(req, domain...)
+		req = append(req, 0)
+	}
+
+	conn, err := d.forward.Dial("tcp", d.addr)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(socks4HandshakeTimeout))
+	if _, err := conn.Write(req); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	resp := make([]byte, 8)
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if resp[1] != 0x5a {
+		conn.Close()
+		return nil, fmt.Errorf("SOCKS4代理拒绝连接, 状态码: 0x%02x", resp[1])
+	}
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
@@ -0,0 +1,121 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"net/url"
+	"testing"
+
+	xproxy "golang.org/x/net/proxy"
+)
+
+// socks4Request 假SOCKS4代理收到的请求
+type socks4Request struct {
+	port   uint16
+	ip     net.IP
+	userID string
+	domain string
+}
+
+// fakeSOCKS4Proxy 启动只处理一个请求的本地SOCKS4/SOCKS4a代理，收到的请求发送到返回的通道
+// 参数 code: 回复的状态码，0x5a 表示允许
+func fakeSOCKS4Proxy(t *testing.T, code byte) (string, <-chan socks4Request) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("监听失败: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	requests := make(chan socks4Request, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		head := make([]byte, 8)
+		if _, err := io.ReadFull(reader, head); err != nil || head[0] != 0x04 || head[1] != 0x01 {
+			return
+		}
+		req := socks4Request{port: uint16(head[2])<<8 | uint16(head[3]), ip: net.IP(head[4:8])}
+		userID, err := reader.ReadBytes(0)
+		if err != nil {
+			return
+		}
+		req.userID = string(bytes.TrimSuffix(userID, []byte{0}))
+		if head[4] == 0 && head[5] == 0 && head[6] == 0 && head[7] != 0 {
+			domain, err := reader.ReadBytes(0)
+			if err != nil {
+				return
+			}
+			req.domain = string(bytes.TrimSuffix(domain, []byte{0}))
+		}
+		requests <- req
+		conn.Write([]byte{0x00, code, 0, 0, 0, 0, 0, 0})
+	}()
+	return ln.Addr().String(), requests
+}
+
+func dialSOCKS4(t *testing.T, proxyURL, target string) (net.Conn, error) {
+	t.Helper()
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		t.Fatalf("解析代理URL失败: %v", err)
+	}
+	dialer, err := xproxy.FromURL(u, xproxy.Direct)
+	if err != nil {
+		t.Fatalf("创建拨号器失败: %v", err)
+	}
+	return dialer.Dial("tcp", target)
+}
+
+func TestSOCKS4DialIP(t *testing.T) {
+	addr, requests := fakeSOCKS4Proxy(t, 0x5a)
+	conn, err := dialSOCKS4(t, "socks4://user@"+addr, "93.184.216.34:80")
+	if err != nil {
+		t.Fatalf("SOCKS4 连接失败: %v", err)
+	}
+	conn.Close()
+
+	req := <-requests
+	if !req.ip.Equal(net.ParseIP("93.184.216.34")) || req.port != 80 {
+		t.Errorf("目标地址 = %s:%d，期望 93.184.216.34:80", req.ip, req.port)
+	}
+	if req.userID != "user" {
+		t.Errorf("用户标识 = %q，期望 \"user\"", req.userID)
+	}
+	if req.domain != "" {
+		t.Errorf("SOCKS4 不应附加域名，实际 %q", req.domain)
+	}
+}
+
+func TestSOCKS4aDialDomain(t *testing.T) {
+	addr, requests := fakeSOCKS4Proxy(t, 0x5a)
+	conn, err := dialSOCKS4(t, "socks4a://"+addr, "example.com:443")
+	if err != nil {
+		t.Fatalf("SOCKS4a 连接失败: %v", err)
+	}
+	conn.Close()
+
+	req := <-requests
+	if req.domain != "example.com" || req.port != 443 {
+		t.Errorf("目标地址 = %s:%d，期望 example.com:443", req.domain, req.port)
+	}
+	if !req.ip.Equal(net.IPv4(0, 0, 0, 1)) {
+		t.Errorf("SOCKS4a 地址字段 = %s，期望 0.0.0.1", req.ip)
+	}
+}
+
+func TestSOCKS4DialRejected(t *testing.T) {
+	for _, scheme := range []string{"socks4", "socks4a"} {
+		addr, _ := fakeSOCKS4Proxy(t, 0x5b)
+		conn, err := dialSOCKS4(t, scheme+"://"+addr, "93.184.216.34:80")
+		if err == nil {
+			conn.Close()
+			t.Errorf("%s 代理回复 0x5b 时应返回错误", scheme)
+		}
+	}
+}
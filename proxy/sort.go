@@ -0,0 +1,103 @@
+package proxy
+
+import "sort"
+
+// SortKey 有效代理列表的排序字段
+type SortKey string
+
+const (
+	SortByProtocol    SortKey = "protocol"
+	SortByAddress     SortKey = "address"
+	SortByLatency     SortKey = "latency"
+	SortBySpeed       SortKey = "speed"
+	SortByStability   SortKey = "stability"
+	SortByScore       SortKey = "score"
+	SortByAnonymity   SortKey = "anonymity"
+	SortByHTTPS       SortKey = "https"
+	SortByDNS         SortKey = "dns"
+	SortByIPVersion   SortKey = "ip_version"
+	SortByTraffic     SortKey = "traffic"
+	SortByCountry     SortKey = "country"
+	SortByLastChecked SortKey = "last_checked"
+	SortByExitIP      SortKey = "exit_ip"
+)
+
+// SortOrder 排序字段和方向，Desc 为 true 时降序
+type SortOrder struct {
+	Key  SortKey `json:"key"`
+	Desc bool    `json:"desc"`
+}
+
+// DefaultSortOrder 默认按延迟升序排列
+var DefaultSortOrder = SortOrder{Key: SortByLatency}
+
+// sortLess 各排序字段的升序比较函数
+var sortLess = map[SortKey]func(a, b *Proxy) bool{
+	SortByProtocol:  func(a, b *Proxy) bool { return a.Protocol < b.Protocol },
+	SortByAddress:   func(a, b *Proxy) bool { return a.Address < b.Address },
+	SortByLatency:   func(a, b *Proxy) bool { return a.Latency < b.Latency },
+	SortBySpeed:     func(a, b *Proxy) bool { return a.Speed < b.Speed },
+	SortByStability: func(a, b *Proxy) bool { return a.Stability < b.Stability },
+	SortByScore:     func(a, b *Proxy) bool { return a.Score < b.Score },
+	SortByAnonymity: func(a, b *Proxy) bool { return a.Anonymity < b.Anonymity },
+	SortByHTTPS:     func(a, b *Proxy) bool { return !a.SupportsHTTPS && b.SupportsHTTPS },
+	SortByDNS:       func(a, b *Proxy) bool { return dnsRank(a) < dnsRank(b) },
+	SortByIPVersion: func(a, b *Proxy) bool { return !a.IsIPv6() && b.IsIPv6() },
+	SortByTraffic:   func(a, b *Proxy) bool { return a.BytesUp+a.BytesDown < b.BytesUp+b.BytesDown },
+	SortByCountry: func(a, b *Proxy) bool {
+		if a.Country != b.Country {
+			return a.Country < b.Country
+		}
+		return a.Location < b.Location
+	},
+	SortByLastChecked: func(a, b *Proxy) bool { return a.LastChecked.Before(b.LastChecked) },
+	SortByExitIP:      func(a, b *Proxy) bool { return a.ExitIP < b.ExitIP },
+}
+
+// dnsRank DNS检测结果的排序权重：未检测 < 存在泄漏 < 远程解析
+func dnsRank(p *Proxy) int {
+	switch {
+	case !p.DNSChecked:
+		return 0
+	case p.RemoteDNS:
+		return 2
+	}
+	return 1
+}
+
+// PrioritizeCandidates 返回按代理源报告的质量排序的副本，检测时优先检测更可能有效的代理
+// 在线率高的在前，在线率相同时源站延迟低的在前；没有源站报告的代理保持原有顺序排在最后
+func PrioritizeCandidates(proxies []*Proxy) []*Proxy {
+	sorted := make([]*Proxy, len(proxies))
+	copy(sorted, proxies)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.Uptime != b.Uptime {
+			return a.Uptime > b.Uptime
+		}
+		if (a.SourceLatency > 0) != (b.SourceLatency > 0) {
+			return a.SourceLatency > 0
+		}
+		return a.SourceLatency < b.SourceLatency
+	})
+	return sorted
+}
+
+// SortProxies 按排序字段对代理稳定排序，字段相同的代理保持原有顺序
+// 按延迟排序时未测量延迟的代理无论升序降序都排在最后；未知字段按延迟排序
+func SortProxies(proxies []*Proxy, order SortOrder) {
+	less, ok := sortLess[order.Key]
+	if !ok {
+		order.Key, less = SortByLatency, sortLess[SortByLatency]
+	}
+	sort.SliceStable(proxies, func(i, j int) bool {
+		a, b := proxies[i], proxies[j]
+		if order.Key == SortByLatency && (a.Latency > 0) != (b.Latency > 0) {
+			return a.Latency > 0
+		}
+		if order.Desc {
+			return less(b, a)
+		}
+		return less(a, b)
+	})
+}
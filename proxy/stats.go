@@ -0,0 +1,164 @@
+package proxy
+
+import (
+	"sort"
+	"time"
+)
+
+// topCountryLimit 统计中保留的国家数量
+const topCountryLimit = 5
+
+// CountEntry 分类计数
+type CountEntry struct {
+	Name  string
+	Count int
+}
+
+// PoolStats 代理池汇总统计
+// AvgLatency: 有效代理的平均延迟(秒)，只统计已测出延迟的代理
+// AvgSpeed: 有效代理的平均速度(KB/s)，只统计已测速的代理
+// Fresh: 在统计窗口内检测过的有效代理数量
+// Sidelined: 因连续失败被搁置、等待复检的代理数量
+type PoolStats struct {
+	RawCount     int
+	ValidCount   int
+	Sidelined    int
+	ByProtocol   []CountEntry
+	TopCountries []CountEntry
+	AvgLatency   float64
+	AvgSpeed     float64
+	Fresh        int
+}
+
+// Stats 汇总当前代理池的统计信息
+// 参数 freshWithin: 最近检测时间在该时长内的代理视为新鲜
+func (r *Rotator) Stats(freshWithin time.Duration) PoolStats {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	stats := PoolStats{
+		RawCount:   len(r.rawProxies),
+		ValidCount: len(r.validProxies),
+		Sidelined:  len(r.sidelined),
+	}
+	protocols := make(map[string]int)
+	countries := make(map[string]int)
+	var latencySum, speedSum float64
+	var latencyCount, speedCount int
+	for _, p := range r.validProxies {
+		protocols[p.Protocol]++
+		if p.Country != "" {
+			countries[p.Country]++
+		}
+		if p.Latency > 0 {
+			latencySum += p.Latency
+			latencyCount++
+		}
+		if p.Speed > 0 {
+			speedSum += p.Speed
+			speedCount++
+		}
+		if !p.LastChecked.IsZero() && time.Since(p.LastChecked) <= freshWithin {
+			stats.Fresh++
+		}
+	}
+	if latencyCount > 0 {
+		stats.AvgLatency = latencySum / float64(latencyCount)
+	}
+	if speedCount > 0 {
+		stats.AvgSpeed = speedSum / float64(speedCount)
+	}
+	stats.ByProtocol = sortedCounts(protocols, 0)
+	stats.TopCountries = sortedCounts(countries, topCountryLimit)
+	return stats
+}
+
+// sortedCounts 按数量降序(数量相同按名称)排列计数，limit 大于0时只保留前 limit 项
+func sortedCounts(counts map[string]int, limit int) []CountEntry {
+	entries := make([]CountEntry, 0, len(counts))
+	for name, count := range counts {
+		entries = append(entries, CountEntry{Name: name, Count: count})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Count != entries[j].Count {
+			return entries[i].Count > entries[j].Count
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries
+}
+
+// topTrafficLimit 流量统计中列出的代理数量
+const topTrafficLimit = 5
+
+// TrafficEntry 单个代理的转发流量
+type TrafficEntry struct {
+	Address      string
+	BytesUp      int64
+	BytesDown    int64
+	Connections  int64
+	ConnFailures int64
+}
+
+// TrafficStats 本地服务经由代理池转发的流量汇总
+// Top: 总流量最大的前 topTrafficLimit 个代理
+// Failing: 连接失败次数最多的前 topTrafficLimit 个代理，用于发现失效的出口
+type TrafficStats struct {
+	BytesUp      int64
+	BytesDown    int64
+	Connections  int64
+	ConnFailures int64
+	Top          []TrafficEntry
+	Failing      []TrafficEntry
+}
+
+// TrafficStats 汇总原始列表和有效列表中全部代理的转发流量
+func (r *Rotator) TrafficStats() TrafficStats {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var stats TrafficStats
+	var entries []TrafficEntry
+	for _, p := range r.knownProxies() {
+		if p.Connections == 0 && p.ConnFailures == 0 {
+			continue
+		}
+		stats.BytesUp += p.BytesUp
+		stats.BytesDown += p.BytesDown
+		stats.Connections += p.Connections
+		stats.ConnFailures += p.ConnFailures
+		entries = append(entries, TrafficEntry{
+			Address:      p.Address,
+			BytesUp:      p.BytesUp,
+			BytesDown:    p.BytesDown,
+			Connections:  p.Connections,
+			ConnFailures: p.ConnFailures,
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].BytesUp+entries[i].BytesDown > entries[j].BytesUp+entries[j].BytesDown
+	})
+	for _, e := range entries {
+		if len(stats.Top) == topTrafficLimit {
+			break
+		}
+		if e.BytesUp+e.BytesDown > 0 {
+			stats.Top = append(stats.Top, e)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ConnFailures > entries[j].ConnFailures
+	})
+	for _, e := range entries {
+		if len(stats.Failing) == topTrafficLimit || e.ConnFailures == 0 {
+			break
+		}
+		stats.Failing = append(stats.Failing, e)
+	}
+	return stats
+}
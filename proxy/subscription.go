@@ -0,0 +1,139 @@
+package proxy
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// clientOnlySchemes 需要专用客户端、无法作为普通 socks/http 代理使用的分享链接协议
+var clientOnlySchemes = map[string]bool{
+	"ss":        true,
+	"ssr":       true,
+	"vmess":     true,
+	"vless":     true,
+	"trojan":    true,
+	"hysteria":  true,
+	"hysteria2": true,
+	"tuic":      true,
+}
+
+// SubscriptionNode 订阅中需要专用客户端的节点，只用于统计和日志
+// Scheme: 链接协议(ss/vmess等)
+// Server: 节点的 host:port，无法解析时为空
+// Name: 节点备注
+type SubscriptionNode struct {
+	Scheme string
+	Server string
+	Name   string
+}
+
+// DecodeSubscription 解码订阅内容，返回逐行的分享链接
+// 订阅内容通常整体为 base64 编码(标准或URL安全编码，可能省略填充或按行折断)，
+// 内容本身已是链接列表或无法解码时按明文处理
+func DecodeSubscription(data []byte) []string {
+	content := strings.TrimSpace(string(data))
+	if !strings.Contains(content, "://") {
+		if decoded, ok := decodeBase64(strings.Join(strings.Fields(content), "")); ok {
+			content = decoded
+		}
+	}
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// SplitSubscription 将订阅中的链接分为可导入的代理行和需要专用客户端的节点
+// socks://、http(s):// 等链接原样保留，交给 ParseProxyLine 解析；
+// ss:// 和 vmess:// 节点尽量解析出服务器地址，但不会导入代理池
+func SplitSubscription(lines []string) (usable []string, nodes []SubscriptionNode) {
+	for _, line := range lines {
+		scheme, rest, ok := strings.Cut(line, "://")
+		scheme = strings.ToLower(scheme)
+		if !ok || !clientOnlySchemes[scheme] {
+			usable = append(usable, line)
+			continue
+		}
+		node := SubscriptionNode{Scheme: scheme}
+		switch scheme {
+		case "vmess":
+			node.Server, node.Name, _ = parseVmessLink(rest)
+		case "ss":
+			node.Server, node.Name, _ = parseSSLink(rest)
+		default:
+			if u, err := url.Parse(line); err == nil {
+				node.Server, node.Name = u.Host, u.Fragment
+			}
+		}
+		nodes = append(nodes, node)
+	}
+	return usable, nodes
+}
+
+// parseVmessLink 解析 V2RayN 格式的 vmess 链接，链接内容为 base64 编码的JSON
+func parseVmessLink(rest string) (server, name string, err error) {
+	decoded, ok := decodeBase64(rest)
+	if !ok {
+		return "", "", errors.New("vmess链接不是base64编码")
+	}
+	var config struct {
+		Add  string          `json:"add"`
+		Port json.RawMessage `json:"port"`
+		PS   string          `json:"ps"`
+	}
+	if err := json.Unmarshal([]byte(decoded), &config); err != nil {
+		return "", "", fmt.Errorf("解析vmess链接失败: %v", err)
+	}
+	port := strings.Trim(string(config.Port), `"`)
+	if config.Add == "" || port == "" {
+		return "", config.PS, errors.New("vmess链接缺少地址")
+	}
+	return net.JoinHostPort(config.Add, port), config.PS, nil
+}
+
+// parseSSLink 解析 ss 链接，兼容 SIP002 格式 ss://base64(方法:密码)@host:port#备注
+// 和旧格式 ss://base64(方法:密码@host:port)#备注
+func parseSSLink(rest string) (server, name string, err error) {
+	if i := strings.Index(rest, "#"); i >= 0 {
+		name, _ = url.PathUnescape(rest[i+1:])
+		rest = rest[:i]
+	}
+	if i := strings.IndexAny(rest, "?/"); i >= 0 {
+		rest = rest[:i]
+	}
+	if i := strings.LastIndex(rest, "@"); i >= 0 {
+		return rest[i+1:], name, nil
+	}
+	decoded, ok := decodeBase64(rest)
+	if !ok {
+		return "", name, errors.New("ss链接格式无效")
+	}
+	i := strings.LastIndex(decoded, "@")
+	if i < 0 {
+		return "", name, errors.New("ss链接缺少地址")
+	}
+	return decoded[i+1:], name, nil
+}
+
+// decodeBase64 依次尝试标准和URL安全的 base64 编码(填充可省略)，只接受解码为有效UTF-8文本的结果
+func decodeBase64(s string) (string, bool) {
+	s = strings.TrimRight(strings.TrimSpace(s), "=")
+	if s == "" {
+		return "", false
+	}
+	for _, encoding := range []*base64.Encoding{base64.RawStdEncoding, base64.RawURLEncoding} {
+		if raw, err := encoding.DecodeString(s); err == nil && utf8.Valid(raw) {
+			return string(raw), true
+		}
+	}
+	return "", false
+}
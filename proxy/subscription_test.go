@@ -0,0 +1,116 @@
+package proxy
+
+import (
+	"encoding/base64"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// 常见机场订阅中的节点链接样例
+const (
+	sampleSS        = "ss://YWVzLTI1Ni1nY206cGFzc3dvcmQ@203.0.113.10:8388#%E9%A6%99%E6%B8%AF%2001"
+	sampleSSPlugin  = "ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpwYXNz@198.51.100.2:443/?plugin=obfs-local%3Bobfs%3Dhttp#JP"
+	sampleSSLegacy  = "ss://YWVzLTI1Ni1nY206cGFzc3dvcmRAMjAzLjAuMTEzLjExOjgzODk=#US"
+	sampleVmess     = "vmess://eyJ2IjoiMiIsInBzIjoiSEstdm1lc3MiLCJhZGQiOiIyMDMuMC4xMTMuMjAiLCJwb3J0IjoiNDQzIiwiaWQiOiJiODMxMzgxZC02MzI0LTRkNTMtYWQ0Zi04Y2RhNDhiMzA4MTEiLCJhaWQiOiIwIiwibmV0Ijoid3MiLCJ0eXBlIjoibm9uZSIsImhvc3QiOiIiLCJwYXRoIjoiL3JheSIsInRscyI6InRscyJ9"
+	sampleTrojan    = "trojan://password@trojan.example.com:443?sni=trojan.example.com#SG"
+	sampleSocks     = "socks://dXNlcjpwYXNz@1.2.3.4:1080#HK-socks"
+	sampleHTTP      = "http://5.6.7.8:3128"
+	sampleSubscribe = sampleSocks + "\n" + sampleSS + "\n" + sampleVmess + "\n" + sampleHTTP + "\n"
+)
+
+func TestDecodeSubscription(t *testing.T) {
+	want := []string{sampleSocks, sampleSS, sampleVmess, sampleHTTP}
+	std := base64.StdEncoding.EncodeToString([]byte(sampleSubscribe))
+	var folded strings.Builder
+	for i := 0; i < len(std); i += 76 {
+		end := i + 76
+		if end > len(std) {
+			end = len(std)
+		}
+		folded.WriteString(std[i:end] + "\r\n")
+	}
+	cases := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{"标准base64", std, want},
+		{"按76列折行的base64", folded.String(), want},
+		{"URL安全且省略填充的base64", base64.RawURLEncoding.EncodeToString([]byte(sampleSubscribe)), want},
+		{"明文链接列表", "\r\n" + strings.ReplaceAll(sampleSubscribe, "\n", "\r\n\r\n"), want},
+		{"无法解码时按明文处理", "1.2.3.4:8080\n5.6.7.8:3128", []string{"1.2.3.4:8080", "5.6.7.8:3128"}},
+		{"空订阅", "  \n", nil},
+	}
+	for _, c := range cases {
+		if got := DecodeSubscription([]byte(c.data)); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: 得到 %q，期望 %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestSplitSubscription(t *testing.T) {
+	lines := []string{sampleSocks, sampleSS, "VMESS://" + strings.TrimPrefix(sampleVmess, "vmess://"), sampleHTTP, sampleTrojan, "1.2.3.4:8080", "ss://!!!"}
+	usable, nodes := SplitSubscription(lines)
+
+	if want := []string{sampleSocks, sampleHTTP, "1.2.3.4:8080"}; !reflect.DeepEqual(usable, want) {
+		t.Errorf("可导入的代理行 = %q，期望 %q", usable, want)
+	}
+	want := []SubscriptionNode{
+		{Scheme: "ss", Server: "203.0.113.10:8388", Name: "香港 01"},
+		{Scheme: "vmess", Server: "203.0.113.20:443", Name: "HK-vmess"},
+		{Scheme: "trojan", Server: "trojan.example.com:443", Name: "SG"},
+		{Scheme: "ss"}, // 无法解析的节点仍计入，只是没有地址
+	}
+	if !reflect.DeepEqual(nodes, want) {
+		t.Errorf("专用客户端节点 = %+v，期望 %+v", nodes, want)
+	}
+}
+
+func TestParseSSLink(t *testing.T) {
+	cases := []struct {
+		link    string
+		server  string
+		name    string
+		wantErr bool
+	}{
+		// SIP002: base64(方法:密码)@host:port，可带插件参数
+		{link: sampleSS, server: "203.0.113.10:8388", name: "香港 01"},
+		{link: sampleSSPlugin, server: "198.51.100.2:443", name: "JP"},
+		{link: "ss://YWVzLTI1Ni1nY206cGFzc3dvcmQ@[2001:db8::1]:8388", server: "[2001:db8::1]:8388"},
+		// 旧格式: base64(方法:密码@host:port)，填充可省略，密码中可含 @
+		{link: sampleSSLegacy, server: "203.0.113.11:8389", name: "US"},
+		{link: "ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpwQHNzQDE5OC41MS4xMDAuMzo0NDM", server: "198.51.100.3:443"},
+		{link: "ss://YWVzLTI1Ni1nY206cGFzc3dvcmQ#no-server", name: "no-server", wantErr: true},
+		{link: "ss://!!!#bad", name: "bad", wantErr: true},
+	}
+	for _, c := range cases {
+		server, name, err := parseSSLink(strings.TrimPrefix(c.link, "ss://"))
+		if (err != nil) != c.wantErr || server != c.server || name != c.name {
+			t.Errorf("%s: 得到 (%q, %q, %v)，期望 (%q, %q)，期望出错 %v", c.link, server, name, err, c.server, c.name, c.wantErr)
+		}
+	}
+}
+
+func TestParseVmessLink(t *testing.T) {
+	cases := []struct {
+		name    string
+		link    string
+		server  string
+		ps      string
+		wantErr bool
+	}{
+		{"端口为字符串", sampleVmess, "203.0.113.20:443", "HK-vmess", false},
+		{"端口为数字且URL安全编码", "vmess://eyJ2IjoiMiIsInBzIjoi5pel5pysIDAyIiwiYWRkIjoidm1lc3MuZXhhbXBsZS5jb20iLCJwb3J0IjoxMDA4NiwiaWQiOiJiODMxMzgxZC02MzI0LTRkNTMtYWQ0Zi04Y2RhNDhiMzA4MTEiLCJhaWQiOjAsIm5ldCI6InRjcCJ9", "vmess.example.com:10086", "日本 02", false},
+		{"IPv6地址", "vmess://eyJ2IjoiMiIsInBzIjoidjYiLCJhZGQiOiIyMDAxOmRiODo6MiIsInBvcnQiOiI4NDQzIn0=", "[2001:db8::2]:8443", "v6", false},
+		{"缺少地址", "vmess://eyJ2IjoiMiIsInBzIjoibm8tYWRkciIsInBvcnQiOiI0NDMifQ==", "", "no-addr", true},
+		{"不是base64", "vmess://not base64!", "", "", true},
+		{"不是JSON", "vmess://" + base64.StdEncoding.EncodeToString([]byte("add=1.2.3.4")), "", "", true},
+	}
+	for _, c := range cases {
+		server, ps, err := parseVmessLink(strings.TrimPrefix(c.link, "vmess://"))
+		if (err != nil) != c.wantErr || server != c.server || ps != c.ps {
+			t.Errorf("%s: 得到 (%q, %q, %v)，期望 (%q, %q)，期望出错 %v", c.name, server, ps, err, c.server, c.ps, c.wantErr)
+		}
+	}
+}
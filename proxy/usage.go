@@ -0,0 +1,86 @@
+package proxy
+
+import "time"
+
+// UsageLimits 本地服务为新连接选择上游代理时的单代理使用限制，避免一个快速代理承担全部流量而被封禁
+// MaxConns: 单个代理同时承载的最大连接数，0 表示不限制
+// Cooldown: 代理被选用后再次被选用前的最短间隔，0 表示不限制
+type UsageLimits struct {
+	MaxConns int
+	Cooldown time.Duration
+}
+
+// proxyUsage 代理当前承载的连接数和最近一次被选用的时间
+type proxyUsage struct {
+	active   int
+	lastUsed time.Time
+}
+
+// SetUsageLimits 设置单代理使用限制，对之后选择的代理生效
+func (r *Rotator) SetUsageLimits(limits UsageLimits) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.limits = limits
+}
+
+// Acquire 记录本地服务开始经由代理转发一个连接，连接结束时需调用 Release
+func (r *Rotator) Acquire(p *Proxy) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	u := r.usage[p.Address]
+	if u == nil {
+		u = &proxyUsage{}
+		r.usage[p.Address] = u
+	}
+	u.active++
+	u.lastUsed = time.Now()
+}
+
+// Release 记录经由代理转发的连接已结束
+func (r *Rotator) Release(p *Proxy) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if u := r.usage[p.Address]; u != nil && u.active > 0 {
+		u.active--
+	}
+}
+
+// ActiveConnections 返回代理当前承载的本地服务连接数
+func (r *Rotator) ActiveConnections(address string) int {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	if u := r.usage[address]; u != nil {
+		return u.active
+	}
+	return 0
+}
+
+// applyUsageLimits 按单代理使用限制筛选候选代理，调用方需持有锁
+// 所有候选都超出限制时退回承载连接最少(相同时最久未被选用)的代理，避免因限制拒绝客户端连接
+func (r *Rotator) applyUsageLimits(candidates []*Proxy) []*Proxy {
+	if r.limits.MaxConns <= 0 && r.limits.Cooldown <= 0 || len(candidates) == 0 {
+		return candidates
+	}
+	now := time.Now()
+	var allowed []*Proxy
+	var idlest *Proxy
+	var idlestUsage proxyUsage
+	for _, p := range candidates {
+		var u proxyUsage
+		if v := r.usage[p.Address]; v != nil {
+			u = *v
+		}
+		if (r.limits.MaxConns <= 0 || u.active < r.limits.MaxConns) &&
+			(r.limits.Cooldown <= 0 || now.Sub(u.lastUsed) >= r.limits.Cooldown) {
+			allowed = append(allowed, p)
+		}
+		if idlest == nil || u.active < idlestUsage.active ||
+			u.active == idlestUsage.active && u.lastUsed.Before(idlestUsage.lastUsed) {
+			idlest, idlestUsage = p, u
+		}
+	}
+	if len(allowed) == 0 {
+		return []*Proxy{idlest}
+	}
+	return allowed
+}
@@ -0,0 +1,227 @@
+package proxy
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// v2rayOutbound V2Ray/Xray 配置中的单个出站
+type v2rayOutbound struct {
+	Tag            string              `json:"tag,omitempty"`
+	Protocol       string              `json:"protocol"`
+	Settings       v2rayServerSettings `json:"settings"`
+	StreamSettings *v2rayStream        `json:"streamSettings,omitempty"`
+}
+
+// v2rayServerSettings socks/http 出站的服务器列表
+type v2rayServerSettings struct {
+	Servers []v2rayServer `json:"servers"`
+}
+
+// v2rayServer 出站服务器地址，带认证的服务器在 users 中给出凭据
+type v2rayServer struct {
+	Address string      `json:"address"`
+	Port    int         `json:"port"`
+	Users   []v2rayUser `json:"users,omitempty"`
+}
+
+// v2rayUser socks/http 出站的认证凭据
+type v2rayUser struct {
+	User string `json:"user"`
+	Pass string `json:"pass"`
+}
+
+// v2rayStream 出站传输设置，这里只关心是否启用TLS
+type v2rayStream struct {
+	Security string `json:"security,omitempty"`
+}
+
+// exportV2Ray 生成 V2Ray/Xray 的 outbounds 配置
+// V2Ray 的 socks 出站只支持 SOCKS5，SOCKS4 代理会被跳过；HTTPS代理以 http 出站加 TLS 表示
+func exportV2Ray(proxies []*Proxy) ([]byte, int, error) {
+	outbounds := make([]v2rayOutbound, 0, len(proxies))
+	for _, p := range proxies {
+		port, ok := proxyPort(p)
+		if !ok {
+			continue
+		}
+		server := v2rayServer{Address: p.Host(), Port: port}
+		if p.Username != "" {
+			server.Users = []v2rayUser{{User: p.Username, Pass: p.Password}}
+		}
+		out := v2rayOutbound{
+			Tag:      fmt.Sprintf("proxy-%d", len(outbounds)+1),
+			Settings: v2rayServerSettings{Servers: []v2rayServer{server}},
+		}
+		switch strings.ToLower(p.Protocol) {
+		case "http":
+			out.Protocol = "http"
+		case "https":
+			out.Protocol = "http"
+			out.StreamSettings = &v2rayStream{Security: "tls"}
+		case "socks5":
+			out.Protocol = "socks"
+		default:
+			continue
+		}
+		outbounds = append(outbounds, out)
+	}
+
+	data, err := json.MarshalIndent(struct {
+		Outbounds []v2rayOutbound `json:"outbounds"`
+	}{outbounds}, "", "  ")
+	if err != nil {
+		return nil, 0, err
+	}
+	return append(data, '\n'), len(outbounds), nil
+}
+
+// ParseV2RayJSON 从 V2Ray/Xray 配置中解析 socks 和 http 出站
+// 支持完整配置({"outbounds": [...]})、出站数组和单个出站三种形式
+// 服务器带多个用户时只取第一个用户的凭据
+// 返回解析出的代理和被跳过的出站或服务器数量(其他协议或地址无效的服务器)
+func ParseV2RayJSON(data []byte) ([]*Proxy, int, error) {
+	var outbounds []v2rayOutbound
+	trimmed := strings.TrimSpace(string(data))
+	switch {
+	case strings.HasPrefix(trimmed, "["):
+		if err := json.Unmarshal(data, &outbounds); err != nil {
+			return nil, 0, fmt.Errorf("解析V2Ray出站列表失败: %v", err)
+		}
+	default:
+		var config struct {
+			Outbounds []v2rayOutbound `json:"outbounds"`
+		}
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, 0, fmt.Errorf("解析V2Ray配置失败: %v", err)
+		}
+		outbounds = config.Outbounds
+		if outbounds == nil {
+			var single v2rayOutbound
+			if err := json.Unmarshal(data, &single); err == nil && single.Protocol != "" {
+				outbounds = []v2rayOutbound{single}
+			}
+		}
+	}
+
+	var proxies []*Proxy
+	skipped := 0
+	for _, out := range outbounds {
+		var protocol string
+		switch out.Protocol {
+		case "socks":
+			protocol = "socks5"
+		case "http":
+			protocol = "http"
+			if out.StreamSettings != nil && out.StreamSettings.Security == "tls" {
+				protocol = "https"
+			}
+		default:
+			// freedom、blackhole 以及 vmess 等协议无法作为普通代理使用
+			skipped++
+			continue
+		}
+		for _, srv := range out.Settings.Servers {
+			if srv.Address == "" || srv.Port <= 0 || srv.Port > 65535 {
+				skipped++
+				continue
+			}
+			p := &Proxy{
+				Address:  net.JoinHostPort(srv.Address, strconv.Itoa(srv.Port)),
+				Protocol: protocol,
+			}
+			if len(srv.Users) > 0 {
+				p.Username = srv.Users[0].User
+				p.Password = srv.Users[0].Pass
+			}
+			proxies = append(proxies, p)
+		}
+	}
+	return proxies, skipped, nil
+}
+
+// exportShareLinks 生成每行一个的分享链接，如 socks://1.2.3.4:1080#美国%20120ms
+// SOCKS5 使用 V2Ray 生态通用的 socks:// 前缀，凭据按 V2RayN 的习惯写为 base64(用户名:密码)；
+// 其他协议使用各自的协议名，凭据以 user:pass@ 形式写入
+func exportShareLinks(proxies []*Proxy) ([]byte, int, error) {
+	var b strings.Builder
+	for _, p := range proxies {
+		scheme := strings.ToLower(p.Protocol)
+		userinfo := ""
+		if scheme == "socks5" {
+			scheme = "socks"
+			if p.Username != "" {
+				userinfo = base64.StdEncoding.EncodeToString([]byte(p.Username+":"+p.Password)) + "@"
+			}
+		} else if p.Username != "" {
+			userinfo = url.UserPassword(p.Username, p.Password).String() + "@"
+		}
+		fmt.Fprintf(&b, "%s://%s%s#%s\n", scheme, userinfo, p.Address, url.PathEscape(clashName(p)))
+	}
+	return []byte(b.String()), len(proxies), nil
+}
+
+// errUnsupportedShareLink 无法作为普通代理使用的分享链接
+var errUnsupportedShareLink = errors.New("不支持Shadowsocks等需要专用客户端的分享链接")
+
+// parseShareLink 解析 socks://、http:// 等分享链接的地址和凭据部分
+// 去掉链接中的备注(#)和参数(?)；用户信息可以是 user:pass，也可以是 V2RayN 格式的 base64(用户名:密码)
+// 参数 scheme: 已转为小写的协议前缀
+// 参数 rest: "://" 之后的部分
+func parseShareLink(scheme, rest string) (protocol, hostport, username, password string, err error) {
+	if scheme == "ss" {
+		return "", "", "", "", errUnsupportedShareLink
+	}
+	if i := strings.IndexAny(rest, "#?"); i >= 0 {
+		rest = rest[:i]
+	}
+	if i := strings.LastIndex(rest, "@"); i >= 0 {
+		username, password = splitUserinfo(rest[:i])
+		rest = rest[i+1:]
+	}
+
+	protocol = scheme
+	if scheme == "socks" {
+		protocol = "socks5"
+	}
+	return protocol, strings.TrimSuffix(rest, "/"), username, password, nil
+}
+
+// splitUserinfo 将用户信息拆分为用户名和密码
+// 用户信息可能是明文(可含URL转义)的 用户名:密码，也可能是其 base64 编码；
+// 只有解码结果含有冒号时才按 base64 处理，避免把明文用户名误当作 base64
+func splitUserinfo(userinfo string) (username, password string) {
+	if !strings.Contains(userinfo, ":") {
+		encoded := strings.TrimRight(userinfo, "=")
+		if raw, err := base64.RawStdEncoding.DecodeString(encoded); err == nil && utf8.Valid(raw) && strings.Contains(string(raw), ":") {
+			userinfo = string(raw)
+		} else if raw, err := base64.RawURLEncoding.DecodeString(encoded); err == nil && utf8.Valid(raw) && strings.Contains(string(raw), ":") {
+			userinfo = string(raw)
+		}
+	}
+	username, password, _ = strings.Cut(userinfo, ":")
+	if decoded, err := url.PathUnescape(username); err == nil {
+		username = decoded
+	}
+	if decoded, err := url.PathUnescape(password); err == nil {
+		password = decoded
+	}
+	return username, password
+}
+
+// proxyPort 返回代理地址中的端口
+func proxyPort(p *Proxy) (int, bool) {
+	_, portStr, err := net.SplitHostPort(p.Address)
+	if err != nil {
+		return 0, false
+	}
+	port, err := strconv.Atoi(portStr)
+	return port, err == nil
+}
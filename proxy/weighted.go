@@ -0,0 +1,89 @@
+package proxy
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// minWeight 评分为0(被扣完分)的代理的选择权重，保证其仍有少量机会被选中
+const minWeight = 1.0
+
+// weightedPool 按 Proxy.Score 加权随机选择代理的累积权重表
+// 构建时计算一次前缀和，选择时二分查找，代理池不变时可重复使用
+type weightedPool struct {
+	proxies    []*Proxy
+	cumulative []float64
+}
+
+// newWeightedPool 根据候选代理当前的评分构建累积权重表
+// 未测出延迟的代理不参与加权选择，仅当所有候选都未测量时才在其中等权选择
+func newWeightedPool(candidates []*Proxy) *weightedPool {
+	var measuredProxies []*Proxy
+	for _, p := range candidates {
+		if measured(p) {
+			measuredProxies = append(measuredProxies, p)
+		}
+	}
+	if len(measuredProxies) > 0 {
+		candidates = measuredProxies
+	}
+	w := &weightedPool{
+		proxies:    candidates,
+		cumulative: make([]float64, len(candidates)),
+	}
+	total := 0.0
+	for i, p := range candidates {
+		weight := p.Score
+		if weight < minWeight || !measured(p) {
+			weight = minWeight
+		}
+		total += weight
+		w.cumulative[i] = total
+	}
+	return w
+}
+
+// measured 判断代理是否已检测并测出延迟
+func measured(p *Proxy) bool {
+	return p.Latency > 0 && !p.LastChecked.IsZero()
+}
+
+// pick 按权重随机选择一个代理，表为空时返回nil
+func (w *weightedPool) pick(rng *rand.Rand) *Proxy {
+	if len(w.proxies) == 0 {
+		return nil
+	}
+	target := rng.Float64() * w.cumulative[len(w.cumulative)-1]
+	i := sort.SearchFloat64s(w.cumulative, target)
+	if i >= len(w.proxies) {
+		i = len(w.proxies) - 1
+	}
+	return w.proxies[i]
+}
+
+// pickWeighted 在候选代理中按评分加权随机选择一个，候选为空时返回nil
+// 调用方需持有写锁(随机数生成器不是并发安全的)
+func (r *Rotator) pickWeighted(candidates []*Proxy) *Proxy {
+	return newWeightedPool(candidates).pick(r.rng)
+}
+
+// pickFromPool 在全部可选代理中按评分加权随机选择一个
+// 使用缓存的累积权重表，代理池变化或有代理解除隔离后才重建；选中的代理在此期间被判定为劫持时重建后重选
+// 调用方需持有写锁
+func (r *Rotator) pickFromPool() *Proxy {
+	r.releaseQuarantine()
+	if r.pool == nil {
+		r.pool = newWeightedPool(r.selectable())
+	}
+	p := r.pool.pick(r.rng)
+	if p != nil && p.Hijacked {
+		r.pool = newWeightedPool(r.selectable())
+		p = r.pool.pick(r.rng)
+	}
+	return p
+}
+
+// invalidatePool 代理池变化后丢弃缓存的累积权重表，下次选择时重建，调用方需持有写锁
+func (r *Rotator) invalidatePool() {
+	r.pool = nil
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// AccessList 访问规则列表
+// 规则可以是IP、CIDR网段或域名；域名规则同时匹配其所有子域名
+// CIDR和IP规则只匹配IP形式的地址，不会为域名做本地解析(避免DNS泄漏)
+type AccessList struct {
+	domains []string
+	nets    []*net.IPNet
+}
+
+// SplitRules 将规则文本按逗号、分号、空白或换行拆分为单条规则
+func SplitRules(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == ';' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+}
+
+// ParseAccessList 解析规则文本，规则之间以逗号、空白或换行分隔
+// 返回解析后的规则列表，遇到无法识别的规则时返回错误
+func ParseAccessList(text string) (*AccessList, error) {
+	l := &AccessList{}
+	for _, rule := range SplitRules(text) {
+		rule = strings.ToLower(strings.TrimSpace(rule))
+		if _, ipNet, err := net.ParseCIDR(rule); err == nil {
+			l.nets = append(l.nets, ipNet)
+			continue
+		}
+		if ip := net.ParseIP(rule); ip != nil {
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 8 * net.IPv4len
+			}
+			l.nets = append(l.nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		domain := strings.TrimPrefix(strings.TrimPrefix(rule, "*."), ".")
+		if domain == "" || strings.ContainsAny(domain, "/:") {
+			return nil, fmt.Errorf("无法识别的规则: %s", rule)
+		}
+		l.domains = append(l.domains, domain)
+	}
+	return l, nil
+}
+
+// Empty 判断规则列表是否为空，nil 视为空列表
+func (l *AccessList) Empty() bool {
+	return l == nil || (len(l.domains) == 0 && len(l.nets) == 0)
+}
+
+// Match 判断主机(IP或域名)是否命中任一规则
+func (l *AccessList) Match(host string) bool {
+	if l.Empty() {
+		return false
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if ip := net.ParseIP(host); ip != nil {
+		for _, n := range l.nets {
+			if n.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, d := range l.domains {
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"sync"
+	"time"
+)
+
+// queueTimeout 排队的连接等待空闲名额的最长时间，超时后关闭连接
+const queueTimeout = 30 * time.Second
+
+// admission 新连接的准入结果
+type admission int
+
+const (
+	admitNow   admission = iota // 立即处理
+	admitQueue                  // 进入队列等待名额
+	admitDeny                   // 队列已满，拒绝
+)
+
+// connGate 限制同时处理的连接数
+// limit 为0时不限制；达到上限后最多 queue 个连接排队等待，其余直接拒绝，
+// 因此处理连接的goroutine数量不超过 limit+queue
+type connGate struct {
+	mutex   sync.Mutex
+	cond    *sync.Cond
+	limit   int
+	queue   int
+	active  int
+	waiting int
+}
+
+// newConnGate 创建不限制并发的连接闸门
+func newConnGate() *connGate {
+	g := &connGate{}
+	g.cond = sync.NewCond(&g.mutex)
+	return g
+}
+
+// setLimit 修改并发上限和队列长度，唤醒等待中的连接按新上限重新判断
+func (g *connGate) setLimit(limit, queue int) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.limit = limit
+	g.queue = queue
+	g.cond.Broadcast()
+}
+
+// admit 判断新连接能否立即处理，立即处理或排队时同时占用相应的计数
+// 已有连接在排队时新连接也排队，保证先到先处理
+func (g *connGate) admit() admission {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if g.limit <= 0 || (g.active < g.limit && g.waiting == 0) {
+		g.active++
+		return admitNow
+	}
+	if g.waiting < g.queue {
+		g.waiting++
+		return admitQueue
+	}
+	return admitDeny
+}
+
+// wait 排队等待空闲名额，返回是否在超时前获得名额
+func (g *connGate) wait(timeout time.Duration) bool {
+	expired := false
+	timer := time.AfterFunc(timeout, func() {
+		g.mutex.Lock()
+		expired = true
+		g.cond.Broadcast()
+		g.mutex.Unlock()
+	})
+	defer timer.Stop()
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	for g.limit > 0 && g.active >= g.limit && !expired {
+		g.cond.Wait()
+	}
+	g.waiting--
+	if g.limit > 0 && g.active >= g.limit {
+		return false
+	}
+	g.active++
+	return true
+}
+
+// leave 连接处理结束，释放名额并唤醒一个排队的连接
+func (g *connGate) leave() {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.active--
+	g.cond.Signal()
+}
+
+// stats 返回正在处理和排队的连接数
+func (g *connGate) stats() (active, waiting int) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return g.active, g.waiting
+}
+
+// SetConnectionLimit 设置同时处理的最大连接数
+// 参数 max: 并发上限，0 表示不限制
+// 参数 queue: 达到上限后最多排队的连接数，0 表示直接拒绝；排队超过30秒仍无名额的连接被关闭
+func (s *Server) SetConnectionLimit(max, queue int) {
+	if max < 0 {
+		max = 0
+	}
+	if queue < 0 {
+		queue = 0
+	}
+	s.gate.setLimit(max, queue)
+}
+
+// ConnectionStats 返回正在处理和排队等待的连接数
+func (s *Server) ConnectionStats() (active, queued int) {
+	return s.gate.stats()
+}
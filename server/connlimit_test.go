@@ -0,0 +1,111 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+// waitAsync 在后台排队等待名额，结果从返回的通道发出
+func waitAsync(g *connGate, timeout time.Duration) <-chan bool {
+	result := make(chan bool, 1)
+	go func() { result <- g.wait(timeout) }()
+	return result
+}
+
+// receive 读取排队结果，超过1秒未返回视为等待没有被唤醒
+func receive(t *testing.T, result <-chan bool) bool {
+	t.Helper()
+	select {
+	case ok := <-result:
+		return ok
+	case <-time.After(time.Second):
+		t.Fatal("排队的连接没有被唤醒")
+		return false
+	}
+}
+
+func TestConnGateAdmit(t *testing.T) {
+	g := newConnGate()
+	for i := 0; i < 5; i++ {
+		if got := g.admit(); got != admitNow {
+			t.Fatalf("不限制并发时第 %d 个连接的准入结果 = %d，期望立即处理", i+1, got)
+		}
+	}
+
+	g = newConnGate()
+	g.setLimit(2, 1)
+	want := []admission{admitNow, admitNow, admitQueue, admitDeny}
+	for i, w := range want {
+		if got := g.admit(); got != w {
+			t.Errorf("第 %d 个连接的准入结果 = %d，期望 %d", i+1, got, w)
+		}
+	}
+	if active, waiting := g.stats(); active != 2 || waiting != 1 {
+		t.Errorf("处理中 %d 个、排队 %d 个，期望 2 和 1", active, waiting)
+	}
+
+	// 有连接在排队时，即使空出名额新连接也要排在后面
+	g.leave()
+	if got := g.admit(); got != admitDeny {
+		t.Errorf("已有连接排队时新连接的准入结果 = %d，期望队列已满被拒绝", got)
+	}
+}
+
+func TestConnGateWaitForSlot(t *testing.T) {
+	g := newConnGate()
+	g.setLimit(1, 1)
+	g.admit()
+	if got := g.admit(); got != admitQueue {
+		t.Fatalf("第 2 个连接的准入结果 = %d，期望排队", got)
+	}
+	result := waitAsync(g, 5*time.Second)
+
+	g.leave()
+	if !receive(t, result) {
+		t.Error("名额释放后排队的连接没有获得名额")
+	}
+	if active, waiting := g.stats(); active != 1 || waiting != 0 {
+		t.Errorf("处理中 %d 个、排队 %d 个，期望 1 和 0", active, waiting)
+	}
+}
+
+func TestConnGateWaitTimeout(t *testing.T) {
+	g := newConnGate()
+	g.setLimit(1, 1)
+	g.admit()
+	g.admit()
+
+	start := time.Now()
+	if g.wait(50 * time.Millisecond) {
+		t.Error("没有空闲名额时排队的连接获得了名额")
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("排队 %v 后就返回，期望等到超时", elapsed)
+	}
+	if active, waiting := g.stats(); active != 1 || waiting != 0 {
+		t.Errorf("超时后处理中 %d 个、排队 %d 个，期望 1 和 0", active, waiting)
+	}
+}
+
+func TestConnGateSetLimitZeroReleasesWaiters(t *testing.T) {
+	g := newConnGate()
+	g.setLimit(1, 2)
+	g.admit()
+	var results []<-chan bool
+	for i := 0; i < 2; i++ {
+		if got := g.admit(); got != admitQueue {
+			t.Fatalf("第 %d 个连接的准入结果 = %d，期望排队", i+2, got)
+		}
+		results = append(results, waitAsync(g, 5*time.Second))
+	}
+
+	g.setLimit(0, 0)
+	for i, result := range results {
+		if !receive(t, result) {
+			t.Errorf("取消并发限制后第 %d 个排队的连接没有获得名额", i+1)
+		}
+	}
+	if active, waiting := g.stats(); active != 3 || waiting != 0 {
+		t.Errorf("处理中 %d 个、排队 %d 个，期望 3 和 0", active, waiting)
+	}
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ConnRecord 一个客户端连接的处理记录，连接结束时写入日志
+// Client: 客户端地址
+// Target: 请求的目标地址，握手失败时为空
+// Upstream: 出口代理地址，未选到代理时为空
+// BytesUp/BytesDown: 发往目标和从目标收到的字节数
+// Err: 失败原因，正常结束时为空
+type ConnRecord struct {
+	Start     time.Time     `json:"start"`
+	Client    string        `json:"client"`
+	Target    string        `json:"target"`
+	Upstream  string        `json:"upstream"`
+	BytesUp   int64         `json:"bytes_up"`
+	BytesDown int64         `json:"bytes_down"`
+	Duration  time.Duration `json:"duration"`
+	Err       string        `json:"error,omitempty"`
+}
+
+// connTracker 记录连接处理过程中的目标、上游、流量和错误
+// 转发期间两个方向的字节数由不同goroutine累加，使用原子操作
+type connTracker struct {
+	record    ConnRecord
+	bytesUp   int64
+	bytesDown int64
+}
+
+// SetLogger 替换服务使用的日志实例，用于统一日志级别、格式和输出位置
+// 需在 Start 之前调用
+func (s *Server) SetLogger(logger *logrus.Logger) {
+	if logger == nil {
+		return
+	}
+	s.logger = logger
+}
+
+// SetConnectionHook 设置连接结束时的回调，用于在界面中展示最近的连接
+func (s *Server) SetConnectionHook(hook func(ConnRecord)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.connHook = hook
+}
+
+// trackConn 开始记录一个客户端连接
+func (s *Server) trackConn(clientConn net.Conn) *connTracker {
+	return &connTracker{record: ConnRecord{Start: time.Now(), Client: clientConn.RemoteAddr().String()}}
+}
+
+// fail 记录连接失败的原因，只保留第一个错误
+func (t *connTracker) fail(err error) {
+	if t.record.Err == "" && err != nil {
+		t.record.Err = err.Error()
+	}
+}
+
+// addUp 累加发往目标的字节数
+func (t *connTracker) addUp(n int64) {
+	atomic.AddInt64(&t.bytesUp, n)
+}
+
+// addDown 累加从目标收到的字节数
+func (t *connTracker) addDown(n int64) {
+	atomic.AddInt64(&t.bytesDown, n)
+}
+
+// finishConn 结束连接记录，输出一条结构化日志并通知回调
+// 失败的连接以 Warn 级别输出，正常结束的连接以 Info 级别输出
+func (s *Server) finishConn(t *connTracker) {
+	rec := t.record
+	rec.BytesUp = atomic.LoadInt64(&t.bytesUp)
+	rec.BytesDown = atomic.LoadInt64(&t.bytesDown)
+	rec.Duration = time.Since(rec.Start)
+
+	entry := s.logger.WithFields(logrus.Fields{
+		"listen":      s.addr,
+		"client":      rec.Client,
+		"target":      rec.Target,
+		"upstream":    rec.Upstream,
+		"bytes_up":    rec.BytesUp,
+		"bytes_down":  rec.BytesDown,
+		"duration_ms": rec.Duration.Milliseconds(),
+	})
+	if rec.Err != "" {
+		entry.WithField("error", rec.Err).Warn("连接失败")
+	} else {
+		entry.Info("连接结束")
+	}
+
+	s.mutex.Lock()
+	hook := s.connHook
+	s.mutex.Unlock()
+	if hook != nil {
+		hook(rec)
+	}
+}
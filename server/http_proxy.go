@@ -0,0 +1,168 @@
+package server
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// hopHeaders 逐跳头部，转发给目标服务器前需要移除
+var hopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// bufferedConn 先读取 bufio.Reader 中已缓冲的数据，再读取底层连接
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
+
+// CloseWrite 关闭底层TCP连接的写方向，使转发结束时对端能收到EOF
+func (c *bufferedConn) CloseWrite() error {
+	if tcpConn, ok := c.Conn.(interface{ CloseWrite() error }); ok {
+		return tcpConn.CloseWrite()
+	}
+	return nil
+}
+
+// handleHTTPConnection 完整处理单个HTTP代理客户端连接
+// CONNECT 请求建立隧道后双向转发；普通请求(GET等)改写为源站形式后转发，
+// 每个连接只处理一个普通请求，响应结束后关闭连接
+// 参数 clientConn: 客户端TCP连接
+func (s *Server) handleHTTPConnection(clientConn net.Conn) {
+	defer clientConn.Close()
+	conn := s.trackConn(clientConn)
+	defer s.finishConn(conn)
+
+	reader := bufio.NewReader(clientConn)
+	req, err := http.ReadRequest(reader)
+	if err != nil {
+		conn.fail(fmt.Errorf("读取HTTP代理请求失败: %v", err))
+		return
+	}
+
+	user, ok := s.httpAuthorized(req)
+	if !ok {
+		conn.fail(errors.New("HTTP代理认证失败"))
+		fmt.Fprint(clientConn, "HTTP/1.1 407 Proxy Authentication Required\r\n"+
+			"Proxy-Authenticate: Basic realm=\"go_proxy\"\r\nContent-Length: 0\r\nConnection: close\r\n\r\n")
+		return
+	}
+
+	targetAddr, err := httpTargetAddr(req)
+	if err != nil {
+		conn.fail(fmt.Errorf("HTTP代理请求无效: %v", err))
+		httpReply(clientConn, http.StatusBadRequest)
+		return
+	}
+	conn.record.Target = targetAddr
+
+	if !s.targetAllowed(targetAddr) {
+		conn.fail(errors.New("目标被访问规则禁止"))
+		httpReply(clientConn, http.StatusForbidden)
+		return
+	}
+
+	upstreamConn, upstream, err := s.connectUpstream(targetAddr, sessionKey(clientConn.RemoteAddr(), user))
+	if err != nil {
+		conn.fail(err)
+		if errors.Is(err, errNoUpstream) {
+			httpReply(clientConn, http.StatusServiceUnavailable)
+		} else {
+			httpReply(clientConn, http.StatusBadGateway)
+		}
+		return
+	}
+	defer upstreamConn.Close()
+	defer s.rotator.Release(upstream)
+	conn.record.Upstream = upstream.Address
+	s.noteSelfTestUpstream(clientConn.RemoteAddr(), upstream)
+
+	if req.Method == http.MethodConnect {
+		if _, err := fmt.Fprint(clientConn, "HTTP/1.1 200 Connection Established\r\n\r\n"); err != nil {
+			conn.fail(fmt.Errorf("发送CONNECT应答失败: %v", err))
+			return
+		}
+		s.forwardData(&bufferedConn{Conn: clientConn, reader: reader}, upstreamConn, upstream, conn)
+		return
+	}
+
+	for _, h := range hopHeaders {
+		req.Header.Del(h)
+	}
+	req.Close = true
+	if err := req.Write(&trafficWriter{w: upstreamConn, record: func(n int64) {
+		s.rotator.AddTraffic(upstream, n, 0)
+		conn.addUp(n)
+	}}); err != nil {
+		conn.fail(fmt.Errorf("转发HTTP请求到 %s 失败: %v", targetAddr, err))
+		httpReply(clientConn, http.StatusBadGateway)
+		return
+	}
+	s.forwardData(&bufferedConn{Conn: clientConn, reader: reader}, upstreamConn, upstream, conn)
+}
+
+// httpAuthorized 校验请求的 Proxy-Authorization 基本认证，未设置凭据时直接通过
+// 返回认证通过的用户名(无需认证时为空)和是否通过
+func (s *Server) httpAuthorized(req *http.Request) (string, bool) {
+	if user, _ := s.credentials(); user == "" {
+		return "", true
+	}
+	auth := req.Header.Get("Proxy-Authorization")
+	scheme, encoded, ok := strings.Cut(auth, " ")
+	if !ok || !strings.EqualFold(scheme, "Basic") {
+		return "", false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return "", false
+	}
+	user, pass, ok := strings.Cut(string(decoded), ":")
+	if !ok || !s.checkCredentials(user, pass) {
+		return "", false
+	}
+	return user, true
+}
+
+// httpTargetAddr 从代理请求中解析目标地址(host:port)
+// CONNECT 请求使用 authority 形式，普通请求使用绝对URL，缺省端口按协议补全
+func httpTargetAddr(req *http.Request) (string, error) {
+	if req.Method == http.MethodConnect {
+		if _, _, err := net.SplitHostPort(req.Host); err != nil {
+			return "", fmt.Errorf("CONNECT目标无效: %s", req.Host)
+		}
+		return req.Host, nil
+	}
+	if !req.URL.IsAbs() || req.URL.Host == "" {
+		return "", fmt.Errorf("代理请求必须使用绝对URL: %s", req.RequestURI)
+	}
+	if !strings.EqualFold(req.URL.Scheme, "http") {
+		return "", fmt.Errorf("不支持的请求协议: %s", req.URL.Scheme)
+	}
+	port := req.URL.Port()
+	if port == "" {
+		port = "80"
+	}
+	return net.JoinHostPort(req.URL.Hostname(), port), nil
+}
+
+// httpReply 向客户端发送只有状态行的HTTP应答
+func httpReply(conn net.Conn, status int) {
+	fmt.Fprintf(conn, "HTTP/1.1 %d %s\r\nContent-Length: 0\r\nConnection: close\r\n\r\n", status, http.StatusText(status))
+}
@@ -0,0 +1,201 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go_proxy/proxy"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ListenerConfig 附加监听器的配置
+// 每个监听器是一个独立的 Server 实例，共用代理池，但各自使用不同的上游选择策略
+// Name: 显示名称，为空时使用监听地址
+// Region: 国家筛选，多个国家以逗号分隔，空表示不限制
+// Target: 上游代理必须通过的检测目标名称，空表示不限制
+// Pool: 绑定的命名代理池，空表示使用全部有效代理
+// StickyMinutes: 会话保持时长(分钟)，0 表示每个连接都轮换
+type ListenerConfig struct {
+	Name          string         `json:"name"`
+	Host          string         `json:"host"`
+	Port          int            `json:"port"`
+	Mode          ListenMode     `json:"mode"`
+	Region        string         `json:"region"`
+	Strategy      proxy.Strategy `json:"strategy"`
+	PremiumOnly   bool           `json:"premium_only"`
+	Target        string         `json:"target,omitempty"`
+	Pool          string         `json:"pool,omitempty"`
+	StickyMinutes int            `json:"sticky_minutes"`
+}
+
+// Addr 返回监听地址(host:port)，同时作为监听器的唯一标识
+func (c ListenerConfig) Addr() string {
+	return net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+}
+
+// Validate 检查监听器配置是否合法
+func (c ListenerConfig) Validate() error {
+	if err := ValidateBindHost(c.Host); err != nil {
+		return err
+	}
+	if c.Port <= 0 || c.Port > 65535 {
+		return fmt.Errorf("端口 %d 无效", c.Port)
+	}
+	switch c.Strategy {
+	case "", proxy.StrategyWeighted, proxy.StrategyRoundRobin, proxy.StrategyFastest, proxy.StrategyDiverse:
+	default:
+		return fmt.Errorf("未知的选择策略: %s", c.Strategy)
+	}
+	if c.StickyMinutes < 0 {
+		return errors.New("会话保持时长不能为负数")
+	}
+	return nil
+}
+
+// ListenerManager 管理同时运行的多个附加监听器
+// configure 在每个监听器启动前调用，用于应用认证、访问规则、限速等所有监听器共用的设置
+type ListenerManager struct {
+	rotator   *proxy.Rotator
+	logger    *logrus.Logger
+	configure func(*Server) error
+	mutex     sync.Mutex
+	listeners map[string]*managedListener
+}
+
+// managedListener 运行中的附加监听器
+type managedListener struct {
+	config ListenerConfig
+	server *Server
+}
+
+// NewListenerManager 创建监听器管理器
+// 参数 rotator: 所有监听器共用的代理轮换器
+// 参数 configure: 应用共用设置的回调，可以为nil
+func NewListenerManager(rotator *proxy.Rotator, configure func(*Server) error) *ListenerManager {
+	return &ListenerManager{
+		rotator:   rotator,
+		configure: configure,
+		listeners: make(map[string]*managedListener),
+	}
+}
+
+// SetLogger 设置之后启动的监听器使用的日志实例
+func (m *ListenerManager) SetLogger(logger *logrus.Logger) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.logger = logger
+}
+
+// Start 按配置创建并启动一个监听器
+// 地址已被其他监听器或程序占用时返回错误
+func (m *ListenerManager) Start(config ListenerConfig) error {
+	config.Host = strings.TrimSpace(config.Host)
+	config.Region = strings.TrimSpace(config.Region)
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	if config.Mode != ModeHTTP {
+		config.Mode = ModeSOCKS5
+	}
+	config.Pool = strings.TrimSpace(config.Pool)
+	if config.Pool != "" && !m.rotator.HasPool(config.Pool) {
+		return fmt.Errorf("代理池 %s 不存在", config.Pool)
+	}
+	addr := config.Addr()
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if _, ok := m.listeners[addr]; ok {
+		return fmt.Errorf("监听器 %s 已在运行", addr)
+	}
+
+	srv := NewServer(config.Host, config.Port, m.rotator)
+	srv.SetLogger(m.logger)
+	if m.configure != nil {
+		if err := m.configure(srv); err != nil {
+			return err
+		}
+	}
+	srv.SetListenMode(config.Mode)
+	srv.SetRegion(config.Region)
+	srv.SetStrategy(config.Strategy)
+	srv.SetPremiumOnly(config.PremiumOnly)
+	srv.SetTarget(config.Target)
+	srv.SetPool(config.Pool)
+	srv.SetStickySessions(time.Duration(config.StickyMinutes) * time.Minute)
+	if err := srv.Start(); err != nil {
+		return err
+	}
+	m.listeners[addr] = &managedListener{config: config, server: srv}
+	return nil
+}
+
+// Stop 停止指定地址的监听器
+func (m *ListenerManager) Stop(addr string) error {
+	m.mutex.Lock()
+	l, ok := m.listeners[addr]
+	delete(m.listeners, addr)
+	m.mutex.Unlock()
+	if !ok {
+		return fmt.Errorf("监听器 %s 不存在", addr)
+	}
+	return l.server.Stop()
+}
+
+// StopAll 停止全部监听器
+func (m *ListenerManager) StopAll() {
+	m.mutex.Lock()
+	listeners := m.listeners
+	m.listeners = make(map[string]*managedListener)
+	m.mutex.Unlock()
+	for _, l := range listeners {
+		l.server.Stop()
+	}
+}
+
+// List 返回运行中监听器的配置，按地址排序
+func (m *ListenerManager) List() []ListenerConfig {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	configs := make([]ListenerConfig, 0, len(m.listeners))
+	for _, l := range m.listeners {
+		configs = append(configs, l.config)
+	}
+	sort.Slice(configs, func(i, j int) bool { return configs[i].Addr() < configs[j].Addr() })
+	return configs
+}
+
+// ConnectionStats 返回所有附加监听器正在处理和排队的连接数之和
+func (m *ListenerManager) ConnectionStats() (active, queued int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for _, l := range m.listeners {
+		a, q := l.server.ConnectionStats()
+		active += a
+		queued += q
+	}
+	return active, queued
+}
+
+// Reconfigure 对所有运行中的监听器重新应用共用设置
+// 用于认证、访问规则或限速修改后立即生效
+func (m *ListenerManager) Reconfigure() error {
+	if m.configure == nil {
+		return nil
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for _, l := range m.listeners {
+		if err := m.configure(l.server); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiter 令牌桶限速器，令牌单位为字节
+// 桶容量为一秒的速率，允许短时突发；令牌不足时预支并按欠额休眠，多个连接共用时按到达顺序排队
+type rateLimiter struct {
+	mutex  sync.Mutex
+	rate   float64 // 每秒补充的字节数
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter 创建限速器
+// 参数 bytesPerSec: 每秒允许的字节数，不大于0时返回nil(不限速)
+func newRateLimiter(bytesPerSec int64) *rateLimiter {
+	if bytesPerSec <= 0 {
+		return nil
+	}
+	return &rateLimiter{rate: float64(bytesPerSec), tokens: float64(bytesPerSec), last: time.Now()}
+}
+
+// wait 消耗 n 字节的令牌，令牌不足时阻塞到补足为止；nil 限速器直接返回
+func (l *rateLimiter) wait(n int) {
+	if l == nil {
+		return
+	}
+	l.mutex.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.rate {
+		l.tokens = l.rate
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mutex.Unlock()
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+// SetRateLimits 设置转发限速，对之后建立的连接生效
+// 上传和下载方向分别限速，0 表示不限制
+// 参数 perConn: 每个连接每个方向的速率上限(字节/秒)
+// 参数 global: 所有连接合计每个方向的速率上限(字节/秒)
+func (s *Server) SetRateLimits(perConn, global int64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.connRate = perConn
+	s.globalUp = newRateLimiter(global)
+	s.globalDown = newRateLimiter(global)
+}
+
+// connLimiters 为新连接创建上传和下载方向的限速器列表
+// 每个方向依次经过连接限速和全局限速
+func (s *Server) connLimiters() (up, down []*rateLimiter) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if l := newRateLimiter(s.connRate); l != nil {
+		up = append(up, l)
+	}
+	if l := newRateLimiter(s.connRate); l != nil {
+		down = append(down, l)
+	}
+	if s.globalUp != nil {
+		up = append(up, s.globalUp)
+	}
+	if s.globalDown != nil {
+		down = append(down, s.globalDown)
+	}
+	return up, down
+}
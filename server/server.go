@@ -1,6 +1,8 @@
 package server
 
 import (
+	"bytes"
+	"crypto/subtle"
 	"encoding/binary"
 	"encoding/json"
 	"errors"
@@ -12,6 +14,8 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"go_proxy/proxy"
@@ -24,17 +28,99 @@ import (
 // 实现基于代理池的SOCKS5代理服务器，支持动态代理切换
 // 包含服务配置、代理轮换器和连接管理功能
 type Server struct {
-	socks5Addr string
-	rotator    *proxy.Rotator
-	logger     *logrus.Logger
+	host    string
+	addr    string
+	mode    ListenMode
+	rotator *proxy.Rotator
+	logger  *logrus.Logger
 
 	listener     net.Listener
 	running      bool
 	mutex        sync.Mutex
 	healthTicker *time.Ticker
 	healthStop   chan struct{}
+	lastUpstream *proxy.Proxy
+
+	// 自检连接实际使用的上游代理，以自检客户端的地址为键，见 SelfTest
+	selfTests map[string]*proxy.Proxy
+
+	// 链式转发，开启后每个连接依次经过两个上游代理
+	chainMode bool
+
+	// 只使用标记为高级的上游代理
+	premiumOnly bool
+
+	// 上游代理的国家筛选和挑选方式，turn 为轮询序号
+	region   string
+	strategy proxy.Strategy
+	turn     uint64
+
+	// 上游代理必须通过的检测目标名称，空表示不限制
+	target string
+
+	// 绑定的命名代理池，空表示使用全部有效代理
+	pool string
+
+	// 固定的上游代理，非空时新连接都优先使用该代理，见 SetFixedUpstream
+	fixed *proxy.Proxy
+
+	// 访问控制，nil 表示不限制
+	targetBlock *AccessList
+	targetAllow *AccessList
+	clientAllow *AccessList
+	clientDeny  *AccessList
+
+	// 客户端认证凭据，用户名为空表示不需要认证
+	authUser string
+	authPass string
+
+	// 上游连接失败时最多尝试的代理数量
+	maxAttempts int
+
+	// 会话保持，开启后同一客户端在有效期内复用同一上游代理
+	sticky stickyTable
+
+	// 目标主机亲和，开启后同一目标主机在有效期内复用同一上游代理
+	affinity stickyTable
+
+	// 连接结束时的回调，见 SetConnectionHook
+	connHook func(ConnRecord)
+
+	// 并发连接限制，见 SetConnectionLimit
+	gate *connGate
+
+	// 转发限速: 每个连接每个方向的速率上限(字节/秒，0 表示不限制)和全局共享的限速器
+	connRate   int64
+	globalUp   *rateLimiter
+	globalDown *rateLimiter
 }
 
+// defaultMaxAttempts 默认每个连接最多尝试的上游代理数量
+const defaultMaxAttempts = 3
+
+// staleProxyAge 清理失效代理时，超过该时长未检测的代理同样被移除
+const staleProxyAge = 24 * time.Hour
+
+// ListenMode 本地服务监听的协议
+type ListenMode string
+
+const (
+	ModeSOCKS5 ListenMode = "socks5"
+	ModeHTTP   ListenMode = "http"
+)
+
+// SOCKS5 应答状态码
+const (
+	socks5Succeeded          byte = 0x00
+	socks5GeneralFailure     byte = 0x01
+	socks5NotAllowed         byte = 0x02
+	socks5HostUnreachable    byte = 0x04
+	socks5CommandUnsupported byte = 0x07
+)
+
+// selfTestURL 本地服务自检时访问的地址，返回请求的来源IP
+const selfTestURL = "http://httpbin.org/ip"
+
 // NewServer 创建新的代理服务实例
 // 参数 host: 监听主机地址
 // 参数 port: 监听端口号
@@ -42,13 +128,48 @@ type Server struct {
 // 返回初始化后的Server实例
 func NewServer(host string, port int, rotator *proxy.Rotator) *Server {
 	return &Server{
-		socks5Addr: fmt.Sprintf("%s:%d", host, port),
-		rotator:    rotator,
-		logger:     logrus.New(),
+		host:        host,
+		addr:        net.JoinHostPort(host, strconv.Itoa(port)),
+		mode:        ModeSOCKS5,
+		rotator:     rotator,
+		logger:      logrus.New(),
+		maxAttempts: defaultMaxAttempts,
+		gate:        newConnGate(),
+		selfTests:   make(map[string]*proxy.Proxy),
 	}
 }
 
-// Start 启动SOCKS5代理服务
+// SetMaxAttempts 设置上游连接失败时每个客户端连接最多尝试的代理数量
+// 小于1时按1处理(不重试)
+func (s *Server) SetMaxAttempts(n int) {
+	if n < 1 {
+		n = 1
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.maxAttempts = n
+}
+
+// SetListenMode 设置监听协议，需在 Start 之前调用
+// 参数 mode: ModeSOCKS5 或 ModeHTTP，未知值按SOCKS5处理
+func (s *Server) SetListenMode(mode ListenMode) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if mode != ModeHTTP {
+		mode = ModeSOCKS5
+	}
+	s.mode = mode
+}
+
+// modeName 返回监听协议的显示名称
+func (s *Server) modeName() string {
+	if s.mode == ModeHTTP {
+		return "HTTP"
+	}
+	return "SOCKS5"
+}
+
+// Start 启动代理服务
 // 开始在指定地址监听TCP连接
 // 如果服务已运行或监听失败返回错误
 func (s *Server) Start() error {
@@ -58,21 +179,144 @@ func (s *Server) Start() error {
 		return errors.New("服务已在运行")
 	}
 
-	listener, err := net.Listen("tcp", s.socks5Addr)
+	listener, err := net.Listen("tcp", s.addr)
 	if err != nil {
 		s.mutex.Unlock()
-		return fmt.Errorf("SOCKS5监听失败: %v", err)
+		if isAddrInUse(err) {
+			return &PortInUseError{Addr: s.addr, Err: err}
+		}
+		return fmt.Errorf("%s监听失败: %v", s.modeName(), err)
 	}
 	s.listener = listener
 	s.running = true
 	s.mutex.Unlock()
 
-	s.logger.Infof("SOCKS5代理服务已在 %s 启动", s.listener.Addr().String())
+	s.logger.Infof("%s代理服务已在 %s 启动", s.modeName(), s.listener.Addr().String())
 	go s.acceptConnections()
 	return nil
 }
 
-// Stop 停止SOCKS5代理服务
+// PortInUseError 监听地址已被其他程序占用
+type PortInUseError struct {
+	Addr string
+	Err  error
+}
+
+func (e *PortInUseError) Error() string {
+	return fmt.Sprintf("端口已被占用: %s", e.Addr)
+}
+
+func (e *PortInUseError) Unwrap() error {
+	return e.Err
+}
+
+// wsaeaddrinuse Windows 下地址被占用的错误码
+const wsaeaddrinuse = syscall.Errno(10048)
+
+// wsaeconnreset Windows 下连接被对端重置的错误码
+const wsaeconnreset = syscall.Errno(10054)
+
+// isConnReset 判断读取错误是否为连接被对端重置
+func isConnReset(err error) bool {
+	var errno syscall.Errno
+	if !errors.As(err, &errno) {
+		return false
+	}
+	return errno == syscall.ECONNRESET || errno == wsaeconnreset
+}
+
+// isAddrInUse 判断监听错误是否为地址已被占用
+func isAddrInUse(err error) bool {
+	var errno syscall.Errno
+	if !errors.As(err, &errno) {
+		return false
+	}
+	return errno == syscall.EADDRINUSE || errno == wsaeaddrinuse
+}
+
+// ValidateBindHost 检查监听地址是否为合法的IP或主机名
+func ValidateBindHost(host string) error {
+	if host == "" {
+		return errors.New("监听地址不能为空")
+	}
+	if net.ParseIP(host) != nil {
+		return nil
+	}
+	if len(host) > 253 {
+		return fmt.Errorf("无效的监听地址: %s", host)
+	}
+	for _, label := range strings.Split(strings.TrimSuffix(host, "."), ".") {
+		if label == "" || len(label) > 63 || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return fmt.Errorf("无效的监听地址: %s", host)
+		}
+		for _, r := range label {
+			if !(r == '-' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+				return fmt.Errorf("无效的监听地址: %s", host)
+			}
+		}
+	}
+	return nil
+}
+
+// IsLoopbackHost 判断监听地址是否只对本机开放
+func IsLoopbackHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// LocalBindAddresses 返回可供选择的监听地址
+// 依次为本机IPv4/IPv6回环地址、所有网卡(0.0.0.0 和 ::)以及各个已启用网卡的单播IP，不含链路本地地址
+// 监听 :: 时大多数系统同时接受IPv4连接
+func LocalBindAddresses() []string {
+	addrs := []string{"127.0.0.1", "::1", "0.0.0.0", "::"}
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return addrs
+	}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		ifaceAddrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range ifaceAddrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok || ipNet.IP.IsLinkLocalUnicast() {
+				continue
+			}
+			addrs = append(addrs, ipNet.IP.String())
+		}
+	}
+	return addrs
+}
+
+// IsPortAvailable 检查服务监听主机上的端口当前是否可以绑定
+func (s *Server) IsPortAvailable(port int) bool {
+	listener, err := net.Listen("tcp", net.JoinHostPort(s.host, strconv.Itoa(port)))
+	if err != nil {
+		return false
+	}
+	listener.Close()
+	return true
+}
+
+// NextAvailablePort 从 start 开始向后查找第一个可用端口
+// 最多尝试100个端口，找不到时返回0
+func (s *Server) NextAvailablePort(start int) int {
+	for port := start; port < start+100 && port <= 65535; port++ {
+		if s.IsPortAvailable(port) {
+			return port
+		}
+	}
+	return 0
+}
+
+// Stop 停止代理服务
 // 关闭监听器并停止接受新连接
 // 如果服务未运行返回错误
 func (s *Server) Stop() error {
@@ -83,16 +327,328 @@ func (s *Server) Stop() error {
 	}
 	s.running = false
 	if err := s.listener.Close(); err != nil {
-		s.logger.Errorf("关闭SOCKS5监听器错误: %v", err)
+		s.logger.Errorf("关闭%s监听器错误: %v", s.modeName(), err)
 	}
 	if s.healthTicker != nil {
 		s.healthTicker.Stop()
 		close(s.healthStop)
 	}
-	s.logger.Info("SOCKS5代理服务已停止")
+	s.logger.Infof("%s代理服务已停止", s.modeName())
 	return nil
 }
 
+// SetTargetBlocklist 设置目标地址黑名单，命中的目标将被拒绝
+// 参数 rules: 以逗号或空白分隔的IP/CIDR/域名规则，为空表示不限制
+func (s *Server) SetTargetBlocklist(rules string) error {
+	list, err := ParseAccessList(rules)
+	if err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.targetBlock = list
+	return nil
+}
+
+// SetTargetAllowlist 设置目标地址白名单，非空时只允许命中的目标
+// 参数 rules: 以逗号或空白分隔的IP/CIDR/域名规则，为空表示不限制
+func (s *Server) SetTargetAllowlist(rules string) error {
+	list, err := ParseAccessList(rules)
+	if err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.targetAllow = list
+	return nil
+}
+
+// SetClientAllowlist 设置允许连接的客户端来源IP，非空时只接受命中的客户端
+// 参数 rules: 以逗号或空白分隔的IP/CIDR规则，为空表示不限制
+func (s *Server) SetClientAllowlist(rules string) error {
+	list, err := ParseAccessList(rules)
+	if err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.clientAllow = list
+	return nil
+}
+
+// SetClientDenylist 设置拒绝连接的客户端来源IP，优先于允许列表
+// 参数 rules: 以逗号或空白分隔的IP/CIDR规则，为空表示不拒绝
+func (s *Server) SetClientDenylist(rules string) error {
+	list, err := ParseAccessList(rules)
+	if err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.clientDeny = list
+	return nil
+}
+
+// SetAuth 设置客户端认证的用户名和密码
+// SOCKS5使用RFC 1929用户名/密码认证，HTTP使用 Proxy-Authorization 基本认证
+// 用户名为空时关闭认证
+func (s *Server) SetAuth(user, pass string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.authUser = user
+	s.authPass = pass
+}
+
+// credentials 返回当前的认证凭据
+func (s *Server) credentials() (string, string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.authUser, s.authPass
+}
+
+// checkCredentials 以固定时间比较客户端提供的凭据
+func (s *Server) checkCredentials(user, pass string) bool {
+	wantUser, wantPass := s.credentials()
+	userOK := subtle.ConstantTimeCompare([]byte(user), []byte(wantUser)) == 1
+	passOK := subtle.ConstantTimeCompare([]byte(pass), []byte(wantPass)) == 1
+	return userOK && passOK
+}
+
+// SetChainMode 开启或关闭双代理链式转发
+// 开启后延迟约为单跳的两倍，没有合适的第二个代理时自动退回单跳
+func (s *Server) SetChainMode(enabled bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.chainMode = enabled
+}
+
+// SetPremiumOnly 设置是否只使用标记为高级的上游代理
+// 开启后没有高级代理时连接将因无可用上游而失败
+func (s *Server) SetPremiumOnly(enabled bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.premiumOnly = enabled
+}
+
+// SetRegion 设置上游代理的国家筛选
+// 参数 region: 国家名称，多个以逗号分隔，空或 "All" 表示不限制；没有满足的代理时连接失败
+func (s *Server) SetRegion(region string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.region = strings.TrimSpace(region)
+}
+
+// SetStrategy 设置在候选代理中挑选上游代理的方式，空值为按综合评分加权随机
+func (s *Server) SetStrategy(strategy proxy.Strategy) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.strategy = strategy
+}
+
+// SetTarget 设置上游代理必须通过的检测目标
+// 参数 name: 检测目标名称，为空表示不限制；没有通过该目标的代理时连接失败
+func (s *Server) SetTarget(name string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.target = strings.TrimSpace(name)
+}
+
+// SetPool 将服务绑定到命名代理池，只从池中选择上游代理
+// 参数 name: 代理池名称，为空表示使用全部有效代理；池不存在或为空时连接失败
+func (s *Server) SetPool(name string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.pool = strings.TrimSpace(name)
+}
+
+// SetFixedUpstream 固定新连接使用的上游代理，用于让定时轮换决定出口IP
+// 固定的代理优先于会话保持、目标主机亲和和链式转发；代理已失效、不满足上游筛选或连接失败时，
+// 该连接退回按策略选择其他代理
+// 参数 p: 上游代理，nil 表示恢复为每个连接按策略选择
+func (s *Server) SetFixedUpstream(p *proxy.Proxy) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.fixed = p
+}
+
+// policy 返回当前的上游选择策略
+func (s *Server) policy() proxy.Policy {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return proxy.Policy{Region: s.region, PremiumOnly: s.premiumOnly, Strategy: s.strategy, Target: s.target, Pool: s.pool, Previous: s.lastUpstream}
+}
+
+// targetAllowed 判断目标地址(host:port)是否允许访问
+func (s *Server) targetAllowed(targetAddr string) bool {
+	host, _, err := net.SplitHostPort(targetAddr)
+	if err != nil {
+		return false
+	}
+	s.mutex.Lock()
+	block, allow := s.targetBlock, s.targetAllow
+	s.mutex.Unlock()
+	if block.Match(host) {
+		return false
+	}
+	return allow.Empty() || allow.Match(host)
+}
+
+// clientAllowed 判断客户端来源地址是否允许连接
+// 命中拒绝列表的客户端一律拒绝；允许列表非空时只接受命中的客户端
+func (s *Server) clientAllowed(addr net.Addr) bool {
+	s.mutex.Lock()
+	allow, deny := s.clientAllow, s.clientDeny
+	s.mutex.Unlock()
+	if allow.Empty() && deny.Empty() {
+		return true
+	}
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return false
+	}
+	if deny.Match(host) {
+		return false
+	}
+	return allow.Empty() || allow.Match(host)
+}
+
+// LastUpstream 返回最近一次转发使用的上游代理
+// 尚未处理过连接时返回nil
+func (s *Server) LastUpstream() *proxy.Proxy {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.lastUpstream
+}
+
+// ExitReport 通过本地服务观察到的出口信息
+// ExitIP: 测试地址看到的出口IP，经多层转发时为最外层的IP
+// Country/Province/City: 出口IP的地理位置，由调用方查询填写
+// Latency: 经本地服务完成一次请求的耗时
+// Upstream: 本次使用的上游代理，未知时为nil
+type ExitReport struct {
+	ExitIP   string
+	Country  string
+	Province string
+	City     string
+	Latency  time.Duration
+	Upstream *proxy.Proxy
+}
+
+// SelfTest 以客户端身份通过本地服务(SOCKS5或HTTP)访问测试地址
+// 用于端到端验证 监听 → 轮换器 → 上游代理 整条链路是否正常
+// 报告的上游代理是自检连接本身使用的代理，不受同时进行的其他转发影响
+// 返回观察到的出口信息和可能的错误
+func (s *Server) SelfTest() (*ExitReport, error) {
+	s.mutex.Lock()
+	if !s.running {
+		s.mutex.Unlock()
+		return nil, errors.New("服务未在运行")
+	}
+	host, port, err := net.SplitHostPort(s.listener.Addr().String())
+	s.mutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	// 监听所有网卡时通过回环地址连接
+	if ip := net.ParseIP(host); ip == nil || ip.IsUnspecified() {
+		host = "127.0.0.1"
+	}
+
+	localAddr := net.JoinHostPort(host, port)
+	// 按客户端地址登记自检连接，处理该连接时记录所选的上游代理
+	var clients []string
+	defer func() {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		for _, client := range clients {
+			delete(s.selfTests, client)
+		}
+	}()
+	dial := dialerFunc(func(network, addr string) (net.Conn, error) {
+		conn, err := net.DialTimeout(network, localAddr, selfTestTimeout)
+		if err != nil {
+			return nil, err
+		}
+		client := conn.LocalAddr().String()
+		s.mutex.Lock()
+		s.selfTests[client] = nil
+		clients = append(clients, client)
+		s.mutex.Unlock()
+		return conn, nil
+	})
+
+	user, pass := s.credentials()
+	transport := &http.Transport{Dial: dial, DisableKeepAlives: true}
+	if s.mode == ModeHTTP {
+		proxyURL := &url.URL{Scheme: "http", Host: localAddr}
+		if user != "" {
+			proxyURL.User = url.UserPassword(user, pass)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	} else {
+		var auth *xproxy.Auth
+		if user != "" {
+			auth = &xproxy.Auth{User: user, Password: pass}
+		}
+		dialer, err := xproxy.SOCKS5("tcp", localAddr, auth, dial)
+		if err != nil {
+			return nil, err
+		}
+		transport.Dial = dialer.Dial
+	}
+	client := &http.Client{Transport: transport, Timeout: selfTestTimeout}
+	start := time.Now()
+	resp, err := client.Get(selfTestURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("测试地址返回异常状态: %s", resp.Status)
+	}
+	var result struct {
+		Origin string `json:"origin"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	// 经多层转发时 origin 为以逗号分隔的IP列表
+	exitIP, _, _ := strings.Cut(result.Origin, ",")
+	report := &ExitReport{
+		ExitIP:  strings.TrimSpace(exitIP),
+		Latency: time.Since(start),
+	}
+	s.mutex.Lock()
+	for _, client := range clients {
+		if upstream := s.selfTests[client]; upstream != nil {
+			report.Upstream = upstream
+		}
+	}
+	s.mutex.Unlock()
+	return report, nil
+}
+
+// selfTestTimeout 自检请求的超时时间
+const selfTestTimeout = 15 * time.Second
+
+// dialerFunc 将拨号函数适配为 xproxy.Dialer
+type dialerFunc func(network, addr string) (net.Conn, error)
+
+func (f dialerFunc) Dial(network, addr string) (net.Conn, error) {
+	return f(network, addr)
+}
+
+// noteSelfTestUpstream 记录自检连接所用的上游代理，不是 SelfTest 发起的连接时忽略
+// 参数 client: 客户端连接的远端地址
+func (s *Server) noteSelfTestUpstream(client net.Addr, upstream *proxy.Proxy) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.selfTests[client.String()]; ok {
+		s.selfTests[client.String()] = upstream
+	}
+}
+
 // StartHealthChecks 启动代理健康检查
 // interval: 检查间隔时间
 func (s *Server) StartHealthChecks(interval time.Duration) {
@@ -112,16 +668,13 @@ func (s *Server) StartHealthChecks(interval time.Duration) {
 
 // createProxyClient 创建配置了指定代理的HTTP客户端
 func (s *Server) createProxyClient(p *proxy.Proxy) (*http.Client, error) {
-	proxyURL, err := url.Parse(fmt.Sprintf("%s://%s", strings.ToLower(p.Protocol), p.Address))
-	if err != nil {
-		return nil, err
-	}
+	proxyURL := p.URL()
 
 	var transport *http.Transport
 	switch strings.ToLower(p.Protocol) {
 	case "http", "https":
 		transport = &http.Transport{Proxy: http.ProxyURL(proxyURL)}
-	case "socks5", "socks4":
+	case "socks5", "socks4", "socks4a":
 		dialer, err := xproxy.FromURL(proxyURL, xproxy.Direct)
 		if err != nil {
 			return nil, err
@@ -172,18 +725,20 @@ func (s *Server) checkAllProxies() {
 		return
 	}
 	for _, p := range proxies {
-		if _, _, err := s.checkProxy(p); err != nil {
-			p.FailCount++
-		} else {
-			p.FailCount = 0
-		}
+		latency, _, err := s.checkProxy(p)
+		s.rotator.RecordCheck(p, latency, 0, err)
 	}
-	s.rotator.CleanupProxies(24 * time.Hour)
+	s.rotator.CleanupProxies(staleProxyAge)
 }
 
 // acceptConnections 循环接受客户端连接
 // 在独立goroutine中运行，持续接受新连接并分发给handleConnection处理
+// 设置了并发上限时，超出的连接排队等待或被直接关闭
 func (s *Server) acceptConnections() {
+	handle := s.handleConnection
+	if s.mode == ModeHTTP {
+		handle = s.handleHTTPConnection
+	}
 	for {
 		conn, err := s.listener.Accept()
 		if err != nil {
@@ -193,7 +748,31 @@ func (s *Server) acceptConnections() {
 			s.logger.Errorf("接受连接失败: %v", err)
 			continue
 		}
-		go s.handleConnection(conn)
+		if !s.clientAllowed(conn.RemoteAddr()) {
+			s.logger.Warnf("拒绝来自 %s 的连接: 客户端访问控制未通过", conn.RemoteAddr())
+			conn.Close()
+			continue
+		}
+		switch s.gate.admit() {
+		case admitNow:
+			go func() {
+				defer s.gate.leave()
+				handle(conn)
+			}()
+		case admitQueue:
+			go func() {
+				if !s.gate.wait(queueTimeout) {
+					s.logger.Warnf("关闭来自 %s 的连接: 排队等待超时", conn.RemoteAddr())
+					conn.Close()
+					return
+				}
+				defer s.gate.leave()
+				handle(conn)
+			}()
+		default:
+			s.logger.Warnf("拒绝来自 %s 的连接: 已达到并发连接上限", conn.RemoteAddr())
+			conn.Close()
+		}
 	}
 }
 
@@ -202,68 +781,278 @@ func (s *Server) acceptConnections() {
 // 参数 clientConn: 客户端TCP连接
 func (s *Server) handleConnection(clientConn net.Conn) {
 	defer clientConn.Close()
+	conn := s.trackConn(clientConn)
+	defer s.finishConn(conn)
 
-	if err := s.socks5Auth(clientConn); err != nil {
-		s.logger.Errorf("SOCKS5认证失败: %v", err)
+	user, err := s.socks5Auth(clientConn)
+	if err != nil {
+		conn.fail(fmt.Errorf("SOCKS5认证失败: %v", err))
 		return
 	}
 
 	targetAddr, err := s.socks5Connect(clientConn)
 	if err != nil {
-		s.logger.Errorf("SOCKS5连接请求失败: %v", err)
+		conn.fail(fmt.Errorf("SOCKS5连接请求失败: %v", err))
 		return
 	}
+	conn.record.Target = targetAddr
 
-	proxyInfo := s.rotator.GetNextProxy("All", false)
-	if proxyInfo == nil {
-		s.logger.Error("无可用上游代理，无法处理请求")
+	if !s.targetAllowed(targetAddr) {
+		conn.fail(errors.New("目标被访问规则禁止"))
+		s.socks5Reply(clientConn, socks5NotAllowed)
 		return
 	}
-	s.logger.Infof("使用代理 %s 转发到 %s", proxyInfo.Address, targetAddr)
 
-	upstreamConn, err := s.dialUpstream(proxyInfo, targetAddr)
+	upstreamConn, upstream, err := s.connectUpstream(targetAddr, sessionKey(clientConn.RemoteAddr(), user))
 	if err != nil {
-		s.logger.Errorf("连接上游代理 %s 失败: %v", proxyInfo.Address, err)
+		conn.fail(err)
+		if errors.Is(err, errNoUpstream) {
+			s.socks5Reply(clientConn, socks5GeneralFailure)
+		} else {
+			s.socks5Reply(clientConn, socks5HostUnreachable)
+		}
 		return
 	}
 	defer upstreamConn.Close()
+	defer s.rotator.Release(upstream)
+	conn.record.Upstream = upstream.Address
+	s.noteSelfTestUpstream(clientConn.RemoteAddr(), upstream)
+
+	if err := s.socks5Reply(clientConn, socks5Succeeded); err != nil {
+		conn.fail(fmt.Errorf("发送SOCKS5应答失败: %v", err))
+		return
+	}
+
+	s.forwardData(clientConn, upstreamConn, upstream, conn)
+}
+
+// errNoUpstream 代理池中没有可用的上游代理
+var errNoUpstream = errors.New("无可用上游代理，无法处理请求")
+
+// connectUpstream 从代理池选择上游代理并连接到目标地址
+// 开启链式转发时优先使用两跳代理链，SOCKS5和HTTP监听共用此流程
+// 连接失败时通过 reportFailure 反馈给轮换器并换用其他代理，最多尝试 maxAttempts 次
+// 固定了上游代理时优先使用该代理，否则开启会话保持或目标主机亲和时优先使用已绑定的代理，连接成功后更新绑定
+// 每次尝试的结果计入出口代理的连接统计；返回的出口代理已通过 Rotator.Acquire 计入活动连接，连接结束时调用方需调用 Release
+// 参数 session: 会话保持键，见 sessionKey
+// 返回上游连接和出口代理，没有可用代理时返回 errNoUpstream
+func (s *Server) connectUpstream(targetAddr, session string) (net.Conn, *proxy.Proxy, error) {
+	policy := s.policy()
+	s.mutex.Lock()
+	chainMode, maxAttempts, fixed := s.chainMode, s.maxAttempts, s.fixed
+	s.mutex.Unlock()
+
+	host := hostKey(targetAddr)
+	bound, haveBound := stickySession{exit: fixed}, false
+	if fixed != nil && s.sessionUsable(bound, policy) {
+		haveBound = true
+	} else {
+		bound, haveBound = s.boundUpstream(session, host, policy)
+	}
+
+	tried := make(map[string]bool)
+	var lastErr error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		var entry, proxyInfo *proxy.Proxy
+		if haveBound {
+			entry, proxyInfo = bound.entry, bound.exit
+			haveBound = false
+		} else {
+			if chainMode {
+				entry, proxyInfo = s.rotator.GetProxyChain(targetAddr, policy.Region, policy.PremiumOnly, policy.Pool, tried)
+				if entry == nil {
+					s.logger.Warn("没有可用于链式转发的第二个代理，退回单跳转发")
+					proxyInfo = nil
+				}
+			}
+			if proxyInfo == nil {
+				proxyInfo = s.rotator.SelectProxy(targetAddr, policy, tried, atomic.AddUint64(&s.turn, 1)-1)
+			}
+		}
+		if proxyInfo == nil {
+			break
+		}
+		s.mutex.Lock()
+		s.lastUpstream = proxyInfo
+		s.mutex.Unlock()
+		// 选中后立即计入，拨号期间的并发连接也能看到该代理的占用
+		s.rotator.Acquire(proxyInfo)
 
-	s.forwardData(clientConn, upstreamConn)
+		var upstreamConn net.Conn
+		var err error
+		if entry != nil {
+			s.logger.Debugf("使用代理链 %s -> %s 转发到 %s", entry.Address, proxyInfo.Address, targetAddr)
+			upstreamConn, err = s.dialChain(entry, proxyInfo, targetAddr)
+		} else {
+			s.logger.Debugf("使用代理 %s 转发到 %s", proxyInfo.Address, targetAddr)
+			upstreamConn, err = s.dialUpstream(proxyInfo, targetAddr)
+		}
+		s.rotator.RecordConnection(proxyInfo, err == nil)
+		if err == nil {
+			s.sticky.bind(session, entry, proxyInfo)
+			s.affinity.bind(host, entry, proxyInfo)
+			return upstreamConn, proxyInfo, nil
+		}
+
+		s.rotator.Release(proxyInfo)
+		s.sticky.release(session)
+		s.affinity.release(host)
+		lastErr = fmt.Errorf("连接上游代理 %s 失败: %v", proxyInfo.Address, err)
+		s.logger.Warnf("%v (第 %d/%d 次尝试)", lastErr, attempt, maxAttempts)
+		// 链式转发时无法确定是哪一跳失败，不计入失败次数，但两跳都不再在本次连接中重试
+		tried[proxyInfo.Address] = true
+		if entry != nil {
+			tried[entry.Address] = true
+		} else {
+			s.reportFailure(proxyInfo)
+		}
+		// 分散模式下重试时避开刚失败的代理所在的网段
+		policy.Previous = proxyInfo
+	}
+	if lastErr == nil {
+		return nil, nil, errNoUpstream
+	}
+	return nil, nil, lastErr
+}
+
+// boundUpstream 查找已绑定的上游代理，客户端会话保持优先于目标主机亲和
+// 绑定的代理已不可用时解除该绑定
+func (s *Server) boundUpstream(session, host string, policy proxy.Policy) (stickySession, bool) {
+	for _, b := range []struct {
+		table *stickyTable
+		key   string
+	}{{&s.sticky, session}, {&s.affinity, host}} {
+		bound, ok := b.table.lookup(b.key)
+		if !ok {
+			continue
+		}
+		if s.sessionUsable(bound, policy) {
+			return bound, true
+		}
+		b.table.release(b.key)
+	}
+	return stickySession{}, false
 }
 
+// reportFailure 将上游代理转发失败反馈给轮换器，连续失败的代理被暂时隔离
+// 失败次数达到上限时立即清理失效代理(搁置等待复检)，不必等到下一次健康检查
+func (s *Server) reportFailure(p *proxy.Proxy) {
+	if s.rotator.ReportFailure(p) {
+		s.logger.Warnf("上游代理 %s 连续失败 %d 次，搁置等待复检", p.Address, p.FailCount)
+		s.rotator.CleanupProxies(staleProxyAge)
+	}
+}
+
+// sessionUsable 判断会话保持或主机亲和绑定的代理是否仍可使用
+// 代理已被移出有效列表或处于隔离期、不满足当前的国家和高级代理限制或不再属于绑定的代理池时需要重新选择
+func (s *Server) sessionUsable(session stickySession, policy proxy.Policy) bool {
+	for _, p := range []*proxy.Proxy{session.entry, session.exit} {
+		if p == nil {
+			continue
+		}
+		if !s.rotator.IsValid(p) || s.rotator.IsQuarantined(p.Address) || !policy.Allows(p) || !s.rotator.InPool(policy.Pool, p) {
+			return false
+		}
+	}
+	return session.exit != nil
+}
+
+// SOCKS5 认证方法
+const (
+	socks5AuthNone         byte = 0x00
+	socks5AuthPassword     byte = 0x02
+	socks5AuthUnacceptable byte = 0xFF
+)
+
 // socks5Auth 处理SOCKS5协议的认证阶段
-// 仅支持无认证方式(0x00)
-// 返回错误如果客户端不支持无认证或通信失败
-func (s *Server) socks5Auth(conn net.Conn) error {
-	buf := make([]byte, 256)
-	n, err := io.ReadFull(conn, buf[:2])
+// 未设置凭据时使用无认证方式(0x00)，否则要求用户名/密码认证(0x02, RFC 1929)
+// 返回认证通过的用户名(无认证时为空)，以及客户端不支持所需的认证方式、凭据错误或通信失败时的错误
+func (s *Server) socks5Auth(conn net.Conn) (string, error) {
+	buf := make([]byte, 2)
+	n, err := io.ReadFull(conn, buf)
 	if n != 2 || err != nil {
-		return errors.New("读取认证信息失败")
+		return "", errors.New("读取认证信息失败")
 	}
 	if buf[0] != 0x05 {
-		return errors.New("不支持的SOCKS版本")
+		return "", errors.New("不支持的SOCKS版本")
 	}
 	nMethods := int(buf[1])
-	n, err = io.ReadFull(conn, buf[:nMethods])
+	methods := make([]byte, nMethods)
+	n, err = io.ReadFull(conn, methods)
 	if n != nMethods || err != nil {
-		return errors.New("读取认证方法失败")
+		return "", errors.New("读取认证方法失败")
 	}
-	_, err = conn.Write([]byte{0x05, 0x00})
-	return err
+
+	required := socks5AuthNone
+	if user, _ := s.credentials(); user != "" {
+		required = socks5AuthPassword
+	}
+	if !bytes.Contains(methods, []byte{required}) {
+		conn.Write([]byte{0x05, socks5AuthUnacceptable})
+		return "", errors.New("客户端不支持所需的认证方式")
+	}
+	if _, err := conn.Write([]byte{0x05, required}); err != nil {
+		return "", err
+	}
+	if required == socks5AuthPassword {
+		return s.socks5PasswordAuth(conn)
+	}
+	return "", nil
+}
+
+// socks5PasswordAuth 处理RFC 1929用户名/密码子协商
+// 请求格式: VER(0x01) ULEN UNAME PLEN PASSWD，应答: VER STATUS(0x00成功)
+// 返回认证通过的用户名
+func (s *Server) socks5PasswordAuth(conn net.Conn) (string, error) {
+	buf := make([]byte, 255)
+	if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+		return "", errors.New("读取用户名长度失败")
+	}
+	if buf[0] != 0x01 {
+		return "", errors.New("不支持的认证子协商版本")
+	}
+	userLen := int(buf[1])
+	if _, err := io.ReadFull(conn, buf[:userLen]); err != nil {
+		return "", errors.New("读取用户名失败")
+	}
+	user := string(buf[:userLen])
+	if _, err := io.ReadFull(conn, buf[:1]); err != nil {
+		return "", errors.New("读取密码长度失败")
+	}
+	passLen := int(buf[0])
+	if _, err := io.ReadFull(conn, buf[:passLen]); err != nil {
+		return "", errors.New("读取密码失败")
+	}
+	pass := string(buf[:passLen])
+
+	if !s.checkCredentials(user, pass) {
+		conn.Write([]byte{0x01, 0x01})
+		return "", fmt.Errorf("用户 %q 认证失败", user)
+	}
+	if _, err := conn.Write([]byte{0x01, 0x00}); err != nil {
+		return "", err
+	}
+	return user, nil
 }
 
-// socks5Connect 处理SOCKS5连接请求并解析目标地址
+// socks5Connect 读取SOCKS5连接请求并解析目标地址
 // 支持IPv4、IPv6和域名类型的目标地址
+// 应答由调用方在完成访问检查和上游拨号后通过 socks5Reply 发送
 // 返回解析后的目标地址字符串和可能的错误
 func (s *Server) socks5Connect(conn net.Conn) (string, error) {
-	buf := make([]byte, 256)
+	// 最长的请求部分为域名: 1字节长度后跟最多255字节域名和2字节端口
+	buf := make([]byte, 255+2)
 	n, err := io.ReadFull(conn, buf[:4])
 	if n != 4 || err != nil {
 		return "", errors.New("读取连接请求失败")
 	}
-	if buf[0] != 0x05 || buf[1] != 0x01 {
+	if buf[0] != 0x05 {
 		return "", errors.New("无效的连接请求")
 	}
+	if buf[1] != 0x01 {
+		s.socks5Reply(conn, socks5CommandUnsupported)
+		return "", errors.New("仅支持CONNECT命令")
+	}
 
 	var host string
 	switch buf[3] {
@@ -288,49 +1077,144 @@ func (s *Server) socks5Connect(conn net.Conn) (string, error) {
 		host = string(buf[:domainLen])
 		port := binary.BigEndian.Uint16(buf[domainLen : domainLen+2])
 		host = net.JoinHostPort(host, strconv.Itoa(int(port)))
+	case 0x04:
+		n, err = io.ReadFull(conn, buf[:18])
+		if n != 18 || err != nil {
+			return "", errors.New("读取IPv6地址失败")
+		}
+		ip := make(net.IP, net.IPv6len)
+		copy(ip, buf[:16])
+		port := binary.BigEndian.Uint16(buf[16:18])
+		host = net.JoinHostPort(ip.String(), strconv.Itoa(int(port)))
 	default:
 		return "", errors.New("不支持的地址类型")
 	}
 
-	_, err = conn.Write([]byte{0x05, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00})
-	return host, err
+	return host, nil
+}
+
+// socks5Reply 向客户端发送SOCKS5应答
+// 绑定地址固定填 0.0.0.0:0，客户端通常不使用该字段
+func (s *Server) socks5Reply(conn net.Conn, code byte) error {
+	_, err := conn.Write([]byte{0x05, code, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00})
+	return err
 }
 
 // dialUpstream 通过选中的上游代理连接到目标地址
-// 根据代理协议类型(SOCKS/HTTP)创建相应的拨号器
+// 根据代理协议类型(SOCKS4/SOCKS4a/SOCKS5/HTTP)创建相应的拨号器
 // 参数 p: 选中的上游代理
 // 参数 targetAddr: 最终目标地址(格式: host:port)
 func (s *Server) dialUpstream(p *proxy.Proxy, targetAddr string) (net.Conn, error) {
-	dialer, err := xproxy.SOCKS5("tcp", p.Address, nil, xproxy.Direct)
+	dialer, err := proxyDialer(p, xproxy.Direct)
 	if err != nil {
-		if p.Protocol == "http" || p.Protocol == "https" {
-			return net.DialTimeout("tcp", targetAddr, 10*time.Second)
-		}
 		return nil, err
 	}
 	return dialer.Dial("tcp", targetAddr)
 }
 
+// dialChain 经过两个上游代理链式连接到目标地址
+// 先通过入口代理连接出口代理，再由出口代理连接目标
+// 参数 entry: 入口代理
+// 参数 exit: 出口代理
+// 参数 targetAddr: 最终目标地址(格式: host:port)
+func (s *Server) dialChain(entry, exit *proxy.Proxy, targetAddr string) (net.Conn, error) {
+	entryDialer, err := proxyDialer(entry, xproxy.Direct)
+	if err != nil {
+		return nil, err
+	}
+	exitDialer, err := proxyDialer(exit, entryDialer)
+	if err != nil {
+		return nil, err
+	}
+	return exitDialer.Dial("tcp", targetAddr)
+}
+
+// proxyDialer 创建经由代理 p 建立连接的拨号器
+// SOCKS代理按各自协议握手，HTTP代理通过 CONNECT 建立隧道(见 proxy 包中注册的拨号器)
+// 参数 forward: 连接代理本身所用的拨号器，链式转发时为上一跳的拨号器
+func proxyDialer(p *proxy.Proxy, forward xproxy.Dialer) (xproxy.Dialer, error) {
+	if !p.CanTunnel() {
+		return nil, errors.New("不支持的代理协议: " + p.Protocol)
+	}
+	proxyURL := p.URL()
+	return xproxy.FromURL(proxyURL, forward)
+}
+
 // forwardData 在客户端和目标服务器之间双向转发数据
-// 使用两个goroutine分别处理两个方向的数据传输
+// 使用两个goroutine分别处理两个方向的数据传输，转发的字节数实时计入上游代理的流量统计
+// 设置了限速时每个方向按连接限速和全局限速节流
+// 上游连接在转发中被重置时通过 reportFailure 反馈给轮换器；链式转发无法确定是哪一跳重置，不计入
 // 参数 client: 客户端连接
 // 参数 target: 目标服务器连接
-func (s *Server) forwardData(client, target net.Conn) {
+// 参数 upstream: 转发所经由的出口代理
+// 参数 conn: 连接记录，转发的字节数同时计入其中
+func (s *Server) forwardData(client, target net.Conn, upstream *proxy.Proxy, conn *connTracker) {
+	upLimits, downLimits := s.connLimiters()
 	var wg sync.WaitGroup
 	wg.Add(2)
 	go func() {
 		defer wg.Done()
-		io.Copy(target, client)
+		io.Copy(&trafficWriter{w: target, limits: upLimits, record: func(n int64) {
+			s.rotator.AddTraffic(upstream, n, 0)
+			conn.addUp(n)
+		}}, client)
 		if tcpConn, ok := target.(interface{ CloseWrite() error }); ok {
 			tcpConn.CloseWrite()
 		}
 	}()
+	down := &errorReader{r: target}
 	go func() {
 		defer wg.Done()
-		io.Copy(client, target)
+		io.Copy(&trafficWriter{w: client, limits: downLimits, record: func(n int64) {
+			s.rotator.AddTraffic(upstream, 0, n)
+			conn.addDown(n)
+		}}, down)
 		if tcpConn, ok := client.(interface{ CloseWrite() error }); ok {
 			tcpConn.CloseWrite()
 		}
 	}()
 	wg.Wait()
+
+	if isConnReset(down.err) {
+		conn.fail(fmt.Errorf("上游连接被重置: %v", down.err))
+		s.mutex.Lock()
+		chainMode := s.chainMode
+		s.mutex.Unlock()
+		if !chainMode {
+			s.reportFailure(upstream)
+		}
+	}
+}
+
+// errorReader 记录读取中遇到的错误(不含 io.EOF)，用于区分上游重置和客户端一侧的写入失败
+type errorReader struct {
+	r   io.Reader
+	err error
+}
+
+func (e *errorReader) Read(b []byte) (int, error) {
+	n, err := e.r.Read(b)
+	if err != nil && err != io.EOF {
+		e.err = err
+	}
+	return n, err
+}
+
+// trafficWriter 记录写入字节数并按限速器节流的 io.Writer
+// 不实现 io.ReaderFrom，io.Copy 会按块写入，每块写入前等待限速令牌，写入后即计入统计
+type trafficWriter struct {
+	w      io.Writer
+	limits []*rateLimiter
+	record func(n int64)
+}
+
+func (t *trafficWriter) Write(b []byte) (int, error) {
+	for _, l := range t.limits {
+		l.wait(len(b))
+	}
+	n, err := t.w.Write(b)
+	if n > 0 {
+		t.record(int64(n))
+	}
+	return n, err
 }
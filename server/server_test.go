@@ -0,0 +1,121 @@
+package server
+
+import (
+	"bufio"
+	"encoding/binary"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go_proxy/proxy"
+)
+
+// socks5ConnectRequest 通过内存管道发送SOCKS5 CONNECT请求，返回 socks5Connect 解析出的目标地址
+func socks5ConnectRequest(t *testing.T, atyp byte, addr []byte, port uint16) (string, error) {
+	t.Helper()
+	s := NewServer("127.0.0.1", 0, proxy.NewRotator())
+	client, srv := net.Pipe()
+	defer client.Close()
+	defer srv.Close()
+
+	req := append([]byte{0x05, 0x01, 0x00, atyp}, addr...)
+	req = binary.BigEndian.AppendUint16(req, port)
+	go client.Write(req)
+	return s.socks5Connect(srv)
+}
+
+func TestSocks5ConnectMaxLengthDomain(t *testing.T) {
+	domain := strings.Repeat("a", 255)
+	addr := append([]byte{byte(len(domain))}, domain...)
+	host, err := socks5ConnectRequest(t, 0x03, addr, 8080)
+	if err != nil {
+		t.Fatalf("socks5Connect 返回错误: %v", err)
+	}
+	if want := domain + ":8080"; host != want {
+		t.Errorf("目标地址 = %q，期望 %q", host, want)
+	}
+}
+
+func TestSocks5ConnectIPv6(t *testing.T) {
+	host, err := socks5ConnectRequest(t, 0x04, net.ParseIP("2001:db8::1").To16(), 443)
+	if err != nil {
+		t.Fatalf("socks5Connect 返回错误: %v", err)
+	}
+	if want := "[2001:db8::1]:443"; host != want {
+		t.Errorf("目标地址 = %q，期望 %q", host, want)
+	}
+}
+
+// fakeUpstream 启动一个本地HTTP代理，接受 CONNECT 后自己充当目标，对隧道中的请求返回固定的来源IP
+func fakeUpstream(t *testing.T, origin string) *proxy.Proxy {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("监听失败: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				if _, err := http.ReadRequest(reader); err != nil {
+					return
+				}
+				conn.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n"))
+				if _, err := http.ReadRequest(reader); err != nil {
+					return
+				}
+				body := `{"origin":"` + origin + `"}`
+				conn.Write([]byte("HTTP/1.1 200 OK\r\nContent-Type: application/json\r\nConnection: close\r\nContent-Length: " +
+					strconv.Itoa(len(body)) + "\r\n\r\n" + body))
+			}(conn)
+		}
+	}()
+	return &proxy.Proxy{
+		Address:     ln.Addr().String(),
+		Protocol:    "http",
+		Latency:     0.1,
+		Score:       10,
+		LastChecked: time.Now(),
+	}
+}
+
+func TestSelfTestReportsOwnUpstream(t *testing.T) {
+	for _, mode := range []ListenMode{ModeSOCKS5, ModeHTTP} {
+		upstream := fakeUpstream(t, "203.0.113.7")
+		rotator := proxy.NewRotator()
+		rotator.SetValidProxies([]*proxy.Proxy{upstream})
+		s := NewServer("127.0.0.1", 0, rotator)
+		s.SetListenMode(mode)
+		if err := s.Start(); err != nil {
+			t.Fatalf("启动服务失败: %v", err)
+		}
+		// 模拟其他连接刚刚使用了另一个代理
+		s.mutex.Lock()
+		s.lastUpstream = &proxy.Proxy{Address: "198.51.100.1:1080", Protocol: "socks5"}
+		s.mutex.Unlock()
+
+		report, err := s.SelfTest()
+		s.Stop()
+		if err != nil {
+			t.Fatalf("%s 自检失败: %v", mode, err)
+		}
+		if report.ExitIP != "203.0.113.7" {
+			t.Errorf("%s 出口IP = %q，期望 \"203.0.113.7\"", mode, report.ExitIP)
+		}
+		if report.Upstream != upstream {
+			t.Errorf("%s 自检报告的上游代理 = %v，期望 %s", mode, report.Upstream, upstream.Address)
+		}
+		if len(s.selfTests) != 0 {
+			t.Errorf("%s 自检结束后仍有 %d 个登记的连接", mode, len(s.selfTests))
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"go_proxy/proxy"
+)
+
+// stickySession 会话保持记录，保存客户端绑定的上游代理
+// 链式转发时 entry 为入口代理，单跳时为nil
+type stickySession struct {
+	entry   *proxy.Proxy
+	exit    *proxy.Proxy
+	expires time.Time
+}
+
+// stickyTable 客户端或目标主机到上游代理的绑定表
+// 在 TTL 内没有新连接时绑定过期，之后重新按轮换策略选择
+type stickyTable struct {
+	mutex    sync.Mutex
+	ttl      time.Duration
+	sessions map[string]stickySession
+}
+
+// SetStickySessions 设置会话保持时长
+// 开启后同一客户端(已认证时按用户名，否则按客户端IP)在 ttl 内的连接复用同一个上游代理，
+// 每次使用都会顺延过期时间；ttl 为0时关闭并清除已有绑定
+func (s *Server) SetStickySessions(ttl time.Duration) {
+	s.sticky.mutex.Lock()
+	defer s.sticky.mutex.Unlock()
+	s.sticky.ttl = ttl
+	if ttl <= 0 {
+		s.sticky.sessions = nil
+	}
+}
+
+// SetHostAffinity 设置目标主机亲和时长
+// 开启后在 ttl 内所有连接到同一目标主机的请求复用同一个上游代理，不区分客户端；
+// 同时开启会话保持时客户端绑定优先；ttl 为0时关闭并清除已有绑定
+func (s *Server) SetHostAffinity(ttl time.Duration) {
+	s.affinity.mutex.Lock()
+	defer s.affinity.mutex.Unlock()
+	s.affinity.ttl = ttl
+	if ttl <= 0 {
+		s.affinity.sessions = nil
+	}
+}
+
+// hostKey 计算目标地址的主机亲和键，忽略端口和大小写
+func hostKey(targetAddr string) string {
+	host, _, err := net.SplitHostPort(targetAddr)
+	if err != nil {
+		host = targetAddr
+	}
+	return strings.ToLower(host)
+}
+
+// sessionKey 计算客户端的会话保持键
+// 参数 user: 认证用户名，为空时使用客户端IP
+func sessionKey(remote net.Addr, user string) string {
+	if user != "" {
+		return "user:" + user
+	}
+	host, _, err := net.SplitHostPort(remote.String())
+	if err != nil {
+		host = remote.String()
+	}
+	return "ip:" + host
+}
+
+// lookup 返回客户端当前绑定的上游代理并顺延过期时间
+// 未开启会话保持或绑定已过期时返回 ok=false
+func (t *stickyTable) lookup(key string) (session stickySession, ok bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.ttl <= 0 || key == "" {
+		return stickySession{}, false
+	}
+	session, ok = t.sessions[key]
+	if !ok {
+		return stickySession{}, false
+	}
+	now := time.Now()
+	if now.After(session.expires) {
+		delete(t.sessions, key)
+		return stickySession{}, false
+	}
+	session.expires = now.Add(t.ttl)
+	t.sessions[key] = session
+	return session, true
+}
+
+// bind 将客户端绑定到上游代理，同时清理已过期的绑定
+func (t *stickyTable) bind(key string, entry, exit *proxy.Proxy) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.ttl <= 0 || key == "" {
+		return
+	}
+	now := time.Now()
+	if t.sessions == nil {
+		t.sessions = make(map[string]stickySession)
+	}
+	for k, session := range t.sessions {
+		if now.After(session.expires) {
+			delete(t.sessions, k)
+		}
+	}
+	t.sessions[key] = stickySession{entry: entry, exit: exit, expires: now.Add(t.ttl)}
+}
+
+// release 解除客户端的绑定，用于绑定的代理连接失败后重新选择
+func (t *stickyTable) release(key string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	delete(t.sessions, key)
+}
@@ -1,22 +1,45 @@
 package storage
 
 import (
+	"bufio"
 	"encoding/json"
 	"go_proxy/proxy"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 )
 
 const (
-	rawProxiesFile   = "raw_proxies.json"
-	validProxiesFile = "valid_proxies.json"
+	rawProxiesFile    = "raw_proxies.json"
+	validProxiesFile  = "valid_proxies.json"
+	pinnedProxiesFile = "pinned_proxies.json"
+	blacklistFile     = "blacklist.json"
+	poolsFile         = "pools.json"
+	checkHistoryFile  = "check_history.jsonl"
 )
 
+// dataFileMode 数据文件的权限，代理列表中含认证凭据，只允许当前用户读写
+const dataFileMode os.FileMode = 0600
+
+// checkRecord 检测历史文件中的一行记录
+type checkRecord struct {
+	Address string `json:"address"`
+	proxy.Sample
+}
+
+// 确保 DiskStorage 实现了 Storage 接口
+var _ Storage = (*DiskStorage)(nil)
+
+// DiskStorage 基于JSON文件的存储实现
+// history: 检测历史文件的追加句柄，首次记录时打开，清理过期记录和 Close 时关闭
+// lastPrune: 上次清理过期检测结果的时间
 type DiskStorage struct {
-	basePath string
-	mu       sync.RWMutex
+	basePath  string
+	mu        sync.RWMutex
+	history   *os.File
+	lastPrune time.Time
 }
 
 func NewDiskStorage(basePath string) *DiskStorage {
@@ -48,12 +71,250 @@ func (s *DiskStorage) LoadValidProxies() ([]*proxy.Proxy, error) {
 	return s.loadProxies(filepath.Join(s.basePath, validProxiesFile))
 }
 
+// LoadProxies 加载指定列表的全部代理
+func (s *DiskStorage) LoadProxies(kind ListKind) ([]*proxy.Proxy, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.loadProxies(s.listPath(kind))
+}
+
+// UpsertProxies 插入或更新指定列表中的代理
+// JSON文件不支持局部写入，因此会读出整个列表合并后重写
+func (s *DiskStorage) UpsertProxies(kind ListKind, proxies []*proxy.Proxy) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path := s.listPath(kind)
+	existing, err := s.loadProxies(path)
+	if err != nil {
+		return err
+	}
+
+	index := make(map[string]int, len(existing))
+	for i, p := range existing {
+		index[p.Address] = i
+	}
+	for _, p := range proxies {
+		if i, ok := index[p.Address]; ok {
+			existing[i] = p
+		} else {
+			index[p.Address] = len(existing)
+			existing = append(existing, p)
+		}
+	}
+	return s.saveProxies(path, existing)
+}
+
+// DeleteProxies 从指定列表中删除代理
+func (s *DiskStorage) DeleteProxies(kind ListKind, proxies []*proxy.Proxy) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path := s.listPath(kind)
+	existing, err := s.loadProxies(path)
+	if err != nil {
+		return err
+	}
+
+	remove := make(map[string]bool, len(proxies))
+	for _, p := range proxies {
+		remove[p.Address] = true
+	}
+	kept := existing[:0]
+	for _, p := range existing {
+		if !remove[p.Address] {
+			kept = append(kept, p)
+		}
+	}
+	return s.saveProxies(path, kept)
+}
+
+// RecordCheck 以JSON行的形式向检测历史文件追加一条记录
+// 文件句柄在多次记录间复用；距上次清理超过 checkHistoryPruneInterval 时先删除过期记录
+func (s *DiskStorage) RecordCheck(address string, sample proxy.Sample) error {
+	data, err := json.Marshal(checkRecord{Address: address, Sample: sample})
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now := time.Now(); now.Sub(s.lastPrune) >= checkHistoryPruneInterval {
+		s.lastPrune = now
+		if err := s.pruneCheckHistory(now.Add(-checkHistoryRetention)); err != nil {
+			return err
+		}
+	}
+	if s.history == nil {
+		f, err := os.OpenFile(s.historyPath(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, dataFileMode)
+		if err != nil {
+			return err
+		}
+		if err := f.Chmod(dataFileMode); err != nil { // 收紧旧版本以0644创建的文件
+			f.Close()
+			return err
+		}
+		s.history = f
+	}
+	_, err = s.history.Write(append(data, '\n'))
+	return err
+}
+
+// LoadCheckHistory 扫描检测历史文件，返回 since 之后的检测结果
+func (s *DiskStorage) LoadCheckHistory(since time.Time) (map[string][]proxy.Sample, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	history := make(map[string][]proxy.Sample)
+	err := s.scanCheckHistory(func(record checkRecord) {
+		if !record.Time.Before(since) {
+			history[record.Address] = append(history[record.Address], record.Sample)
+		}
+	})
+	return history, err
+}
+
+// pruneCheckHistory 删除检测历史文件中 before 之前的记录，调用方需持有写锁
+// 保留的记录先写入临时文件再替换原文件，避免中途失败丢失历史
+func (s *DiskStorage) pruneCheckHistory(before time.Time) error {
+	var kept []byte
+	pruned := false
+	err := s.scanCheckHistory(func(record checkRecord) {
+		if record.Time.Before(before) {
+			pruned = true
+			return
+		}
+		if data, err := json.Marshal(record); err == nil {
+			kept = append(append(kept, data...), '\n')
+		}
+	})
+	if err != nil || !pruned {
+		return err
+	}
+	if s.history != nil {
+		s.history.Close()
+		s.history = nil
+	}
+	return writeDataFile(s.historyPath(), kept)
+}
+
+// scanCheckHistory 按文件中的顺序逐条读取检测历史记录，文件不存在时不调用 fn
+func (s *DiskStorage) scanCheckHistory(fn func(record checkRecord)) error {
+	f, err := os.Open(s.historyPath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record checkRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue // 跳过写入中断产生的残缺行
+		}
+		fn(record)
+	}
+	return scanner.Err()
+}
+
+// historyPath 返回检测历史文件的路径
+func (s *DiskStorage) historyPath() string {
+	return filepath.Join(s.basePath, checkHistoryFile)
+}
+
+// SaveBlacklist 将黑名单条目写入 blacklist.json
+func (s *DiskStorage) SaveBlacklist(entries []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	return writeDataFile(filepath.Join(s.basePath, blacklistFile), data)
+}
+
+// LoadBlacklist 读取黑名单条目，文件不存在时返回空列表
+func (s *DiskStorage) LoadBlacklist() ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	data, err := ioutil.ReadFile(filepath.Join(s.basePath, blacklistFile))
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []string
+	err = json.Unmarshal(data, &entries)
+	return entries, err
+}
+
+// SavePools 将命名代理池定义写入 pools.json
+func (s *DiskStorage) SavePools(pools []proxy.NamedPool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := json.MarshalIndent(pools, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeDataFile(filepath.Join(s.basePath, poolsFile), data)
+}
+
+// LoadPools 读取命名代理池定义，文件不存在时返回空列表
+func (s *DiskStorage) LoadPools() ([]proxy.NamedPool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	data, err := ioutil.ReadFile(filepath.Join(s.basePath, poolsFile))
+	if os.IsNotExist(err) {
+		return []proxy.NamedPool{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var pools []proxy.NamedPool
+	err = json.Unmarshal(data, &pools)
+	return pools, err
+}
+
+// Close 关闭检测历史文件的追加句柄
+func (s *DiskStorage) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.history == nil {
+		return nil
+	}
+	err := s.history.Close()
+	s.history = nil
+	return err
+}
+
+// listPath 返回列表类型对应的文件路径
+func (s *DiskStorage) listPath(kind ListKind) string {
+	switch kind {
+	case ValidList:
+		return filepath.Join(s.basePath, validProxiesFile)
+	case PinnedList:
+		return filepath.Join(s.basePath, pinnedProxiesFile)
+	default:
+		return filepath.Join(s.basePath, rawProxiesFile)
+	}
+}
+
 func (s *DiskStorage) saveProxies(path string, proxies []*proxy.Proxy) error {
 	data, err := json.Marshal(proxies)
 	if err != nil {
 		return err
 	}
-	return ioutil.WriteFile(path, data, 0644)
+	return writeDataFile(path, data)
+}
+
+// writeDataFile 以 dataFileMode 权限写入数据文件
+// 先写入临时文件再重命名，既避免写入中途退出导致文件损坏，也使旧版本以0644创建的文件换成新权限
+func writeDataFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, dataFileMode); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
 }
 
 func (s *DiskStorage) loadProxies(path string) ([]*proxy.Proxy, error) {
@@ -0,0 +1,385 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"go_proxy/proxy"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const sqliteFile = "proxies.db"
+
+// createProxiesTable 代理表，以 列表类型+地址 为主键，与代理池一样同一地址只保留一个协议
+// 常用指标单独成列便于查询，完整代理信息以JSON保存在 data 列
+const createProxiesTable = `CREATE TABLE IF NOT EXISTS proxies (
+	list         TEXT NOT NULL,
+	address      TEXT NOT NULL,
+	protocol     TEXT NOT NULL,
+	latency      REAL NOT NULL DEFAULT 0,
+	speed        REAL NOT NULL DEFAULT 0,
+	score        REAL NOT NULL DEFAULT 0,
+	last_checked INTEGER NOT NULL DEFAULT 0,
+	data         TEXT NOT NULL,
+	PRIMARY KEY (list, address)
+)`
+
+// legacyProxiesKey 旧版代理表的主键定义，主键含协议，打开时迁移为 (list, address)
+const legacyProxiesKey = "PRIMARY KEY (list, address, protocol)"
+
+// createCheckResultsTable 检测结果时间序列表，每次检测一行
+const createCheckResultsTable = `CREATE TABLE IF NOT EXISTS check_results (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	address    TEXT NOT NULL,
+	checked_at INTEGER NOT NULL,
+	latency    REAL NOT NULL DEFAULT 0,
+	speed      REAL NOT NULL DEFAULT 0,
+	success    INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_check_results_address ON check_results (address, checked_at);
+CREATE INDEX IF NOT EXISTS idx_check_results_time ON check_results (checked_at)`
+
+// createBlacklistTable 黑名单表，每个条目一行
+const createBlacklistTable = `CREATE TABLE IF NOT EXISTS blacklist (
+	entry TEXT PRIMARY KEY
+)`
+
+// createPoolsTable 命名代理池表，定义以JSON保存在 data 列
+const createPoolsTable = `CREATE TABLE IF NOT EXISTS pools (
+	name TEXT PRIMARY KEY,
+	data TEXT NOT NULL
+)`
+
+const upsertProxySQL = `INSERT INTO proxies (list, address, protocol, latency, speed, score, last_checked, data)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (list, address) DO UPDATE SET
+	protocol = excluded.protocol,
+	latency = excluded.latency,
+	speed = excluded.speed,
+	score = excluded.score,
+	last_checked = excluded.last_checked,
+	data = excluded.data`
+
+// 确保 SQLiteStorage 实现了 Storage 接口
+var _ Storage = (*SQLiteStorage)(nil)
+
+// SQLiteStorage 基于SQLite的存储实现
+// 支持按地址增量更新，适合持续更新的大型代理池
+// 检测结果按时间序列单独存表，可用于趋势分析
+// lastPrune: 上次清理过期检测结果的时间(Unix纳秒)，并发记录时只由一个调用者执行清理
+type SQLiteStorage struct {
+	db        *sql.DB
+	lastPrune atomic.Int64
+}
+
+// NewSQLiteStorage 在 basePath 下打开(或创建)SQLite数据库
+func NewSQLiteStorage(basePath string) (*SQLiteStorage, error) {
+	if err := os.MkdirAll(basePath, 0755); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite3", filepath.Join(basePath, sqliteFile))
+	if err != nil {
+		return nil, err
+	}
+	// SQLite同一时间只允许一个写入者，串行化连接避免并发检测时出现 database is locked
+	db.SetMaxOpenConns(1)
+	if err := migrateProxiesKey(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	for _, schema := range []string{createProxiesTable, createCheckResultsTable, createBlacklistTable, createPoolsTable} {
+		if _, err := db.Exec(schema); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	return &SQLiteStorage{db: db}, nil
+}
+
+func (s *SQLiteStorage) SaveRawProxies(proxies []*proxy.Proxy) error {
+	return s.replaceList(RawList, proxies)
+}
+
+func (s *SQLiteStorage) LoadRawProxies() ([]*proxy.Proxy, error) {
+	return s.loadList(RawList)
+}
+
+func (s *SQLiteStorage) SaveValidProxies(proxies []*proxy.Proxy) error {
+	return s.replaceList(ValidList, proxies)
+}
+
+func (s *SQLiteStorage) LoadValidProxies() ([]*proxy.Proxy, error) {
+	return s.loadList(ValidList)
+}
+
+// LoadProxies 加载指定列表的全部代理
+func (s *SQLiteStorage) LoadProxies(kind ListKind) ([]*proxy.Proxy, error) {
+	return s.loadList(kind)
+}
+
+// UpsertProxies 插入或更新指定列表中的代理
+func (s *SQLiteStorage) UpsertProxies(kind ListKind, proxies []*proxy.Proxy) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := upsertProxies(tx, kind, proxies); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteProxies 从指定列表中删除代理
+func (s *SQLiteStorage) DeleteProxies(kind ListKind, proxies []*proxy.Proxy) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(`DELETE FROM proxies WHERE list = ? AND address = ?`)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+	for _, p := range proxies {
+		if _, err := stmt.Exec(string(kind), p.Address); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// RecordCheck 向检测结果表追加一条记录，时间以毫秒精度保存
+// 距上次清理超过 checkHistoryPruneInterval 时先删除过期记录
+func (s *SQLiteStorage) RecordCheck(address string, sample proxy.Sample) error {
+	now := time.Now()
+	if last := s.lastPrune.Load(); now.Sub(time.Unix(0, last)) >= checkHistoryPruneInterval && s.lastPrune.CompareAndSwap(last, now.UnixNano()) {
+		if err := s.pruneCheckHistory(now.Add(-checkHistoryRetention)); err != nil {
+			return err
+		}
+	}
+	_, err := s.db.Exec(`INSERT INTO check_results (address, checked_at, latency, speed, success) VALUES (?, ?, ?, ?, ?)`,
+		address, sample.Time.UnixMilli(), sample.Latency, sample.Speed, sample.Success)
+	return err
+}
+
+// LoadCheckHistory 按时间先后加载 since 之后的检测结果，按地址分组
+func (s *SQLiteStorage) LoadCheckHistory(since time.Time) (map[string][]proxy.Sample, error) {
+	rows, err := s.db.Query(`SELECT address, checked_at, latency, speed, success FROM check_results
+WHERE checked_at >= ? ORDER BY checked_at, id`, since.UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := make(map[string][]proxy.Sample)
+	for rows.Next() {
+		var address string
+		var checkedAt int64
+		var sample proxy.Sample
+		if err := rows.Scan(&address, &checkedAt, &sample.Latency, &sample.Speed, &sample.Success); err != nil {
+			return nil, err
+		}
+		sample.Time = time.UnixMilli(checkedAt)
+		history[address] = append(history[address], sample)
+	}
+	return history, rows.Err()
+}
+
+// pruneCheckHistory 删除 before 之前的检测结果
+func (s *SQLiteStorage) pruneCheckHistory(before time.Time) error {
+	_, err := s.db.Exec(`DELETE FROM check_results WHERE checked_at < ?`, before.UnixMilli())
+	return err
+}
+
+// SaveBlacklist 在一个事务内整体替换黑名单
+func (s *SQLiteStorage) SaveBlacklist(entries []string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM blacklist`); err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, entry := range entries {
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO blacklist (entry) VALUES (?)`, entry); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// LoadBlacklist 读取全部黑名单条目
+func (s *SQLiteStorage) LoadBlacklist() ([]string, error) {
+	rows, err := s.db.Query(`SELECT entry FROM blacklist ORDER BY entry`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []string{}
+	for rows.Next() {
+		var entry string
+		if err := rows.Scan(&entry); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// SavePools 在一个事务内整体替换命名代理池定义
+func (s *SQLiteStorage) SavePools(pools []proxy.NamedPool) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM pools`); err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, pool := range pools {
+		data, err := json.Marshal(pool)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		if _, err := tx.Exec(`INSERT OR REPLACE INTO pools (name, data) VALUES (?, ?)`, pool.Name, string(data)); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// LoadPools 读取全部命名代理池定义
+func (s *SQLiteStorage) LoadPools() ([]proxy.NamedPool, error) {
+	rows, err := s.db.Query(`SELECT data FROM pools ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pools := []proxy.NamedPool{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var pool proxy.NamedPool
+		if err := json.Unmarshal([]byte(data), &pool); err != nil {
+			return nil, err
+		}
+		pools = append(pools, pool)
+	}
+	return pools, rows.Err()
+}
+
+// Close 关闭数据库连接
+func (s *SQLiteStorage) Close() error {
+	return s.db.Close()
+}
+
+// replaceList 在一个事务内用新列表整体替换指定列表
+func (s *SQLiteStorage) replaceList(kind ListKind, proxies []*proxy.Proxy) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM proxies WHERE list = ?`, string(kind)); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := upsertProxies(tx, kind, proxies); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// loadList 按插入顺序读取指定列表的全部代理
+func (s *SQLiteStorage) loadList(kind ListKind) ([]*proxy.Proxy, error) {
+	rows, err := s.db.Query(`SELECT data FROM proxies WHERE list = ? ORDER BY rowid`, string(kind))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	proxies := []*proxy.Proxy{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var p proxy.Proxy
+		if err := json.Unmarshal([]byte(data), &p); err != nil {
+			return nil, err
+		}
+		proxies = append(proxies, &p)
+	}
+	return proxies, rows.Err()
+}
+
+// migrateProxiesKey 将旧版以 (list, address, protocol) 为主键的代理表迁移为以 (list, address) 为主键
+// 同一地址有多个协议时保留最后写入的一行；代理表不存在或已是新主键时不做任何事
+func migrateProxiesKey(db *sql.DB) error {
+	var schema string
+	err := db.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'proxies'`).Scan(&schema)
+	if err == sql.ErrNoRows || err == nil && !strings.Contains(schema, legacyProxiesKey) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	for _, stmt := range []string{
+		`ALTER TABLE proxies RENAME TO proxies_legacy`,
+		createProxiesTable,
+		`INSERT OR REPLACE INTO proxies (list, address, protocol, latency, speed, score, last_checked, data)
+SELECT list, address, protocol, latency, speed, score, last_checked, data FROM proxies_legacy ORDER BY rowid`,
+		`DROP TABLE proxies_legacy`,
+	} {
+		if _, err := tx.Exec(stmt); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// upsertProxies 在事务中批量插入或更新代理
+func upsertProxies(tx *sql.Tx, kind ListKind, proxies []*proxy.Proxy) error {
+	stmt, err := tx.Prepare(upsertProxySQL)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, p := range proxies {
+		data, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+		var lastChecked int64
+		if !p.LastChecked.IsZero() {
+			lastChecked = p.LastChecked.Unix()
+		}
+		if _, err := stmt.Exec(string(kind), p.Address, p.Protocol, p.Latency, p.Speed, p.Score, lastChecked, string(data)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
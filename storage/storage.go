@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"fmt"
+	"go_proxy/proxy"
+	"time"
+)
+
+// ListKind 代理列表类型
+type ListKind string
+
+const (
+	// RawList 原始(未验证)代理列表
+	RawList ListKind = "raw"
+	// ValidList 有效(已验证)代理列表
+	ValidList ListKind = "valid"
+	// PinnedList 用户固定的代理，独立于代理池保存
+	PinnedList ListKind = "pinned"
+)
+
+const (
+	// checkHistoryRetention 检测结果的保留时长，更早的记录被删除
+	checkHistoryRetention = 30 * 24 * time.Hour
+	// checkHistoryPruneInterval 两次清理过期检测结果之间的最短间隔
+	checkHistoryPruneInterval = time.Hour
+)
+
+// 可选的存储后端
+const (
+	BackendJSON   = "json"
+	BackendSQLite = "sqlite"
+)
+
+// Storage 代理池持久化接口
+// 支持整表保存/加载，按地址增量更新和删除，检测结果的时间序列、黑名单以及命名代理池定义
+type Storage interface {
+	SaveRawProxies(proxies []*proxy.Proxy) error
+	LoadRawProxies() ([]*proxy.Proxy, error)
+	SaveValidProxies(proxies []*proxy.Proxy) error
+	LoadValidProxies() ([]*proxy.Proxy, error)
+
+	// LoadProxies 加载指定列表的全部代理
+	LoadProxies(kind ListKind) ([]*proxy.Proxy, error)
+	// UpsertProxies 插入或更新代理，以地址作为唯一键(与代理池一致，同一地址只保留一个协议)
+	UpsertProxies(kind ListKind, proxies []*proxy.Proxy) error
+	// DeleteProxies 按地址删除代理
+	DeleteProxies(kind ListKind, proxies []*proxy.Proxy) error
+
+	// RecordCheck 追加一条代理检测结果，用于长期趋势分析；超过 checkHistoryRetention 的旧记录会被定期删除
+	RecordCheck(address string, sample proxy.Sample) error
+	// LoadCheckHistory 加载 since 之后的全部检测结果，按地址分组，组内按时间先后排列
+	LoadCheckHistory(since time.Time) (map[string][]proxy.Sample, error)
+
+	// SaveBlacklist 整体保存黑名单条目(代理地址、IP或CIDR)
+	SaveBlacklist(entries []string) error
+	// LoadBlacklist 加载黑名单条目
+	LoadBlacklist() ([]string, error)
+
+	// SavePools 整体保存命名代理池定义
+	SavePools(pools []proxy.NamedPool) error
+	// LoadPools 加载命名代理池定义
+	LoadPools() ([]proxy.NamedPool, error)
+
+	Close() error
+}
+
+// New 根据后端名称创建存储实例
+// 参数 backend: 存储后端(json/sqlite)，为空时使用json
+// 参数 basePath: 数据目录
+func New(backend, basePath string) (Storage, error) {
+	switch backend {
+	case "", BackendJSON:
+		return NewDiskStorage(basePath), nil
+	case BackendSQLite:
+		return NewSQLiteStorage(basePath)
+	default:
+		return nil, fmt.Errorf("不支持的存储后端: %s", backend)
+	}
+}
@@ -0,0 +1,189 @@
+package storage
+
+import (
+	"database/sql"
+	"go_proxy/proxy"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// backends 返回在临时目录下创建各存储后端的构造函数
+func backends(t *testing.T) map[string]func(dir string) Storage {
+	t.Helper()
+	return map[string]func(dir string) Storage{
+		BackendJSON: func(dir string) Storage { return NewDiskStorage(dir) },
+		BackendSQLite: func(dir string) Storage {
+			s, err := NewSQLiteStorage(dir)
+			if err != nil {
+				t.Fatalf("打开SQLite存储失败: %v", err)
+			}
+			return s
+		},
+	}
+}
+
+func TestCheckHistory(t *testing.T) {
+	now := time.Now().Truncate(time.Millisecond)
+	for name, open := range backends(t) {
+		dir := t.TempDir()
+		s := open(dir)
+		records := []struct {
+			address string
+			sample  proxy.Sample
+		}{
+			{"1.1.1.1:1080", proxy.Sample{Time: now.Add(-2 * time.Hour), Latency: 0.5, Success: true}},
+			{"2.2.2.2:8080", proxy.Sample{Time: now.Add(-time.Hour), Success: false}},
+			{"1.1.1.1:1080", proxy.Sample{Time: now, Latency: 0.25, Speed: 300, Success: true}},
+		}
+		for _, r := range records {
+			if err := s.RecordCheck(r.address, r.sample); err != nil {
+				t.Fatalf("%s: 记录检测结果失败: %v", name, err)
+			}
+		}
+
+		history, err := s.LoadCheckHistory(time.Time{})
+		if err != nil {
+			t.Fatalf("%s: 加载检测历史失败: %v", name, err)
+		}
+		first := history["1.1.1.1:1080"]
+		if len(first) != 2 || !first[0].Time.Equal(records[0].sample.Time) || first[1].Speed != 300 || len(history["2.2.2.2:8080"]) != 1 {
+			t.Errorf("%s: 检测历史 = %+v", name, history)
+		}
+		history, err = s.LoadCheckHistory(now.Add(-90 * time.Minute))
+		if err != nil || len(history["1.1.1.1:1080"]) != 1 || len(history["2.2.2.2:8080"]) != 1 {
+			t.Errorf("%s: 按时间筛选后的检测历史 = %+v，错误 %v", name, history, err)
+		}
+
+		// 关闭后重新打开，记录仍然保留
+		if err := s.Close(); err != nil {
+			t.Fatalf("%s: 关闭存储失败: %v", name, err)
+		}
+		s = open(dir)
+		history, err = s.LoadCheckHistory(time.Time{})
+		if err != nil || len(history["1.1.1.1:1080"]) != 2 {
+			t.Errorf("%s: 重新打开后的检测历史 = %+v，错误 %v", name, history, err)
+		}
+		s.Close()
+	}
+}
+
+func TestPruneCheckHistory(t *testing.T) {
+	now := time.Now()
+	for name, open := range backends(t) {
+		s := open(t.TempDir())
+		// 首次记录时清理的是空历史，之后写入的过期记录留到下一次清理
+		old := proxy.Sample{Time: now.Add(-checkHistoryRetention - time.Hour), Success: true}
+		if err := s.RecordCheck("1.1.1.1:1080", old); err != nil {
+			t.Fatalf("%s: 记录检测结果失败: %v", name, err)
+		}
+		if err := s.RecordCheck("2.2.2.2:8080", proxy.Sample{Time: now, Success: true}); err != nil {
+			t.Fatalf("%s: 记录检测结果失败: %v", name, err)
+		}
+
+		if err := s.(interface{ pruneCheckHistory(time.Time) error }).pruneCheckHistory(now.Add(-checkHistoryRetention)); err != nil {
+			t.Fatalf("%s: 清理过期检测结果失败: %v", name, err)
+		}
+		// 清理后仍能继续追加记录
+		if err := s.RecordCheck("2.2.2.2:8080", proxy.Sample{Time: now, Success: false}); err != nil {
+			t.Fatalf("%s: 清理后记录检测结果失败: %v", name, err)
+		}
+
+		history, err := s.LoadCheckHistory(time.Time{})
+		if err != nil {
+			t.Fatalf("%s: 加载检测历史失败: %v", name, err)
+		}
+		if len(history["1.1.1.1:1080"]) != 0 || len(history["2.2.2.2:8080"]) != 2 {
+			t.Errorf("%s: 清理后的检测历史 = %+v，期望只保留 2.2.2.2:8080 的 2 条记录", name, history)
+		}
+		s.Close()
+	}
+}
+
+func TestUpsertProxiesKeyedByAddress(t *testing.T) {
+	for name, open := range backends(t) {
+		s := open(t.TempDir())
+		first := &proxy.Proxy{Address: "1.1.1.1:1080", Protocol: "socks5"}
+		other := &proxy.Proxy{Address: "2.2.2.2:8080", Protocol: "http"}
+		if err := s.UpsertProxies(ValidList, []*proxy.Proxy{first, other}); err != nil {
+			t.Fatalf("%s: 写入代理失败: %v", name, err)
+		}
+		// 同一地址换了协议，更新原有记录而不是新增一行
+		if err := s.UpsertProxies(ValidList, []*proxy.Proxy{{Address: first.Address, Protocol: "http"}}); err != nil {
+			t.Fatalf("%s: 更新代理失败: %v", name, err)
+		}
+		proxies, err := s.LoadProxies(ValidList)
+		if err != nil || len(proxies) != 2 || proxies[0].Address != first.Address || proxies[0].Protocol != "http" {
+			t.Errorf("%s: 更新后的代理 = %+v，错误 %v", name, proxies, err)
+		}
+
+		// 删除只按地址匹配
+		if err := s.DeleteProxies(ValidList, []*proxy.Proxy{{Address: first.Address, Protocol: "socks5"}}); err != nil {
+			t.Fatalf("%s: 删除代理失败: %v", name, err)
+		}
+		proxies, err = s.LoadProxies(ValidList)
+		if err != nil || len(proxies) != 1 || proxies[0].Address != other.Address {
+			t.Errorf("%s: 删除后的代理 = %+v，错误 %v", name, proxies, err)
+		}
+		s.Close()
+	}
+}
+
+func TestMigrateLegacyProxiesKey(t *testing.T) {
+	dir := t.TempDir()
+	db, err := sql.Open("sqlite3", filepath.Join(dir, sqliteFile))
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	legacy := strings.Replace(createProxiesTable, "PRIMARY KEY (list, address)", legacyProxiesKey, 1)
+	if _, err := db.Exec(legacy); err != nil {
+		t.Fatalf("创建旧版代理表失败: %v", err)
+	}
+	for _, protocol := range []string{"socks5", "http"} {
+		data := `{"Address":"1.1.1.1:1080","Protocol":"` + protocol + `"}`
+		if _, err := db.Exec(`INSERT INTO proxies (list, address, protocol, data) VALUES ('valid', '1.1.1.1:1080', ?, ?)`, protocol, data); err != nil {
+			t.Fatalf("写入旧版代理表失败: %v", err)
+		}
+	}
+	db.Close()
+
+	s, err := NewSQLiteStorage(dir)
+	if err != nil {
+		t.Fatalf("迁移旧版代理表失败: %v", err)
+	}
+	defer s.Close()
+	proxies, err := s.LoadProxies(ValidList)
+	if err != nil || len(proxies) != 1 || proxies[0].Protocol != "http" {
+		t.Errorf("迁移后的代理 = %+v，错误 %v，期望只保留最后写入的 http 记录", proxies, err)
+	}
+	if err := s.UpsertProxies(ValidList, []*proxy.Proxy{{Address: "1.1.1.1:1080", Protocol: "socks5"}}); err != nil {
+		t.Errorf("迁移后按地址更新代理失败: %v", err)
+	}
+}
+
+func TestDataFileMode(t *testing.T) {
+	dir := t.TempDir()
+	s := NewDiskStorage(dir)
+	defer s.Close()
+	p := &proxy.Proxy{Address: "1.1.1.1:1080", Protocol: "socks5", Username: "user", Password: "pass"}
+	if err := s.SaveValidProxies([]*proxy.Proxy{p}); err != nil {
+		t.Fatalf("保存有效代理失败: %v", err)
+	}
+	if err := s.UpsertProxies(PinnedList, []*proxy.Proxy{p}); err != nil {
+		t.Fatalf("保存固定代理失败: %v", err)
+	}
+	if err := s.RecordCheck(p.Address, proxy.Sample{Time: time.Now(), Success: true}); err != nil {
+		t.Fatalf("记录检测结果失败: %v", err)
+	}
+	for _, name := range []string{validProxiesFile, pinnedProxiesFile, checkHistoryFile} {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("读取 %s 失败: %v", name, err)
+		}
+		if mode := info.Mode().Perm(); mode != dataFileMode {
+			t.Errorf("%s 的权限 = %o，期望 %o", name, mode, dataFileMode)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package sysproxy
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// errUnsupported 当前操作系统或桌面环境不支持自动设置系统代理
+var errUnsupported = errors.New("当前系统不支持自动设置系统代理")
+
+// Set 将操作系统级别的代理指向本地服务
+// Windows 写入 WinINET 设置，macOS 对所有已启用的网络服务调用 networksetup，Linux 通过 gsettings 设置 GNOME 代理
+// 参数 host: 本地服务地址，应为客户端可以连接的地址(不能是 0.0.0.0)
+// 参数 port: 本地服务端口
+// 参数 socks: 为 true 时设置为SOCKS代理，否则设置为HTTP和HTTPS代理
+func Set(host string, port int, socks bool) error {
+	return set(host, port, socks)
+}
+
+// Clear 关闭操作系统级别的代理
+func Clear() error {
+	return unset()
+}
+
+// run 执行外部命令，失败时把命令输出附加到错误中
+func run(name string, args ...string) (string, error) {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%s %s 失败: %v %s", name, strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return string(out), nil
+}
@@ -0,0 +1,71 @@
+//go:build darwin
+
+package sysproxy
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+func set(host string, port int, socks bool) error {
+	services, err := networkServices()
+	if err != nil {
+		return err
+	}
+	portStr := strconv.Itoa(port)
+	for _, service := range services {
+		commands := [][]string{
+			{"-setwebproxy", service, host, portStr},
+			{"-setsecurewebproxy", service, host, portStr},
+		}
+		if socks {
+			commands = [][]string{
+				{"-setsocksfirewallproxy", service, host, portStr},
+				{"-setsocksfirewallproxystate", service, "on"},
+			}
+		}
+		for _, args := range commands {
+			if _, err := run("networksetup", args...); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func unset() error {
+	services, err := networkServices()
+	if err != nil {
+		return err
+	}
+	for _, service := range services {
+		for _, option := range []string{"-setwebproxystate", "-setsecurewebproxystate", "-setsocksfirewallproxystate"} {
+			if _, err := run("networksetup", option, service, "off"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// networkServices 返回所有已启用的网络服务名称
+// networksetup 输出的第一行为说明文字，以 * 开头的服务已被停用
+func networkServices() ([]string, error) {
+	out, err := run("networksetup", "-listallnetworkservices")
+	if err != nil {
+		return nil, err
+	}
+	var services []string
+	for i, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if i == 0 || line == "" || strings.HasPrefix(line, "*") {
+			continue
+		}
+		services = append(services, line)
+	}
+	if len(services) == 0 {
+		return nil, errors.New("没有已启用的网络服务")
+	}
+	return services, nil
+}
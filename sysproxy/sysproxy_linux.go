@@ -0,0 +1,40 @@
+//go:build linux
+
+package sysproxy
+
+import (
+	"os/exec"
+	"strconv"
+)
+
+// proxySchema GNOME 代理设置的 gsettings 架构
+const proxySchema = "org.gnome.system.proxy"
+
+func set(host string, port int, socks bool) error {
+	if _, err := exec.LookPath("gsettings"); err != nil {
+		return errUnsupported
+	}
+	portStr := strconv.Itoa(port)
+	schemas := []string{proxySchema + ".http", proxySchema + ".https"}
+	if socks {
+		schemas = []string{proxySchema + ".socks"}
+	}
+	for _, schema := range schemas {
+		if _, err := run("gsettings", "set", schema, "host", host); err != nil {
+			return err
+		}
+		if _, err := run("gsettings", "set", schema, "port", portStr); err != nil {
+			return err
+		}
+	}
+	_, err := run("gsettings", "set", proxySchema, "mode", "manual")
+	return err
+}
+
+func unset() error {
+	if _, err := exec.LookPath("gsettings"); err != nil {
+		return errUnsupported
+	}
+	_, err := run("gsettings", "set", proxySchema, "mode", "none")
+	return err
+}
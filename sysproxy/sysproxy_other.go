@@ -0,0 +1,11 @@
+//go:build !windows && !darwin && !linux
+
+package sysproxy
+
+func set(host string, port int, socks bool) error {
+	return errUnsupported
+}
+
+func unset() error {
+	return errUnsupported
+}
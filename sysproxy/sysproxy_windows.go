@@ -0,0 +1,68 @@
+//go:build windows
+
+package sysproxy
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+// internetSettingsKey 当前用户的 WinINET 代理设置所在的注册表项
+const internetSettingsKey = `Software\Microsoft\Windows\CurrentVersion\Internet Settings`
+
+// InternetSetOption 的选项，通知已运行的程序重新读取代理设置
+const (
+	internetOptionRefresh         = 37
+	internetOptionSettingsChanged = 39
+)
+
+var procInternetSetOption = windows.NewLazySystemDLL("wininet.dll").NewProc("InternetSetOptionW")
+
+func set(host string, port int, socks bool) error {
+	key, err := registry.OpenKey(registry.CURRENT_USER, internetSettingsKey, registry.SET_VALUE)
+	if err != nil {
+		return fmt.Errorf("打开注册表失败: %v", err)
+	}
+	defer key.Close()
+
+	server := net.JoinHostPort(host, strconv.Itoa(port))
+	if socks {
+		server = "socks=" + server
+	}
+	if err := key.SetStringValue("ProxyServer", server); err != nil {
+		return err
+	}
+	if err := key.SetStringValue("ProxyOverride", "<local>"); err != nil {
+		return err
+	}
+	if err := key.SetDWordValue("ProxyEnable", 1); err != nil {
+		return err
+	}
+	return notifySettingsChanged()
+}
+
+func unset() error {
+	key, err := registry.OpenKey(registry.CURRENT_USER, internetSettingsKey, registry.SET_VALUE)
+	if err != nil {
+		return fmt.Errorf("打开注册表失败: %v", err)
+	}
+	defer key.Close()
+	if err := key.SetDWordValue("ProxyEnable", 0); err != nil {
+		return err
+	}
+	return notifySettingsChanged()
+}
+
+// notifySettingsChanged 通知系统代理设置已修改，使浏览器等程序立即生效
+func notifySettingsChanged() error {
+	for _, option := range []uintptr{internetOptionSettingsChanged, internetOptionRefresh} {
+		if ret, _, err := procInternetSetOption.Call(0, option, 0, 0); ret == 0 {
+			return fmt.Errorf("通知系统代理变更失败: %v", err)
+		}
+	}
+	return nil
+}
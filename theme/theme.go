@@ -7,8 +7,18 @@ import (
 	"fyne.io/fyne/v2/theme"
 )
 
+// 主题模式，决定使用浅色还是深色变体
+const (
+	ModeSystem = "system" // 跟随系统设置
+	ModeLight  = "light"
+	ModeDark   = "dark"
+)
+
 // MyTheme 定义了自定义主题
-type MyTheme struct{}
+// Mode 为空或 ModeSystem 时跟随系统，否则强制使用对应的明暗变体
+type MyTheme struct {
+	Mode string
+}
 
 // 确保 MyTheme 实现了 fyne.Theme 接口
 var _ fyne.Theme = (*MyTheme)(nil)
@@ -21,6 +31,13 @@ func (m *MyTheme) Font(style fyne.TextStyle) fyne.Resource {
 
 // Color 返回自定义主题的颜色
 func (m *MyTheme) Color(name fyne.ThemeColorName, variant fyne.ThemeVariant) color.Color {
+	switch m.Mode {
+	case ModeLight:
+		variant = theme.VariantLight
+	case ModeDark:
+		variant = theme.VariantDark
+	}
+
 	switch name {
 	case theme.ColorNameSeparator:
 		if variant == theme.VariantDark {
@@ -31,14 +48,22 @@ func (m *MyTheme) Color(name fyne.ThemeColorName, variant fyne.ThemeVariant) col
 		if variant == theme.VariantDark {
 			return color.NRGBA{R: 30, G: 30, B: 30, A: 255} // 深色背景
 		}
+		return color.NRGBA{R: 245, G: 245, B: 245, A: 255} // 浅色背景
 	case theme.ColorNameForeground:
 		if variant == theme.VariantDark {
 			return color.NRGBA{R: 220, G: 220, B: 220, A: 255} // 深色前景
 		}
+		return color.NRGBA{R: 33, G: 33, B: 33, A: 255} // 浅色前景
 	case theme.ColorNameButton:
 		if variant == theme.VariantDark {
 			return color.NRGBA{R: 60, G: 60, B: 60, A: 255} // 深色按钮
 		}
+		return color.NRGBA{R: 225, G: 225, B: 225, A: 255} // 浅色按钮
+	case theme.ColorNameInputBackground:
+		if variant == theme.VariantDark {
+			return color.NRGBA{R: 45, G: 45, B: 45, A: 255} // 深色输入框
+		}
+		return color.NRGBA{R: 255, G: 255, B: 255, A: 255} // 浅色输入框
 	}
 	return theme.DefaultTheme().Color(name, variant)
 }
@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"go_proxy/lang"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showBlacklistDialog 显示黑名单管理对话框
+// 条目可以是代理地址(host:port)、IP 或 CIDR 网段，添加和删除立即生效
+func showBlacklistDialog(app Apper) {
+	entries := app.GetBlacklist()
+
+	var list *widget.List
+	list = widget.NewList(
+		func() int { return len(entries) },
+		func() fyne.CanvasObject {
+			return container.NewBorder(nil, nil, nil, widget.NewButton(lang.T("删除"), nil), widget.NewLabel(""))
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			row := obj.(*fyne.Container)
+			label := row.Objects[0].(*widget.Label)
+			deleteBtn := row.Objects[1].(*widget.Button)
+
+			entry := entries[id]
+			label.SetText(entry)
+			deleteBtn.OnTapped = func() {
+				app.RemoveBlacklistEntry(entry)
+				entries = app.GetBlacklist()
+				list.Refresh()
+			}
+		},
+	)
+
+	win := app.GetWindow()
+	entryInput := widget.NewEntry()
+	entryInput.SetPlaceHolder(lang.T("例如: 1.2.3.4、1.2.3.0/24 或 1.2.3.4:8080"))
+	addBtn := widget.NewButton(lang.T("添加"), func() {
+		if err := app.AddBlacklistEntry(entryInput.Text); err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		entries = app.GetBlacklist()
+		list.Refresh()
+		entryInput.SetText("")
+	})
+	entryInput.OnSubmitted = func(string) { addBtn.OnTapped() }
+
+	form := container.NewBorder(nil, nil, nil, addBtn, entryInput)
+	content := container.NewBorder(nil, widget.NewCard(lang.T("添加条目"), lang.T("命中的代理会立即从代理池删除"), form), nil, nil, list)
+	d := dialog.NewCustom(lang.T("黑名单管理"), lang.T("关闭"), content, win)
+	d.Resize(fyne.NewSize(560, 480))
+	d.Show()
+}
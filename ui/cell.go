@@ -0,0 +1,32 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 确保 proxyCell 支持右键点击
+var _ fyne.SecondaryTappable = (*proxyCell)(nil)
+
+// proxyCell 代理表格的单元格
+// 在普通标签的基础上支持右键点击，用于弹出针对所在行的操作菜单
+type proxyCell struct {
+	widget.Label
+	row            int
+	onSecondaryTap func(row int, e *fyne.PointEvent)
+}
+
+// newProxyCell 创建代理表格单元格
+// 参数 onSecondaryTap: 右键点击时的回调，参数为单元格所在行
+func newProxyCell(onSecondaryTap func(row int, e *fyne.PointEvent)) *proxyCell {
+	c := &proxyCell{onSecondaryTap: onSecondaryTap}
+	c.ExtendBaseWidget(c)
+	return c
+}
+
+// TappedSecondary 处理右键点击
+func (c *proxyCell) TappedSecondary(e *fyne.PointEvent) {
+	if c.onSecondaryTap != nil {
+		c.onSecondaryTap(c.row, e)
+	}
+}
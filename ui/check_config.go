@@ -0,0 +1,118 @@
+package ui
+
+import (
+	"go_proxy/checker"
+	"go_proxy/lang"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showCheckConfigDialog 显示检测服务设置对话框
+// 可替换连通性检测、匿名度判断和测速使用的地址，并设置逐一检测的目标站点，点击保存后对之后的检测生效
+func showCheckConfigDialog(app Apper) {
+	cfg := app.GetCheckConfig()
+
+	connectivityEntry := widget.NewEntry()
+	connectivityEntry.SetText(cfg.ConnectivityURL)
+	statusEntry := widget.NewEntry()
+	statusEntry.SetPlaceHolder("200")
+	if cfg.ExpectStatus != 0 {
+		statusEntry.SetText(strconv.Itoa(cfg.ExpectStatus))
+	}
+	bodyEntry := widget.NewEntry()
+	bodyEntry.SetPlaceHolder(lang.T("可选，响应中必须包含的内容"))
+	bodyEntry.SetText(cfg.ExpectBody)
+	jsonFieldEntry := widget.NewEntry()
+	jsonFieldEntry.SetPlaceHolder(lang.T("可选，响应JSON中必须存在的字段，如 headers.Host"))
+	jsonFieldEntry.SetText(cfg.ExpectJSONField)
+	judgeEntry := widget.NewEntry()
+	judgeEntry.SetPlaceHolder(lang.T("留空表示不判断匿名度"))
+	judgeEntry.SetText(cfg.JudgeURL)
+	httpsEntry := widget.NewEntry()
+	httpsEntry.SetPlaceHolder(lang.T("留空表示不检测HTTPS"))
+	httpsEntry.SetText(cfg.HTTPSCheckURL)
+	speedEntry := widget.NewEntry()
+	speedEntry.SetPlaceHolder(lang.T("留空表示不测速"))
+	speedEntry.SetText(cfg.SpeedTestURL)
+	sizeEntry := widget.NewEntry()
+	sizeEntry.SetPlaceHolder(lang.T("0 表示下载完整文件"))
+	sizeEntry.SetText(strconv.FormatInt(cfg.SpeedTestSize>>10, 10))
+	targetsEntry := widget.NewMultiLineEntry()
+	targetsEntry.SetPlaceHolder(lang.T("每行一个: 名称 地址 [期望状态码]\n例如: google https://www.google.com 200"))
+	targetsEntry.SetText(checker.FormatTargets(cfg.Targets))
+	targetsEntry.SetMinRowsVisible(3)
+
+	resetBtn := widget.NewButton(lang.T("恢复默认"), func() {
+		def := checker.DefaultConfig()
+		connectivityEntry.SetText(def.ConnectivityURL)
+		statusEntry.SetText(strconv.Itoa(def.ExpectStatus))
+		bodyEntry.SetText(def.ExpectBody)
+		jsonFieldEntry.SetText(def.ExpectJSONField)
+		judgeEntry.SetText(def.JudgeURL)
+		httpsEntry.SetText(def.HTTPSCheckURL)
+		speedEntry.SetText(def.SpeedTestURL)
+		sizeEntry.SetText(strconv.FormatInt(def.SpeedTestSize>>10, 10))
+		targetsEntry.SetText(checker.FormatTargets(def.Targets))
+	})
+
+	form := container.New(layout.NewFormLayout(),
+		widget.NewLabel(lang.T("连通性检测地址:")), connectivityEntry,
+		widget.NewLabel(lang.T("期望状态码:")), statusEntry,
+		widget.NewLabel(lang.T("期望响应内容:")), bodyEntry,
+		widget.NewLabel(lang.T("期望JSON字段:")), jsonFieldEntry,
+		widget.NewLabel(lang.T("匿名度判断地址:")), judgeEntry,
+		widget.NewLabel(lang.T("HTTPS检测地址:")), httpsEntry,
+		widget.NewLabel(lang.T("测速地址:")), speedEntry,
+		widget.NewLabel(lang.T("测速大小(KB):")), sizeEntry,
+		widget.NewLabel(lang.T("检测目标:")), targetsEntry,
+		layout.NewSpacer(), container.NewHBox(resetBtn),
+	)
+
+	win := app.GetWindow()
+	d := dialog.NewCustomConfirm(lang.T("检测服务设置"), lang.T("保存"), lang.T("取消"), form, func(save bool) {
+		if !save {
+			return
+		}
+		newCfg := checker.Config{
+			ConnectivityURL: strings.TrimSpace(connectivityEntry.Text),
+			ExpectBody:      bodyEntry.Text,
+			ExpectJSONField: strings.TrimSpace(jsonFieldEntry.Text),
+			JudgeURL:        strings.TrimSpace(judgeEntry.Text),
+			HTTPSCheckURL:   strings.TrimSpace(httpsEntry.Text),
+			SpeedTestURL:    strings.TrimSpace(speedEntry.Text),
+		}
+		if text := strings.TrimSpace(statusEntry.Text); text != "" {
+			status, err := strconv.Atoi(text)
+			if err != nil {
+				dialog.ShowError(err, win)
+				return
+			}
+			newCfg.ExpectStatus = status
+		}
+		if text := strings.TrimSpace(sizeEntry.Text); text != "" {
+			kb, err := strconv.ParseInt(text, 10, 64)
+			if err != nil {
+				dialog.ShowError(err, win)
+				return
+			}
+			newCfg.SpeedTestSize = kb << 10
+		}
+		targets, err := checker.ParseTargets(targetsEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		newCfg.Targets = targets
+		if err := app.SetCheckConfig(newCfg); err != nil {
+			dialog.ShowError(err, win)
+		}
+	}, win)
+	d.Resize(fyne.NewSize(560, 0))
+	d.Show()
+}
@@ -0,0 +1,51 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"go_proxy/lang"
+	"go_proxy/server"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+)
+
+// createConnectionLogView 创建本地服务连接日志列表
+// 每行显示一个已结束的连接: 时间、客户端、目标、出口代理、流量、耗时和失败原因，最新的在前
+func createConnectionLogView(app Apper) fyne.CanvasObject {
+	records := app.GetConnectionLog()
+	list := widget.NewList(
+		func() int { return len(records) },
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("")
+			label.Truncation = fyne.TextTruncateEllipsis
+			return label
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			obj.(*widget.Label).SetText(formatConnRecord(records[id]))
+		},
+	)
+	startRefresh(func() {
+		records = app.GetConnectionLog()
+		list.Refresh()
+	})
+	return list
+}
+
+// formatConnRecord 格式化一条连接记录
+func formatConnRecord(rec server.ConnRecord) string {
+	target := rec.Target
+	if target == "" {
+		target = "-"
+	}
+	text := fmt.Sprintf("%s %s → %s", rec.Start.Format("15:04:05"), rec.Client, target)
+	if rec.Upstream != "" {
+		text += lang.T(" 经 ") + rec.Upstream
+	}
+	text += fmt.Sprintf(" ↑%s ↓%s %s", formatBytes(rec.BytesUp), formatBytes(rec.BytesDown), rec.Duration.Round(time.Millisecond))
+	if rec.Err != "" {
+		text += " ✗ " + rec.Err
+	}
+	return text
+}
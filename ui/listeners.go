@@ -0,0 +1,164 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go_proxy/lang"
+	"go_proxy/proxy"
+	"go_proxy/server"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showListenersDialog 显示附加监听器管理对话框
+// 每个附加监听器在独立端口上提供服务，可以设置各自的国家筛选、选择策略和会话保持，
+// 认证、访问规则和限速与主服务共用
+func showListenersDialog(app Apper) {
+	win := app.GetWindow()
+	listeners := app.GetListeners()
+
+	var table *widget.List
+	table = widget.NewList(
+		func() int { return len(listeners) },
+		func() fyne.CanvasObject {
+			return container.NewBorder(nil, nil, nil, widget.NewButton(lang.T("停止"), nil), widget.NewLabel(""))
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			row := obj.(*fyne.Container)
+			label := row.Objects[0].(*widget.Label)
+			stopBtn := row.Objects[1].(*widget.Button)
+
+			config := listeners[id]
+			label.SetText(describeListener(config))
+			stopBtn.OnTapped = func() {
+				if err := app.StopListener(config.Addr()); err != nil {
+					dialog.ShowError(err, win)
+				}
+				listeners = app.GetListeners()
+				table.Refresh()
+			}
+		},
+	)
+
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder(lang.T("可选，例如: 美国轮询"))
+	hostEntry := widget.NewSelectEntry(server.LocalBindAddresses())
+	hostEntry.SetText("127.0.0.1")
+	portEntry := widget.NewEntry()
+	portEntry.SetPlaceHolder(lang.T("例如: 10809"))
+	modeSelect := widget.NewSelect([]string{"SOCKS5", "HTTP"}, nil)
+	modeSelect.SetSelected("SOCKS5")
+	regionEntry := widget.NewEntry()
+	regionEntry.SetPlaceHolder(lang.T("留空表示不限，多个国家以逗号分隔"))
+	strategyLabels := make([]string, len(proxy.Strategies))
+	for i, s := range proxy.Strategies {
+		strategyLabels[i] = lang.T(s.Label())
+	}
+	strategySelect := widget.NewSelect(strategyLabels, nil)
+	strategySelect.SetSelected(strategyLabels[0])
+	premiumCheck := widget.NewCheck(lang.T("只使用高级代理"), nil)
+	targetOptions := []string{lang.T("不限")}
+	for _, t := range app.GetCheckConfig().Targets {
+		targetOptions = append(targetOptions, t.Name)
+	}
+	targetSelect := widget.NewSelect(targetOptions, nil)
+	targetSelect.SetSelected(targetOptions[0])
+	poolOptions := []string{lang.T("全部")}
+	for _, pool := range app.GetPools() {
+		poolOptions = append(poolOptions, pool.Name)
+	}
+	poolSelect := widget.NewSelect(poolOptions, nil)
+	poolSelect.SetSelected(poolOptions[0])
+	stickyEntry := widget.NewEntry()
+	stickyEntry.SetPlaceHolder(lang.T("0 表示每个连接都轮换"))
+
+	startBtn := widget.NewButton(lang.T("启动"), func() {
+		port, err := strconv.Atoi(strings.TrimSpace(portEntry.Text))
+		if err != nil {
+			dialog.ShowError(fmt.Errorf(lang.T("端口 '%s' 无效"), portEntry.Text), win)
+			return
+		}
+		sticky := 0
+		if text := strings.TrimSpace(stickyEntry.Text); text != "" {
+			if sticky, err = strconv.Atoi(text); err != nil {
+				dialog.ShowError(fmt.Errorf(lang.T("会话保持时长 '%s' 无效"), text), win)
+				return
+			}
+		}
+		target := targetSelect.Selected
+		if targetSelect.SelectedIndex() <= 0 {
+			target = ""
+		}
+		pool := poolSelect.Selected
+		if poolSelect.SelectedIndex() <= 0 {
+			pool = ""
+		}
+		config := server.ListenerConfig{
+			Name:          strings.TrimSpace(nameEntry.Text),
+			Host:          strings.TrimSpace(hostEntry.Text),
+			Port:          port,
+			Mode:          server.ListenMode(strings.ToLower(modeSelect.Selected)),
+			Region:        regionEntry.Text,
+			Strategy:      proxy.Strategies[strategySelect.SelectedIndex()],
+			PremiumOnly:   premiumCheck.Checked,
+			Target:        target,
+			Pool:          pool,
+			StickyMinutes: sticky,
+		}
+		if err := app.StartListener(config); err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		listeners = app.GetListeners()
+		table.Refresh()
+		portEntry.SetText("")
+	})
+
+	form := container.New(layout.NewFormLayout(),
+		widget.NewLabel(lang.T("名称:")), nameEntry,
+		widget.NewLabel(lang.T("监听地址:")), hostEntry,
+		widget.NewLabel(lang.T("端口:")), portEntry,
+		widget.NewLabel(lang.T("协议:")), modeSelect,
+		widget.NewLabel(lang.T("国家:")), regionEntry,
+		widget.NewLabel(lang.T("选择策略:")), strategySelect,
+		widget.NewLabel(lang.T("上游范围:")), premiumCheck,
+		widget.NewLabel(lang.T("目标可用:")), targetSelect,
+		widget.NewLabel(lang.T("代理池:")), poolSelect,
+		widget.NewLabel(lang.T("会话保持(分钟):")), stickyEntry,
+		layout.NewSpacer(), startBtn,
+	)
+	content := container.NewBorder(nil, widget.NewCard(lang.T("新建监听器"), lang.T("认证、访问规则和限速与主服务共用"), form), nil, nil, table)
+	d := dialog.NewCustom(lang.T("附加监听器"), lang.T("关闭"), content, win)
+	d.Resize(fyne.NewSize(620, 640))
+	d.Show()
+}
+
+// describeListener 生成监听器列表中一行的显示文本
+func describeListener(config server.ListenerConfig) string {
+	parts := []string{fmt.Sprintf("%s://%s", config.Mode, config.Addr()), lang.T(config.Strategy.Label())}
+	if config.Name != "" {
+		parts = append([]string{config.Name}, parts...)
+	}
+	if config.Region != "" {
+		parts = append(parts, config.Region)
+	}
+	if config.PremiumOnly {
+		parts = append(parts, lang.T("高级"))
+	}
+	if config.Target != "" {
+		parts = append(parts, lang.T("目标:")+config.Target)
+	}
+	if config.Pool != "" {
+		parts = append(parts, lang.T("池:")+config.Pool)
+	}
+	if config.StickyMinutes > 0 {
+		parts = append(parts, fmt.Sprintf(lang.T("保持%d分钟"), config.StickyMinutes))
+	}
+	return strings.Join(parts, " | ")
+}
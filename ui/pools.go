@@ -0,0 +1,126 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go_proxy/lang"
+	"go_proxy/proxy"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showPoolsDialog 显示命名代理池管理对话框
+// 代理池由标签、国家、最大延迟和HTTPS条件定义，保存同名代理池会替换原有定义
+func showPoolsDialog(app Apper) {
+	pools := app.GetPools()
+
+	var list *widget.List
+	list = widget.NewList(
+		func() int { return len(pools) },
+		func() fyne.CanvasObject {
+			return container.NewBorder(nil, nil, nil, widget.NewButton(lang.T("删除"), nil), widget.NewLabel(""))
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			row := obj.(*fyne.Container)
+			label := row.Objects[0].(*widget.Label)
+			deleteBtn := row.Objects[1].(*widget.Button)
+
+			pool := pools[id]
+			label.SetText(describePool(pool))
+			deleteBtn.OnTapped = func() {
+				app.RemovePool(pool.Name)
+				pools = app.GetPools()
+				list.Refresh()
+			}
+		},
+	)
+
+	win := app.GetWindow()
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder(lang.T("例如: scrape"))
+	tagsEntry := widget.NewEntry()
+	tagsEntry.SetPlaceHolder(lang.T("带有任一标签的代理，多个以逗号分隔，留空不限"))
+	regionEntry := widget.NewEntry()
+	regionEntry.SetPlaceHolder(lang.T("留空表示不限，多个国家以逗号分隔"))
+	latencyEntry := widget.NewEntry()
+	latencyEntry.SetPlaceHolder(lang.T("毫秒，留空或0表示不限"))
+	httpsCheck := widget.NewCheck(lang.T("只包含支持HTTPS的代理"), nil)
+
+	saveBtn := widget.NewButton(lang.T("保存"), func() {
+		maxLatency := 0.0
+		if text := strings.TrimSpace(latencyEntry.Text); text != "" {
+			ms, err := strconv.ParseFloat(text, 64)
+			if err != nil || ms < 0 {
+				dialog.ShowError(fmt.Errorf(lang.T("最大延迟 '%s' 无效"), text), win)
+				return
+			}
+			maxLatency = ms / 1000
+		}
+		pool := proxy.NamedPool{
+			Name:       nameEntry.Text,
+			Tags:       proxy.ParseTags(tagsEntry.Text),
+			Region:     regionEntry.Text,
+			MaxLatency: maxLatency,
+			HTTPSOnly:  httpsCheck.Checked,
+		}
+		if err := app.SavePool(pool); err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		pools = app.GetPools()
+		list.Refresh()
+		nameEntry.SetText("")
+	})
+
+	form := container.New(layout.NewFormLayout(),
+		widget.NewLabel(lang.T("名称:")), nameEntry,
+		widget.NewLabel(lang.T("标签:")), tagsEntry,
+		widget.NewLabel(lang.T("国家:")), regionEntry,
+		widget.NewLabel(lang.T("最大延迟:")), latencyEntry,
+		widget.NewLabel("HTTPS:"), httpsCheck,
+		layout.NewSpacer(), saveBtn,
+	)
+	content := container.NewBorder(nil, widget.NewCard(lang.T("添加代理池"), lang.T("本地服务和附加监听器可以绑定到代理池，只从池中选择上游代理"), form), nil, nil, list)
+	d := dialog.NewCustom(lang.T("代理池管理"), lang.T("关闭"), content, win)
+	d.Resize(fyne.NewSize(600, 520))
+	d.Show()
+}
+
+// describePool 生成代理池列表中一行的显示文本
+func describePool(pool proxy.NamedPool) string {
+	parts := []string{pool.Name}
+	if len(pool.Tags) > 0 {
+		parts = append(parts, lang.T("标签:")+strings.Join(pool.Tags, ","))
+	}
+	if pool.Region != "" {
+		parts = append(parts, pool.Region)
+	}
+	if pool.MaxLatency > 0 {
+		parts = append(parts, fmt.Sprintf("≤%.0fms", pool.MaxLatency*1000))
+	}
+	if pool.HTTPSOnly {
+		parts = append(parts, "HTTPS")
+	}
+	return strings.Join(parts, " | ")
+}
+
+// showTagsDialog 显示编辑代理标签的对话框，标签以逗号分隔，清空表示删除全部标签
+func showTagsDialog(app Apper, p *proxy.Proxy) {
+	tagsEntry := widget.NewEntry()
+	tagsEntry.SetText(strings.Join(p.Tags, ", "))
+	tagsEntry.SetPlaceHolder(lang.T("例如: scrape, stream, cn-direct"))
+	items := []*widget.FormItem{widget.NewFormItem(lang.T("标签"), tagsEntry)}
+	d := dialog.NewForm(lang.T("编辑标签 - ")+p.Address, lang.T("保存"), lang.T("取消"), items, func(save bool) {
+		if save {
+			app.SetProxyTags(p, proxy.ParseTags(tagsEntry.Text))
+		}
+	}, app.GetWindow())
+	d.Resize(fyne.NewSize(420, 160))
+	d.Show()
+}
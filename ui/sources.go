@@ -0,0 +1,282 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"go_proxy/fetcher"
+	"go_proxy/lang"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showSourcesDialog 显示代理源管理对话框
+// 可启用/停用、删除和添加代理源，点击保存后生效并写入配置文件
+func showSourcesDialog(app Apper) {
+	sources := app.GetProxySources()
+
+	var list *widget.List
+	list = widget.NewList(
+		func() int { return len(sources) },
+		func() fyne.CanvasObject {
+			return container.NewBorder(nil, nil, widget.NewCheck("", nil), widget.NewButton(lang.T("删除"), nil), widget.NewLabel(""))
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			row := obj.(*fyne.Container)
+			label := row.Objects[0].(*widget.Label)
+			check := row.Objects[1].(*widget.Check)
+			deleteBtn := row.Objects[2].(*widget.Button)
+
+			s := sources[id]
+			name := s.Name
+			if name == "" {
+				name = s.URL
+			}
+			if s.Premium {
+				name += lang.T(" (高级)")
+			}
+			if s.Provider != nil && s.Name != s.Provider.Name {
+				name += " (" + s.Provider.Name + ")"
+			}
+			if s.Table != nil {
+				name += lang.T(" (表格)")
+			}
+			if s.MaxPages > 1 {
+				name += fmt.Sprintf(lang.T(" (最多%d页)"), s.MaxPages)
+			}
+			label.SetText(fmt.Sprintf("[%s] %s", s.Protocol, name))
+			check.OnChanged = nil
+			check.SetChecked(!s.Disabled)
+			check.OnChanged = func(enabled bool) {
+				sources[id].Disabled = !enabled
+			}
+			deleteBtn.OnTapped = func() {
+				sources = append(sources[:id:id], sources[id+1:]...)
+				list.Refresh()
+			}
+		},
+	)
+
+	urlEntry := widget.NewEntry()
+	urlEntry.SetPlaceHolder("https://example.com/proxies.txt")
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder(lang.T("可选"))
+	protocolSelect := widget.NewSelect([]string{"http", "https", "socks4", "socks5"}, nil)
+	protocolSelect.SetSelected("http")
+	apiCheck := widget.NewCheck(lang.T("API/纯文本响应"), nil)
+	apiCheck.SetChecked(true)
+	premiumCheck := widget.NewCheck(lang.T("标记为高级"), nil)
+	rawListCheck := widget.NewCheck(lang.T("条件请求(ETag)"), nil)
+	regexEntry := widget.NewEntry()
+	regexEntry.SetPlaceHolder(lang.T(`可选，例如: (\d+\.\d+\.\d+\.\d+:\d+)`))
+	jsonPathEntry := widget.NewEntry()
+	jsonPathEntry.SetPlaceHolder(lang.T("可选，例如: data.list"))
+	pageURLEntry := widget.NewEntry()
+	pageURLEntry.SetPlaceHolder(lang.T("可选，{page} 替换为页码，例如: https://example.com/list/{page}.html"))
+	maxPagesEntry := widget.NewEntry()
+	maxPagesEntry.SetPlaceHolder(lang.T("含第1页，0 或 1 表示不翻页"))
+	tableRowsEntry := widget.NewEntry()
+	tableRowsEntry.SetPlaceHolder(lang.T("可选，CSS选择器，例如: table.table-striped tbody tr"))
+	tableColumnsEntry := widget.NewEntry()
+	noProvider := lang.T("无")
+	providerSelect := widget.NewSelect(append([]string{noProvider}, fetcher.Providers...), nil)
+	providerSelect.SetSelected(noProvider)
+	apiKeyEntry := widget.NewPasswordEntry()
+	apiKeyEntry.SetPlaceHolder(lang.T("付费服务商的API密钥"))
+	zoneEntry := widget.NewEntry()
+	zoneEntry.SetPlaceHolder(lang.T("区域"))
+	customerEntry := widget.NewEntry()
+	customerEntry.SetPlaceHolder(lang.T("客户ID"))
+	zonePassEntry := widget.NewPasswordEntry()
+	zonePassEntry.SetPlaceHolder(lang.T("区域密码"))
+	tableColumnsEntry.SetPlaceHolder(lang.T("IP,端口,协议,国家,匿名度 所在列(从1开始，0 表示没有)，例如: 1,2,0,3,5"))
+
+	win := app.GetWindow()
+	addBtn := widget.NewButton(lang.T("添加代理源"), func() {
+		maxPages := 0
+		if text := strings.TrimSpace(maxPagesEntry.Text); text != "" {
+			n, err := strconv.Atoi(text)
+			if err != nil {
+				dialog.ShowError(errors.New(lang.T("最大页数必须为整数")), win)
+				return
+			}
+			maxPages = n
+		}
+		table, err := fetcher.ParseTableColumns(tableRowsEntry.Text, tableColumnsEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		source := fetcher.ProxySource{
+			URL:      urlEntry.Text,
+			Protocol: protocolSelect.Selected,
+			IsAPI:    apiCheck.Checked,
+			Name:     nameEntry.Text,
+			Regex:    regexEntry.Text,
+			JSONPath: jsonPathEntry.Text,
+			Premium:  premiumCheck.Checked,
+			RawList:  rawListCheck.Checked,
+			PageURL:  strings.TrimSpace(pageURLEntry.Text),
+			MaxPages: maxPages,
+			Table:    table,
+		}
+		if providerSelect.Selected != noProvider {
+			source.Provider = &fetcher.ProviderConfig{
+				Name:     providerSelect.Selected,
+				APIKey:   apiKeyEntry.Text,
+				Zone:     strings.TrimSpace(zoneEntry.Text),
+				Customer: strings.TrimSpace(customerEntry.Text),
+				Password: zonePassEntry.Text,
+			}
+		}
+		if err := source.Validate(); err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		sources = append(sources, source)
+		list.Refresh()
+		urlEntry.SetText("")
+		nameEntry.SetText("")
+		regexEntry.SetText("")
+		jsonPathEntry.SetText("")
+		pageURLEntry.SetText("")
+		maxPagesEntry.SetText("")
+		tableRowsEntry.SetText("")
+		tableColumnsEntry.SetText("")
+		apiKeyEntry.SetText("")
+		zoneEntry.SetText("")
+		customerEntry.SetText("")
+		zonePassEntry.SetText("")
+		providerSelect.SetSelected(noProvider)
+	})
+
+	form := container.New(layout.NewFormLayout(),
+		widget.NewLabel(lang.T("地址:")), urlEntry,
+		widget.NewLabel(lang.T("名称:")), nameEntry,
+		widget.NewLabel(lang.T("协议:")), container.NewHBox(protocolSelect, apiCheck, premiumCheck, rawListCheck),
+		widget.NewLabel(lang.T("提取正则:")), regexEntry,
+		widget.NewLabel(lang.T("JSON路径:")), jsonPathEntry,
+		widget.NewLabel(lang.T("分页地址:")), pageURLEntry,
+		widget.NewLabel(lang.T("最大页数:")), maxPagesEntry,
+		widget.NewLabel(lang.T("付费服务商:")), container.NewBorder(nil, nil, providerSelect, nil, apiKeyEntry),
+		widget.NewLabel("Bright Data:"), container.NewGridWithColumns(3, zoneEntry, customerEntry, zonePassEntry),
+		widget.NewLabel(lang.T("表格行:")), tableRowsEntry,
+		widget.NewLabel(lang.T("表格列:")), tableColumnsEntry,
+		layout.NewSpacer(), addBtn,
+	)
+
+	content := container.NewBorder(nil, widget.NewCard(lang.T("添加代理源"), "", form), nil, nil, list)
+	d := dialog.NewCustomConfirm(lang.T("代理源管理"), lang.T("保存"), lang.T("取消"), content, func(save bool) {
+		if !save {
+			return
+		}
+		if err := app.SetProxySources(sources); err != nil {
+			dialog.ShowError(err, win)
+		}
+	}, win)
+	d.Resize(fyne.NewSize(720, 600))
+	d.Show()
+}
+
+// showSubscriptionDialog 显示订阅导入对话框，默认填入上次使用的订阅地址
+func showSubscriptionDialog(app Apper) {
+	urlEntry := widget.NewEntry()
+	urlEntry.SetText(app.GetSubscriptionURL())
+	urlEntry.SetPlaceHolder("https://example.com/sub")
+	items := []*widget.FormItem{widget.NewFormItem(lang.T("订阅地址"), urlEntry)}
+	d := dialog.NewForm(lang.T("订阅导入"), lang.T("导入"), lang.T("取消"), items, func(ok bool) {
+		if ok {
+			app.ImportSubscription(urlEntry.Text)
+		}
+	}, app.GetWindow())
+	d.Resize(fyne.NewSize(520, 160))
+	d.Show()
+}
+
+// createSourceStatsView 创建代理源统计视图
+// 列出每个代理源最近一次获取的代理数、新增数、HTTP状态、耗时和检测有效率，
+// 可直接启用/停用代理源，便于停用长期无效的代理源
+func createSourceStatsView(app Apper) fyne.CanvasObject {
+	stats := app.GetSourceStats()
+	win := app.GetWindow()
+	list := widget.NewList(
+		func() int { return len(stats) },
+		func() fyne.CanvasObject {
+			result := widget.NewLabel("")
+			result.Truncation = fyne.TextTruncateEllipsis
+			name := widget.NewLabel("")
+			name.Truncation = fyne.TextTruncateEllipsis
+			return container.NewBorder(nil, nil, widget.NewCheck("", nil), nil, container.NewVBox(name, result))
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			row := obj.(*fyne.Container)
+			labels := row.Objects[0].(*fyne.Container)
+			check := row.Objects[1].(*widget.Check)
+			name := labels.Objects[0].(*widget.Label)
+			result := labels.Objects[1].(*widget.Label)
+
+			s := stats[id]
+			title := s.Source.Name
+			if title == "" {
+				title = s.Source.URL
+			}
+			name.SetText(fmt.Sprintf("[%s] %s", s.Source.Protocol, title))
+			result.SetText(formatSourceStats(s))
+			if s.Err != "" {
+				result.Importance = widget.DangerImportance
+			} else {
+				result.Importance = widget.MediumImportance
+			}
+			result.Refresh()
+
+			url := s.Source.URL
+			check.OnChanged = nil
+			check.SetChecked(!s.Source.Disabled)
+			check.OnChanged = func(enabled bool) {
+				if err := app.SetSourceEnabled(url, enabled); err != nil {
+					dialog.ShowError(err, win)
+				}
+			}
+		},
+	)
+	startRefresh(func() {
+		stats = app.GetSourceStats()
+		list.Refresh()
+	})
+	return list
+}
+
+// formatSourceStats 格式化代理源最近一次获取的统计
+func formatSourceStats(s fetcher.SourceStats) string {
+	if s.LastFetch.IsZero() {
+		return lang.T("本次运行尚未获取")
+	}
+	status := "-"
+	if s.Status != 0 {
+		status = fmt.Sprintf("HTTP %d", s.Status)
+	}
+	text := fmt.Sprintf("%s  %s  %.1fs", s.LastFetch.Format("15:04:05"), status, s.Duration.Seconds())
+	if s.Status == http.StatusNotModified {
+		text += lang.T("  列表未变化")
+	}
+	if s.Retries > 0 {
+		text += fmt.Sprintf(lang.T("  重试 %d 次"), s.Retries)
+	}
+	if s.Err != "" {
+		return text + "  ✗ " + s.Err
+	}
+	if s.Pages > 1 {
+		text += fmt.Sprintf(lang.T("  %d 页"), s.Pages)
+	}
+	text += fmt.Sprintf(lang.T("  获取 %d  新增 %d"), s.Fetched, s.Unique)
+	if s.Tested > 0 {
+		text += fmt.Sprintf(lang.T("  有效 %d/%d (%.0f%%)"), s.Valid, s.Tested, s.ValidRate()*100)
+	}
+	return text
+}
@@ -0,0 +1,101 @@
+package ui
+
+import (
+	"errors"
+	"go_proxy/checker"
+	"go_proxy/lang"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showTestSettingsDialog 显示测试设置对话框
+// 可调整批量测试的并发数、连接超时、检测超时和测速大小，保存后对之后的测试生效并在下次启动时恢复
+func showTestSettingsDialog(app Apper) {
+	s := app.GetTestSettings()
+
+	concurrencyEntry := widget.NewEntry()
+	concurrencyEntry.SetText(strconv.Itoa(s.Concurrency))
+	connectEntry := widget.NewEntry()
+	connectEntry.SetText(formatSeconds(s.ConnectTimeout))
+	checkEntry := widget.NewEntry()
+	checkEntry.SetText(formatSeconds(s.CheckTimeout))
+	sizeEntry := widget.NewEntry()
+	sizeEntry.SetPlaceHolder(lang.T("0 表示下载完整文件"))
+	sizeEntry.SetText(strconv.FormatInt(s.SpeedTestSize>>10, 10))
+
+	resetBtn := widget.NewButton(lang.T("恢复默认"), func() {
+		def := checker.DefaultTestSettings()
+		concurrencyEntry.SetText(strconv.Itoa(def.Concurrency))
+		connectEntry.SetText(formatSeconds(def.ConnectTimeout))
+		checkEntry.SetText(formatSeconds(def.CheckTimeout))
+		sizeEntry.SetText(strconv.FormatInt(def.SpeedTestSize>>10, 10))
+	})
+
+	form := container.New(layout.NewFormLayout(),
+		widget.NewLabel(lang.T("并发数:")), concurrencyEntry,
+		widget.NewLabel(lang.T("连接超时(秒):")), connectEntry,
+		widget.NewLabel(lang.T("检测超时(秒):")), checkEntry,
+		widget.NewLabel(lang.T("测速大小(KB):")), sizeEntry,
+		layout.NewSpacer(), container.NewHBox(resetBtn),
+		layout.NewSpacer(), widget.NewLabel(lang.T("网络较差时降低并发、延长超时；服务器带宽充足时可提高并发。")),
+	)
+
+	win := app.GetWindow()
+	d := dialog.NewCustomConfirm(lang.T("测试设置"), lang.T("保存"), lang.T("取消"), form, func(save bool) {
+		if !save {
+			return
+		}
+		concurrency, err := strconv.Atoi(strings.TrimSpace(concurrencyEntry.Text))
+		if err != nil {
+			dialog.ShowError(errors.New(lang.T("并发数必须为整数")), win)
+			return
+		}
+		connect, err := parseSeconds(connectEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		check, err := parseSeconds(checkEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		kb, err := strconv.ParseInt(strings.TrimSpace(sizeEntry.Text), 10, 64)
+		if err != nil {
+			dialog.ShowError(errors.New(lang.T("测速大小必须为整数")), win)
+			return
+		}
+		err = app.SetTestSettings(checker.TestSettings{
+			Concurrency:    concurrency,
+			ConnectTimeout: connect,
+			CheckTimeout:   check,
+			SpeedTestSize:  kb << 10,
+		})
+		if err != nil {
+			dialog.ShowError(err, win)
+		}
+	}, win)
+	d.Resize(fyne.NewSize(420, 0))
+	d.Show()
+}
+
+// formatSeconds 将时长格式化为秒数，去掉多余的小数位
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+}
+
+// parseSeconds 解析可带小数的秒数
+func parseSeconds(text string) (time.Duration, error) {
+	seconds, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+	if err != nil || seconds <= 0 {
+		return 0, errors.New(lang.T("超时必须为正数(秒)"))
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
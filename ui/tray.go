@@ -0,0 +1,130 @@
+package ui
+
+import (
+	"fmt"
+	"go_proxy/lang"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+	fynetheme "fyne.io/fyne/v2/theme"
+)
+
+// trayState 托盘菜单的状态，切换界面语言后重新创建菜单时保留
+var trayState = struct {
+	visible  bool
+	trayMode bool
+}{visible: true, trayMode: true}
+
+// trayListeners 当前托盘菜单注册的监听器，重建菜单时先移除上一次的
+var trayListeners listenerSet
+
+// SetupTray 在支持系统托盘的桌面平台上创建托盘图标和快捷菜单
+// 菜单包含显示/隐藏窗口、当前代理、启停服务、切换轮换和退出，点击当前代理可复制其地址
+// 切换界面语言后再次调用以按新语言重建菜单，旧菜单的监听器会被移除
+// 启用"关闭时最小化到托盘"后，点击窗口关闭按钮只会隐藏窗口
+func SetupTray(app Apper) {
+	trayListeners.removeAll()
+	desk, ok := fyne.CurrentApp().(desktop.App)
+	if !ok {
+		return
+	}
+	win := app.GetWindow()
+
+	var menu *fyne.Menu
+	showItem := fyne.NewMenuItem(lang.T("隐藏窗口"), nil)
+	setVisible := func(show bool) {
+		trayState.visible = show
+		if show {
+			win.Show()
+			win.RequestFocus()
+			showItem.Label = lang.T("隐藏窗口")
+		} else {
+			win.Hide()
+			showItem.Label = lang.T("显示窗口")
+		}
+		menu.Refresh()
+	}
+	showItem.Action = func() {
+		setVisible(!trayState.visible)
+	}
+
+	serverItem := fyne.NewMenuItem(lang.T("启动服务"), func() {
+		app.ToggleServer(app.GetServerHost(), app.GetServerPort())
+	})
+	rotationItem := fyne.NewMenuItem(lang.T("启用代理轮换"), func() {
+		enabled, _ := app.GetRotationStatus().Get()
+		app.ToggleRotation(!enabled)
+	})
+
+	currentProxy := app.GetCurrentProxy()
+	currentItem := fyne.NewMenuItem(lang.T("当前代理: ")+lang.T("无"), func() {
+		address, _ := currentProxy.Get()
+		if address == "" || address == "无" {
+			return
+		}
+		win.Clipboard().SetContent(address)
+		app.Log(fmt.Sprintf(lang.T("当前代理 %s 已复制到剪贴板"), address))
+	})
+
+	trayItem := fyne.NewMenuItem(lang.T("关闭时最小化到托盘"), nil)
+	trayItem.Checked = trayState.trayMode
+	trayItem.Action = func() {
+		trayState.trayMode = !trayState.trayMode
+		trayItem.Checked = trayState.trayMode
+		menu.Refresh()
+	}
+
+	quitItem := fyne.NewMenuItem(lang.T("退出"), func() {
+		fyne.CurrentApp().Quit()
+	})
+	quitItem.IsQuit = true
+
+	menu = fyne.NewMenu(lang.T("代理池工具"),
+		showItem,
+		fyne.NewMenuItemSeparator(),
+		currentItem,
+		serverItem,
+		rotationItem,
+		fyne.NewMenuItemSeparator(),
+		trayItem,
+		quitItem,
+	)
+
+	serverStatus := app.GetServerStatus()
+	trayListeners.add(serverStatus, func() {
+		if running, _ := serverStatus.Get(); running {
+			serverItem.Label = lang.T("停止服务")
+		} else {
+			serverItem.Label = lang.T("启动服务")
+		}
+		menu.Refresh()
+	})
+	rotationStatus := app.GetRotationStatus()
+	trayListeners.add(rotationStatus, func() {
+		if enabled, _ := rotationStatus.Get(); enabled {
+			rotationItem.Label = lang.T("停止代理轮换")
+		} else {
+			rotationItem.Label = lang.T("启用代理轮换")
+		}
+		menu.Refresh()
+	})
+	trayListeners.add(currentProxy, func() {
+		address, _ := currentProxy.Get()
+		if address == "" {
+			address = "无"
+		}
+		currentItem.Label = lang.T("当前代理: ") + lang.T(address)
+		menu.Refresh()
+	})
+
+	desk.SetSystemTrayIcon(fynetheme.ComputerIcon())
+	desk.SetSystemTrayMenu(menu)
+
+	win.SetCloseIntercept(func() {
+		if trayState.trayMode {
+			setVisible(false)
+			return
+		}
+		win.Close()
+	})
+}
@@ -2,16 +2,25 @@ package ui
 
 import (
 	"fmt"
+	"go_proxy/checker"
+	"go_proxy/config"
+	"go_proxy/fetcher"
+	"go_proxy/lang"
+	"go_proxy/logging"
 	"go_proxy/proxy"
+	"go_proxy/server"
+	"net"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/data/binding"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/layout"
-	fynetheme "fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 
 	customtheme "go_proxy/theme"
@@ -23,68 +32,207 @@ import (
 type Apper interface {
 	GetWindow() fyne.Window
 	GetProxyList() binding.UntypedList
-	GetLogBinding() binding.String
+	GetListRevision() binding.Int
+	SetListPage(page int)
+	ListPageInfo() (page, pages, total int)
+	FindListedProxy(address string) *proxy.Proxy
+	GetLogRevision() binding.Int
+	GetLogEntries(min logging.Level, search string) []logging.Entry
+	ExportLog(entries []logging.Entry)
 	GetProgressBar() *widget.ProgressBar
 	GetServerStatus() binding.Bool
 	GetRotationStatus() binding.Bool
+	GetSystemProxyStatus() binding.Bool
 	GetCurrentProxy() binding.String
+	GetAutoRefreshStatus() binding.Bool
+	GetProxyHistory(address string) []proxy.Sample
+	GetServerHost() string
+	GetServerPort() string
+	GetServerMode() string
+	GetAutoPersist() bool
+	GetSettings() config.Settings
+	SetTheme(mode string)
+	SetLanguage(l lang.Language)
+	GetPoolStats() proxy.PoolStats
+	GetTrafficStats() proxy.TrafficStats
+	GetConnectionLog() []server.ConnRecord
 	Log(message string)
+	LogError(message string)
 	FetchProxies()
 	TestAllProxies()
+	TestNeverCheckedProxies()
+	TestSelectedProxies(proxies []*proxy.Proxy)
+	RetestFailedProxies()
 	ImportProxies()
+	GetProxySources() []fetcher.ProxySource
+	GetSourceStats() []fetcher.SourceStats
+	SetSourceEnabled(url string, enabled bool) error
+	SetProxySources(sources []fetcher.ProxySource) error
+	ImportFromClipboard()
+	ImportSubscription(subURL string)
+	GetSubscriptionURL() string
 	ExportProxies()
 	ClearProxies()
-	ToggleServer(port string)
+	TogglePin(p *proxy.Proxy)
+	TogglePremium(p *proxy.Proxy)
+	DeleteProxy(p *proxy.Proxy)
+	BlacklistProxy(p *proxy.Proxy)
+	GetBlacklist() []string
+	AddBlacklistEntry(entry string) error
+	RemoveBlacklistEntry(entry string)
+	RecheckProxy(p *proxy.Proxy)
+	ToggleServer(host, port string)
+	TestLocalServer()
+	VerifyExit() (*server.ExitReport, error)
+	QuickConnectString() (string, error)
+	SetAccessRules(targetBlock, targetAllow, clientAllow, clientDeny string)
+	SetServerAuth(user, pass string)
+	SetChainMode(enabled bool)
+	SetPremiumOnly(enabled bool)
+	SetFollowRotation(enabled bool)
+	NextProxy()
+	SetRequiredTarget(name string)
+	SetServerPool(name string)
+	GetPools() []proxy.NamedPool
+	SavePool(pool proxy.NamedPool) error
+	RemovePool(name string)
+	SetProxyTags(p *proxy.Proxy, tags []string)
+	SetStickySessions(minutes int)
+	SetHostAffinity(minutes int)
+	SetRateLimits(connKB, globalKB int)
+	SetConnectionLimit(max, queue int)
+	SetProxyUsageLimits(maxConns, cooldownSeconds int)
+	GetConnectionGauge() (active, queued, max int)
+	ToggleSystemProxy(enable bool)
+	GetListeners() []server.ListenerConfig
+	StartListener(config server.ListenerConfig) error
+	StopListener(addr string) error
+	SetServerMode(mode string)
 	ToggleRotation(enable bool)
 	SetRotationInterval(seconds int)
-	ApplyFilters(maxLatency, minSpeed string)
+	GetTestSettings() checker.TestSettings
+	SetTestSettings(s checker.TestSettings) error
+	SetCheckRetries(retries int, backoffSeconds float64)
+	SetLatencySamples(n int)
+	SetImportPrecheck(enabled bool)
+	SetImportPremium(enabled bool)
+	SetImportDetect(enabled bool)
+	ChooseGeoIPDatabase()
+	SetGeoIPDatabase(path string)
+	ChooseASNDatabase()
+	SetASNDatabase(path string)
+	GetCheckConfig() checker.Config
+	SetCheckConfig(cfg checker.Config) error
+	CancelImportPrecheck()
+	CancelTasks()
+	ToggleAutoRefresh(enable bool)
+	SetAutoPersist(enabled bool)
+	SetAutoRefreshInterval(minutes int)
+	SetAutoRefreshTest(enabled bool)
+	ToggleRevalidation(enable bool)
+	SetRevalidation(minutes, workers int)
+	ApplyFilters(maxLatency, minSpeed string, remoteDNSOnly, httpsOnly bool, ipVersion int)
+	SetTableFilter(search, protocol, country, anonymity string)
+	GetFilterOptions() (protocols, countries, anonymities []string)
+	GetSortOrder() proxy.SortOrder
+	SetSortOrder(order proxy.SortOrder)
 }
 
 // SetupUI 初始化应用主界面，排列所有UI组件
+// 切换界面语言后再次调用，按新语言重建整个窗口内容，旧界面注册的监听器和快捷键会先被移除
 // 参数 app 提供了访问应用核心功能和数据绑定的接口
 func SetupUI(app Apper) {
+	close(refreshStop)
+	refreshStop = make(chan struct{})
+	uiListeners.removeAll()
+
 	toolbar := createToolbar(app)
 	filterControl := createFilterControlPanel(app)
 	serverControl := createServerControlPanel(app)
 	rotationControl := createRotationControlPanel(app)
-	progressCard := widget.NewCard("进度", "", app.GetProgressBar())
+	progressCard := widget.NewCard(lang.T("进度"), "", app.GetProgressBar())
 
 	// 创建代理详情显示区域
 	currentProxyInfo := widget.NewMultiLineEntry()
 	currentProxyInfo.Disable()
-	currentProxyInfo.SetPlaceHolder("当前代理信息将在此显示...")
+	currentProxyInfo.SetPlaceHolder(lang.T("当前代理信息将在此显示..."))
 
 	// 绑定当前代理信息更新
-	app.GetCurrentProxy().AddListener(binding.NewDataListener(func() {
+	uiListeners.add(app.GetCurrentProxy(), func() {
 		proxyAddr, _ := app.GetCurrentProxy().Get()
 		if proxyAddr != "" {
 			// 获取完整代理信息
-			items, _ := app.GetProxyList().Get()
-			for _, item := range items {
-				p := item.(*proxy.Proxy)
-				if p.Address == proxyAddr {
-					info := fmt.Sprintf("当前代理: %s\n协议: %s\n国家: %s\n省份: %s\n城市: %s\n延迟: %.0fms\n速度: %.2fKB/s\n匿名度: %s",
-						p.Address, p.Protocol, p.Country, p.Province, p.City, p.Latency*1000, p.Speed, p.Anonymity)
-					currentProxyInfo.SetText(info)
-					break
+			if p := app.FindListedProxy(proxyAddr); p != nil {
+				remoteDNS := lang.T("未检测")
+				if p.DNSChecked && p.RemoteDNS {
+					remoteDNS = lang.T("是")
+				} else if p.DNSChecked {
+					remoteDNS = lang.T("否(存在DNS泄漏)")
+				}
+				info := fmt.Sprintf(lang.T("当前代理: %s\n协议: %s\n出口IP: %s\n国家: %s\n省份: %s\n城市: %s\n延迟: %.0fms\n速度: %.2fKB/s\n匿名度: %s\n远程DNS: %s"),
+					p.Address, p.Protocol, p.ExitDescription(), p.Country, p.Province, p.City, p.Latency*1000, p.Speed, p.Anonymity, remoteDNS)
+				if p.SupportsHTTPS {
+					info += lang.T("\nHTTPS: 支持")
+				} else {
+					info += lang.T("\nHTTPS: 不支持或未检测")
+				}
+				if len(p.Tags) > 0 {
+					info += lang.T("\n标签: ") + strings.Join(p.Tags, ", ")
+				}
+				if p.ASN != 0 {
+					info += fmt.Sprintf("\nASN: AS%d %s", p.ASN, p.ASOrg)
+				}
+				if len(p.TargetChecks) > 0 {
+					info += lang.T("\n检测目标: ") + formatTargetChecks(p.TargetChecks)
+				}
+				if p.IsPremium {
+					info += lang.T("\n高级代理: 是")
+				}
+				if p.Stability > 0 {
+					info += fmt.Sprintf(lang.T("\n稳定性: %.0f (抖动 %.0fms)"), p.Stability, p.Jitter*1000)
+				}
+				if p.Uptime > 0 || p.SourceLatency > 0 {
+					info += fmt.Sprintf(lang.T("\n代理源报告: 在线率 %.0f%%，延迟 %.0fms"), p.Uptime, p.SourceLatency*1000)
 				}
+				if p.Intermittent > 0 {
+					info += fmt.Sprintf(lang.T("\n不稳定: %d 次检测重试后才成功"), p.Intermittent)
+				}
+				if p.AmbiguousProtocol() {
+					info += lang.T("\n候选协议: ") + strings.Join(p.ProtocolCandidates, "/")
+				}
+				if p.Username != "" {
+					info += lang.T("\n认证用户: ") + p.Username
+				}
+				if p.LastFailure != "" {
+					info += lang.T("\n最近失败: ") + p.LastFailure
+				}
+				if history := app.GetProxyHistory(p.Address); len(history) > 0 {
+					info += "\n\n" + formatHistory(history)
+				}
+				currentProxyInfo.SetText(info)
 			}
 		} else {
 			currentProxyInfo.SetText("")
 		}
-	}))
+	})
 
+	statsCard := createStatsCard(app)
+	trafficCard := createTrafficCard(app)
 	proxyList := createProxyList(app)
 	logView := createLogView(app)
 
 	// 新的三栏布局：代理列表 | 代理详情 | 日志
 	leftPanel := container.NewBorder(nil, nil, nil, nil, proxyList)
 	centerPanel := container.NewBorder(
-		widget.NewLabelWithStyle("当前代理详情", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
-		nil, nil, nil,
+		widget.NewLabelWithStyle(lang.T("当前代理详情"), fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
+		container.NewVBox(statsCard, trafficCard), nil, nil,
 		container.NewScroll(currentProxyInfo),
 	)
-	rightPanel := container.NewBorder(nil, nil, nil, nil, logView)
+	rightPanel := container.NewAppTabs(
+		container.NewTabItem(lang.T("应用日志"), logView),
+		container.NewTabItem(lang.T("连接日志"), createConnectionLogView(app)),
+		container.NewTabItem(lang.T("代理源"), createSourceStatsView(app)),
+	)
 
 	// 第一层分割：左侧代理列表和中间区域
 	leftSplit := container.NewHSplit(leftPanel, centerPanel)
@@ -98,47 +246,243 @@ func SetupUI(app Apper) {
 	mainLayout := container.NewBorder(topPanel, nil, nil, nil, mainSplit)
 
 	win := app.GetWindow()
+	win.SetTitle(lang.T("代理池工具") + " v0.1")
 	win.SetContent(container.NewPadded(mainLayout))
 	win.Resize(fyne.NewSize(1280, 800))
+
+	// Ctrl+N(macOS 为 Cmd+N) 立即切换到下一个代理，重建界面时先移除上次注册的快捷键
+	win.Canvas().RemoveShortcut(nextProxyShortcut)
+	win.Canvas().AddShortcut(nextProxyShortcut, func(fyne.Shortcut) { app.NextProxy() })
+}
+
+// nextProxyShortcut 切换到下一个代理的快捷键
+var nextProxyShortcut = &desktop.CustomShortcut{KeyName: fyne.KeyN, Modifier: fyne.KeyModifierShortcutDefault}
+
+// formatHistory 格式化代理的检测历史，包含成功率、延迟/速度统计和延迟走势
+func formatHistory(history []proxy.Sample) string {
+	stats := proxy.SummarizeHistory(history)
+	text := fmt.Sprintf(lang.T("检测历史: %d 次，成功率 %.0f%%"), stats.Count, stats.SuccessRate()*100)
+	if stats.Successes > 0 {
+		text += fmt.Sprintf(lang.T("\n延迟(ms): 最小 %.0f / 平均 %.0f / 最大 %.0f"),
+			stats.MinLatency*1000, stats.AvgLatency*1000, stats.MaxLatency*1000)
+	}
+	if stats.AvgSpeed > 0 {
+		text += fmt.Sprintf(lang.T("\n速度(KB/s): 最小 %.2f / 平均 %.2f / 最大 %.2f"),
+			stats.MinSpeed, stats.AvgSpeed, stats.MaxSpeed)
+	}
+	return text + lang.T("\n延迟走势: ") + proxy.LatencySparkline(history)
+}
+
+// createStatsCard 创建代理池统计卡片
+// 代理列表变化时自动刷新，快速了解代理池整体状况
+func createStatsCard(app Apper) fyne.CanvasObject {
+	statsLabel := widget.NewLabel("")
+	uiListeners.add(app.GetListRevision(), func() {
+		statsLabel.SetText(formatPoolStats(app.GetPoolStats()))
+	})
+	return widget.NewCard(lang.T("代理池统计"), "", statsLabel)
+}
+
+// formatPoolStats 格式化代理池统计信息
+func formatPoolStats(stats proxy.PoolStats) string {
+	text := fmt.Sprintf(lang.T("原始代理: %d    有效代理: %d    近期检测: %d"), stats.RawCount, stats.ValidCount, stats.Fresh)
+	if stats.Sidelined > 0 {
+		text += fmt.Sprintf(lang.T("    搁置待复检: %d"), stats.Sidelined)
+	}
+	text += lang.T("\n平均延迟: ") + formatAverage(stats.AvgLatency*1000, "%.0fms")
+	text += lang.T("    平均速度: ") + formatAverage(stats.AvgSpeed, "%.2fKB/s")
+	text += lang.T("\n协议: ") + formatCounts(stats.ByProtocol)
+	text += lang.T("\n国家(前5): ") + formatCounts(stats.TopCountries)
+	return text
+}
+
+// trafficRefreshInterval 流量统计的刷新间隔
+const trafficRefreshInterval = 2 * time.Second
+
+// refreshStop 关闭时通知当前界面的定时刷新协程退出，每次创建界面时替换
+var refreshStop = make(chan struct{})
+
+// listenerSet 界面组件在应用长期持有的数据绑定上注册的监听器
+// 重建界面前需要全部移除，否则旧组件的监听器会一直保留并重复执行
+type listenerSet []boundListener
+
+// boundListener 一个已注册的监听器及其所在的数据绑定
+type boundListener struct {
+	data     binding.DataItem
+	listener binding.DataListener
+}
+
+// add 在数据绑定上注册监听器并记录，以便重建界面时移除
+func (s *listenerSet) add(data binding.DataItem, fn func()) {
+	listener := binding.NewDataListener(fn)
+	data.AddListener(listener)
+	*s = append(*s, boundListener{data: data, listener: listener})
+}
+
+// removeAll 移除记录的全部监听器
+func (s *listenerSet) removeAll() {
+	for _, l := range *s {
+		l.data.RemoveListener(l.listener)
+	}
+	*s = nil
+}
+
+// uiListeners 当前主界面注册的监听器，每次创建界面时先移除上一次的
+var uiListeners listenerSet
+
+// startRefresh 按 trafficRefreshInterval 定时执行刷新函数，界面重建后停止
+func startRefresh(refresh func()) {
+	stop := refreshStop
+	go func() {
+		ticker := time.NewTicker(trafficRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				refresh()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// createTrafficCard 创建流量统计卡片
+// 流量随本地服务转发持续变化，因此定时刷新而不是跟随代理列表刷新
+func createTrafficCard(app Apper) fyne.CanvasObject {
+	trafficLabel := widget.NewLabel(formatTrafficStats(app.GetTrafficStats()))
+	startRefresh(func() {
+		trafficLabel.SetText(formatTrafficStats(app.GetTrafficStats()))
+	})
+	return widget.NewCard(lang.T("流量统计"), "", trafficLabel)
+}
+
+// formatTrafficStats 格式化流量统计，列出流量最大和连接失败最多的代理
+func formatTrafficStats(stats proxy.TrafficStats) string {
+	text := fmt.Sprintf(lang.T("上传: %s    下载: %s    连接: %d    失败连接: %d"),
+		formatBytes(stats.BytesUp), formatBytes(stats.BytesDown), stats.Connections, stats.ConnFailures)
+	parts := make([]string, len(stats.Top))
+	for i, e := range stats.Top {
+		parts[i] = fmt.Sprintf("%s %s", e.Address, formatBytes(e.BytesUp+e.BytesDown))
+	}
+	text += lang.T("\n流量最大: ") + joinOrDash(parts)
+	parts = make([]string, len(stats.Failing))
+	for i, e := range stats.Failing {
+		parts[i] = fmt.Sprintf(lang.T("%s 失败%d/成功%d"), e.Address, e.ConnFailures, e.Connections)
+	}
+	text += lang.T("\n失败最多: ") + joinOrDash(parts)
+	return text
+}
+
+// joinOrDash 以逗号连接列表，空列表显示 -
+func joinOrDash(parts []string) string {
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatBytes 将字节数格式化为 B/KB/MB/GB
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	value := float64(n) / unit
+	for _, suffix := range []string{"KB", "MB", "GB"} {
+		if value < unit || suffix == "GB" {
+			return fmt.Sprintf("%.1f%s", value, suffix)
+		}
+		value /= unit
+	}
+	return ""
+}
+
+// formatTargetChecks 将各检测目标的结果格式化为 "名称 ✓, 名称 ✗"，按名称排序
+func formatTargetChecks(checks map[string]bool) string {
+	names := make([]string, 0, len(checks))
+	for name := range checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		mark := "✗"
+		if checks[name] {
+			mark = "✓"
+		}
+		parts[i] = name + " " + mark
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatAverage 格式化平均值，没有数据时显示 -
+func formatAverage(value float64, format string) string {
+	if value <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf(format, value)
+}
+
+// formatCounts 将分类计数格式化为 "名称 数量" 列表
+func formatCounts(entries []proxy.CountEntry) string {
+	if len(entries) == 0 {
+		return "-"
+	}
+	parts := make([]string, len(entries))
+	for i, e := range entries {
+		parts[i] = fmt.Sprintf("%s %d", e.Name, e.Count)
+	}
+	return strings.Join(parts, ", ")
 }
 
 // createToolbar 创建顶部工具栏，包含代理操作的主要功能按钮
 // 包括获取代理、测试代理、导入导出和清空列表等操作
 func createToolbar(app Apper) fyne.CanvasObject {
 	ipEntry := widget.NewEntry()
-	ipEntry.SetPlaceHolder("输入IP地址")
-
-	// 主题切换按钮
-	themeBtn := widget.NewButton("切换主题", func() {
-		currentTheme := fyne.CurrentApp().Settings().Theme()
-		if _, isCustom := currentTheme.(*customtheme.MyTheme); isCustom {
-			// 如果当前是自定义主题，切换内置主题
-			if currentTheme == fynetheme.DarkTheme() {
-				fyne.CurrentApp().Settings().SetTheme(fynetheme.LightTheme())
-			} else {
-				fyne.CurrentApp().Settings().SetTheme(fynetheme.DarkTheme())
-			}
-		} else {
-			// 如果当前是内置主题，切换自定义主题
-			fyne.CurrentApp().Settings().SetTheme(&customtheme.MyTheme{})
-		}
-		app.GetWindow().Content().Refresh()
+	ipEntry.SetPlaceHolder(lang.T("输入IP地址"))
+
+	// 主题选择，选择结果保存到偏好设置
+	themeModes := map[string]string{
+		lang.T("浅色"):   customtheme.ModeLight,
+		lang.T("深色"):   customtheme.ModeDark,
+		lang.T("跟随系统"): customtheme.ModeSystem,
+	}
+	themeSelect := widget.NewSelect([]string{lang.T("浅色"), lang.T("深色"), lang.T("跟随系统")}, func(label string) {
+		app.SetTheme(themeModes[label])
 	})
+	currentMode := customtheme.ModeSystem
+	if t, ok := fyne.CurrentApp().Settings().Theme().(*customtheme.MyTheme); ok && t.Mode != "" {
+		currentMode = t.Mode
+	}
+	for label, mode := range themeModes {
+		if mode == currentMode {
+			themeSelect.SetSelected(label)
+		}
+	}
 
 	buttons := container.NewHBox(
-		widget.NewButton("获取代理", app.FetchProxies),
-		widget.NewButton("测试代理", app.TestAllProxies),
-		widget.NewButton("导入代理", app.ImportProxies),
-		widget.NewButton("导出代理", app.ExportProxies),
-		themeBtn,
-		widget.NewButton("查询IP", func() {
+		widget.NewButton(lang.T("获取代理"), app.FetchProxies),
+		widget.NewButton(lang.T("代理源"), func() { showSourcesDialog(app) }),
+		widget.NewButton(lang.T("黑名单"), func() { showBlacklistDialog(app) }),
+		widget.NewButton(lang.T("代理池"), func() { showPoolsDialog(app) }),
+		widget.NewButton(lang.T("测试代理"), app.TestAllProxies),
+		widget.NewButton(lang.T("测试未测试"), app.TestNeverCheckedProxies),
+		widget.NewButton(lang.T("重测失败"), app.RetestFailedProxies),
+		widget.NewButton(lang.T("取消"), app.CancelTasks),
+		widget.NewButton(lang.T("导入代理"), app.ImportProxies),
+		widget.NewButton(lang.T("从剪贴板导入"), app.ImportFromClipboard),
+		widget.NewButton(lang.T("订阅导入"), func() { showSubscriptionDialog(app) }),
+		widget.NewButton(lang.T("导出代理"), app.ExportProxies),
+		themeSelect,
+		widget.NewButton(lang.T("查询IP"), func() {
 			ip := ipEntry.Text
 			if ip != "" {
 				go func() {
-					app.Log(fmt.Sprintf("正在查询IP: %s", ip))
+					app.Log(fmt.Sprintf(lang.T("正在查询IP: %s"), ip))
 					location, err := queryIPCountry(ip)
 					if err != nil {
-						app.Log(fmt.Sprintf("查询IP失败: %v", err))
+						app.LogError(fmt.Sprintf(lang.T("查询IP失败: %v"), err))
 						return
 					}
 					parts := strings.Split(location, "|")
@@ -146,19 +490,19 @@ func createToolbar(app Apper) fyne.CanvasObject {
 						country := parts[0]
 						province := parts[1]
 						city := parts[2]
-						app.Log(fmt.Sprintf("IP %s 位置: %s %s %s", ip, country, province, city))
+						app.Log(fmt.Sprintf(lang.T("IP %s 位置: %s %s %s"), ip, country, province, city))
 						// 更新当前代理的位置信息
 						currentProxy, _ := app.GetCurrentProxy().Get()
 						if currentProxy != "" {
 							// 这里需要app有方法更新代理的位置信息
-							app.Log(fmt.Sprintf("已更新代理 %s 的位置为 %s %s %s", currentProxy, country, province, city))
+							app.Log(fmt.Sprintf(lang.T("已更新代理 %s 的位置为 %s %s %s"), currentProxy, country, province, city))
 						}
 					}
 				}()
 			}
 		}),
-		widget.NewButton("清空列表", func() {
-			dialog.ShowConfirm("确认", "确定要清空所有代理列表吗?", func(ok bool) {
+		widget.NewButton(lang.T("清空列表"), func() {
+			dialog.ShowConfirm(lang.T("确认"), lang.T("确定要清空所有代理列表吗?"), func(ok bool) {
 				if ok {
 					app.ClearProxies()
 				}
@@ -172,65 +516,445 @@ func createToolbar(app Apper) fyne.CanvasObject {
 // createFilterControlPanel 创建代理筛选控制面板
 // 提供按延迟和速度筛选代理的功能，支持实时过滤代理列表
 func createFilterControlPanel(app Apper) fyne.CanvasObject {
+	filter := app.GetSettings().Filter
 	latencyEntry := widget.NewEntry()
-	latencyEntry.SetPlaceHolder("例如: 500 (ms)")
+	latencyEntry.SetPlaceHolder(lang.T("例如: 500 (ms)"))
+	if filter.MaxLatency > 0 {
+		latencyEntry.SetText(strconv.FormatFloat(filter.MaxLatency*1000, 'f', -1, 64))
+	}
 
 	speedEntry := widget.NewEntry()
-	speedEntry.SetPlaceHolder("例如: 1024 (KB/s)")
+	speedEntry.SetPlaceHolder(lang.T("例如: 1024 (KB/s)"))
+	if filter.MinSpeed >= 0 {
+		speedEntry.SetText(strconv.FormatFloat(filter.MinSpeed, 'f', -1, 64))
+	}
+
+	remoteDNSCheck := widget.NewCheck(lang.T("仅远程DNS解析 (无DNS泄漏)"), nil)
+	remoteDNSCheck.SetChecked(filter.RemoteDNSOnly)
+	httpsCheck := widget.NewCheck(lang.T("仅支持HTTPS的代理"), nil)
+	httpsCheck.SetChecked(filter.HTTPSOnly)
+
+	ipVersions := map[string]int{lang.T("不限"): 0, lang.T("仅IPv4"): 4, lang.T("仅IPv6"): 6}
+	ipSelect := widget.NewSelect([]string{lang.T("不限"), lang.T("仅IPv4"), lang.T("仅IPv6")}, nil)
+	ipSelect.SetSelected(lang.T("不限"))
+	for label, version := range ipVersions {
+		if version == filter.IPVersion {
+			ipSelect.SetSelected(label)
+		}
+	}
 
-	applyBtn := widget.NewButton("应用筛选", func() {
-		app.ApplyFilters(latencyEntry.Text, speedEntry.Text)
+	applyBtn := widget.NewButton(lang.T("应用筛选"), func() {
+		app.ApplyFilters(latencyEntry.Text, speedEntry.Text, remoteDNSCheck.Checked, httpsCheck.Checked, ipVersions[ipSelect.Selected])
 	})
 
 	grid := container.New(layout.NewFormLayout(),
-		widget.NewLabel("最大延迟 (ms):"), latencyEntry,
-		widget.NewLabel("最低速度 (KB/s):"), speedEntry,
+		widget.NewLabel(lang.T("最大延迟 (ms):")), latencyEntry,
+		widget.NewLabel(lang.T("最低速度 (KB/s):")), speedEntry,
+		widget.NewLabel("DNS:"), remoteDNSCheck,
+		widget.NewLabel("HTTPS:"), httpsCheck,
+		widget.NewLabel(lang.T("IP版本:")), ipSelect,
 	)
 
 	accordion := widget.NewAccordion(
-		widget.NewAccordionItem("筛选器", container.NewBorder(nil, nil, nil, applyBtn, grid)),
+		widget.NewAccordionItem(lang.T("筛选器"), container.NewBorder(nil, nil, nil, applyBtn, grid)),
+		widget.NewAccordionItem(lang.T("检测设置"), createCheckSettingsPanel(app)),
+		widget.NewAccordionItem(lang.T("自动刷新"), createAutoRefreshPanel(app)),
+		widget.NewAccordionItem(lang.T("数据存储"), createStoragePanel(app)),
+		widget.NewAccordionItem(lang.T("界面"), createInterfacePanel(app)),
 	)
 	return accordion
 }
 
+// createStoragePanel 创建数据存储设置面板
+// 开启自动保存后代理池变化会写入磁盘，下次启动时自动恢复
+func createStoragePanel(app Apper) fyne.CanvasObject {
+	persistCheck := widget.NewCheck(lang.T("自动保存代理池并在启动时恢复"), app.SetAutoPersist)
+	persistCheck.SetChecked(app.GetAutoPersist())
+	return container.New(layout.NewFormLayout(),
+		widget.NewLabel(lang.T("自动保存:")), persistCheck,
+	)
+}
+
+// createInterfacePanel 创建界面设置面板
+// 语言以该语言本身显示，切换后立即按新语言重建窗口和托盘菜单
+func createInterfacePanel(app Apper) fyne.CanvasObject {
+	labels := make([]string, len(lang.Languages))
+	for i, l := range lang.Languages {
+		labels[i] = l.Label()
+	}
+	languageSelect := widget.NewSelect(labels, nil)
+	languageSelect.SetSelected(lang.Current().Label())
+	languageSelect.OnChanged = func(string) {
+		l := lang.Languages[languageSelect.SelectedIndex()]
+		if l == lang.Current() {
+			return
+		}
+		app.SetLanguage(l)
+		rebuildUI(app)
+	}
+	return container.New(layout.NewFormLayout(),
+		widget.NewLabel(lang.T("语言:")), languageSelect,
+	)
+}
+
+// rebuildUI 按当前语言重建主界面和托盘菜单，保留窗口大小
+func rebuildUI(app Apper) {
+	win := app.GetWindow()
+	size := win.Canvas().Size()
+	SetupUI(app)
+	SetupTray(app)
+	win.Resize(size)
+}
+
+// createAutoRefreshPanel 创建自动刷新设置面板
+// 启用后按间隔自动获取新代理、只测试新增代理并清理失效代理，
+// 也可单独开启对有效代理的定期复检
+func createAutoRefreshPanel(app Apper) fyne.CanvasObject {
+	settings := app.GetSettings()
+	status := app.GetAutoRefreshStatus()
+	toggle := widget.NewCheck(lang.T("启用定时获取代理"), app.ToggleAutoRefresh)
+	autoTestCheck := widget.NewCheck(lang.T("获取后自动测试新增代理"), nil)
+	autoTestCheck.SetChecked(settings.AutoRefresh.Test)
+	autoTestCheck.OnChanged = app.SetAutoRefreshTest
+	uiListeners.add(status, func() {
+		enabled, _ := status.Get()
+		toggle.SetChecked(enabled)
+	})
+
+	intervalEntry := widget.NewEntry()
+	intervalEntry.SetPlaceHolder(lang.T("例如: 30 (分钟)"))
+	intervalEntry.SetText(strconv.Itoa(settings.AutoRefresh.IntervalMinutes))
+	intervalBtn := widget.NewButton(lang.T("设置间隔"), func() {
+		minutes, err := strconv.Atoi(intervalEntry.Text)
+		if err == nil && minutes > 0 {
+			app.SetAutoRefreshInterval(minutes)
+		}
+	})
+
+	revalidateCheck := widget.NewCheck(lang.T("定期复检有效代理"), app.ToggleRevalidation)
+	revalidateIntervalEntry := widget.NewEntry()
+	revalidateIntervalEntry.SetText(strconv.Itoa(settings.Revalidate.IntervalMinutes))
+	revalidateWorkersEntry := widget.NewEntry()
+	revalidateWorkersEntry.SetText(strconv.Itoa(settings.Revalidate.Workers))
+	revalidateBtn := widget.NewButton(lang.T("应用复检设置"), func() {
+		minutes, err1 := strconv.Atoi(revalidateIntervalEntry.Text)
+		workers, err2 := strconv.Atoi(revalidateWorkersEntry.Text)
+		if err1 == nil && err2 == nil {
+			app.SetRevalidation(minutes, workers)
+		}
+	})
+
+	grid := container.New(layout.NewFormLayout(),
+		widget.NewLabel(lang.T("自动刷新:")), toggle,
+		layout.NewSpacer(), autoTestCheck,
+		widget.NewLabel(lang.T("间隔(分钟):")), container.NewBorder(nil, nil, nil, intervalBtn, intervalEntry),
+		widget.NewLabel(lang.T("定期复检:")), revalidateCheck,
+		widget.NewLabel(lang.T("复检间隔(分钟):")), revalidateIntervalEntry,
+		widget.NewLabel(lang.T("复检并发:")), container.NewBorder(nil, nil, nil, revalidateBtn, revalidateWorkersEntry),
+	)
+	return grid
+}
+
+// createCheckSettingsPanel 创建代理检测设置面板
+// 可调整测试并发数和超时，网络较快时缩短超时可显著加快批量测试
+func createCheckSettingsPanel(app Apper) fyne.CanvasObject {
+	settings := app.GetSettings()
+	retriesEntry := widget.NewEntry()
+	retriesEntry.SetPlaceHolder(lang.T("重试次数，0 不重试"))
+	retriesEntry.SetText(strconv.Itoa(settings.Check.Retries))
+	backoffEntry := widget.NewEntry()
+	backoffEntry.SetPlaceHolder(lang.T("首次间隔(秒)，之后翻倍"))
+	backoffEntry.SetText(strconv.FormatFloat(settings.Check.RetryBackoff, 'f', -1, 64))
+	retriesBtn := widget.NewButton(lang.T("设置"), func() {
+		retries, err1 := strconv.Atoi(strings.TrimSpace(retriesEntry.Text))
+		backoff, err2 := strconv.ParseFloat(strings.TrimSpace(backoffEntry.Text), 64)
+		if err1 == nil && err2 == nil && retries >= 0 && backoff >= 0 {
+			app.SetCheckRetries(retries, backoff)
+		}
+	})
+
+	samplesEntry := widget.NewEntry()
+	samplesEntry.SetPlaceHolder(lang.T("1 表示只采样一次"))
+	samplesEntry.SetText(strconv.Itoa(settings.Check.LatencySamples))
+	samplesBtn := widget.NewButton(lang.T("设置"), func() {
+		n, err := strconv.Atoi(strings.TrimSpace(samplesEntry.Text))
+		if err == nil && n > 0 {
+			app.SetLatencySamples(n)
+		}
+	})
+
+	precheckCheck := widget.NewCheck(lang.T("导入时快速预检 (丢弃端口不可达的代理)"), nil)
+	precheckCheck.SetChecked(settings.Import.Precheck)
+	precheckCheck.OnChanged = app.SetImportPrecheck
+	cancelPrecheckBtn := widget.NewButton(lang.T("取消预检"), app.CancelImportPrecheck)
+	importPremiumCheck := widget.NewCheck(lang.T("导入的代理标记为高级"), nil)
+	importPremiumCheck.SetChecked(settings.Import.Premium)
+	importPremiumCheck.OnChanged = app.SetImportPremium
+	importDetectCheck := widget.NewCheck(lang.T("自动识别未写明协议的代理 (socks5/socks4/http/https)"), nil)
+	importDetectCheck.SetChecked(settings.Import.Detect)
+	importDetectCheck.OnChanged = app.SetImportDetect
+
+	grid := container.New(layout.NewFormLayout(),
+		widget.NewLabel(lang.T("并发与超时:")), widget.NewButton(lang.T("测试设置..."), func() { showTestSettingsDialog(app) }),
+		widget.NewLabel(lang.T("失败重试:")), container.NewBorder(nil, nil, nil, retriesBtn, container.NewGridWithColumns(2, retriesEntry, backoffEntry)),
+		widget.NewLabel(lang.T("延迟采样次数:")), container.NewBorder(nil, nil, nil, samplesBtn, samplesEntry),
+		widget.NewLabel(lang.T("检测服务:")), widget.NewButton(lang.T("设置检测地址..."), func() { showCheckConfigDialog(app) }),
+		widget.NewLabel(lang.T("导入预检:")), container.NewBorder(nil, nil, nil, cancelPrecheckBtn, precheckCheck),
+		widget.NewLabel(lang.T("导入标记:")), importPremiumCheck,
+		widget.NewLabel(lang.T("协议识别:")), importDetectCheck,
+		widget.NewLabel(lang.T("离线GeoIP:")), container.NewHBox(
+			widget.NewButton(lang.T("选择 .mmdb 数据库"), app.ChooseGeoIPDatabase),
+			widget.NewButton(lang.T("使用在线接口"), func() { app.SetGeoIPDatabase("") }),
+		),
+		widget.NewLabel(lang.T("离线ASN:")), container.NewHBox(
+			widget.NewButton(lang.T("选择 .mmdb 数据库"), app.ChooseASNDatabase),
+			widget.NewButton(lang.T("不查询ASN"), func() { app.SetASNDatabase("") }),
+		),
+	)
+	return grid
+}
+
 // createServerControlPanel 创建本地代理服务控制面板
-// 允许配置端口并启动/停止SOCKS5代理服务，显示当前服务状态
+// 允许配置监听地址、协议和端口并启动/停止代理服务，显示当前服务状态
 func createServerControlPanel(app Apper) *widget.Card {
+	settings := app.GetSettings().Server
+	hostEntry := widget.NewSelectEntry(server.LocalBindAddresses())
+	hostEntry.SetPlaceHolder(lang.T("例如: 127.0.0.1、0.0.0.0 或网卡IP"))
+	hostEntry.SetText(app.GetServerHost())
+	hostWarning := widget.NewLabel("")
+	hostWarning.Wrapping = fyne.TextWrapWord
+	hostEntry.OnChanged = func(host string) {
+		host = strings.TrimSpace(host)
+		switch {
+		case host == "" || server.IsLoopbackHost(host):
+			hostWarning.SetText("")
+		case server.ValidateBindHost(host) != nil:
+			hostWarning.SetText(lang.T("⚠ 无效的监听地址"))
+		default:
+			hostWarning.SetText(lang.T("⚠ 非本机地址，局域网内其他设备可以连接此服务"))
+		}
+	}
+
 	portEntry := widget.NewEntry()
-	portEntry.SetPlaceHolder("例如: 10808")
-	portEntry.SetText("10808")
+	portEntry.SetPlaceHolder(lang.T("例如: 10808"))
+	portEntry.SetText(app.GetServerPort())
 
 	serverStatusBinding := app.GetServerStatus()
-	statusLabel := widget.NewLabel("服务未运行")
-	serverStatusBinding.AddListener(binding.NewDataListener(func() {
+	statusLabel := widget.NewLabel(lang.T("服务未运行"))
+	uiListeners.add(serverStatusBinding, func() {
 		running, _ := serverStatusBinding.Get()
 		if running {
-			statusLabel.SetText(fmt.Sprintf("服务运行于 127.0.0.1:%s", portEntry.Text))
+			portEntry.SetText(app.GetServerPort()) // 可能已改用其他可用端口
+			statusLabel.SetText(fmt.Sprintf(lang.T("服务运行于 %s://%s"), app.GetServerMode(), net.JoinHostPort(app.GetServerHost(), app.GetServerPort())))
 		} else {
-			statusLabel.SetText("服务未运行")
+			statusLabel.SetText(lang.T("服务未运行"))
 		}
-	}))
+	})
 
-	toggleServerBtn := widget.NewButton("启动服务", func() {
-		app.ToggleServer(portEntry.Text)
+	toggleServerBtn := widget.NewButton(lang.T("启动服务"), func() {
+		app.ToggleServer(hostEntry.Text, portEntry.Text)
 	})
-	serverStatusBinding.AddListener(binding.NewDataListener(func() {
+	uiListeners.add(serverStatusBinding, func() {
 		running, _ := serverStatusBinding.Get()
 		if running {
-			toggleServerBtn.SetText("停止服务")
+			toggleServerBtn.SetText(lang.T("停止服务"))
+			hostEntry.Disable()
 			portEntry.Disable()
 		} else {
-			toggleServerBtn.SetText("启动服务")
+			toggleServerBtn.SetText(lang.T("启动服务"))
+			hostEntry.Enable()
 			portEntry.Enable()
 		}
-	}))
+	})
+
+	modes := map[string]string{"SOCKS5": "socks5", "HTTP": "http"}
+	modeSelect := widget.NewSelect([]string{"SOCKS5", "HTTP"}, func(label string) {
+		app.SetServerMode(modes[label])
+	})
+	for label, mode := range modes {
+		if mode == app.GetServerMode() {
+			modeSelect.SetSelected(label)
+		}
+	}
+
+	testServerBtn := widget.NewButton(lang.T("测试本地服务"), app.TestLocalServer)
+	verifyExitBtn := widget.NewButton(lang.T("验证出口IP"), func() { showVerifyExitDialog(app) })
+	listenersBtn := widget.NewButton(lang.T("附加监听器"), func() { showListenersDialog(app) })
+	systemProxyCheck := widget.NewCheck(lang.T("启用系统代理"), app.ToggleSystemProxy)
+	systemProxyStatus := app.GetSystemProxyStatus()
+	uiListeners.add(systemProxyStatus, func() {
+		enabled, _ := systemProxyStatus.Get()
+		systemProxyCheck.SetChecked(enabled)
+	})
+	chainCheck := widget.NewCheck(lang.T("双代理链式转发 (延迟约翻倍)"), nil)
+	chainCheck.SetChecked(settings.ChainMode)
+	chainCheck.OnChanged = app.SetChainMode
+	followCheck := widget.NewCheck(lang.T("固定当前代理 (跟随轮换)"), nil)
+	followCheck.SetChecked(settings.FollowRotation)
+	followCheck.OnChanged = app.SetFollowRotation
+	premiumOnlyCheck := widget.NewCheck(lang.T("只使用高级代理"), nil)
+	premiumOnlyCheck.SetChecked(settings.PremiumOnly)
+	premiumOnlyCheck.OnChanged = app.SetPremiumOnly
+	targetEntry := widget.NewEntry()
+	targetEntry.SetPlaceHolder(lang.T("检测目标名称，留空不限"))
+	targetEntry.SetText(settings.RequiredTarget)
+	targetBtn := widget.NewButton(lang.T("设置"), func() {
+		app.SetRequiredTarget(targetEntry.Text)
+	})
+	poolEntry := widget.NewEntry()
+	poolEntry.SetPlaceHolder(lang.T("代理池名称，留空使用全部"))
+	poolEntry.SetText(settings.Pool)
+	poolBtn := widget.NewButton(lang.T("设置"), func() {
+		app.SetServerPool(poolEntry.Text)
+	})
+	stickyEntry := widget.NewEntry()
+	stickyEntry.SetPlaceHolder(lang.T("0 表示每个连接都轮换"))
+	stickyEntry.SetText(strconv.Itoa(settings.StickyMinutes))
+	stickyBtn := widget.NewButton(lang.T("设置"), func() {
+		minutes, err := strconv.Atoi(strings.TrimSpace(stickyEntry.Text))
+		if err == nil && minutes >= 0 {
+			app.SetStickySessions(minutes)
+		}
+	})
+	affinityEntry := widget.NewEntry()
+	affinityEntry.SetPlaceHolder(lang.T("0 表示关闭"))
+	affinityEntry.SetText(strconv.Itoa(settings.AffinityMinutes))
+	affinityBtn := widget.NewButton(lang.T("设置"), func() {
+		minutes, err := strconv.Atoi(strings.TrimSpace(affinityEntry.Text))
+		if err == nil && minutes >= 0 {
+			app.SetHostAffinity(minutes)
+		}
+	})
+
+	rateConnEntry := widget.NewEntry()
+	rateConnEntry.SetPlaceHolder(lang.T("单连接，0 不限"))
+	rateConnEntry.SetText(strconv.Itoa(settings.RateLimitConnKB))
+	rateGlobalEntry := widget.NewEntry()
+	rateGlobalEntry.SetPlaceHolder(lang.T("全局，0 不限"))
+	rateGlobalEntry.SetText(strconv.Itoa(settings.RateLimitGlobalKB))
+	rateBtn := widget.NewButton(lang.T("设置"), func() {
+		connKB, err1 := strconv.Atoi(strings.TrimSpace(rateConnEntry.Text))
+		globalKB, err2 := strconv.Atoi(strings.TrimSpace(rateGlobalEntry.Text))
+		if err1 == nil && err2 == nil && connKB >= 0 && globalKB >= 0 {
+			app.SetRateLimits(connKB, globalKB)
+		}
+	})
+
+	maxConnsEntry := widget.NewEntry()
+	maxConnsEntry.SetPlaceHolder(lang.T("上限，0 不限"))
+	maxConnsEntry.SetText(strconv.Itoa(settings.MaxConns))
+	queueEntry := widget.NewEntry()
+	queueEntry.SetPlaceHolder(lang.T("排队数，0 不排队"))
+	queueEntry.SetText(strconv.Itoa(settings.ConnQueue))
+	connLimitBtn := widget.NewButton(lang.T("设置"), func() {
+		max, err1 := strconv.Atoi(strings.TrimSpace(maxConnsEntry.Text))
+		queue, err2 := strconv.Atoi(strings.TrimSpace(queueEntry.Text))
+		if err1 == nil && err2 == nil && max >= 0 && queue >= 0 {
+			app.SetConnectionLimit(max, queue)
+		}
+	})
+
+	proxyMaxConnsEntry := widget.NewEntry()
+	proxyMaxConnsEntry.SetPlaceHolder(lang.T("并发上限，0 不限"))
+	proxyMaxConnsEntry.SetText(strconv.Itoa(settings.ProxyMaxConns))
+	proxyCooldownEntry := widget.NewEntry()
+	proxyCooldownEntry.SetPlaceHolder(lang.T("冷却秒数，0 不限"))
+	proxyCooldownEntry.SetText(strconv.Itoa(settings.ProxyCooldownSeconds))
+	proxyLimitBtn := widget.NewButton(lang.T("设置"), func() {
+		maxConns, err1 := strconv.Atoi(strings.TrimSpace(proxyMaxConnsEntry.Text))
+		cooldown, err2 := strconv.Atoi(strings.TrimSpace(proxyCooldownEntry.Text))
+		if err1 == nil && err2 == nil && maxConns >= 0 && cooldown >= 0 {
+			app.SetProxyUsageLimits(maxConns, cooldown)
+		}
+	})
 
 	grid := container.New(layout.NewFormLayout(),
-		widget.NewLabel("本地SOCKS5端口:"), portEntry,
-		widget.NewLabel("当前状态:"), statusLabel,
-		layout.NewSpacer(), toggleServerBtn,
+		widget.NewLabel(lang.T("监听地址:")), container.NewVBox(hostEntry, hostWarning),
+		widget.NewLabel(lang.T("监听协议:")), modeSelect,
+		widget.NewLabel(lang.T("本地端口:")), portEntry,
+		widget.NewLabel(lang.T("当前状态:")), statusLabel,
+		widget.NewLabel(lang.T("当前连接:")), createConnectionGauge(app),
+		widget.NewLabel(lang.T("转发模式:")), container.NewHBox(chainCheck, followCheck),
+		widget.NewLabel(lang.T("上游范围:")), premiumOnlyCheck,
+		widget.NewLabel(lang.T("目标可用:")), container.NewBorder(nil, nil, nil, targetBtn, targetEntry),
+		widget.NewLabel(lang.T("代理池:")), container.NewBorder(nil, nil, nil, poolBtn, poolEntry),
+		widget.NewLabel(lang.T("会话保持(分钟):")), container.NewBorder(nil, nil, nil, stickyBtn, stickyEntry),
+		widget.NewLabel(lang.T("主机亲和(分钟):")), container.NewBorder(nil, nil, nil, affinityBtn, affinityEntry),
+		widget.NewLabel(lang.T("系统代理:")), systemProxyCheck,
+		widget.NewLabel(lang.T("并发连接:")), container.NewBorder(nil, nil, nil, connLimitBtn, container.NewGridWithColumns(2, maxConnsEntry, queueEntry)),
+		widget.NewLabel(lang.T("单代理限制:")), container.NewBorder(nil, nil, nil, proxyLimitBtn, container.NewGridWithColumns(2, proxyMaxConnsEntry, proxyCooldownEntry)),
+		widget.NewLabel(lang.T("限速(KB/s):")), container.NewBorder(nil, nil, nil, rateBtn, container.NewGridWithColumns(2, rateConnEntry, rateGlobalEntry)),
+		layout.NewSpacer(), container.NewHBox(toggleServerBtn, testServerBtn, verifyExitBtn, listenersBtn),
 	)
-	return widget.NewCard("服务控制", "启动本地代理服务以使用轮换IP", grid)
+	return widget.NewCard(lang.T("服务控制"), lang.T("启动本地代理服务以使用轮换IP"), container.NewVBox(grid, createAccessRulesPanel(app)))
+}
+
+// createConnectionGauge 创建并发连接量表，显示正在处理和排队的连接数
+// 设置了并发上限时进度条表示已占用的比例
+func createConnectionGauge(app Apper) fyne.CanvasObject {
+	gauge := widget.NewProgressBar()
+	var active, queued, max int
+	gauge.TextFormatter = func() string {
+		if max == 0 {
+			return fmt.Sprintf(lang.T("活动 %d (不限)"), active)
+		}
+		return fmt.Sprintf(lang.T("活动 %d / %d，排队 %d"), active, max, queued)
+	}
+	update := func() {
+		active, queued, max = app.GetConnectionGauge()
+		gauge.Max = float64(max)
+		value := float64(active)
+		if max == 0 {
+			gauge.Max, value = 1, 0
+		}
+		gauge.SetValue(value)
+	}
+	update()
+	startRefresh(update)
+	return gauge
+}
+
+// createAccessRulesPanel 创建本地服务访问规则面板
+// 可配置目标黑名单、目标白名单、允许和拒绝连接的客户端IP以及客户端认证
+func createAccessRulesPanel(app Apper) fyne.CanvasObject {
+	settings := app.GetSettings().Server
+	blockEntry := widget.NewEntry()
+	blockEntry.SetPlaceHolder(lang.T("例如: 10.0.0.0/8, example.com"))
+	blockEntry.SetText(settings.TargetBlock)
+	allowEntry := widget.NewEntry()
+	allowEntry.SetPlaceHolder(lang.T("留空表示允许所有目标"))
+	allowEntry.SetText(settings.TargetAllow)
+	clientEntry := widget.NewEntry()
+	clientEntry.SetPlaceHolder(lang.T("例如: 127.0.0.1, 192.168.1.0/24"))
+	clientEntry.SetText(settings.ClientAllow)
+	clientDenyEntry := widget.NewEntry()
+	clientDenyEntry.SetPlaceHolder(lang.T("例如: 192.168.1.100，优先于允许列表"))
+	clientDenyEntry.SetText(settings.ClientDeny)
+
+	applyBtn := widget.NewButton(lang.T("应用规则"), func() {
+		app.SetAccessRules(blockEntry.Text, allowEntry.Text, clientEntry.Text, clientDenyEntry.Text)
+	})
+
+	userEntry := widget.NewEntry()
+	userEntry.SetPlaceHolder(lang.T("留空表示不需要认证"))
+	userEntry.SetText(settings.AuthUser)
+	passEntry := widget.NewPasswordEntry()
+	passEntry.SetText(settings.AuthPass)
+	authBtn := widget.NewButton(lang.T("应用认证"), func() {
+		app.SetServerAuth(userEntry.Text, passEntry.Text)
+	})
+
+	grid := container.New(layout.NewFormLayout(),
+		widget.NewLabel(lang.T("目标黑名单:")), blockEntry,
+		widget.NewLabel(lang.T("目标白名单:")), allowEntry,
+		widget.NewLabel(lang.T("允许的客户端:")), clientEntry,
+		widget.NewLabel(lang.T("拒绝的客户端:")), clientDenyEntry,
+		layout.NewSpacer(), applyBtn,
+		widget.NewLabel(lang.T("认证用户名:")), userEntry,
+		widget.NewLabel(lang.T("认证密码:")), passEntry,
+		layout.NewSpacer(), authBtn,
+	)
+	return widget.NewAccordion(widget.NewAccordionItem(lang.T("访问控制"), grid))
 }
 
 // queryIPCountry 本地查询IP地理位置信息
@@ -268,97 +992,109 @@ func queryIPCountry(ip string) (string, error) {
 	return "未知|未知|未知", nil
 }
 
+// proxyColumn 代理列表的一列
+// key: 点击列头时的排序字段
+// descFirst: 首次按该列排序时是否降序，数值越大越好的列(速度、评分等)默认降序
+type proxyColumn struct {
+	title     string
+	key       proxy.SortKey
+	width     float32
+	descFirst bool
+}
+
+// proxyColumns 代理列表的全部列，出口IP列始终位于最后，可以隐藏
+var proxyColumns = []proxyColumn{
+	{"协议", proxy.SortByProtocol, 70, false},
+	{"代理地址", proxy.SortByAddress, 260, false}, // IPv6地址较长
+	{"延迟(ms)", proxy.SortByLatency, 100, false},
+	{"速度(KB/s)", proxy.SortBySpeed, 110, true},
+	{"稳定性", proxy.SortByStability, 110, true},
+	{"评分", proxy.SortByScore, 70, true},
+	{"匿名度", proxy.SortByAnonymity, 100, false},
+	{"HTTPS", proxy.SortByHTTPS, 70, true},
+	{"DNS", proxy.SortByDNS, 60, true},
+	{"IP", proxy.SortByIPVersion, 50, false},
+	{"流量", proxy.SortByTraffic, 130, true},
+	{"地区", proxy.SortByCountry, 80, false},
+	{"检测时间", proxy.SortByLastChecked, 100, true},
+	{"出口IP", proxy.SortByExitIP, 130, false},
+}
+
 // createProxyList 创建代理列表表格视图
-// 以表格形式展示所有可用代理，包含协议、地址、延迟、速度等关键信息
+// 以表格形式展示所有可用代理，点击任意列头按该列排序，再次点击切换升序/降序，
+// 排序状态保存在应用中，列表刷新后保持不变
 func createProxyList(app Apper) fyne.CanvasObject {
 	data := app.GetProxyList()
-	var (
-		sortBySpeedDesc   bool = true
-		sortByLatencyDesc bool = true
-	)
+	var showExitIP bool
+	// 点击行切换选中状态，选中的代理(按地址，跨页保留)可以单独测试
+	selected := make(map[string]*proxy.Proxy)
+	testSelectedBtn := widget.NewButton(lang.T("测试选中"), nil)
+	clearSelectedBtn := widget.NewButton(lang.T("清除选中"), nil)
+	updateSelection := func() {
+		testSelectedBtn.SetText(fmt.Sprintf(lang.T("测试选中 (%d)"), len(selected)))
+	}
 
-	// 排序代理列表
-	sortProxies := func(sortBy string) {
-		items, _ := data.Get()
-		proxies := make([]*proxy.Proxy, len(items))
-		for i, item := range items {
-			proxies[i] = item.(*proxy.Proxy)
-		}
-
-		// 排序代理
-		switch sortBy {
-		case "speed":
-			if sortBySpeedDesc {
-				// 降序排序
-				for i := 0; i < len(proxies)-1; i++ {
-					for j := i + 1; j < len(proxies); j++ {
-						if proxies[i].Speed < proxies[j].Speed {
-							proxies[i], proxies[j] = proxies[j], proxies[i]
-						}
-					}
-				}
-			} else {
-				// 升序排序
-				for i := 0; i < len(proxies)-1; i++ {
-					for j := i + 1; j < len(proxies); j++ {
-						if proxies[i].Speed > proxies[j].Speed {
-							proxies[i], proxies[j] = proxies[j], proxies[i]
-						}
-					}
-				}
-			}
-		case "latency":
-			if sortByLatencyDesc {
-				// 降序排序
-				for i := 0; i < len(proxies)-1; i++ {
-					for j := i + 1; j < len(proxies); j++ {
-						if proxies[i].Latency < proxies[j].Latency {
-							proxies[i], proxies[j] = proxies[j], proxies[i]
-						}
-					}
-				}
-			} else {
-				// 升序排序
-				for i := 0; i < len(proxies)-1; i++ {
-					for j := i + 1; j < len(proxies); j++ {
-						if proxies[i].Latency > proxies[j].Latency {
-							proxies[i], proxies[j] = proxies[j], proxies[i]
-						}
-					}
-				}
-			}
+	// 右键菜单，针对点击所在行的代理
+	var table *widget.Table
+	showRowMenu := func(row int, e *fyne.PointEvent) {
+		if row == 0 {
+			return
 		}
-
-		newItems := make([]interface{}, len(proxies))
-		for i, p := range proxies {
-			newItems[i] = p
+		item, err := data.GetValue(row - 1)
+		if err != nil {
+			return
+		}
+		p := item.(*proxy.Proxy)
+		pinLabel := lang.T("固定")
+		if p.Pinned {
+			pinLabel = lang.T("取消固定")
+		}
+		premiumLabel := lang.T("标记为高级")
+		if p.IsPremium {
+			premiumLabel = lang.T("取消高级")
 		}
-		data.Set(newItems)
+		menu := fyne.NewMenu("",
+			fyne.NewMenuItem(pinLabel, func() { app.TogglePin(p) }),
+			fyne.NewMenuItem(premiumLabel, func() { app.TogglePremium(p) }),
+			fyne.NewMenuItem(lang.T("编辑标签..."), func() { showTagsDialog(app, p) }),
+			fyne.NewMenuItemSeparator(),
+			fyne.NewMenuItem(lang.T("复制地址"), func() {
+				app.GetWindow().Clipboard().SetContent(p.Address)
+				app.Log(fmt.Sprintf(lang.T("已复制代理地址 %s"), p.Address))
+			}),
+			copyAsItem(app, p),
+			fyne.NewMenuItem(lang.T("立即重测"), func() { app.RecheckProxy(p) }),
+			fyne.NewMenuItemSeparator(),
+			fyne.NewMenuItem(lang.T("删除"), func() { app.DeleteProxy(p) }),
+			fyne.NewMenuItem(lang.T("加入黑名单"), func() { app.BlacklistProxy(p) }),
+		)
+		widget.ShowPopUpMenuAtPosition(menu, app.GetWindow().Canvas(), e.AbsolutePosition)
 	}
 
-	table := widget.NewTable(
-		func() (int, int) { return data.Length() + 1, 6 },
-		func() fyne.CanvasObject { return widget.NewLabel("Template") },
+	table = widget.NewTable(
+		func() (int, int) {
+			if showExitIP {
+				return data.Length() + 1, len(proxyColumns)
+			}
+			return data.Length() + 1, len(proxyColumns) - 1
+		},
+		func() fyne.CanvasObject { return newProxyCell(showRowMenu) },
 		func(id widget.TableCellID, cell fyne.CanvasObject) {
-			label := cell.(*widget.Label)
+			label := cell.(*proxyCell)
+			label.row = id.Row
 			if id.Row == 0 {
-				headers := []string{"协议", "代理地址", "延迟(ms)", "速度(KB/s)", "匿名度", "地区"}
-				switch id.Col {
-				case 2: // 延迟列
-					if sortByLatencyDesc {
-						headers[2] = "延迟(ms) ▼"
+				column := proxyColumns[id.Col]
+				title := lang.T(column.title)
+				if order := app.GetSortOrder(); order.Key == column.key {
+					if order.Desc {
+						title += " ▼"
 					} else {
-						headers[2] = "延迟(ms) ▲"
-					}
-				case 3: // 速度列
-					if sortBySpeedDesc {
-						headers[3] = "速度(KB/s) ▼"
-					} else {
-						headers[3] = "速度(KB/s) ▲"
+						title += " ▲"
 					}
 				}
-				label.SetText(headers[id.Col])
 				label.TextStyle.Bold = true
+				label.Importance = widget.MediumImportance
+				label.SetText(title)
 				return
 			}
 			item, err := data.GetValue(id.Row - 1)
@@ -367,55 +1103,246 @@ func createProxyList(app Apper) fyne.CanvasObject {
 			}
 			p := item.(*proxy.Proxy)
 			var text string
-			switch id.Col {
-			case 0:
+			switch proxyColumns[id.Col].key {
+			case proxy.SortByProtocol:
 				text = p.Protocol
-			case 1:
+			case proxy.SortByAddress:
 				text = p.Address
-			case 2:
+				if p.Pinned {
+					text = "★ " + text
+				}
+				if selected[p.Address] != nil {
+					text = "✔ " + text
+				}
+			case proxy.SortByLatency:
 				if p.Latency > 0 {
 					text = fmt.Sprintf("%6.0f", p.Latency*1000) // 右对齐数字
 				} else {
 					text = fmt.Sprintf("%6s", "-") // 保持相同宽度
 				}
-			case 3:
+			case proxy.SortBySpeed:
 				if p.Speed > 0 {
 					text = fmt.Sprintf("%6.2f", p.Speed) // 右对齐数字
 				} else {
 					text = fmt.Sprintf("%6s", "-") // 保持相同宽度
 				}
-			case 4:
+			case proxy.SortByStability:
+				if p.Stability > 0 {
+					text = fmt.Sprintf("%3.0f ±%.0fms", p.Stability, p.Jitter*1000)
+				} else {
+					text = "-"
+				}
+			case proxy.SortByAnonymity:
 				text = p.Anonymity
-			case 5:
+			case proxy.SortByHTTPS:
+				if p.SupportsHTTPS {
+					text = "✓"
+				} else {
+					text = "-"
+				}
+			case proxy.SortByDNS:
+				switch {
+				case !p.DNSChecked:
+					text = "-"
+				case p.RemoteDNS:
+					text = lang.T("远程")
+				default:
+					text = lang.T("泄漏")
+				}
+			case proxy.SortByIPVersion:
+				if p.IsIPv6() {
+					text = "IPv6"
+				} else {
+					text = "IPv4"
+				}
+			case proxy.SortByTraffic:
+				if p.Connections > 0 || p.ConnFailures > 0 {
+					text = fmt.Sprintf("↑%s ↓%s", formatBytes(p.BytesUp), formatBytes(p.BytesDown))
+				} else {
+					text = "-"
+				}
+			case proxy.SortByCountry:
 				text = p.Location
+			case proxy.SortByScore:
+				text = fmt.Sprintf("%3.0f", p.Score)
+			case proxy.SortByLastChecked:
+				if p.LastChecked.IsZero() {
+					text = "-"
+				} else {
+					text = p.LastChecked.Format("01-02 15:04")
+				}
+			case proxy.SortByExitIP:
+				text = p.ExitIP
+			}
+			label.TextStyle.Bold = p.Pinned // 固定的代理以粗体显示
+			label.Importance = widget.MediumImportance
+			if selected[p.Address] != nil {
+				label.Importance = widget.HighImportance // 选中的行以强调色显示
 			}
 			label.SetText(text)
-			label.TextStyle.Bold = false
 		},
 	)
-	table.SetColumnWidth(0, 70)  // 协议列
-	table.SetColumnWidth(1, 200) // 代理地址列
-	table.SetColumnWidth(2, 100) // 延迟列
-	table.SetColumnWidth(3, 100) // 速度列
-	table.SetColumnWidth(4, 100) // 匿名度列
-	table.SetColumnWidth(5, 80)  // 地区列
-
-	// 点击速度列头排序
+	for i, column := range proxyColumns {
+		table.SetColumnWidth(i, column.width)
+	}
+
+	// 点击列头按该列排序，再次点击同一列切换方向；点击其他行切换该行的选中状态
 	table.OnSelected = func(id widget.TableCellID) {
-		if id.Row == 0 {
-			switch id.Col {
-			case 2: // 点击延迟列头
-				sortByLatencyDesc = !sortByLatencyDesc
-				sortProxies("latency")
-			case 3: // 点击速度列头
-				sortBySpeedDesc = !sortBySpeedDesc
-				sortProxies("speed")
+		table.UnselectAll()
+		if id.Row != 0 {
+			item, err := data.GetValue(id.Row - 1)
+			if err != nil {
+				return
+			}
+			p := item.(*proxy.Proxy)
+			if selected[p.Address] != nil {
+				delete(selected, p.Address)
+			} else {
+				selected[p.Address] = p
 			}
+			updateSelection()
 			table.Refresh()
+			return
+		}
+		column := proxyColumns[id.Col]
+		order := app.GetSortOrder()
+		if order.Key == column.key {
+			order.Desc = !order.Desc
+		} else {
+			order = proxy.SortOrder{Key: column.key, Desc: column.descFirst}
+		}
+		app.SetSortOrder(order)
+		table.Refresh()
+	}
+
+	exitIPCheck := widget.NewCheck(lang.T("显示出口IP列"), func(checked bool) {
+		showExitIP = checked
+		table.Refresh()
+	})
+
+	testSelectedBtn.OnTapped = func() {
+		proxies := make([]*proxy.Proxy, 0, len(selected))
+		for _, p := range selected {
+			proxies = append(proxies, p)
+		}
+		app.TestSelectedProxies(proxies)
+	}
+	clearSelectedBtn.OnTapped = func() {
+		selected = make(map[string]*proxy.Proxy)
+		updateSelection()
+		table.Refresh()
+	}
+	updateSelection()
+
+	bottom := container.NewBorder(nil, nil, container.NewHBox(testSelectedBtn, clearSelectedBtn), nil, createPager(app, table))
+	return widget.NewCard(lang.T("有效代理列表"), "", container.NewBorder(createTableFilterBar(app, exitIPCheck), bottom, nil, nil, table))
+}
+
+// createPager 创建代理列表下方的分页控件
+// 列表每次刷新后更新页码并重绘表格，翻页后滚动到表格顶部
+func createPager(app Apper, table *widget.Table) fyne.CanvasObject {
+	pageLabel := widget.NewLabel("")
+	prevBtn := widget.NewButton(lang.T("上一页"), nil)
+	nextBtn := widget.NewButton(lang.T("下一页"), nil)
+	turn := func(delta int) {
+		page, _, _ := app.ListPageInfo()
+		app.SetListPage(page + delta)
+		table.ScrollToTop()
+	}
+	prevBtn.OnTapped = func() { turn(-1) }
+	nextBtn.OnTapped = func() { turn(1) }
+
+	uiListeners.add(app.GetListRevision(), func() {
+		page, pages, total := app.ListPageInfo()
+		pageLabel.SetText(fmt.Sprintf(lang.T("第 %d/%d 页，共 %d 个"), page+1, pages, total))
+		if page > 0 {
+			prevBtn.Enable()
+		} else {
+			prevBtn.Disable()
 		}
+		if page < pages-1 {
+			nextBtn.Enable()
+		} else {
+			nextBtn.Disable()
+		}
+		table.Refresh()
+	})
+	return container.NewHBox(layout.NewSpacer(), prevBtn, pageLabel, nextBtn, layout.NewSpacer())
+}
+
+// createTableFilterBar 创建代理列表上方的搜索框和列筛选下拉框，输入或选择时实时过滤列表
+// 下拉框的选项取自有效代理中出现过的值，随代理池变化更新
+func createTableFilterBar(app Apper, extra fyne.CanvasObject) fyne.CanvasObject {
+	allOption := lang.T("全部")
+	searchEntry := widget.NewEntry()
+	searchEntry.SetPlaceHolder(lang.T("搜索地址、协议、国家或匿名度"))
+	protocolSelect := widget.NewSelect(nil, nil)
+	protocolSelect.PlaceHolder = lang.T("协议")
+	countrySelect := widget.NewSelect(nil, nil)
+	countrySelect.PlaceHolder = lang.T("国家")
+	anonymitySelect := widget.NewSelect(nil, nil)
+	anonymitySelect.PlaceHolder = lang.T("匿名度")
+
+	// 恢复上次保存的搜索和列筛选，选项在列表刷新后才会填充，因此直接设置选中值
+	filter := app.GetSettings().Filter
+	searchEntry.SetText(filter.Search)
+	protocolSelect.Selected = filter.Protocol
+	countrySelect.Selected = filter.Country
+	anonymitySelect.Selected = filter.Anonymity
+
+	value := func(s *widget.Select) string {
+		if s.Selected == allOption {
+			return ""
+		}
+		return s.Selected
+	}
+	apply := func(string) {
+		app.SetTableFilter(searchEntry.Text, value(protocolSelect), value(countrySelect), value(anonymitySelect))
 	}
+	searchEntry.OnChanged = apply
+	protocolSelect.OnChanged = apply
+	countrySelect.OnChanged = apply
+	anonymitySelect.OnChanged = apply
 
-	return widget.NewCard("有效代理列表", "", table)
+	uiListeners.add(app.GetListRevision(), func() {
+		protocols, countries, anonymities := app.GetFilterOptions()
+		protocolSelect.Options = append([]string{allOption}, protocols...)
+		countrySelect.Options = append([]string{allOption}, countries...)
+		anonymitySelect.Options = append([]string{allOption}, anonymities...)
+	})
+
+	selects := container.NewHBox(protocolSelect, countrySelect, anonymitySelect, extra)
+	return container.NewBorder(nil, nil, nil, selects, searchEntry)
+}
+
+// copyAsFormats 代理右键菜单"复制为"中的片段格式，按显示顺序排列
+var copyAsFormats = []struct {
+	label  string
+	format proxy.SnippetFormat
+}{
+	{"curl", proxy.SnippetCurl},
+	{"环境变量", proxy.SnippetEnv},
+	{"proxychains", proxy.SnippetProxychains},
+}
+
+// copyAsItem 创建"复制为"子菜单，将代理转换为可直接粘贴的命令或配置片段
+func copyAsItem(app Apper, p *proxy.Proxy) *fyne.MenuItem {
+	items := make([]*fyne.MenuItem, len(copyAsFormats))
+	for i, f := range copyAsFormats {
+		format := f.format
+		items[i] = fyne.NewMenuItem(lang.T(f.label), func() {
+			text, err := proxy.Snippet(format, p)
+			if err != nil {
+				app.LogError(fmt.Sprintf(lang.T("复制失败: %v"), err))
+				return
+			}
+			app.GetWindow().Clipboard().SetContent(text)
+			app.Log(fmt.Sprintf(lang.T("已将代理 %s 复制为 %s"), p.Address, lang.T(f.label)))
+		})
+	}
+	item := fyne.NewMenuItem(lang.T("复制为…"), nil)
+	item.ChildMenu = fyne.NewMenu("", items...)
+	return item
 }
 
 // createRotationControlPanel 创建代理轮换控制面板
@@ -425,52 +1352,132 @@ func createRotationControlPanel(app Apper) *widget.Card {
 	currentProxy := app.GetCurrentProxy()
 
 	// Rotation toggle switch
-	toggle := widget.NewCheck("启用代理轮换", func(enable bool) {
+	toggle := widget.NewCheck(lang.T("启用代理轮换"), func(enable bool) {
 		app.ToggleRotation(enable)
 	})
-	rotationStatus.AddListener(binding.NewDataListener(func() {
+	uiListeners.add(rotationStatus, func() {
 		enabled, _ := rotationStatus.Get()
 		toggle.SetChecked(enabled)
-	}))
+	})
 
 	// Current proxy display
 	currentProxyDisplay := widget.NewLabel("")
-	widget.NewLabel("当前代理: ")
-	currentProxy.AddListener(binding.NewDataListener(func() {
-		proxy, _ := currentProxy.Get()
-		currentProxyDisplay.SetText(proxy)
-	}))
+	widget.NewLabel(lang.T("当前代理: "))
+	uiListeners.add(currentProxy, func() {
+		address, _ := currentProxy.Get()
+		text := lang.T(address)
+		if p := app.FindListedProxy(address); p != nil && p.ExitIP != "" {
+			text += fmt.Sprintf(lang.T("  出口IP: %s"), p.ExitIP)
+		}
+		currentProxyDisplay.SetText(text)
+	})
+	nextBtn := widget.NewButton(lang.T("下一个代理"), app.NextProxy)
 
 	// Rotation interval setting
 	intervalEntry := widget.NewEntry()
-	intervalEntry.SetPlaceHolder("例如: 60 (秒)")
-	intervalEntry.SetText("60")
-	intervalBtn := widget.NewButton("设置间隔", func() {
+	intervalEntry.SetPlaceHolder(lang.T("例如: 60 (秒)"))
+	intervalEntry.SetText(strconv.Itoa(app.GetSettings().Rotation.IntervalSeconds))
+	intervalBtn := widget.NewButton(lang.T("设置间隔"), func() {
 		seconds, err := strconv.Atoi(intervalEntry.Text)
 		if err == nil && seconds > 0 {
 			app.SetRotationInterval(seconds)
 		}
 	})
 
+	// 快速连接：生成可粘贴到终端的代理环境变量命令
+	quickEntry := widget.NewMultiLineEntry()
+	quickEntry.SetMinRowsVisible(1)
+	quickEntry.SetPlaceHolder(lang.T("点击生成获取终端代理设置命令"))
+	generateBtn := widget.NewButton(lang.T("生成"), func() {
+		text, err := app.QuickConnectString()
+		if err != nil {
+			app.LogError(fmt.Sprintf(lang.T("生成快速连接命令失败: %v"), err))
+			return
+		}
+		quickEntry.SetText(text)
+	})
+	copyBtn := widget.NewButton(lang.T("复制"), func() {
+		if quickEntry.Text == "" {
+			return
+		}
+		app.GetWindow().Clipboard().SetContent(quickEntry.Text)
+		app.Log(lang.T("快速连接命令已复制到剪贴板"))
+	})
+
 	grid := container.New(layout.NewFormLayout(),
-		widget.NewLabel("轮换设置:"), toggle,
-		widget.NewLabel("当前代理:"), currentProxyDisplay,
-		widget.NewLabel("轮换间隔(秒):"), intervalEntry,
+		widget.NewLabel(lang.T("轮换设置:")), toggle,
+		widget.NewLabel(lang.T("当前代理:")), container.NewBorder(nil, nil, nil, nextBtn, currentProxyDisplay),
+		widget.NewLabel(lang.T("轮换间隔(秒):")), intervalEntry,
 		layout.NewSpacer(), intervalBtn,
+		widget.NewLabel(lang.T("快速连接:")), container.NewBorder(nil, nil, nil, container.NewHBox(generateBtn, copyBtn), quickEntry),
 	)
-	return widget.NewCard("代理轮换", "控制代理自动轮换行为", grid)
+	return widget.NewCard(lang.T("代理轮换"), lang.T("控制代理自动轮换行为"), grid)
 }
 
 // createLogView 创建应用日志显示区域
-// 实时显示应用操作日志和代理测试结果，支持自动滚动更新
+// 按级别和搜索内容过滤日志，有新日志时自动滚动到底部，可复制或保存当前显示的日志
 func createLogView(app Apper) fyne.CanvasObject {
-	logBinding := app.GetLogBinding()
-	logEntry := widget.NewMultiLineEntry()
-	logEntry.Bind(logBinding)
-	logEntry.Disable()
-	scroll := container.NewScroll(logEntry)
-	logBinding.AddListener(binding.NewDataListener(func() {
-		scroll.ScrollToBottom()
-	}))
-	return widget.NewCard("实时日志", "", scroll)
+	minLevel := logging.LevelInfo
+	search := ""
+	var entries []logging.Entry
+
+	list := widget.NewList(
+		func() int { return len(entries) },
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("")
+			label.Truncation = fyne.TextTruncateEllipsis
+			return label
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			label := obj.(*widget.Label)
+			label.Importance = logImportance(entries[id].Level)
+			label.SetText(entries[id].String())
+		},
+	)
+	refresh := func() {
+		entries = app.GetLogEntries(minLevel, search)
+		list.Refresh()
+		list.ScrollToBottom()
+	}
+
+	levelLabels := make([]string, len(logging.Levels))
+	for i, l := range logging.Levels {
+		levelLabels[i] = l.String()
+	}
+	levelSelect := widget.NewSelect(levelLabels, nil)
+	levelSelect.SetSelected(minLevel.String())
+	levelSelect.OnChanged = func(string) {
+		minLevel = logging.Levels[levelSelect.SelectedIndex()]
+		refresh()
+	}
+	searchEntry := widget.NewEntry()
+	searchEntry.SetPlaceHolder(lang.T("搜索日志"))
+	searchEntry.OnChanged = func(text string) {
+		search = strings.TrimSpace(text)
+		refresh()
+	}
+
+	copyBtn := widget.NewButton(lang.T("复制"), func() {
+		var text strings.Builder
+		logging.WriteEntries(&text, entries)
+		app.GetWindow().Clipboard().SetContent(text.String())
+	})
+	saveBtn := widget.NewButton(lang.T("保存日志"), func() {
+		app.ExportLog(entries)
+	})
+
+	uiListeners.add(app.GetLogRevision(), refresh)
+	bar := container.NewBorder(nil, nil, levelSelect, container.NewHBox(copyBtn, saveBtn), searchEntry)
+	return widget.NewCard(lang.T("实时日志"), "", container.NewBorder(bar, nil, nil, nil, list))
+}
+
+// logImportance 按日志级别选择显示样式，错误以警示色显示，调试信息淡化显示
+func logImportance(level logging.Level) widget.Importance {
+	switch level {
+	case logging.LevelError:
+		return widget.DangerImportance
+	case logging.LevelDebug:
+		return widget.LowImportance
+	}
+	return widget.MediumImportance
 }
@@ -0,0 +1,73 @@
+package ui
+
+import (
+	"fmt"
+	"go_proxy/lang"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showVerifyExitDialog 显示出口IP验证对话框
+// 请求经本地服务而不是直接经上游代理发出，显示的出口IP、位置和延迟反映客户端实际看到的效果
+func showVerifyExitDialog(app Apper) {
+	status := widget.NewLabel("")
+	exitIP := widget.NewLabel("")
+	location := widget.NewLabel("")
+	latency := widget.NewLabel("")
+	upstream := widget.NewLabel("")
+
+	var verifyBtn *widget.Button
+	verify := func() {
+		verifyBtn.Disable()
+		status.SetText(lang.T("正在通过本地服务验证..."))
+		for _, label := range []*widget.Label{exitIP, location, latency, upstream} {
+			label.SetText("")
+		}
+		go func() {
+			defer verifyBtn.Enable()
+			report, err := app.VerifyExit()
+			if err != nil {
+				status.SetText(fmt.Sprintf(lang.T("验证失败: %v"), err))
+				return
+			}
+			status.SetText(lang.T("链路正常"))
+			exitIP.SetText(report.ExitIP)
+			var parts []string
+			for _, part := range []string{report.Country, report.Province, report.City} {
+				if part != "" {
+					parts = append(parts, part)
+				}
+			}
+			if len(parts) == 0 {
+				location.SetText(lang.T("未知"))
+			} else {
+				location.SetText(strings.Join(parts, " "))
+			}
+			latency.SetText(fmt.Sprintf("%dms", report.Latency.Milliseconds()))
+			if report.Upstream != nil {
+				upstream.SetText(fmt.Sprintf("%s://%s", report.Upstream.Protocol, report.Upstream.Address))
+			} else {
+				upstream.SetText(lang.T("未知"))
+			}
+		}()
+	}
+	verifyBtn = widget.NewButton(lang.T("重新验证"), verify)
+
+	grid := container.New(layout.NewFormLayout(),
+		widget.NewLabel(lang.T("状态:")), status,
+		widget.NewLabel(lang.T("出口IP:")), exitIP,
+		widget.NewLabel(lang.T("位置:")), location,
+		widget.NewLabel(lang.T("延迟:")), latency,
+		widget.NewLabel(lang.T("上游代理:")), upstream,
+	)
+	content := container.NewBorder(nil, container.NewHBox(layout.NewSpacer(), verifyBtn), nil, nil, grid)
+	d := dialog.NewCustom(lang.T("验证出口IP"), lang.T("关闭"), content, app.GetWindow())
+	d.Resize(fyne.NewSize(420, 260))
+	d.Show()
+	verify()
+}